@@ -0,0 +1,219 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SetAccountingMode enables or disables accounting on the device, which
+// requires root. Disabling it clears the stats of all processes.
+func (d Device) SetAccountingMode(mode EnableState) error {
+	return backend.DeviceSetAccountingMode(d.handle, mode)
+}
+
+// ClearAccountingPids clears the accounting stats of the processes that
+// terminated, which requires root.
+func (d Device) ClearAccountingPids() error {
+	return backend.DeviceClearAccountingPids(d.handle)
+}
+
+// Values NVML reports for the accounting stats it can't measure on the
+// device, i.e. NVML_VALUE_NOT_AVAILABLE.
+const (
+	accountingUtilizationNotAvailable = 0xFFFFFFFF
+	accountingMemoryNotAvailable      = ^uint64(0)
+)
+
+// errAccountingSessionEnded is returned by the AccountingSession methods
+// called after End.
+var errAccountingSessionEnded = errors.New("accounting session ended")
+
+// AccountingSession collects the accounting stats of the processes of a job
+// on a device, from StartAccountingSession to End.
+type AccountingSession struct {
+	Device Device
+
+	mu   sync.Mutex
+	pids []uint
+	// enabled is whether the session enabled accounting, which End
+	// disables again.
+	enabled bool
+	ended   bool
+}
+
+// AccountingReport sums up the accounting stats of the processes of an
+// AccountingSession.
+type AccountingReport struct {
+	// Processes are the stats of the tracked processes by PID. The
+	// processes NVML has no stats of, e.g. because they didn't use the
+	// device or started before accounting was enabled, are missing.
+	Processes map[uint]AccountingStats
+	// GPUUtilization and MemoryUtilization are the averages in percent of
+	// those of the processes, weighted by how long they ran.
+	GPUUtilization    uint
+	MemoryUtilization uint
+	// MaxMemoryUsage is the highest MaxMemoryUsage of the processes in
+	// bytes.
+	MaxMemoryUsage uint64
+	// Start is when the first process started and WallTime how long after
+	// it the last one terminated, or until the report if Running.
+	Start    time.Time
+	WallTime time.Duration
+	// Running reports whether some of the processes are still running.
+	Running bool
+}
+
+// StartAccountingSession starts a session on the device, enabling accounting
+// if it isn't already.
+func StartAccountingSession(d Device) (*AccountingSession, error) {
+	mode, err := d.AccountingMode()
+	if err != nil {
+		return nil, err
+	}
+	s := &AccountingSession{Device: d}
+	if mode != EnableStateFeatureEnabled {
+		if err := d.SetAccountingMode(EnableStateFeatureEnabled); err != nil {
+			return nil, err
+		}
+		s.enabled = true
+	}
+	return s, nil
+}
+
+// Track adds the processes of the job to the session. It can be called at
+// any time before End, even after the processes terminated.
+func (s *AccountingSession) Track(pids ...uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, pid := range pids {
+		if !containsUint(s.pids, pid) {
+			s.pids = append(s.pids, pid)
+		}
+	}
+}
+
+// Report returns the report of the tracked processes so far. The stats that
+// couldn't be read for other reasons than NVML having none are left out of
+// the report and their errors are combined in the returned error.
+func (s *AccountingSession) Report() (AccountingReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return AccountingReport{}, errAccountingSessionEnded
+	}
+	return s.report()
+}
+
+func (s *AccountingSession) report() (AccountingReport, error) {
+	var errs errorList
+	now := time.Now()
+	r := AccountingReport{Processes: make(map[uint]AccountingStats)}
+	var (
+		end                  time.Time
+		gpuSum, memSum       float64
+		gpuWeight, memWeight float64
+		gpuValues, memValues []uint
+	)
+	for _, pid := range s.pids {
+		stats, err := s.Device.AccountingStats(pid)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("pid %d: %w", pid, err))
+			continue
+		}
+		r.Processes[pid] = *stats
+
+		start := time.Unix(0, int64(stats.StartTime)*int64(time.Microsecond))
+		stop := start.Add(time.Duration(stats.Time) * time.Millisecond)
+		if stats.IsRunning {
+			stop = now
+			r.Running = true
+		}
+		if r.Start.IsZero() || start.Before(r.Start) {
+			r.Start = start
+		}
+		if stop.After(end) {
+			end = stop
+		}
+
+		weight := stop.Sub(start).Seconds()
+		if stats.GPUUtilization != accountingUtilizationNotAvailable {
+			gpuSum += weight * float64(stats.GPUUtilization)
+			gpuWeight += weight
+			gpuValues = append(gpuValues, stats.GPUUtilization)
+		}
+		if stats.MemoryUtilization != accountingUtilizationNotAvailable {
+			memSum += weight * float64(stats.MemoryUtilization)
+			memWeight += weight
+			memValues = append(memValues, stats.MemoryUtilization)
+		}
+		if stats.MaxMemoryUsage != accountingMemoryNotAvailable && stats.MaxMemoryUsage > r.MaxMemoryUsage {
+			r.MaxMemoryUsage = stats.MaxMemoryUsage
+		}
+	}
+	r.GPUUtilization = weightedAverage(gpuSum, gpuWeight, gpuValues)
+	r.MemoryUtilization = weightedAverage(memSum, memWeight, memValues)
+	if !r.Start.IsZero() {
+		r.WallTime = end.Sub(r.Start)
+	}
+	return r, errs.err()
+}
+
+// weightedAverage returns sum/weight rounded, or the plain average of values
+// if the processes ran for no measurable time.
+func weightedAverage(sum, weight float64, values []uint) uint {
+	if weight > 0 {
+		return uint(sum/weight + 0.5)
+	}
+	if len(values) == 0 {
+		return 0
+	}
+	var total uint
+	for _, v := range values {
+		total += v
+	}
+	return (total + uint(len(values))/2) / uint(len(values))
+}
+
+// End returns the final report of the session and disables accounting again
+// if StartAccountingSession enabled it, which clears the stats of all the
+// processes of the device. The session can't be used after End.
+func (s *AccountingSession) End() (AccountingReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return AccountingReport{}, errAccountingSessionEnded
+	}
+	r, err := s.report()
+	var errs errorList
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if s.enabled {
+		if err := s.Device.SetAccountingMode(EnableStateFeatureDisabled); err != nil {
+			errs = append(errs, fmt.Errorf("disabling accounting: %w", err))
+		}
+	}
+	s.ended = true
+	return r, errs.err()
+}
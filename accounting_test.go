@@ -0,0 +1,173 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// accountingStart is the start time of the first process of the accounting
+// tests.
+var accountingStart = time.Date(2020, 11, 2, 10, 0, 0, 0, time.UTC)
+
+// accountingStats returns the stats of a process that started after offset
+// from accountingStart and ran for runtime.
+func accountingStats(offset, runtime time.Duration, gpu, mem uint, maxMemory uint64) AccountingStats {
+	return AccountingStats{
+		GPUUtilization:    gpu,
+		MemoryUtilization: mem,
+		MaxMemoryUsage:    maxMemory,
+		Time:              uint64(runtime / time.Millisecond),
+		StartTime:         uint64(accountingStart.Add(offset).UnixNano() / int64(time.Microsecond)),
+	}
+}
+
+func TestAccountingSession(t *testing.T) {
+	fake := &FakeDevice{
+		AccountingStats: map[uint]AccountingStats{
+			100: accountingStats(0, 10*time.Second, 90, 40, 1<<30),
+			// NVML can't measure the memory on this one.
+			200: accountingStats(5*time.Second, 30*time.Second, 10, accountingUtilizationNotAvailable, accountingMemoryNotAvailable),
+			// Not tracked by the session.
+			300: accountingStats(0, time.Hour, 100, 100, 1<<34),
+		},
+	}
+	f, devices := initFakeDevices(t, fake)
+	defer SetBackend(nil)
+	defer Shutdown()
+
+	s, err := StartAccountingSession(devices[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode, _ := devices[0].AccountingMode(); mode != EnableStateFeatureEnabled {
+		t.Errorf("accounting mode = %v after StartAccountingSession, want enabled", mode)
+	}
+	// 400 has no stats, e.g. because it never used the device.
+	s.Track(100, 200, 400, 100)
+
+	r, err := s.Report()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Processes) != 2 || !reflect.DeepEqual(r.Processes[200], fake.AccountingStats[200]) {
+		t.Errorf("Processes = %+v, want the stats of 100 and 200", r.Processes)
+	}
+	// (10s * 90% + 30s * 10%) / 40s, and only 100 has a memory utilization.
+	if r.GPUUtilization != 30 || r.MemoryUtilization != 40 {
+		t.Errorf("utilization = %d%% GPU, %d%% memory; want 30%%, 40%%", r.GPUUtilization, r.MemoryUtilization)
+	}
+	if r.MaxMemoryUsage != 1<<30 {
+		t.Errorf("MaxMemoryUsage = %d, want %d", r.MaxMemoryUsage, 1<<30)
+	}
+	if !r.Start.Equal(accountingStart) || r.WallTime != 35*time.Second || r.Running {
+		t.Errorf("Start, WallTime, Running = %v, %v, %v; want %v, 35s, false", r.Start, r.WallTime, r.Running, accountingStart)
+	}
+
+	end, err := s.End()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(end, r) {
+		t.Errorf("End() = %+v, want %+v", end, r)
+	}
+	// The session enabled accounting, so End disabled it again.
+	f.Lock()
+	mode := fake.AccountingMode
+	f.Unlock()
+	if mode != EnableStateFeatureDisabled {
+		t.Errorf("accounting mode = %v after End, want disabled", mode)
+	}
+	if _, err := s.Report(); err != errAccountingSessionEnded {
+		t.Errorf("Report() after End = %v, want %v", err, errAccountingSessionEnded)
+	}
+	if _, err := s.End(); err != errAccountingSessionEnded {
+		t.Errorf("second End() = %v, want %v", err, errAccountingSessionEnded)
+	}
+}
+
+func TestAccountingSessionKeepsAccountingEnabled(t *testing.T) {
+	running := accountingStats(0, 0, 50, 20, 1<<20)
+	running.StartTime = uint64(time.Now().Add(-time.Minute).UnixNano() / int64(time.Microsecond))
+	running.IsRunning = true
+	fake := &FakeDevice{
+		AccountingMode:  EnableStateFeatureEnabled,
+		AccountingStats: map[uint]AccountingStats{100: running},
+	}
+	f, devices := initFakeDevices(t, fake)
+	defer SetBackend(nil)
+	defer Shutdown()
+
+	s, err := StartAccountingSession(devices[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Track(100)
+	r, err := s.End()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A running process counts until the report.
+	if !r.Running || r.WallTime < time.Minute || r.GPUUtilization != 50 {
+		t.Errorf("Running, WallTime, GPUUtilization = %v, %v, %d; want true, at least 1m, 50", r.Running, r.WallTime, r.GPUUtilization)
+	}
+	f.Lock()
+	mode, stats := fake.AccountingMode, len(fake.AccountingStats)
+	f.Unlock()
+	if mode != EnableStateFeatureEnabled || stats != 1 {
+		t.Errorf("after End: accounting mode %v with %d processes, want it left enabled with 1", mode, stats)
+	}
+}
+
+func TestAccountingSessionErrors(t *testing.T) {
+	fake := &FakeDevice{
+		AccountingMode: EnableStateFeatureEnabled,
+		AccountingStats: map[uint]AccountingStats{
+			// Processes that ran for no measurable time get the plain
+			// average.
+			100: accountingStats(0, 0, 10, accountingUtilizationNotAvailable, 0),
+			200: accountingStats(0, 0, 21, accountingUtilizationNotAvailable, 0),
+		},
+	}
+	f, devices := initFakeDevices(t, fake)
+	defer SetBackend(nil)
+	defer Shutdown()
+
+	s, err := StartAccountingSession(devices[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Track(100, 200)
+	r, err := s.Report()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.GPUUtilization != 16 || r.MemoryUtilization != 0 {
+		t.Errorf("utilization = %d%% GPU, %d%% memory; want 16%%, 0%%", r.GPUUtilization, r.MemoryUtilization)
+	}
+
+	f.Lock()
+	fake.Errors = map[string]Return{"nvmlDeviceGetAccountingStats": ReturnErrorNoPermission}
+	f.Unlock()
+	r, err = s.Report()
+	if !errors.Is(err, ErrNoPermission) || len(r.Processes) != 0 {
+		t.Errorf("Report() = %+v, %v; want ErrNoPermission and no processes", r, err)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxAffinityIDs is the number of CPUs and NUMA nodes the affinity masks
+// passed to NVML have room for.
+const maxAffinityIDs = 1024
+
+// appendMaskIDs appends to ids the ids of the bits set in word, which is the
+// part of an affinity mask starting at id offset.
+func appendMaskIDs(ids []uint, word uint64, offset uint) []uint {
+	for bit := uint(0); word != 0; bit++ {
+		if word&1 != 0 {
+			ids = append(ids, offset+bit)
+		}
+		word >>= 1
+	}
+	return ids
+}
+
+// CpuSet is a set of CPU ids, or of NUMA node ids for a memory affinity, in
+// increasing order.
+type CpuSet []uint
+
+// Contains reports whether id is in the set.
+func (s CpuSet) Contains(id uint) bool {
+	for _, v := range s {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// String formats the set in the Linux cpulist syntax, e.g. "0-3,8,10-11", as
+// taken by taskset -c, numactl and the cpuset.cpus and cpuset.mems files of
+// the cpuset cgroup. The empty set formats as "".
+func (s CpuSet) String() string {
+	ids := append([]uint(nil), s...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	var b strings.Builder
+	for i := 0; i < len(ids); {
+		first, last := ids[i], ids[i]
+		for i++; i < len(ids) && ids[i] <= last+1; i++ {
+			last = ids[i]
+		}
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatUint(uint64(first), 10))
+		if last != first {
+			b.WriteByte('-')
+			b.WriteString(strconv.FormatUint(uint64(last), 10))
+		}
+	}
+	return b.String()
+}
+
+// CpuAffinity returns the CPUs of the NUMA node closest to the device.
+func (d Device) CpuAffinity() (CpuSet, error) {
+	ids, err := backend.DeviceGetCpuAffinity(d.handle)
+	return CpuSet(ids), err
+}
+
+// CpuAffinityWithinScope returns the CPUs closest to the device, within the
+// NUMA node or the CPU socket.
+func (d Device) CpuAffinityWithinScope(scope AffinityScope) (CpuSet, error) {
+	ids, err := backend.DeviceGetCpuAffinityWithinScope(d.handle, scope)
+	return CpuSet(ids), err
+}
+
+// MemoryAffinity returns the NUMA nodes closest to the device, within the
+// NUMA node or the CPU socket.
+func (d Device) MemoryAffinity(scope AffinityScope) (CpuSet, error) {
+	ids, err := backend.DeviceGetMemoryAffinity(d.handle, scope)
+	return CpuSet(ids), err
+}
+
+// SetCpuAffinity binds the calling OS thread to the CPUs returned by
+// CpuAffinity. As goroutines move between threads, the goroutine has to be
+// locked to its thread with runtime.LockOSThread first. Only supported on
+// Linux.
+func (d Device) SetCpuAffinity() error {
+	return backend.DeviceSetCpuAffinity(d.handle)
+}
+
+// ClearCpuAffinity undoes SetCpuAffinity, allowing the calling OS thread to
+// run on any CPU again.
+func (d Device) ClearCpuAffinity() error {
+	return backend.DeviceClearCpuAffinity(d.handle)
+}
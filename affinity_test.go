@@ -0,0 +1,75 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCpuSetString(t *testing.T) {
+	for _, c := range []struct {
+		set  CpuSet
+		want string
+	}{
+		{nil, ""},
+		{CpuSet{}, ""},
+		{CpuSet{5}, "5"},
+		{CpuSet{0, 1, 2, 3}, "0-3"},
+		{CpuSet{0, 1, 2, 3, 8, 10, 11}, "0-3,8,10-11"},
+		{CpuSet{0, 2, 4}, "0,2,4"},
+		{CpuSet{11, 3, 10, 0, 2, 1}, "0-3,10-11"},
+		{CpuSet{4, 4, 5, 5, 7, 7}, "4-5,7"},
+		{CpuSet{63, 64, 65}, "63-65"},
+	} {
+		if got := c.set.String(); got != c.want {
+			t.Errorf("CpuSet%v.String() = %q, want %q", []uint(c.set), got, c.want)
+		}
+	}
+}
+
+func TestCpuSetStringKeepsOrder(t *testing.T) {
+	s := CpuSet{3, 1, 2}
+	_ = s.String()
+	if !reflect.DeepEqual(s, CpuSet{3, 1, 2}) {
+		t.Errorf("String() sorted the set in place: %v", []uint(s))
+	}
+}
+
+func TestAppendMaskIDs(t *testing.T) {
+	// The mask words as NVML fills them in: CPUs 0-1, 62-65 and 127, then
+	// 128 in the third word.
+	mask := []uint64{
+		1<<0 | 1<<1 | 1<<62 | 1<<63,
+		1<<0 | 1<<1 | 1<<63,
+		1 << 0,
+	}
+	var ids []uint
+	for i, word := range mask {
+		ids = appendMaskIDs(ids, word, uint(i)*64)
+	}
+	want := []uint{0, 1, 62, 63, 64, 65, 127, 128}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("ids = %v, want %v", ids, want)
+	}
+	if got := CpuSet(ids).String(); got != "0-1,62-65,127-128" {
+		t.Errorf("String() = %q, want %q", got, "0-1,62-65,127-128")
+	}
+	if ids := appendMaskIDs(nil, 0, 64); ids != nil {
+		t.Errorf("appendMaskIDs() of an empty word = %v, want nil", ids)
+	}
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+// DeviceHandle is the backend specific value identifying a device. It is
+// opaque to everything but the Backend that returned it.
+type DeviceHandle interface{}
+
+// Backend is the implementation of the NVML functions used by this package.
+// The default backend calls into the dynamically loaded NVML library. A
+// different one, for example a FakeBackend, can be selected with SetBackend.
+//
+// The methods map one to one to the NVML functions with the same name and
+// return the errors produced for the NVML return codes.
+type Backend interface {
+	// Init loads the first of the given NVML library files that can be
+	// opened and initialized, initializing NVML with nvmlInitWithFlags, or
+	// with nvmlInit if flags is 0. A candidate that fails is unloaded again
+	// before the next one is tried. It returns the path of the library file
+	// it loaded.
+	Init(libraries []string, flags InitFlags) (string, error)
+	Shutdown() error
+	// SupportedFunctions returns the names of the NVML functions used by
+	// this package that the loaded library exports.
+	SupportedFunctions() ([]string, error)
+
+	SystemGetDriverVersion() (string, error)
+	SystemGetNVMLVersion() (string, error)
+	SystemGetProcessName(pid, length uint) (string, error)
+
+	DeviceGetCount() (uint, error)
+	DeviceGetHandleByIndex(idx uint) (DeviceHandle, error)
+	DeviceGetHandleByUUID(uuid string) (DeviceHandle, error)
+	DeviceGetHandleByPciBusId(busID string) (DeviceHandle, error)
+	DeviceGetHandleBySerial(serial string) (DeviceHandle, error)
+
+	DeviceGetIndex(h DeviceHandle) (uint, error)
+	DeviceGetBrand(h DeviceHandle) (DeviceBrand, error)
+	DeviceGetBoardId(h DeviceHandle) (uint, error)
+	DeviceGetComputeMode(h DeviceHandle) (ComputeMode, error)
+	DeviceSetComputeMode(h DeviceHandle, mode ComputeMode) error
+	DeviceGetDisplayMode(h DeviceHandle) (EnableState, error)
+	DeviceGetDisplayActive(h DeviceHandle) (EnableState, error)
+	DeviceGetVbiosVersion(h DeviceHandle) (string, error)
+	DeviceGetCurrentClocksThrottleReasons(h DeviceHandle) (uint64, error)
+	DeviceGetTotalEnergyConsumption(h DeviceHandle) (uint64, error)
+	DeviceGetTotalEccErrors(h DeviceHandle, errorType MemoryErrorType, counterType EccCounterType) (uint64, error)
+	DeviceGetDetailedEccErrors(h DeviceHandle, errorType MemoryErrorType, counterType EccCounterType) (EccErrorCounts, error)
+	DeviceGetMemoryErrorCounter(h DeviceHandle, errorType MemoryErrorType, counterType EccCounterType, location MemoryLocation) (uint64, error)
+	DeviceGetEccMode(h DeviceHandle) (current, pending EnableState, err error)
+	DeviceSetEccMode(h DeviceHandle, mode EnableState) error
+	DeviceClearEccErrorCounts(h DeviceHandle, counterType EccCounterType) error
+	DeviceGetRetiredPages(h DeviceHandle, cause PageRetirementCause) ([]RetiredPage, error)
+	DeviceGetRetiredPagesPendingStatus(h DeviceHandle) (EnableState, error)
+	DeviceGetRemappedRows(h DeviceHandle) (RemappedRows, error)
+	DeviceGetRowRemapperHistogram(h DeviceHandle) (RowRemapperHistogram, error)
+	DeviceGetSerial(h DeviceHandle) (string, error)
+	DeviceGetMinorNumber(h DeviceHandle) (uint, error)
+	DeviceGetPciInfo(h DeviceHandle) (PciInfo, error)
+	DeviceGetUUID(h DeviceHandle) (string, error)
+	DeviceGetName(h DeviceHandle) (string, error)
+	DeviceGetPersistenceMode(h DeviceHandle) (EnableState, error)
+	DeviceSetPersistenceMode(h DeviceHandle, mode EnableState) error
+	DeviceGetPerformanceState(h DeviceHandle) (PowerState, error)
+	DeviceGetClockInfo(h DeviceHandle, clockType ClockType) (uint, error)
+	DeviceGetMaxClockInfo(h DeviceHandle, clockType ClockType) (uint, error)
+	DeviceGetApplicationsClock(h DeviceHandle, clockType ClockType) (uint, error)
+	DeviceGetDefaultApplicationsClock(h DeviceHandle, clockType ClockType) (uint, error)
+	DeviceGetMaxCustomerBoostClock(h DeviceHandle, clockType ClockType) (uint, error)
+	DeviceGetSupportedMemoryClocks(h DeviceHandle) ([]uint, error)
+	DeviceGetSupportedGraphicsClocks(h DeviceHandle, memoryClockMHz uint) ([]uint, error)
+	DeviceSetApplicationsClocks(h DeviceHandle, memClockMHz, graphicsClockMHz uint) error
+	DeviceResetApplicationsClocks(h DeviceHandle) error
+	DeviceSetGpuLockedClocks(h DeviceHandle, minGpuClockMHz, maxGpuClockMHz uint) error
+	DeviceResetGpuLockedClocks(h DeviceHandle) error
+	DeviceGetAutoBoostedClocksEnabled(h DeviceHandle) (enabled, defaultEnabled EnableState, err error)
+	DeviceSetAutoBoostedClocksEnabled(h DeviceHandle, enabled EnableState) error
+	DeviceSetDefaultAutoBoostedClocksEnabled(h DeviceHandle, enabled EnableState, flags uint) error
+	DeviceGetMemoryInfo(h DeviceHandle) (total, used uint64, err error)
+	DeviceGetBAR1MemoryInfo(h DeviceHandle) (total, used uint64, err error)
+	DeviceGetUtilizationRates(h DeviceHandle) (gpu, memory uint, err error)
+	DeviceGetPowerUsage(h DeviceHandle) (uint, error)
+	DeviceGetPowerManagementLimitConstraints(h DeviceHandle) (min, max uint, err error)
+	DeviceGetPowerManagementLimit(h DeviceHandle) (uint, error)
+	DeviceGetPowerManagementDefaultLimit(h DeviceHandle) (uint, error)
+	DeviceGetEnforcedPowerLimit(h DeviceHandle) (uint, error)
+	DeviceSetPowerManagementLimit(h DeviceHandle, limit uint) error
+	DeviceGetPowerManagementMode(h DeviceHandle) (EnableState, error)
+	DeviceGetPowerState(h DeviceHandle) (PowerState, error)
+	DeviceGetPcieThroughput(h DeviceHandle, counter PcieUtilCounter) (uint, error)
+	DeviceGetCurrPcieLinkGeneration(h DeviceHandle) (uint, error)
+	DeviceGetCurrPcieLinkWidth(h DeviceHandle) (uint, error)
+	DeviceGetMaxPcieLinkGeneration(h DeviceHandle) (uint, error)
+	DeviceGetMaxPcieLinkWidth(h DeviceHandle) (uint, error)
+	DeviceGetTemperature(h DeviceHandle, sensor TemperatureSensor) (uint, error)
+	DeviceGetTemperatureThreshold(h DeviceHandle, threshold TemperatureThreshold) (uint, error)
+	DeviceGetFanSpeed(h DeviceHandle) (uint, error)
+	DeviceGetEncoderUtilization(h DeviceHandle) (utilization, samplingPeriodUs uint, err error)
+	DeviceGetEncoderCapacity(h DeviceHandle, encoderType EncoderType) (uint, error)
+	DeviceGetDecoderUtilization(h DeviceHandle) (utilization, samplingPeriodUs uint, err error)
+	// DeviceGetSamples returns the samples of the given type that are newer
+	// than lastSeenTimeStamp (unix epoch in microseconds), oldest first.
+	DeviceGetSamples(h DeviceHandle, samplingType SamplingType, lastSeenTimeStamp uint64) ([]Sample, error)
+	DeviceGetAccountingMode(h DeviceHandle) (EnableState, error)
+	DeviceGetAccountingStats(h DeviceHandle, pid uint) (AccountingStats, error)
+	DeviceGetAccountingPids(h DeviceHandle, count uint) ([]uint, uint, error)
+	DeviceGetAccountingBufferSize(h DeviceHandle) (uint, error)
+	DeviceSetAccountingMode(h DeviceHandle, mode EnableState) error
+	DeviceClearAccountingPids(h DeviceHandle) error
+	DeviceGetProcessUtilization(h DeviceHandle, processCount uint, lastSeenTimeStamp uint64) ([]*Utilization, error)
+	DeviceGetComputeRunningProcesses(h DeviceHandle) ([]Process, error)
+	DeviceGetGraphicsRunningProcesses(h DeviceHandle) ([]Process, error)
+
+	EventSetCreate() (EventSetHandle, error)
+	EventSetFree(set EventSetHandle) error
+	// EventSetWait waits for at most timeoutMs milliseconds for an event.
+	EventSetWait(set EventSetHandle, timeoutMs uint) (EventData, error)
+	DeviceRegisterEvents(h DeviceHandle, eventTypes EventType, set EventSetHandle) error
+	DeviceGetSupportedEventTypes(h DeviceHandle) (EventType, error)
+
+	// DeviceGetFieldValues fills in the fields of values that follow the
+	// FieldID and ScopeID set by the caller.
+	DeviceGetFieldValues(h DeviceHandle, values []FieldValue) error
+
+	DeviceGetNvLinkState(h DeviceHandle, link uint) (EnableState, error)
+	DeviceGetNvLinkVersion(h DeviceHandle, link uint) (uint, error)
+	DeviceGetNvLinkCapability(h DeviceHandle, link uint, capability NvLinkCapability) (uint, error)
+	DeviceGetNvLinkRemotePciInfo(h DeviceHandle, link uint) (PciInfo, error)
+	DeviceGetNvLinkErrorCounter(h DeviceHandle, link uint, counter NvLinkErrorCounter) (uint64, error)
+	DeviceResetNvLinkErrorCounters(h DeviceHandle, link uint) error
+	DeviceSetNvLinkUtilizationControl(h DeviceHandle, link, counter uint, control NvLinkUtilizationControl, reset bool) error
+	DeviceGetNvLinkUtilizationControl(h DeviceHandle, link, counter uint) (NvLinkUtilizationControl, error)
+	DeviceGetNvLinkUtilizationCounter(h DeviceHandle, link, counter uint) (rx, tx uint64, err error)
+	DeviceFreezeNvLinkUtilizationCounter(h DeviceHandle, link, counter uint, freeze EnableState) error
+	DeviceResetNvLinkUtilizationCounter(h DeviceHandle, link, counter uint) error
+
+	DeviceGetTopologyCommonAncestor(h1, h2 DeviceHandle) (TopologyLevel, error)
+	DeviceGetTopologyNearestGpus(h DeviceHandle, level TopologyLevel) ([]DeviceHandle, error)
+	SystemGetTopologyGpuSet(cpu uint) ([]DeviceHandle, error)
+	DeviceGetP2PStatus(h1, h2 DeviceHandle, index P2PCapsIndex) (P2PStatus, error)
+	// The affinity getters return the ids of the bits set in the CPU or
+	// NUMA node masks, which have room for maxAffinityIDs ids.
+	DeviceGetCpuAffinityWithinScope(h DeviceHandle, scope AffinityScope) ([]uint, error)
+	DeviceGetCpuAffinity(h DeviceHandle) ([]uint, error)
+	DeviceGetMemoryAffinity(h DeviceHandle, scope AffinityScope) ([]uint, error)
+	DeviceSetCpuAffinity(h DeviceHandle) error
+	DeviceClearCpuAffinity(h DeviceHandle) error
+
+	DeviceGetMigMode(h DeviceHandle) (current, pending EnableState, err error)
+	DeviceSetMigMode(h DeviceHandle, mode EnableState) (activationStatus Return, err error)
+	DeviceGetGpuInstanceProfileInfo(h DeviceHandle, profile GpuInstanceProfile) (GpuInstanceProfileInfo, error)
+	// count is the size of the buffer passed to NVML, i.e. the
+	// InstanceCount of the profile.
+	DeviceGetGpuInstancePossiblePlacements(h DeviceHandle, profileID, count uint) ([]GpuInstancePlacement, error)
+	DeviceGetGpuInstanceRemainingCapacity(h DeviceHandle, profileID uint) (uint, error)
+	DeviceCreateGpuInstance(h DeviceHandle, profileID uint) (GpuInstanceHandle, error)
+	DeviceGetGpuInstances(h DeviceHandle, profileID, count uint) ([]GpuInstanceHandle, error)
+	DeviceGetGpuInstanceById(h DeviceHandle, id uint) (GpuInstanceHandle, error)
+	GpuInstanceDestroy(gi GpuInstanceHandle) error
+	// GpuInstanceGetInfo and ComputeInstanceGetInfo return the handles of
+	// the parents separately, the Device and GpuInstance fields of the
+	// infos are filled in by this package.
+	GpuInstanceGetInfo(gi GpuInstanceHandle) (DeviceHandle, GpuInstanceInfo, error)
+	GpuInstanceGetComputeInstanceProfileInfo(gi GpuInstanceHandle, profile ComputeInstanceProfile, engProfile ComputeInstanceEngineProfile) (ComputeInstanceProfileInfo, error)
+	GpuInstanceGetComputeInstanceRemainingCapacity(gi GpuInstanceHandle, profileID uint) (uint, error)
+	GpuInstanceCreateComputeInstance(gi GpuInstanceHandle, profileID uint) (ComputeInstanceHandle, error)
+	GpuInstanceGetComputeInstances(gi GpuInstanceHandle, profileID, count uint) ([]ComputeInstanceHandle, error)
+	GpuInstanceGetComputeInstanceById(gi GpuInstanceHandle, id uint) (ComputeInstanceHandle, error)
+	ComputeInstanceDestroy(ci ComputeInstanceHandle) error
+	ComputeInstanceGetInfo(ci ComputeInstanceHandle) (DeviceHandle, GpuInstanceHandle, ComputeInstanceInfo, error)
+	DeviceIsMigDeviceHandle(h DeviceHandle) (bool, error)
+	DeviceGetGpuInstanceId(h DeviceHandle) (uint, error)
+	DeviceGetComputeInstanceId(h DeviceHandle) (uint, error)
+	DeviceGetMaxMigDeviceCount(h DeviceHandle) (uint, error)
+	DeviceGetMigDeviceHandleByIndex(h DeviceHandle, index uint) (DeviceHandle, error)
+	DeviceGetDeviceHandleFromMigDeviceHandle(h DeviceHandle) (DeviceHandle, error)
+}
+
+// backend is the Backend used by the package level functions and the Device
+// methods.
+var backend = newDefaultBackend()
+
+// SetBackend selects the Backend used by this package, e.g. a FakeBackend in
+// tests. Passing nil restores the default backend. It should be called while
+// NVML is not initialized, i.e. before Initialize or after Shutdown: the
+// references taken by Initialize are dropped without shutting the previous
+// backend down.
+func SetBackend(b Backend) {
+	if b == nil {
+		b = newDefaultBackend()
+	}
+	initMu.Lock()
+	defer initMu.Unlock()
+	backend = b
+	initRefs, loadedLibrary = 0, ""
+}
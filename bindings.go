@@ -1,3 +1,7 @@
+//go:build cgo && !(nvml_purego && linux && (amd64 || arm64))
+// +build cgo
+// +build !nvml_purego !linux !amd64,!arm64
+
 /*
 Copyright 2017 Google Inc.
 
@@ -30,6 +34,8 @@ void *nvmlHandle;
 
 nvmlReturn_t (*nvmlInitFunc)(void);
 
+nvmlReturn_t (*nvmlInitWithFlagsFunc)(unsigned int flags);
+
 nvmlReturn_t (*nvmlShutdownFunc)(void);
 
 const char* (*nvmlErrorStringFunc)(nvmlReturn_t result);
@@ -473,267 +479,885 @@ nvmlReturn_t nvmlDeviceGetGraphicsRunningProcesses(nvmlDevice_t device, unsigned
 
 nvmlReturn_t (*nvmlDeviceGetSamplesFunc)(nvmlDevice_t device, nvmlSamplingType_t type, unsigned long long lastSeenTimeStamp, nvmlValueType_t *sampleValType, unsigned int *sampleCount, nvmlSample_t *samples);
 
-// Loads the "libnvidia-ml.so.1" shared library.
-// Loads all symbols needed and initializes NVML.
-// Call this before calling any other methods.
-nvmlReturn_t nvmlInit_dl(void) {
-  nvmlHandle = dlopen("libnvidia-ml.so.1", RTLD_LAZY);
-  if (nvmlHandle == NULL) {
-    return NVML_ERROR_LIBRARY_NOT_FOUND;
-  }
-  nvmlInitFunc = dlsym(nvmlHandle, "nvmlInit_v2");
-  if (nvmlInitFunc == NULL) {
+nvmlReturn_t (*nvmlEventSetCreateFunc)(nvmlEventSet_t *set);
+nvmlReturn_t nvmlEventSetCreate(nvmlEventSet_t *set) {
+  if (nvmlEventSetCreateFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlShutdownFunc = dlsym(nvmlHandle, "nvmlShutdown");
-  if (nvmlShutdownFunc == NULL) {
+  return nvmlEventSetCreateFunc(set);
+}
+
+nvmlReturn_t (*nvmlEventSetFreeFunc)(nvmlEventSet_t set);
+nvmlReturn_t nvmlEventSetFree(nvmlEventSet_t set) {
+  if (nvmlEventSetFreeFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlErrorStringFunc = dlsym(nvmlHandle, "nvmlErrorString");
-  if (nvmlErrorStringFunc == NULL) {
+  return nvmlEventSetFreeFunc(set);
+}
+
+nvmlReturn_t (*nvmlEventSetWaitFunc)(nvmlEventSet_t set, nvmlEventData_t *data, unsigned int timeoutms);
+nvmlReturn_t nvmlEventSetWait(nvmlEventSet_t set, nvmlEventData_t *data, unsigned int timeoutms) {
+  if (nvmlEventSetWaitFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetHandleByIndexFunc = dlsym(nvmlHandle, "nvmlDeviceGetHandleByIndex_v2");
-  if (nvmlDeviceGetHandleByIndexFunc == NULL) {
+  return nvmlEventSetWaitFunc(set, data, timeoutms);
+}
+
+nvmlReturn_t (*nvmlDeviceRegisterEventsFunc)(nvmlDevice_t device, unsigned long long eventTypes, nvmlEventSet_t set);
+nvmlReturn_t nvmlDeviceRegisterEvents(nvmlDevice_t device, unsigned long long eventTypes, nvmlEventSet_t set) {
+  if (nvmlDeviceRegisterEventsFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-	nvmlDeviceGetIndexFunc = dlsym(nvmlHandle, "nvmlDeviceGetIndex");
-	if (nvmlDeviceGetIndexFunc == NULL) {
+  return nvmlDeviceRegisterEventsFunc(device, eventTypes, set);
+}
+
+nvmlReturn_t (*nvmlDeviceGetFieldValuesFunc)(nvmlDevice_t device, int valuesCount, nvmlFieldValue_t *values);
+nvmlReturn_t nvmlDeviceGetFieldValues(nvmlDevice_t device, int valuesCount, nvmlFieldValue_t *values) {
+  if (nvmlDeviceGetFieldValuesFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlSystemGetDriverVersionFunc = dlsym(nvmlHandle, "nvmlSystemGetDriverVersion");
-  if (nvmlSystemGetDriverVersionFunc == NULL) {
+  return nvmlDeviceGetFieldValuesFunc(device, valuesCount, values);
+}
+
+nvmlReturn_t (*nvmlDeviceGetSupportedEventTypesFunc)(nvmlDevice_t device, unsigned long long *eventTypes);
+nvmlReturn_t nvmlDeviceGetSupportedEventTypes(nvmlDevice_t device, unsigned long long *eventTypes) {
+  if (nvmlDeviceGetSupportedEventTypesFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-	nvmlSystemGetNVMLVersionFunc = dlsym(nvmlHandle, "nvmlSystemGetNVMLVersion");
-	if(nvmlSystemGetNVMLVersionFunc == NULL){
-		return NVML_ERROR_FUNCTION_NOT_FOUND;
-	}
-  nvmlDeviceGetCountFunc = dlsym(nvmlHandle, "nvmlDeviceGetCount_v2");
-  if (nvmlDeviceGetCountFunc == NULL) {
+  return nvmlDeviceGetSupportedEventTypesFunc(device, eventTypes);
+}
+
+nvmlReturn_t (*nvmlDeviceGetNvLinkStateFunc)(nvmlDevice_t device, unsigned int link, nvmlEnableState_t *isActive);
+nvmlReturn_t nvmlDeviceGetNvLinkState(nvmlDevice_t device, unsigned int link, nvmlEnableState_t *isActive) {
+  if (nvmlDeviceGetNvLinkStateFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-	nvmlDeviceGetBrandFunc = dlsym(nvmlHandle, "nvmlDeviceGetBrand");
-	if(nvmlDeviceGetBrandFunc == NULL) {
-		return NVML_ERROR_FUNCTION_NOT_FOUND;
-	}
-	nvmlDeviceGetBoardIdFunc = dlsym(nvmlHandle, "nvmlDeviceGetBoardId");
-	if(nvmlDeviceGetBoardIdFunc == NULL) {
-		return NVML_ERROR_FUNCTION_NOT_FOUND;
-	}
-	nvmlDeviceGetComputeModeFunc = dlsym(nvmlHandle, "nvmlDeviceGetComputeMode");
-	if(nvmlDeviceGetComputeModeFunc == NULL) {
-		return NVML_ERROR_FUNCTION_NOT_FOUND;
-	}
-	nvmlDeviceGetDisplayModeFunc = dlsym(nvmlHandle, "nvmlDeviceGetDisplayMode");
-	if(nvmlDeviceGetDisplayModeFunc == NULL) {
-		return NVML_ERROR_FUNCTION_NOT_FOUND;
-	}
-	nvmlDeviceGetDisplayActiveFunc = dlsym(nvmlHandle, "nvmlDeviceGetDisplayActive");
-	if(nvmlDeviceGetDisplayActiveFunc == NULL) {
-		return NVML_ERROR_FUNCTION_NOT_FOUND;
-	}
-	nvmlDeviceGetVbiosVersionFunc = dlsym(nvmlHandle, "nvmlDeviceGetVbiosVersion");
-	if(nvmlDeviceGetDisplayActiveFunc == NULL) {
-		return NVML_ERROR_FUNCTION_NOT_FOUND;
-	}
-	nvmlDeviceGetCurrentClocksThrottleReasonsFunc = dlsym(nvmlHandle, "nvmlDeviceGetCurrentClocksThrottleReasons");
-	if(nvmlDeviceGetCurrentClocksThrottleReasonsFunc == NULL) {
-		return NVML_ERROR_FUNCTION_NOT_FOUND;
-	}
-	nvmlDeviceGetTotalEnergyConsumptionFunc = dlsym(nvmlHandle, "nvmlDeviceGetTotalEnergyConsumption");
-	if(nvmlDeviceGetTotalEnergyConsumptionFunc == NULL) {
-		return NVML_ERROR_FUNCTION_NOT_FOUND;
-	}
-	nvmlDeviceGetTotalEccErrorsFunc = dlsym(nvmlHandle, "nvmlDeviceGetTotalEccErrors");
-	if(nvmlDeviceGetTotalEccErrorsFunc == NULL) {
-		return NVML_ERROR_FUNCTION_NOT_FOUND;
-	}
-	nvmlDeviceGetSerialFunc = dlsym(nvmlHandle, "nvmlDeviceGetSerial");
-	if(nvmlDeviceGetSerialFunc == NULL) {
-		return NVML_ERROR_FUNCTION_NOT_FOUND;
-	}
-	nvmlDeviceGetBAR1MemoryInfoFunc = dlsym(nvmlHandle, "nvmlDeviceGetBAR1MemoryInfo");
-	if(nvmlDeviceGetBAR1MemoryInfoFunc == NULL) {
-		return NVML_ERROR_FUNCTION_NOT_FOUND;
-	}
-	nvmlDeviceGetPcieThroughputFunc = dlsym(nvmlHandle, "nvmlDeviceGetPcieThroughput");
-	if(nvmlDeviceGetPcieThroughputFunc == NULL) {
-		return NVML_ERROR_FUNCTION_NOT_FOUND;
-	}
-	nvmlDeviceGetCurrPcieLinkGenerationFunc = dlsym(nvmlHandle, "nvmlDeviceGetCurrPcieLinkGeneration");
-	if(nvmlDeviceGetCurrPcieLinkGenerationFunc == NULL) {
-		return NVML_ERROR_FUNCTION_NOT_FOUND;
-	}
-	nvmlDeviceGetMaxPcieLinkGenerationFunc = dlsym(nvmlHandle, "nvmlDeviceGetMaxPcieLinkGeneration");
-	if(nvmlDeviceGetMaxPcieLinkGenerationFunc == NULL) {
-		return NVML_ERROR_FUNCTION_NOT_FOUND;
-	}
-	nvmlDeviceGetMaxPcieLinkWidthFunc = dlsym(nvmlHandle, "nvmlDeviceGetMaxPcieLinkWidth");
-	if(nvmlDeviceGetMaxPcieLinkWidthFunc == NULL) {
-		return NVML_ERROR_FUNCTION_NOT_FOUND;
-	}
-	nvmlDeviceGetCurrPcieLinkWidthFunc = dlsym(nvmlHandle, "nvmlDeviceGetCurrPcieLinkWidth");
-	if(nvmlDeviceGetCurrPcieLinkWidthFunc == NULL) {
-		return NVML_ERROR_FUNCTION_NOT_FOUND;
-	}
-  nvmlDeviceGetMinorNumberFunc = dlsym(nvmlHandle, "nvmlDeviceGetMinorNumber");
-  if (nvmlDeviceGetMinorNumberFunc == NULL) {
+  return nvmlDeviceGetNvLinkStateFunc(device, link, isActive);
+}
+
+nvmlReturn_t (*nvmlDeviceGetNvLinkVersionFunc)(nvmlDevice_t device, unsigned int link, unsigned int *version);
+nvmlReturn_t nvmlDeviceGetNvLinkVersion(nvmlDevice_t device, unsigned int link, unsigned int *version) {
+  if (nvmlDeviceGetNvLinkVersionFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetUUIDFunc = dlsym(nvmlHandle, "nvmlDeviceGetUUID");
-  if (nvmlDeviceGetUUIDFunc == NULL) {
+  return nvmlDeviceGetNvLinkVersionFunc(device, link, version);
+}
+
+nvmlReturn_t (*nvmlDeviceGetNvLinkCapabilityFunc)(nvmlDevice_t device, unsigned int link, nvmlNvLinkCapability_t capability, unsigned int *capResult);
+nvmlReturn_t nvmlDeviceGetNvLinkCapability(nvmlDevice_t device, unsigned int link, nvmlNvLinkCapability_t capability, unsigned int *capResult) {
+  if (nvmlDeviceGetNvLinkCapabilityFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetNameFunc = dlsym(nvmlHandle, "nvmlDeviceGetName");
-  if (nvmlDeviceGetNameFunc == NULL) {
+  return nvmlDeviceGetNvLinkCapabilityFunc(device, link, capability, capResult);
+}
+
+nvmlReturn_t (*nvmlDeviceGetNvLinkRemotePciInfoFunc)(nvmlDevice_t device, unsigned int link, nvmlPciInfo_t *pci);
+nvmlReturn_t nvmlDeviceGetNvLinkRemotePciInfo(nvmlDevice_t device, unsigned int link, nvmlPciInfo_t *pci) {
+  if (nvmlDeviceGetNvLinkRemotePciInfoFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetBrandFunc = dlsym(nvmlHandle, "nvmlDeviceGetBrand");
-  if (nvmlDeviceGetBrandFunc == NULL) {
+  return nvmlDeviceGetNvLinkRemotePciInfoFunc(device, link, pci);
+}
+
+nvmlReturn_t (*nvmlDeviceGetNvLinkErrorCounterFunc)(nvmlDevice_t device, unsigned int link, nvmlNvLinkErrorCounter_t counter, unsigned long long *counterValue);
+nvmlReturn_t nvmlDeviceGetNvLinkErrorCounter(nvmlDevice_t device, unsigned int link, nvmlNvLinkErrorCounter_t counter, unsigned long long *counterValue) {
+  if (nvmlDeviceGetNvLinkErrorCounterFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetPersistenceModeFunc = dlsym(nvmlHandle, "nvmlDeviceGetPersistenceMode");
-  if (nvmlDeviceGetPersistenceModeFunc == NULL) {
+  return nvmlDeviceGetNvLinkErrorCounterFunc(device, link, counter, counterValue);
+}
+
+nvmlReturn_t (*nvmlDeviceResetNvLinkErrorCountersFunc)(nvmlDevice_t device, unsigned int link);
+nvmlReturn_t nvmlDeviceResetNvLinkErrorCounters(nvmlDevice_t device, unsigned int link) {
+  if (nvmlDeviceResetNvLinkErrorCountersFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceSetPersistenceModeFunc = dlsym(nvmlHandle, "nvmlDeviceSetPersistenceMode");
-  if (nvmlDeviceSetPersistenceModeFunc == NULL) {
+  return nvmlDeviceResetNvLinkErrorCountersFunc(device, link);
+}
+
+nvmlReturn_t (*nvmlDeviceSetNvLinkUtilizationControlFunc)(nvmlDevice_t device, unsigned int link, unsigned int counter, nvmlNvLinkUtilizationControl_t *control, unsigned int reset);
+nvmlReturn_t nvmlDeviceSetNvLinkUtilizationControl(nvmlDevice_t device, unsigned int link, unsigned int counter, nvmlNvLinkUtilizationControl_t *control, unsigned int reset) {
+  if (nvmlDeviceSetNvLinkUtilizationControlFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetComputeModeFunc = dlsym(nvmlHandle, "nvmlDeviceGetComputeMode");
-  if (nvmlDeviceGetComputeModeFunc == NULL) {
+  return nvmlDeviceSetNvLinkUtilizationControlFunc(device, link, counter, control, reset);
+}
+
+nvmlReturn_t (*nvmlDeviceGetNvLinkUtilizationControlFunc)(nvmlDevice_t device, unsigned int link, unsigned int counter, nvmlNvLinkUtilizationControl_t *control);
+nvmlReturn_t nvmlDeviceGetNvLinkUtilizationControl(nvmlDevice_t device, unsigned int link, unsigned int counter, nvmlNvLinkUtilizationControl_t *control) {
+  if (nvmlDeviceGetNvLinkUtilizationControlFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceSetComputeModeFunc = dlsym(nvmlHandle, "nvmlDeviceSetComputeMode");
-  if (nvmlDeviceSetComputeModeFunc == NULL) {
+  return nvmlDeviceGetNvLinkUtilizationControlFunc(device, link, counter, control);
+}
+
+nvmlReturn_t (*nvmlDeviceGetNvLinkUtilizationCounterFunc)(nvmlDevice_t device, unsigned int link, unsigned int counter, unsigned long long *rxcounter, unsigned long long *txcounter);
+nvmlReturn_t nvmlDeviceGetNvLinkUtilizationCounter(nvmlDevice_t device, unsigned int link, unsigned int counter, unsigned long long *rxcounter, unsigned long long *txcounter) {
+  if (nvmlDeviceGetNvLinkUtilizationCounterFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetPerformanceStateFunc = dlsym(nvmlHandle, "nvmlDeviceGetPerformanceState");
-  if (nvmlDeviceGetPerformanceStateFunc== NULL) {
+  return nvmlDeviceGetNvLinkUtilizationCounterFunc(device, link, counter, rxcounter, txcounter);
+}
+
+nvmlReturn_t (*nvmlDeviceFreezeNvLinkUtilizationCounterFunc)(nvmlDevice_t device, unsigned int link, unsigned int counter, nvmlEnableState_t freeze);
+nvmlReturn_t nvmlDeviceFreezeNvLinkUtilizationCounter(nvmlDevice_t device, unsigned int link, unsigned int counter, nvmlEnableState_t freeze) {
+  if (nvmlDeviceFreezeNvLinkUtilizationCounterFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetClockInfoFunc = dlsym(nvmlHandle, "nvmlDeviceGetClockInfo");
-  if (nvmlDeviceGetClockInfoFunc == NULL) {
+  return nvmlDeviceFreezeNvLinkUtilizationCounterFunc(device, link, counter, freeze);
+}
+
+nvmlReturn_t (*nvmlDeviceResetNvLinkUtilizationCounterFunc)(nvmlDevice_t device, unsigned int link, unsigned int counter);
+nvmlReturn_t nvmlDeviceResetNvLinkUtilizationCounter(nvmlDevice_t device, unsigned int link, unsigned int counter) {
+  if (nvmlDeviceResetNvLinkUtilizationCounterFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetMaxClockInfoFunc = dlsym(nvmlHandle, "nvmlDeviceGetMaxClockInfo");
-  if (nvmlDeviceGetMaxClockInfoFunc == NULL) {
+  return nvmlDeviceResetNvLinkUtilizationCounterFunc(device, link, counter);
+}
+
+nvmlReturn_t (*nvmlDeviceSetMigModeFunc)(nvmlDevice_t device, unsigned int mode, nvmlReturn_t *activationStatus);
+nvmlReturn_t nvmlDeviceSetMigMode(nvmlDevice_t device, unsigned int mode, nvmlReturn_t *activationStatus) {
+  if (nvmlDeviceSetMigModeFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetMemoryInfoFunc = dlsym(nvmlHandle, "nvmlDeviceGetMemoryInfo");
-  if (nvmlDeviceGetMemoryInfoFunc == NULL) {
+  return nvmlDeviceSetMigModeFunc(device, mode, activationStatus);
+}
+
+nvmlReturn_t (*nvmlDeviceGetMigModeFunc)(nvmlDevice_t device, unsigned int *currentMode, unsigned int *pendingMode);
+nvmlReturn_t nvmlDeviceGetMigMode(nvmlDevice_t device, unsigned int *currentMode, unsigned int *pendingMode) {
+  if (nvmlDeviceGetMigModeFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetUtilizationRatesFunc = dlsym(nvmlHandle, "nvmlDeviceGetUtilizationRates");
-  if (nvmlDeviceGetUtilizationRatesFunc == NULL) {
+  return nvmlDeviceGetMigModeFunc(device, currentMode, pendingMode);
+}
+
+nvmlReturn_t (*nvmlDeviceGetGpuInstanceProfileInfoFunc)(nvmlDevice_t device, unsigned int profile, nvmlGpuInstanceProfileInfo_t *info);
+nvmlReturn_t nvmlDeviceGetGpuInstanceProfileInfo(nvmlDevice_t device, unsigned int profile, nvmlGpuInstanceProfileInfo_t *info) {
+  if (nvmlDeviceGetGpuInstanceProfileInfoFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetPowerUsageFunc = dlsym(nvmlHandle, "nvmlDeviceGetPowerUsage");
-  if (nvmlDeviceGetPowerUsageFunc == NULL) {
+  return nvmlDeviceGetGpuInstanceProfileInfoFunc(device, profile, info);
+}
+
+nvmlReturn_t (*nvmlDeviceGetGpuInstancePossiblePlacementsFunc)(nvmlDevice_t device, unsigned int profileId, nvmlGpuInstancePlacement_t *placements, unsigned int *count);
+nvmlReturn_t nvmlDeviceGetGpuInstancePossiblePlacements(nvmlDevice_t device, unsigned int profileId, nvmlGpuInstancePlacement_t *placements, unsigned int *count) {
+  if (nvmlDeviceGetGpuInstancePossiblePlacementsFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetPowerManagementLimitConstraintsFunc = dlsym(nvmlHandle, "nvmlDeviceGetPowerManagementLimitConstraints");
-  if (nvmlDeviceGetPowerManagementLimitConstraintsFunc == NULL) {
+  return nvmlDeviceGetGpuInstancePossiblePlacementsFunc(device, profileId, placements, count);
+}
+
+nvmlReturn_t (*nvmlDeviceGetGpuInstanceRemainingCapacityFunc)(nvmlDevice_t device, unsigned int profileId, unsigned int *count);
+nvmlReturn_t nvmlDeviceGetGpuInstanceRemainingCapacity(nvmlDevice_t device, unsigned int profileId, unsigned int *count) {
+  if (nvmlDeviceGetGpuInstanceRemainingCapacityFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetPowerManagementDefaultLimitFunc = dlsym(nvmlHandle, "nvmlDeviceGetPowerManagementDefaultLimit");
-  if (nvmlDeviceGetPowerManagementDefaultLimitFunc == NULL) {
+  return nvmlDeviceGetGpuInstanceRemainingCapacityFunc(device, profileId, count);
+}
+
+nvmlReturn_t (*nvmlDeviceCreateGpuInstanceFunc)(nvmlDevice_t device, unsigned int profileId, nvmlGpuInstance_t *gpuInstance);
+nvmlReturn_t nvmlDeviceCreateGpuInstance(nvmlDevice_t device, unsigned int profileId, nvmlGpuInstance_t *gpuInstance) {
+  if (nvmlDeviceCreateGpuInstanceFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetPcieThroughputFunc = dlsym(nvmlHandle, "nvmlDeviceGetPcieThroughput");
-  if (nvmlDeviceGetPcieThroughputFunc== NULL) {
+  return nvmlDeviceCreateGpuInstanceFunc(device, profileId, gpuInstance);
+}
+
+nvmlReturn_t (*nvmlGpuInstanceDestroyFunc)(nvmlGpuInstance_t gpuInstance);
+nvmlReturn_t nvmlGpuInstanceDestroy(nvmlGpuInstance_t gpuInstance) {
+  if (nvmlGpuInstanceDestroyFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetCurrPcieLinkGenerationFunc = dlsym(nvmlHandle, "nvmlDeviceGetCurrPcieLinkGeneration");
-  if (nvmlDeviceGetCurrPcieLinkGenerationFunc == NULL) {
+  return nvmlGpuInstanceDestroyFunc(gpuInstance);
+}
+
+nvmlReturn_t (*nvmlDeviceGetGpuInstancesFunc)(nvmlDevice_t device, unsigned int profileId, nvmlGpuInstance_t *gpuInstances, unsigned int *count);
+nvmlReturn_t nvmlDeviceGetGpuInstances(nvmlDevice_t device, unsigned int profileId, nvmlGpuInstance_t *gpuInstances, unsigned int *count) {
+  if (nvmlDeviceGetGpuInstancesFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetCurrPcieLinkWidthFunc = dlsym(nvmlHandle, "nvmlDeviceGetCurrPcieLinkWidth");
-  if (nvmlDeviceGetCurrPcieLinkWidthFunc == NULL) {
+  return nvmlDeviceGetGpuInstancesFunc(device, profileId, gpuInstances, count);
+}
+
+nvmlReturn_t (*nvmlDeviceGetGpuInstanceByIdFunc)(nvmlDevice_t device, unsigned int id, nvmlGpuInstance_t *gpuInstance);
+nvmlReturn_t nvmlDeviceGetGpuInstanceById(nvmlDevice_t device, unsigned int id, nvmlGpuInstance_t *gpuInstance) {
+  if (nvmlDeviceGetGpuInstanceByIdFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetMaxPcieLinkGenerationFunc = dlsym(nvmlHandle, "nvmlDeviceGetMaxPcieLinkGeneration");
-  if (nvmlDeviceGetMaxPcieLinkGenerationFunc == NULL) {
+  return nvmlDeviceGetGpuInstanceByIdFunc(device, id, gpuInstance);
+}
+
+nvmlReturn_t (*nvmlGpuInstanceGetInfoFunc)(nvmlGpuInstance_t gpuInstance, nvmlGpuInstanceInfo_t *info);
+nvmlReturn_t nvmlGpuInstanceGetInfo(nvmlGpuInstance_t gpuInstance, nvmlGpuInstanceInfo_t *info) {
+  if (nvmlGpuInstanceGetInfoFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetMaxPcieLinkWidthFunc = dlsym(nvmlHandle, "nvmlDeviceGetMaxPcieLinkWidth");
-  if (nvmlDeviceGetMaxPcieLinkWidthFunc == NULL) {
+  return nvmlGpuInstanceGetInfoFunc(gpuInstance, info);
+}
+
+nvmlReturn_t (*nvmlGpuInstanceGetComputeInstanceProfileInfoFunc)(nvmlGpuInstance_t gpuInstance, unsigned int profile, unsigned int engProfile, nvmlComputeInstanceProfileInfo_t *info);
+nvmlReturn_t nvmlGpuInstanceGetComputeInstanceProfileInfo(nvmlGpuInstance_t gpuInstance, unsigned int profile, unsigned int engProfile, nvmlComputeInstanceProfileInfo_t *info) {
+  if (nvmlGpuInstanceGetComputeInstanceProfileInfoFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetPowerManagementLimitFunc = dlsym(nvmlHandle, "nvmlDeviceGetPowerManagementLimit");
-	if (nvmlDeviceGetPowerManagementLimitFunc == NULL) {
+  return nvmlGpuInstanceGetComputeInstanceProfileInfoFunc(gpuInstance, profile, engProfile, info);
+}
+
+nvmlReturn_t (*nvmlGpuInstanceGetComputeInstanceRemainingCapacityFunc)(nvmlGpuInstance_t gpuInstance, unsigned int profileId, unsigned int *count);
+nvmlReturn_t nvmlGpuInstanceGetComputeInstanceRemainingCapacity(nvmlGpuInstance_t gpuInstance, unsigned int profileId, unsigned int *count) {
+  if (nvmlGpuInstanceGetComputeInstanceRemainingCapacityFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetEnforcedPowerLimitFunc = dlsym(nvmlHandle, "nvmlDeviceGetEnforcedPowerLimit");
-	if (nvmlDeviceGetEnforcedPowerLimitFunc == NULL) {
+  return nvmlGpuInstanceGetComputeInstanceRemainingCapacityFunc(gpuInstance, profileId, count);
+}
+
+nvmlReturn_t (*nvmlGpuInstanceCreateComputeInstanceFunc)(nvmlGpuInstance_t gpuInstance, unsigned int profileId, nvmlComputeInstance_t *computeInstance);
+nvmlReturn_t nvmlGpuInstanceCreateComputeInstance(nvmlGpuInstance_t gpuInstance, unsigned int profileId, nvmlComputeInstance_t *computeInstance) {
+  if (nvmlGpuInstanceCreateComputeInstanceFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetTemperatureFunc = dlsym(nvmlHandle, "nvmlDeviceGetTemperature");
-  if (nvmlDeviceGetTemperatureFunc == NULL) {
+  return nvmlGpuInstanceCreateComputeInstanceFunc(gpuInstance, profileId, computeInstance);
+}
+
+nvmlReturn_t (*nvmlComputeInstanceDestroyFunc)(nvmlComputeInstance_t computeInstance);
+nvmlReturn_t nvmlComputeInstanceDestroy(nvmlComputeInstance_t computeInstance) {
+  if (nvmlComputeInstanceDestroyFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-	nvmlDeviceGetTemperatureThresholdFunc = dlsym(nvmlHandle, "nvmlDeviceGetTemperatureThreshold");
-	if (nvmlDeviceGetTemperatureThresholdFunc == NULL) {
+  return nvmlComputeInstanceDestroyFunc(computeInstance);
+}
+
+nvmlReturn_t (*nvmlGpuInstanceGetComputeInstancesFunc)(nvmlGpuInstance_t gpuInstance, unsigned int profileId, nvmlComputeInstance_t *computeInstances, unsigned int *count);
+nvmlReturn_t nvmlGpuInstanceGetComputeInstances(nvmlGpuInstance_t gpuInstance, unsigned int profileId, nvmlComputeInstance_t *computeInstances, unsigned int *count) {
+  if (nvmlGpuInstanceGetComputeInstancesFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetFanSpeedFunc = dlsym(nvmlHandle, "nvmlDeviceGetFanSpeed");
-  if (nvmlDeviceGetFanSpeedFunc == NULL) {
+  return nvmlGpuInstanceGetComputeInstancesFunc(gpuInstance, profileId, computeInstances, count);
+}
+
+nvmlReturn_t (*nvmlGpuInstanceGetComputeInstanceByIdFunc)(nvmlGpuInstance_t gpuInstance, unsigned int id, nvmlComputeInstance_t *computeInstance);
+nvmlReturn_t nvmlGpuInstanceGetComputeInstanceById(nvmlGpuInstance_t gpuInstance, unsigned int id, nvmlComputeInstance_t *computeInstance) {
+  if (nvmlGpuInstanceGetComputeInstanceByIdFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetSamplesFunc = dlsym(nvmlHandle, "nvmlDeviceGetSamples");
-  if (nvmlDeviceGetSamplesFunc == NULL) {
+  return nvmlGpuInstanceGetComputeInstanceByIdFunc(gpuInstance, id, computeInstance);
+}
+
+nvmlReturn_t (*nvmlComputeInstanceGetInfoFunc)(nvmlComputeInstance_t computeInstance, nvmlComputeInstanceInfo_t *info);
+nvmlReturn_t nvmlComputeInstanceGetInfo(nvmlComputeInstance_t computeInstance, nvmlComputeInstanceInfo_t *info) {
+  if (nvmlComputeInstanceGetInfoFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetEncoderUtilizationFunc = dlsym(nvmlHandle, "nvmlDeviceGetEncoderUtilization");
-  if (nvmlDeviceGetEncoderUtilizationFunc == NULL) {
+  return nvmlComputeInstanceGetInfoFunc(computeInstance, info);
+}
+
+nvmlReturn_t (*nvmlDeviceIsMigDeviceHandleFunc)(nvmlDevice_t device, unsigned int *isMigDevice);
+nvmlReturn_t nvmlDeviceIsMigDeviceHandle(nvmlDevice_t device, unsigned int *isMigDevice) {
+  if (nvmlDeviceIsMigDeviceHandleFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetEncoderCapacityFunc = dlsym(nvmlHandle, "nvmlDeviceGetEncoderCapacity");
-  if (nvmlDeviceGetEncoderCapacityFunc == NULL) {
+  return nvmlDeviceIsMigDeviceHandleFunc(device, isMigDevice);
+}
+
+nvmlReturn_t (*nvmlDeviceGetGpuInstanceIdFunc)(nvmlDevice_t device, unsigned int *id);
+nvmlReturn_t nvmlDeviceGetGpuInstanceId(nvmlDevice_t device, unsigned int *id) {
+  if (nvmlDeviceGetGpuInstanceIdFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetDecoderUtilizationFunc = dlsym(nvmlHandle, "nvmlDeviceGetDecoderUtilization");
-  if (nvmlDeviceGetDecoderUtilizationFunc == NULL) {
+  return nvmlDeviceGetGpuInstanceIdFunc(device, id);
+}
+
+nvmlReturn_t (*nvmlDeviceGetComputeInstanceIdFunc)(nvmlDevice_t device, unsigned int *id);
+nvmlReturn_t nvmlDeviceGetComputeInstanceId(nvmlDevice_t device, unsigned int *id) {
+  if (nvmlDeviceGetComputeInstanceIdFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlSystemGetProcessNameFunc = dlsym(nvmlHandle, "nvmlSystemGetProcessName");
-  if (nvmlSystemGetProcessNameFunc == NULL) {
+  return nvmlDeviceGetComputeInstanceIdFunc(device, id);
+}
+
+nvmlReturn_t (*nvmlDeviceGetMaxMigDeviceCountFunc)(nvmlDevice_t device, unsigned int *count);
+nvmlReturn_t nvmlDeviceGetMaxMigDeviceCount(nvmlDevice_t device, unsigned int *count) {
+  if (nvmlDeviceGetMaxMigDeviceCountFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetAccountingModeFunc = dlsym(nvmlHandle, "nvmlDeviceGetAccountingMode");
-  if (nvmlDeviceGetAccountingModeFunc == NULL) {
+  return nvmlDeviceGetMaxMigDeviceCountFunc(device, count);
+}
+
+nvmlReturn_t (*nvmlDeviceGetMigDeviceHandleByIndexFunc)(nvmlDevice_t device, unsigned int index, nvmlDevice_t *migDevice);
+nvmlReturn_t nvmlDeviceGetMigDeviceHandleByIndex(nvmlDevice_t device, unsigned int index, nvmlDevice_t *migDevice) {
+  if (nvmlDeviceGetMigDeviceHandleByIndexFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetAccountingStatsFunc = dlsym(nvmlHandle, "nvmlDeviceGetAccountingStats");
-  if (nvmlDeviceGetAccountingStatsFunc == NULL) {
+  return nvmlDeviceGetMigDeviceHandleByIndexFunc(device, index, migDevice);
+}
+
+nvmlReturn_t (*nvmlDeviceGetDeviceHandleFromMigDeviceHandleFunc)(nvmlDevice_t migDevice, nvmlDevice_t *device);
+nvmlReturn_t nvmlDeviceGetDeviceHandleFromMigDeviceHandle(nvmlDevice_t migDevice, nvmlDevice_t *device) {
+  if (nvmlDeviceGetDeviceHandleFromMigDeviceHandleFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetAccountingPidsFunc = dlsym(nvmlHandle, "nvmlDeviceGetAccountingPids");
-  if (nvmlDeviceGetAccountingPidsFunc == NULL) {
+  return nvmlDeviceGetDeviceHandleFromMigDeviceHandleFunc(migDevice, device);
+}
+
+nvmlReturn_t (*nvmlDeviceGetHandleByUUIDFunc)(const char *uuid, nvmlDevice_t *device);
+nvmlReturn_t nvmlDeviceGetHandleByUUID(const char *uuid, nvmlDevice_t *device) {
+  if (nvmlDeviceGetHandleByUUIDFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetAccountingBufferSizeFunc = dlsym(nvmlHandle, "nvmlDeviceGetAccountingBufferSize");
-  if (nvmlDeviceGetAccountingBufferSizeFunc == NULL) {
+  return nvmlDeviceGetHandleByUUIDFunc(uuid, device);
+}
+
+nvmlReturn_t (*nvmlDeviceGetHandleByPciBusIdFunc)(const char *pciBusId, nvmlDevice_t *device);
+nvmlReturn_t nvmlDeviceGetHandleByPciBusId(const char *pciBusId, nvmlDevice_t *device) {
+  if (nvmlDeviceGetHandleByPciBusIdFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetProcessUtilizationFunc = dlsym(nvmlHandle, "nvmlDeviceGetProcessUtilization");
-  if (nvmlDeviceGetProcessUtilizationFunc == NULL) {
+  return nvmlDeviceGetHandleByPciBusIdFunc(pciBusId, device);
+}
+
+nvmlReturn_t (*nvmlDeviceGetHandleBySerialFunc)(const char *serial, nvmlDevice_t *device);
+nvmlReturn_t nvmlDeviceGetHandleBySerial(const char *serial, nvmlDevice_t *device) {
+  if (nvmlDeviceGetHandleBySerialFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetPciInfoFunc = dlsym(nvmlHandle, "nvmlDeviceGetPciInfo");
-  if (nvmlDeviceGetPciInfoFunc == NULL) {
+  return nvmlDeviceGetHandleBySerialFunc(serial, device);
+}
+
+nvmlReturn_t (*nvmlDeviceGetSupportedMemoryClocksFunc)(nvmlDevice_t device, unsigned int *count, unsigned int *clocksMHz);
+nvmlReturn_t nvmlDeviceGetSupportedMemoryClocks(nvmlDevice_t device, unsigned int *count, unsigned int *clocksMHz) {
+  if (nvmlDeviceGetSupportedMemoryClocksFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetApplicationsClockFunc = dlsym(nvmlHandle, "nvmlDeviceGetApplicationsClock");
-	if (nvmlDeviceGetApplicationsClockFunc == NULL) {
+  return nvmlDeviceGetSupportedMemoryClocksFunc(device, count, clocksMHz);
+}
+
+nvmlReturn_t (*nvmlDeviceGetSupportedGraphicsClocksFunc)(nvmlDevice_t device, unsigned int memoryClockMHz, unsigned int *count, unsigned int *clocksMHz);
+nvmlReturn_t nvmlDeviceGetSupportedGraphicsClocks(nvmlDevice_t device, unsigned int memoryClockMHz, unsigned int *count, unsigned int *clocksMHz) {
+  if (nvmlDeviceGetSupportedGraphicsClocksFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetClockInfoFunc = dlsym(nvmlHandle, "nvmlDeviceGetClockInfo");
-  if (nvmlDeviceGetClockInfoFunc == NULL) {
+  return nvmlDeviceGetSupportedGraphicsClocksFunc(device, memoryClockMHz, count, clocksMHz);
+}
+
+nvmlReturn_t (*nvmlDeviceSetApplicationsClocksFunc)(nvmlDevice_t device, unsigned int memClockMHz, unsigned int graphicsClockMHz);
+nvmlReturn_t nvmlDeviceSetApplicationsClocks(nvmlDevice_t device, unsigned int memClockMHz, unsigned int graphicsClockMHz) {
+  if (nvmlDeviceSetApplicationsClocksFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlDeviceGetComputeRunningProcessesFunc = dlsym(nvmlHandle, "nvmlDeviceGetComputeRunningProcesses");
+  return nvmlDeviceSetApplicationsClocksFunc(device, memClockMHz, graphicsClockMHz);
+}
+
+nvmlReturn_t (*nvmlDeviceResetApplicationsClocksFunc)(nvmlDevice_t device);
+nvmlReturn_t nvmlDeviceResetApplicationsClocks(nvmlDevice_t device) {
+  if (nvmlDeviceResetApplicationsClocksFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceResetApplicationsClocksFunc(device);
+}
+
+nvmlReturn_t (*nvmlDeviceGetDefaultApplicationsClockFunc)(nvmlDevice_t device, nvmlClockType_t clockType, unsigned int *clockMHz);
+nvmlReturn_t nvmlDeviceGetDefaultApplicationsClock(nvmlDevice_t device, nvmlClockType_t clockType, unsigned int *clockMHz) {
+  if (nvmlDeviceGetDefaultApplicationsClockFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetDefaultApplicationsClockFunc(device, clockType, clockMHz);
+}
+
+nvmlReturn_t (*nvmlDeviceSetGpuLockedClocksFunc)(nvmlDevice_t device, unsigned int minGpuClockMHz, unsigned int maxGpuClockMHz);
+nvmlReturn_t nvmlDeviceSetGpuLockedClocks(nvmlDevice_t device, unsigned int minGpuClockMHz, unsigned int maxGpuClockMHz) {
+  if (nvmlDeviceSetGpuLockedClocksFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceSetGpuLockedClocksFunc(device, minGpuClockMHz, maxGpuClockMHz);
+}
+
+nvmlReturn_t (*nvmlDeviceResetGpuLockedClocksFunc)(nvmlDevice_t device);
+nvmlReturn_t nvmlDeviceResetGpuLockedClocks(nvmlDevice_t device) {
+  if (nvmlDeviceResetGpuLockedClocksFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceResetGpuLockedClocksFunc(device);
+}
+
+nvmlReturn_t (*nvmlDeviceGetMaxCustomerBoostClockFunc)(nvmlDevice_t device, nvmlClockType_t clockType, unsigned int *clockMHz);
+nvmlReturn_t nvmlDeviceGetMaxCustomerBoostClock(nvmlDevice_t device, nvmlClockType_t clockType, unsigned int *clockMHz) {
+  if (nvmlDeviceGetMaxCustomerBoostClockFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetMaxCustomerBoostClockFunc(device, clockType, clockMHz);
+}
+
+nvmlReturn_t (*nvmlDeviceGetAutoBoostedClocksEnabledFunc)(nvmlDevice_t device, nvmlEnableState_t *isEnabled, nvmlEnableState_t *defaultIsEnabled);
+nvmlReturn_t nvmlDeviceGetAutoBoostedClocksEnabled(nvmlDevice_t device, nvmlEnableState_t *isEnabled, nvmlEnableState_t *defaultIsEnabled) {
+  if (nvmlDeviceGetAutoBoostedClocksEnabledFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetAutoBoostedClocksEnabledFunc(device, isEnabled, defaultIsEnabled);
+}
+
+nvmlReturn_t (*nvmlDeviceSetAutoBoostedClocksEnabledFunc)(nvmlDevice_t device, nvmlEnableState_t enabled);
+nvmlReturn_t nvmlDeviceSetAutoBoostedClocksEnabled(nvmlDevice_t device, nvmlEnableState_t enabled) {
+  if (nvmlDeviceSetAutoBoostedClocksEnabledFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceSetAutoBoostedClocksEnabledFunc(device, enabled);
+}
+
+nvmlReturn_t (*nvmlDeviceSetDefaultAutoBoostedClocksEnabledFunc)(nvmlDevice_t device, nvmlEnableState_t enabled, unsigned int flags);
+nvmlReturn_t nvmlDeviceSetDefaultAutoBoostedClocksEnabled(nvmlDevice_t device, nvmlEnableState_t enabled, unsigned int flags) {
+  if (nvmlDeviceSetDefaultAutoBoostedClocksEnabledFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceSetDefaultAutoBoostedClocksEnabledFunc(device, enabled, flags);
+}
+
+nvmlReturn_t (*nvmlDeviceSetPowerManagementLimitFunc)(nvmlDevice_t device, unsigned int limit);
+nvmlReturn_t nvmlDeviceSetPowerManagementLimit(nvmlDevice_t device, unsigned int limit) {
+  if (nvmlDeviceSetPowerManagementLimitFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceSetPowerManagementLimitFunc(device, limit);
+}
+
+nvmlReturn_t (*nvmlDeviceGetPowerManagementModeFunc)(nvmlDevice_t device, nvmlEnableState_t *mode);
+nvmlReturn_t nvmlDeviceGetPowerManagementMode(nvmlDevice_t device, nvmlEnableState_t *mode) {
+  if (nvmlDeviceGetPowerManagementModeFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetPowerManagementModeFunc(device, mode);
+}
+
+nvmlReturn_t (*nvmlDeviceGetPowerStateFunc)(nvmlDevice_t device, nvmlPstates_t *pState);
+nvmlReturn_t nvmlDeviceGetPowerState(nvmlDevice_t device, nvmlPstates_t *pState) {
+  if (nvmlDeviceGetPowerStateFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetPowerStateFunc(device, pState);
+}
+
+nvmlReturn_t (*nvmlDeviceGetDetailedEccErrorsFunc)(nvmlDevice_t device, nvmlMemoryErrorType_t errorType, nvmlEccCounterType_t counterType, nvmlEccErrorCounts_t *eccCounts);
+nvmlReturn_t nvmlDeviceGetDetailedEccErrors(nvmlDevice_t device, nvmlMemoryErrorType_t errorType, nvmlEccCounterType_t counterType, nvmlEccErrorCounts_t *eccCounts) {
+  if (nvmlDeviceGetDetailedEccErrorsFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetDetailedEccErrorsFunc(device, errorType, counterType, eccCounts);
+}
+
+nvmlReturn_t (*nvmlDeviceGetMemoryErrorCounterFunc)(nvmlDevice_t device, nvmlMemoryErrorType_t errorType, nvmlEccCounterType_t counterType, nvmlMemoryLocation_t locationType, unsigned long long *count);
+nvmlReturn_t nvmlDeviceGetMemoryErrorCounter(nvmlDevice_t device, nvmlMemoryErrorType_t errorType, nvmlEccCounterType_t counterType, nvmlMemoryLocation_t locationType, unsigned long long *count) {
+  if (nvmlDeviceGetMemoryErrorCounterFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetMemoryErrorCounterFunc(device, errorType, counterType, locationType, count);
+}
+
+nvmlReturn_t (*nvmlDeviceGetEccModeFunc)(nvmlDevice_t device, nvmlEnableState_t *current, nvmlEnableState_t *pending);
+nvmlReturn_t nvmlDeviceGetEccMode(nvmlDevice_t device, nvmlEnableState_t *current, nvmlEnableState_t *pending) {
+  if (nvmlDeviceGetEccModeFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetEccModeFunc(device, current, pending);
+}
+
+nvmlReturn_t (*nvmlDeviceSetEccModeFunc)(nvmlDevice_t device, nvmlEnableState_t ecc);
+nvmlReturn_t nvmlDeviceSetEccMode(nvmlDevice_t device, nvmlEnableState_t ecc) {
+  if (nvmlDeviceSetEccModeFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceSetEccModeFunc(device, ecc);
+}
+
+nvmlReturn_t (*nvmlDeviceClearEccErrorCountsFunc)(nvmlDevice_t device, nvmlEccCounterType_t counterType);
+nvmlReturn_t nvmlDeviceClearEccErrorCounts(nvmlDevice_t device, nvmlEccCounterType_t counterType) {
+  if (nvmlDeviceClearEccErrorCountsFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceClearEccErrorCountsFunc(device, counterType);
+}
+
+nvmlReturn_t (*nvmlDeviceGetRetiredPages_v2Func)(nvmlDevice_t device, nvmlPageRetirementCause_t cause, unsigned int *pageCount, unsigned long long *addresses, unsigned long long *timestamps);
+nvmlReturn_t nvmlDeviceGetRetiredPages_v2(nvmlDevice_t device, nvmlPageRetirementCause_t cause, unsigned int *pageCount, unsigned long long *addresses, unsigned long long *timestamps) {
+  if (nvmlDeviceGetRetiredPages_v2Func == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetRetiredPages_v2Func(device, cause, pageCount, addresses, timestamps);
+}
+
+nvmlReturn_t (*nvmlDeviceGetRetiredPagesPendingStatusFunc)(nvmlDevice_t device, nvmlEnableState_t *isPending);
+nvmlReturn_t nvmlDeviceGetRetiredPagesPendingStatus(nvmlDevice_t device, nvmlEnableState_t *isPending) {
+  if (nvmlDeviceGetRetiredPagesPendingStatusFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetRetiredPagesPendingStatusFunc(device, isPending);
+}
+
+nvmlReturn_t (*nvmlDeviceGetRemappedRowsFunc)(nvmlDevice_t device, unsigned int *corrRows, unsigned int *uncRows, unsigned int *isPending, unsigned int *failureOccurred);
+nvmlReturn_t nvmlDeviceGetRemappedRows(nvmlDevice_t device, unsigned int *corrRows, unsigned int *uncRows, unsigned int *isPending, unsigned int *failureOccurred) {
+  if (nvmlDeviceGetRemappedRowsFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetRemappedRowsFunc(device, corrRows, uncRows, isPending, failureOccurred);
+}
+
+nvmlReturn_t (*nvmlDeviceGetRowRemapperHistogramFunc)(nvmlDevice_t device, nvmlRowRemapperHistogramValues_t *values);
+nvmlReturn_t nvmlDeviceGetRowRemapperHistogram(nvmlDevice_t device, nvmlRowRemapperHistogramValues_t *values) {
+  if (nvmlDeviceGetRowRemapperHistogramFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetRowRemapperHistogramFunc(device, values);
+}
+
+nvmlReturn_t (*nvmlDeviceGetTopologyCommonAncestorFunc)(nvmlDevice_t device1, nvmlDevice_t device2, nvmlGpuTopologyLevel_t *pathInfo);
+nvmlReturn_t nvmlDeviceGetTopologyCommonAncestor(nvmlDevice_t device1, nvmlDevice_t device2, nvmlGpuTopologyLevel_t *pathInfo) {
+  if (nvmlDeviceGetTopologyCommonAncestorFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetTopologyCommonAncestorFunc(device1, device2, pathInfo);
+}
+
+nvmlReturn_t (*nvmlDeviceGetTopologyNearestGpusFunc)(nvmlDevice_t device, nvmlGpuTopologyLevel_t level, unsigned int *count, nvmlDevice_t *deviceArray);
+nvmlReturn_t nvmlDeviceGetTopologyNearestGpus(nvmlDevice_t device, nvmlGpuTopologyLevel_t level, unsigned int *count, nvmlDevice_t *deviceArray) {
+  if (nvmlDeviceGetTopologyNearestGpusFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetTopologyNearestGpusFunc(device, level, count, deviceArray);
+}
+
+nvmlReturn_t (*nvmlSystemGetTopologyGpuSetFunc)(unsigned int cpuNumber, unsigned int *count, nvmlDevice_t *deviceArray);
+nvmlReturn_t nvmlSystemGetTopologyGpuSet(unsigned int cpuNumber, unsigned int *count, nvmlDevice_t *deviceArray) {
+  if (nvmlSystemGetTopologyGpuSetFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlSystemGetTopologyGpuSetFunc(cpuNumber, count, deviceArray);
+}
+
+nvmlReturn_t (*nvmlDeviceGetP2PStatusFunc)(nvmlDevice_t device1, nvmlDevice_t device2, nvmlGpuP2PCapsIndex_t p2pIndex, nvmlGpuP2PStatus_t *p2pStatus);
+nvmlReturn_t nvmlDeviceGetP2PStatus(nvmlDevice_t device1, nvmlDevice_t device2, nvmlGpuP2PCapsIndex_t p2pIndex, nvmlGpuP2PStatus_t *p2pStatus) {
+  if (nvmlDeviceGetP2PStatusFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetP2PStatusFunc(device1, device2, p2pIndex, p2pStatus);
+}
+
+nvmlReturn_t (*nvmlDeviceGetCpuAffinityWithinScopeFunc)(nvmlDevice_t device, unsigned int cpuSetSize, unsigned long *cpuSet, nvmlAffinityScope_t scope);
+nvmlReturn_t nvmlDeviceGetCpuAffinityWithinScope(nvmlDevice_t device, unsigned int cpuSetSize, unsigned long *cpuSet, nvmlAffinityScope_t scope) {
+  if (nvmlDeviceGetCpuAffinityWithinScopeFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetCpuAffinityWithinScopeFunc(device, cpuSetSize, cpuSet, scope);
+}
+
+nvmlReturn_t (*nvmlDeviceGetCpuAffinityFunc)(nvmlDevice_t device, unsigned int cpuSetSize, unsigned long *cpuSet);
+nvmlReturn_t nvmlDeviceGetCpuAffinity(nvmlDevice_t device, unsigned int cpuSetSize, unsigned long *cpuSet) {
+  if (nvmlDeviceGetCpuAffinityFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetCpuAffinityFunc(device, cpuSetSize, cpuSet);
+}
+
+nvmlReturn_t (*nvmlDeviceGetMemoryAffinityFunc)(nvmlDevice_t device, unsigned int nodeSetSize, unsigned long *nodeSet, nvmlAffinityScope_t scope);
+nvmlReturn_t nvmlDeviceGetMemoryAffinity(nvmlDevice_t device, unsigned int nodeSetSize, unsigned long *nodeSet, nvmlAffinityScope_t scope) {
+  if (nvmlDeviceGetMemoryAffinityFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceGetMemoryAffinityFunc(device, nodeSetSize, nodeSet, scope);
+}
+
+nvmlReturn_t (*nvmlDeviceSetCpuAffinityFunc)(nvmlDevice_t device);
+nvmlReturn_t nvmlDeviceSetCpuAffinity(nvmlDevice_t device) {
+  if (nvmlDeviceSetCpuAffinityFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceSetCpuAffinityFunc(device);
+}
+
+nvmlReturn_t (*nvmlDeviceClearCpuAffinityFunc)(nvmlDevice_t device);
+nvmlReturn_t nvmlDeviceClearCpuAffinity(nvmlDevice_t device) {
+  if (nvmlDeviceClearCpuAffinityFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceClearCpuAffinityFunc(device);
+}
+
+nvmlReturn_t (*nvmlDeviceSetAccountingModeFunc)(nvmlDevice_t device, nvmlEnableState_t mode);
+nvmlReturn_t nvmlDeviceSetAccountingMode(nvmlDevice_t device, nvmlEnableState_t mode) {
+  if (nvmlDeviceSetAccountingModeFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceSetAccountingModeFunc(device, mode);
+}
+
+nvmlReturn_t (*nvmlDeviceClearAccountingPidsFunc)(nvmlDevice_t device);
+nvmlReturn_t nvmlDeviceClearAccountingPids(nvmlDevice_t device) {
+  if (nvmlDeviceClearAccountingPidsFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  return nvmlDeviceClearAccountingPidsFunc(device);
+}
+
+// Returns whether the loaded NVML library exports the function name.
+int nvmlHasSymbol(const char *name) {
+  return nvmlHandle != NULL && dlsym(nvmlHandle, name) != NULL;
+}
+
+// Resolves the symbols of the library loaded in nvmlHandle. Returns
+// NVML_ERROR_FUNCTION_NOT_FOUND when a required one is missing.
+static nvmlReturn_t nvmlLoadSymbols_dl(void) {
+  nvmlInitFunc = dlsym(nvmlHandle, "nvmlInit_v2");
+  if (nvmlInitFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlShutdownFunc = dlsym(nvmlHandle, "nvmlShutdown");
+  if (nvmlShutdownFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlErrorStringFunc = dlsym(nvmlHandle, "nvmlErrorString");
+  if (nvmlErrorStringFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetHandleByIndexFunc = dlsym(nvmlHandle, "nvmlDeviceGetHandleByIndex_v2");
+  if (nvmlDeviceGetHandleByIndexFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+	nvmlDeviceGetIndexFunc = dlsym(nvmlHandle, "nvmlDeviceGetIndex");
+	if (nvmlDeviceGetIndexFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlSystemGetDriverVersionFunc = dlsym(nvmlHandle, "nvmlSystemGetDriverVersion");
+  if (nvmlSystemGetDriverVersionFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+	nvmlSystemGetNVMLVersionFunc = dlsym(nvmlHandle, "nvmlSystemGetNVMLVersion");
+	if(nvmlSystemGetNVMLVersionFunc == NULL){
+		return NVML_ERROR_FUNCTION_NOT_FOUND;
+	}
+  nvmlDeviceGetCountFunc = dlsym(nvmlHandle, "nvmlDeviceGetCount_v2");
+  if (nvmlDeviceGetCountFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+	nvmlDeviceGetBrandFunc = dlsym(nvmlHandle, "nvmlDeviceGetBrand");
+	if(nvmlDeviceGetBrandFunc == NULL) {
+		return NVML_ERROR_FUNCTION_NOT_FOUND;
+	}
+	nvmlDeviceGetBoardIdFunc = dlsym(nvmlHandle, "nvmlDeviceGetBoardId");
+	if(nvmlDeviceGetBoardIdFunc == NULL) {
+		return NVML_ERROR_FUNCTION_NOT_FOUND;
+	}
+	nvmlDeviceGetComputeModeFunc = dlsym(nvmlHandle, "nvmlDeviceGetComputeMode");
+	if(nvmlDeviceGetComputeModeFunc == NULL) {
+		return NVML_ERROR_FUNCTION_NOT_FOUND;
+	}
+	nvmlDeviceGetDisplayModeFunc = dlsym(nvmlHandle, "nvmlDeviceGetDisplayMode");
+	if(nvmlDeviceGetDisplayModeFunc == NULL) {
+		return NVML_ERROR_FUNCTION_NOT_FOUND;
+	}
+	nvmlDeviceGetDisplayActiveFunc = dlsym(nvmlHandle, "nvmlDeviceGetDisplayActive");
+	if(nvmlDeviceGetDisplayActiveFunc == NULL) {
+		return NVML_ERROR_FUNCTION_NOT_FOUND;
+	}
+	nvmlDeviceGetVbiosVersionFunc = dlsym(nvmlHandle, "nvmlDeviceGetVbiosVersion");
+	if(nvmlDeviceGetDisplayActiveFunc == NULL) {
+		return NVML_ERROR_FUNCTION_NOT_FOUND;
+	}
+	nvmlDeviceGetCurrentClocksThrottleReasonsFunc = dlsym(nvmlHandle, "nvmlDeviceGetCurrentClocksThrottleReasons");
+	if(nvmlDeviceGetCurrentClocksThrottleReasonsFunc == NULL) {
+		return NVML_ERROR_FUNCTION_NOT_FOUND;
+	}
+	nvmlDeviceGetTotalEnergyConsumptionFunc = dlsym(nvmlHandle, "nvmlDeviceGetTotalEnergyConsumption");
+	if(nvmlDeviceGetTotalEnergyConsumptionFunc == NULL) {
+		return NVML_ERROR_FUNCTION_NOT_FOUND;
+	}
+	nvmlDeviceGetTotalEccErrorsFunc = dlsym(nvmlHandle, "nvmlDeviceGetTotalEccErrors");
+	if(nvmlDeviceGetTotalEccErrorsFunc == NULL) {
+		return NVML_ERROR_FUNCTION_NOT_FOUND;
+	}
+	nvmlDeviceGetSerialFunc = dlsym(nvmlHandle, "nvmlDeviceGetSerial");
+	if(nvmlDeviceGetSerialFunc == NULL) {
+		return NVML_ERROR_FUNCTION_NOT_FOUND;
+	}
+	nvmlDeviceGetBAR1MemoryInfoFunc = dlsym(nvmlHandle, "nvmlDeviceGetBAR1MemoryInfo");
+	if(nvmlDeviceGetBAR1MemoryInfoFunc == NULL) {
+		return NVML_ERROR_FUNCTION_NOT_FOUND;
+	}
+	nvmlDeviceGetPcieThroughputFunc = dlsym(nvmlHandle, "nvmlDeviceGetPcieThroughput");
+	if(nvmlDeviceGetPcieThroughputFunc == NULL) {
+		return NVML_ERROR_FUNCTION_NOT_FOUND;
+	}
+	nvmlDeviceGetCurrPcieLinkGenerationFunc = dlsym(nvmlHandle, "nvmlDeviceGetCurrPcieLinkGeneration");
+	if(nvmlDeviceGetCurrPcieLinkGenerationFunc == NULL) {
+		return NVML_ERROR_FUNCTION_NOT_FOUND;
+	}
+	nvmlDeviceGetMaxPcieLinkGenerationFunc = dlsym(nvmlHandle, "nvmlDeviceGetMaxPcieLinkGeneration");
+	if(nvmlDeviceGetMaxPcieLinkGenerationFunc == NULL) {
+		return NVML_ERROR_FUNCTION_NOT_FOUND;
+	}
+	nvmlDeviceGetMaxPcieLinkWidthFunc = dlsym(nvmlHandle, "nvmlDeviceGetMaxPcieLinkWidth");
+	if(nvmlDeviceGetMaxPcieLinkWidthFunc == NULL) {
+		return NVML_ERROR_FUNCTION_NOT_FOUND;
+	}
+	nvmlDeviceGetCurrPcieLinkWidthFunc = dlsym(nvmlHandle, "nvmlDeviceGetCurrPcieLinkWidth");
+	if(nvmlDeviceGetCurrPcieLinkWidthFunc == NULL) {
+		return NVML_ERROR_FUNCTION_NOT_FOUND;
+	}
+  nvmlDeviceGetMinorNumberFunc = dlsym(nvmlHandle, "nvmlDeviceGetMinorNumber");
+  if (nvmlDeviceGetMinorNumberFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetUUIDFunc = dlsym(nvmlHandle, "nvmlDeviceGetUUID");
+  if (nvmlDeviceGetUUIDFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetNameFunc = dlsym(nvmlHandle, "nvmlDeviceGetName");
+  if (nvmlDeviceGetNameFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetBrandFunc = dlsym(nvmlHandle, "nvmlDeviceGetBrand");
+  if (nvmlDeviceGetBrandFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetPersistenceModeFunc = dlsym(nvmlHandle, "nvmlDeviceGetPersistenceMode");
+  if (nvmlDeviceGetPersistenceModeFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceSetPersistenceModeFunc = dlsym(nvmlHandle, "nvmlDeviceSetPersistenceMode");
+  if (nvmlDeviceSetPersistenceModeFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetComputeModeFunc = dlsym(nvmlHandle, "nvmlDeviceGetComputeMode");
+  if (nvmlDeviceGetComputeModeFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceSetComputeModeFunc = dlsym(nvmlHandle, "nvmlDeviceSetComputeMode");
+  if (nvmlDeviceSetComputeModeFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetPerformanceStateFunc = dlsym(nvmlHandle, "nvmlDeviceGetPerformanceState");
+  if (nvmlDeviceGetPerformanceStateFunc== NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetClockInfoFunc = dlsym(nvmlHandle, "nvmlDeviceGetClockInfo");
+  if (nvmlDeviceGetClockInfoFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetMaxClockInfoFunc = dlsym(nvmlHandle, "nvmlDeviceGetMaxClockInfo");
+  if (nvmlDeviceGetMaxClockInfoFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetMemoryInfoFunc = dlsym(nvmlHandle, "nvmlDeviceGetMemoryInfo");
+  if (nvmlDeviceGetMemoryInfoFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetUtilizationRatesFunc = dlsym(nvmlHandle, "nvmlDeviceGetUtilizationRates");
+  if (nvmlDeviceGetUtilizationRatesFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetPowerUsageFunc = dlsym(nvmlHandle, "nvmlDeviceGetPowerUsage");
+  if (nvmlDeviceGetPowerUsageFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetPowerManagementLimitConstraintsFunc = dlsym(nvmlHandle, "nvmlDeviceGetPowerManagementLimitConstraints");
+  if (nvmlDeviceGetPowerManagementLimitConstraintsFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetPowerManagementDefaultLimitFunc = dlsym(nvmlHandle, "nvmlDeviceGetPowerManagementDefaultLimit");
+  if (nvmlDeviceGetPowerManagementDefaultLimitFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetPcieThroughputFunc = dlsym(nvmlHandle, "nvmlDeviceGetPcieThroughput");
+  if (nvmlDeviceGetPcieThroughputFunc== NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetCurrPcieLinkGenerationFunc = dlsym(nvmlHandle, "nvmlDeviceGetCurrPcieLinkGeneration");
+  if (nvmlDeviceGetCurrPcieLinkGenerationFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetCurrPcieLinkWidthFunc = dlsym(nvmlHandle, "nvmlDeviceGetCurrPcieLinkWidth");
+  if (nvmlDeviceGetCurrPcieLinkWidthFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetMaxPcieLinkGenerationFunc = dlsym(nvmlHandle, "nvmlDeviceGetMaxPcieLinkGeneration");
+  if (nvmlDeviceGetMaxPcieLinkGenerationFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetMaxPcieLinkWidthFunc = dlsym(nvmlHandle, "nvmlDeviceGetMaxPcieLinkWidth");
+  if (nvmlDeviceGetMaxPcieLinkWidthFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetPowerManagementLimitFunc = dlsym(nvmlHandle, "nvmlDeviceGetPowerManagementLimit");
+	if (nvmlDeviceGetPowerManagementLimitFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetEnforcedPowerLimitFunc = dlsym(nvmlHandle, "nvmlDeviceGetEnforcedPowerLimit");
+	if (nvmlDeviceGetEnforcedPowerLimitFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetTemperatureFunc = dlsym(nvmlHandle, "nvmlDeviceGetTemperature");
+  if (nvmlDeviceGetTemperatureFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+	nvmlDeviceGetTemperatureThresholdFunc = dlsym(nvmlHandle, "nvmlDeviceGetTemperatureThreshold");
+	if (nvmlDeviceGetTemperatureThresholdFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetFanSpeedFunc = dlsym(nvmlHandle, "nvmlDeviceGetFanSpeed");
+  if (nvmlDeviceGetFanSpeedFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetSamplesFunc = dlsym(nvmlHandle, "nvmlDeviceGetSamples");
+  if (nvmlDeviceGetSamplesFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetEncoderUtilizationFunc = dlsym(nvmlHandle, "nvmlDeviceGetEncoderUtilization");
+  if (nvmlDeviceGetEncoderUtilizationFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetEncoderCapacityFunc = dlsym(nvmlHandle, "nvmlDeviceGetEncoderCapacity");
+  if (nvmlDeviceGetEncoderCapacityFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetDecoderUtilizationFunc = dlsym(nvmlHandle, "nvmlDeviceGetDecoderUtilization");
+  if (nvmlDeviceGetDecoderUtilizationFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlSystemGetProcessNameFunc = dlsym(nvmlHandle, "nvmlSystemGetProcessName");
+  if (nvmlSystemGetProcessNameFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetAccountingModeFunc = dlsym(nvmlHandle, "nvmlDeviceGetAccountingMode");
+  if (nvmlDeviceGetAccountingModeFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetAccountingStatsFunc = dlsym(nvmlHandle, "nvmlDeviceGetAccountingStats");
+  if (nvmlDeviceGetAccountingStatsFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetAccountingPidsFunc = dlsym(nvmlHandle, "nvmlDeviceGetAccountingPids");
+  if (nvmlDeviceGetAccountingPidsFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetAccountingBufferSizeFunc = dlsym(nvmlHandle, "nvmlDeviceGetAccountingBufferSize");
+  if (nvmlDeviceGetAccountingBufferSizeFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetProcessUtilizationFunc = dlsym(nvmlHandle, "nvmlDeviceGetProcessUtilization");
+  if (nvmlDeviceGetProcessUtilizationFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  // nvmlDeviceGetPciInfo_v2 fills in the same nvmlPciInfo_t as _v3, unlike
+  // the unversioned nvmlDeviceGetPciInfo.
+  nvmlDeviceGetPciInfoFunc = dlsym(nvmlHandle, "nvmlDeviceGetPciInfo_v3");
+  if (nvmlDeviceGetPciInfoFunc == NULL) {
+    nvmlDeviceGetPciInfoFunc = dlsym(nvmlHandle, "nvmlDeviceGetPciInfo_v2");
+  }
+  nvmlDeviceGetApplicationsClockFunc = dlsym(nvmlHandle, "nvmlDeviceGetApplicationsClock");
+	if (nvmlDeviceGetApplicationsClockFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetClockInfoFunc = dlsym(nvmlHandle, "nvmlDeviceGetClockInfo");
+  if (nvmlDeviceGetClockInfoFunc == NULL) {
+    return NVML_ERROR_FUNCTION_NOT_FOUND;
+  }
+  nvmlDeviceGetComputeRunningProcessesFunc = dlsym(nvmlHandle, "nvmlDeviceGetComputeRunningProcesses");
 	if (nvmlDeviceGetComputeRunningProcessesFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
@@ -741,7 +1365,112 @@ nvmlReturn_t nvmlInit_dl(void) {
 	if (nvmlDeviceGetGraphicsRunningProcessesFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
-  nvmlReturn_t result = nvmlInitFunc();
+  // The functions below are optional: they are missing from older drivers,
+  // in which case their wrappers return NVML_ERROR_FUNCTION_NOT_FOUND.
+  nvmlEventSetCreateFunc = dlsym(nvmlHandle, "nvmlEventSetCreate");
+  nvmlEventSetFreeFunc = dlsym(nvmlHandle, "nvmlEventSetFree");
+  nvmlEventSetWaitFunc = dlsym(nvmlHandle, "nvmlEventSetWait_v2");
+  nvmlDeviceRegisterEventsFunc = dlsym(nvmlHandle, "nvmlDeviceRegisterEvents");
+  nvmlDeviceGetSupportedEventTypesFunc = dlsym(nvmlHandle, "nvmlDeviceGetSupportedEventTypes");
+  nvmlDeviceGetFieldValuesFunc = dlsym(nvmlHandle, "nvmlDeviceGetFieldValues");
+  nvmlDeviceGetNvLinkStateFunc = dlsym(nvmlHandle, "nvmlDeviceGetNvLinkState");
+  nvmlDeviceGetNvLinkVersionFunc = dlsym(nvmlHandle, "nvmlDeviceGetNvLinkVersion");
+  nvmlDeviceGetNvLinkCapabilityFunc = dlsym(nvmlHandle, "nvmlDeviceGetNvLinkCapability");
+  nvmlDeviceGetNvLinkRemotePciInfoFunc = dlsym(nvmlHandle, "nvmlDeviceGetNvLinkRemotePciInfo_v2");
+  nvmlDeviceGetNvLinkErrorCounterFunc = dlsym(nvmlHandle, "nvmlDeviceGetNvLinkErrorCounter");
+  nvmlDeviceResetNvLinkErrorCountersFunc = dlsym(nvmlHandle, "nvmlDeviceResetNvLinkErrorCounters");
+  nvmlDeviceSetNvLinkUtilizationControlFunc = dlsym(nvmlHandle, "nvmlDeviceSetNvLinkUtilizationControl");
+  nvmlDeviceGetNvLinkUtilizationControlFunc = dlsym(nvmlHandle, "nvmlDeviceGetNvLinkUtilizationControl");
+  nvmlDeviceGetNvLinkUtilizationCounterFunc = dlsym(nvmlHandle, "nvmlDeviceGetNvLinkUtilizationCounter");
+  nvmlDeviceFreezeNvLinkUtilizationCounterFunc = dlsym(nvmlHandle, "nvmlDeviceFreezeNvLinkUtilizationCounter");
+  nvmlDeviceResetNvLinkUtilizationCounterFunc = dlsym(nvmlHandle, "nvmlDeviceResetNvLinkUtilizationCounter");
+  nvmlDeviceSetMigModeFunc = dlsym(nvmlHandle, "nvmlDeviceSetMigMode");
+  nvmlDeviceGetMigModeFunc = dlsym(nvmlHandle, "nvmlDeviceGetMigMode");
+  nvmlDeviceGetGpuInstanceProfileInfoFunc = dlsym(nvmlHandle, "nvmlDeviceGetGpuInstanceProfileInfo");
+  nvmlDeviceGetGpuInstancePossiblePlacementsFunc = dlsym(nvmlHandle, "nvmlDeviceGetGpuInstancePossiblePlacements");
+  nvmlDeviceGetGpuInstanceRemainingCapacityFunc = dlsym(nvmlHandle, "nvmlDeviceGetGpuInstanceRemainingCapacity");
+  nvmlDeviceCreateGpuInstanceFunc = dlsym(nvmlHandle, "nvmlDeviceCreateGpuInstance");
+  nvmlGpuInstanceDestroyFunc = dlsym(nvmlHandle, "nvmlGpuInstanceDestroy");
+  nvmlDeviceGetGpuInstancesFunc = dlsym(nvmlHandle, "nvmlDeviceGetGpuInstances");
+  nvmlDeviceGetGpuInstanceByIdFunc = dlsym(nvmlHandle, "nvmlDeviceGetGpuInstanceById");
+  nvmlGpuInstanceGetInfoFunc = dlsym(nvmlHandle, "nvmlGpuInstanceGetInfo");
+  nvmlGpuInstanceGetComputeInstanceProfileInfoFunc = dlsym(nvmlHandle, "nvmlGpuInstanceGetComputeInstanceProfileInfo");
+  nvmlGpuInstanceGetComputeInstanceRemainingCapacityFunc = dlsym(nvmlHandle, "nvmlGpuInstanceGetComputeInstanceRemainingCapacity");
+  nvmlGpuInstanceCreateComputeInstanceFunc = dlsym(nvmlHandle, "nvmlGpuInstanceCreateComputeInstance");
+  nvmlComputeInstanceDestroyFunc = dlsym(nvmlHandle, "nvmlComputeInstanceDestroy");
+  nvmlGpuInstanceGetComputeInstancesFunc = dlsym(nvmlHandle, "nvmlGpuInstanceGetComputeInstances");
+  nvmlGpuInstanceGetComputeInstanceByIdFunc = dlsym(nvmlHandle, "nvmlGpuInstanceGetComputeInstanceById");
+  nvmlComputeInstanceGetInfoFunc = dlsym(nvmlHandle, "nvmlComputeInstanceGetInfo");
+  nvmlDeviceIsMigDeviceHandleFunc = dlsym(nvmlHandle, "nvmlDeviceIsMigDeviceHandle");
+  nvmlDeviceGetGpuInstanceIdFunc = dlsym(nvmlHandle, "nvmlDeviceGetGpuInstanceId");
+  nvmlDeviceGetComputeInstanceIdFunc = dlsym(nvmlHandle, "nvmlDeviceGetComputeInstanceId");
+  nvmlDeviceGetMaxMigDeviceCountFunc = dlsym(nvmlHandle, "nvmlDeviceGetMaxMigDeviceCount");
+  nvmlDeviceGetMigDeviceHandleByIndexFunc = dlsym(nvmlHandle, "nvmlDeviceGetMigDeviceHandleByIndex");
+  nvmlDeviceGetDeviceHandleFromMigDeviceHandleFunc = dlsym(nvmlHandle, "nvmlDeviceGetDeviceHandleFromMigDeviceHandle");
+  nvmlDeviceGetHandleByUUIDFunc = dlsym(nvmlHandle, "nvmlDeviceGetHandleByUUID");
+  nvmlDeviceGetHandleByPciBusIdFunc = dlsym(nvmlHandle, "nvmlDeviceGetHandleByPciBusId_v2");
+  nvmlDeviceGetHandleBySerialFunc = dlsym(nvmlHandle, "nvmlDeviceGetHandleBySerial");
+  nvmlInitWithFlagsFunc = dlsym(nvmlHandle, "nvmlInitWithFlags");
+  nvmlDeviceGetSupportedMemoryClocksFunc = dlsym(nvmlHandle, "nvmlDeviceGetSupportedMemoryClocks");
+  nvmlDeviceGetSupportedGraphicsClocksFunc = dlsym(nvmlHandle, "nvmlDeviceGetSupportedGraphicsClocks");
+  nvmlDeviceSetApplicationsClocksFunc = dlsym(nvmlHandle, "nvmlDeviceSetApplicationsClocks");
+  nvmlDeviceResetApplicationsClocksFunc = dlsym(nvmlHandle, "nvmlDeviceResetApplicationsClocks");
+  nvmlDeviceGetDefaultApplicationsClockFunc = dlsym(nvmlHandle, "nvmlDeviceGetDefaultApplicationsClock");
+  nvmlDeviceSetGpuLockedClocksFunc = dlsym(nvmlHandle, "nvmlDeviceSetGpuLockedClocks");
+  nvmlDeviceResetGpuLockedClocksFunc = dlsym(nvmlHandle, "nvmlDeviceResetGpuLockedClocks");
+  nvmlDeviceGetMaxCustomerBoostClockFunc = dlsym(nvmlHandle, "nvmlDeviceGetMaxCustomerBoostClock");
+  nvmlDeviceGetAutoBoostedClocksEnabledFunc = dlsym(nvmlHandle, "nvmlDeviceGetAutoBoostedClocksEnabled");
+  nvmlDeviceSetAutoBoostedClocksEnabledFunc = dlsym(nvmlHandle, "nvmlDeviceSetAutoBoostedClocksEnabled");
+  nvmlDeviceSetDefaultAutoBoostedClocksEnabledFunc = dlsym(nvmlHandle, "nvmlDeviceSetDefaultAutoBoostedClocksEnabled");
+  nvmlDeviceSetPowerManagementLimitFunc = dlsym(nvmlHandle, "nvmlDeviceSetPowerManagementLimit");
+  nvmlDeviceGetPowerManagementModeFunc = dlsym(nvmlHandle, "nvmlDeviceGetPowerManagementMode");
+  nvmlDeviceGetPowerStateFunc = dlsym(nvmlHandle, "nvmlDeviceGetPowerState");
+  nvmlDeviceGetDetailedEccErrorsFunc = dlsym(nvmlHandle, "nvmlDeviceGetDetailedEccErrors");
+  nvmlDeviceGetMemoryErrorCounterFunc = dlsym(nvmlHandle, "nvmlDeviceGetMemoryErrorCounter");
+  nvmlDeviceGetEccModeFunc = dlsym(nvmlHandle, "nvmlDeviceGetEccMode");
+  nvmlDeviceSetEccModeFunc = dlsym(nvmlHandle, "nvmlDeviceSetEccMode");
+  nvmlDeviceClearEccErrorCountsFunc = dlsym(nvmlHandle, "nvmlDeviceClearEccErrorCounts");
+  nvmlDeviceGetRetiredPages_v2Func = dlsym(nvmlHandle, "nvmlDeviceGetRetiredPages_v2");
+  nvmlDeviceGetRetiredPagesPendingStatusFunc = dlsym(nvmlHandle, "nvmlDeviceGetRetiredPagesPendingStatus");
+  nvmlDeviceGetRemappedRowsFunc = dlsym(nvmlHandle, "nvmlDeviceGetRemappedRows");
+  nvmlDeviceGetRowRemapperHistogramFunc = dlsym(nvmlHandle, "nvmlDeviceGetRowRemapperHistogram");
+  nvmlDeviceGetTopologyCommonAncestorFunc = dlsym(nvmlHandle, "nvmlDeviceGetTopologyCommonAncestor");
+  nvmlDeviceGetTopologyNearestGpusFunc = dlsym(nvmlHandle, "nvmlDeviceGetTopologyNearestGpus");
+  nvmlSystemGetTopologyGpuSetFunc = dlsym(nvmlHandle, "nvmlSystemGetTopologyGpuSet");
+  nvmlDeviceGetP2PStatusFunc = dlsym(nvmlHandle, "nvmlDeviceGetP2PStatus");
+  nvmlDeviceGetCpuAffinityWithinScopeFunc = dlsym(nvmlHandle, "nvmlDeviceGetCpuAffinityWithinScope");
+  nvmlDeviceGetCpuAffinityFunc = dlsym(nvmlHandle, "nvmlDeviceGetCpuAffinity");
+  nvmlDeviceGetMemoryAffinityFunc = dlsym(nvmlHandle, "nvmlDeviceGetMemoryAffinity");
+  nvmlDeviceSetCpuAffinityFunc = dlsym(nvmlHandle, "nvmlDeviceSetCpuAffinity");
+  nvmlDeviceClearCpuAffinityFunc = dlsym(nvmlHandle, "nvmlDeviceClearCpuAffinity");
+  nvmlDeviceSetAccountingModeFunc = dlsym(nvmlHandle, "nvmlDeviceSetAccountingMode");
+  nvmlDeviceClearAccountingPidsFunc = dlsym(nvmlHandle, "nvmlDeviceClearAccountingPids");
+  return NVML_SUCCESS;
+}
+
+// Loads the NVML shared library at path, usually "libnvidia-ml.so.1".
+// Loads all symbols needed and initializes NVML, with nvmlInitWithFlags if
+// flags is not 0. On failure the library is unloaded again.
+// Call this before calling any other methods.
+nvmlReturn_t nvmlInit_dl(const char *path, unsigned int flags) {
+  nvmlHandle = dlopen(path, RTLD_LAZY);
+  if (nvmlHandle == NULL) {
+    return NVML_ERROR_LIBRARY_NOT_FOUND;
+  }
+  nvmlReturn_t result = nvmlLoadSymbols_dl();
+  if (result != NVML_SUCCESS) {
+    // Leave nothing behind for the next candidate library.
+    dlclose(nvmlHandle);
+    nvmlHandle = NULL;
+    return result;
+  }
+  if (flags == 0) {
+    result = nvmlInitFunc();
+  } else if (nvmlInitWithFlagsFunc == NULL) {
+    result = NVML_ERROR_FUNCTION_NOT_FOUND;
+  } else {
+    result = nvmlInitWithFlagsFunc(flags);
+  }
   if (result != NVML_SUCCESS) {
     dlclose(nvmlHandle);
     nvmlHandle = NULL;
@@ -764,1276 +1493,1663 @@ nvmlReturn_t nvmlShutdown_dl(void) {
   if (r != NVML_SUCCESS) {
     return r;
   }
-  return (dlclose(nvmlHandle) ? NVML_ERROR_UNKNOWN : NVML_SUCCESS);
-}
-
-// This function is here because the API provided by NVML is not very user
-// friendly. This function can be used to get average utilization.gpu or
-// power.draw.
-//
-// `device`: The identifier of the target device.
-// `type`: Type of sampling event. Only NVML_TOTAL_POWER_SAMPLES and NVML_GPU_UTILIZATION_SAMPLES are supported.
-// `lastSeenTimeStamp`: Return average using samples with timestamp greather than this timestamp. Unix epoch in micro seconds.
-// `averageUsage`: Reference in which average is returned.
-//
-// In my experiments, I found that NVML_GPU_UTILIZATION_SAMPLES buffer stores
-// 100 samples that are uniformly spread with ~6 samples per second. So the
-// buffer stores last ~16s of data.
-// NVML_TOTAL_POWER_SAMPLES buffer stores 120 samples, but in different runs I
-// noticed them to be non-uniformly separated. Sometimes 120 samples only
-// consisted of 10s of data and sometimes they were spread over 60s.
-//
-nvmlReturn_t nvmlDeviceGetAverageUsage(nvmlDevice_t device, nvmlSamplingType_t type, unsigned long long lastSeenTimeStamp, unsigned int* averageUsage) {
-  if (nvmlHandle == NULL) {
-    return NVML_ERROR_LIBRARY_NOT_FOUND;
-  }
+  int closed = dlclose(nvmlHandle);
+  // Don't leave the handle dangling: the methods check it to tell whether
+  // the library is loaded.
+  nvmlHandle = NULL;
+  return (closed ? NVML_ERROR_UNKNOWN : NVML_SUCCESS);
+}
+
+nvmlReturn_t nvmlDeviceGetSamples(nvmlDevice_t device, nvmlSamplingType_t type, unsigned long long lastSeenTimeStamp, nvmlValueType_t *sampleValType, unsigned int *sampleCount, nvmlSample_t *samples) {
   if (nvmlDeviceGetSamplesFunc == NULL) {
     return NVML_ERROR_FUNCTION_NOT_FOUND;
   }
+  return nvmlDeviceGetSamplesFunc(device, type, lastSeenTimeStamp, sampleValType, sampleCount, samples);
+}
+*/
+import "C"
+
+import (
+	"time"
+	"unsafe"
+)
+
+const (
+	szDriver       = C.NVML_SYSTEM_DRIVER_VERSION_BUFFER_SIZE
+	szName         = C.NVML_DEVICE_NAME_BUFFER_SIZE
+	szUUID         = C.NVML_DEVICE_UUID_BUFFER_SIZE
+	szNVML         = C.NVML_SYSTEM_NVML_VERSION_BUFFER_SIZE
+	szVBiosVersion = C.NVML_DEVICE_VBIOS_VERSION_BUFFER_SIZE
+	szDeviceSerial = C.NVML_DEVICE_SERIAL_BUFFER_SIZE
+)
+
+// cgoBackend is the Backend calling into the NVML library loaded by
+// nvmlInit_dl.
+type cgoBackend struct{}
+
+func newDefaultBackend() Backend {
+	return cgoBackend{}
+}
+
+// cgoDevice returns the nvmlDevice_t stored in h. Handles that were not
+// returned by this backend yield a NULL device, which NVML rejects with
+// NVML_ERROR_INVALID_ARGUMENT.
+func cgoDevice(h DeviceHandle) C.nvmlDevice_t {
+	dev, _ := h.(C.nvmlDevice_t)
+	return dev
+}
+
+// errorString takes the nvmlReturn_t returned by the NVML function fn and
+// converts it into a golang error.
+func errorString(fn string, ret C.nvmlReturn_t) error {
+	return newError(fn, Return(ret))
+}
+
+func (cgoBackend) Init(libraries []string, flags InitFlags) (string, error) {
+	result := C.nvmlReturn_t(C.NVML_ERROR_LIBRARY_NOT_FOUND)
+	for _, library := range libraries {
+		path := C.CString(library)
+		r := C.nvmlInit_dl(path, C.uint(flags))
+		C.free(unsafe.Pointer(path))
+		if r == C.NVML_SUCCESS {
+			return libraryFile(library, uintptr(unsafe.Pointer(C.nvmlInitFunc))), nil
+		}
+		// Keep the reason the first library found failed to load, but try the
+		// remaining candidates all the same.
+		if result == C.NVML_ERROR_LIBRARY_NOT_FOUND {
+			result = r
+		}
+	}
+	return "", errorString("nvmlInit", result)
+}
+
+func (cgoBackend) Shutdown() error {
+	return errorString("nvmlShutdown", C.nvmlShutdown_dl())
+}
+
+func (cgoBackend) SupportedFunctions() ([]string, error) {
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
+	}
+	var names []string
+	for _, fn := range nvmlFunctions() {
+		name := C.CString(fn)
+		if C.nvmlHasSymbol(name) != 0 {
+			names = append(names, fn)
+		}
+		C.free(unsafe.Pointer(name))
+	}
+	return names, nil
+}
+
+func (cgoBackend) SystemGetDriverVersion() (string, error) {
+	if C.nvmlHandle == nil {
+		return "", errLibraryNotLoaded
+	}
+	var driver [szDriver]C.char
+	r := C.nvmlSystemGetDriverVersion(&driver[0], szDriver)
+	return C.GoString(&driver[0]), errorString("nvmlSystemGetDriverVersion", r)
+}
+
+func (cgoBackend) SystemGetNVMLVersion() (string, error) {
+	if C.nvmlHandle == nil {
+		return "", errLibraryNotLoaded
+	}
+	var nvml [szNVML]C.char
+	r := C.nvmlSystemGetNVMLVersion(&nvml[0], szNVML)
+	return C.GoString(&nvml[0]), errorString("nvmlSystemGetNVMLVersion", r)
+}
+
+func (cgoBackend) SystemGetProcessName(pid, length uint) (string, error) {
+	if C.nvmlHandle == nil {
+		return "", errLibraryNotLoaded
+	}
+	c := make([]C.char, length)
+	r := C.nvmlSystemGetProcessName(C.uint(pid), &c[0], C.uint(length))
+	return C.GoString(&c[0]), errorString("nvmlSystemGetProcessName", r)
+}
+
+func (cgoBackend) DeviceGetCount() (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlDeviceGetCount(&n)
+	return uint(n), errorString("nvmlDeviceGetCount", r)
+}
+
+func (cgoBackend) DeviceGetHandleByIndex(idx uint) (DeviceHandle, error) {
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
+	}
+	var dev C.nvmlDevice_t
+	r := C.nvmlDeviceGetHandleByIndex(C.uint(idx), &dev)
+	return dev, errorString("nvmlDeviceGetHandleByIndex", r)
+}
+
+func (cgoBackend) DeviceGetHandleByUUID(uuid string) (DeviceHandle, error) {
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
+	}
+	cuuid := C.CString(uuid)
+	defer C.free(unsafe.Pointer(cuuid))
+	var dev C.nvmlDevice_t
+	r := C.nvmlDeviceGetHandleByUUID(cuuid, &dev)
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlDeviceGetHandleByUUID", r)
+	}
+	return dev, nil
+}
+
+func (cgoBackend) DeviceGetHandleByPciBusId(busID string) (DeviceHandle, error) {
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
+	}
+	cbusID := C.CString(busID)
+	defer C.free(unsafe.Pointer(cbusID))
+	var dev C.nvmlDevice_t
+	r := C.nvmlDeviceGetHandleByPciBusId(cbusID, &dev)
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlDeviceGetHandleByPciBusId_v2", r)
+	}
+	return dev, nil
+}
+
+func (cgoBackend) DeviceGetHandleBySerial(serial string) (DeviceHandle, error) {
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
+	}
+	cserial := C.CString(serial)
+	defer C.free(unsafe.Pointer(cserial))
+	var dev C.nvmlDevice_t
+	r := C.nvmlDeviceGetHandleBySerial(cserial, &dev)
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlDeviceGetHandleBySerial", r)
+	}
+	return dev, nil
+}
+
+func (cgoBackend) DeviceGetIndex(h DeviceHandle) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var index C.uint
+	r := C.nvmlDeviceGetIndex(cgoDevice(h), &index)
+	return uint(index), errorString("nvmlDeviceGetIndex", r)
+}
+
+func (cgoBackend) DeviceGetBrand(h DeviceHandle) (DeviceBrand, error) {
+	if C.nvmlHandle == nil {
+		return DeviceBrandUnknown, errLibraryNotLoaded
+	}
+	var brand C.nvmlBrandType_t
+	r := C.nvmlDeviceGetBrand(cgoDevice(h), &brand)
+	return DeviceBrand(brand), errorString("nvmlDeviceGetBrand", r)
+}
+
+func (cgoBackend) DeviceGetBoardId(h DeviceHandle) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var boardid C.uint
+	r := C.nvmlDeviceGetBoardId(cgoDevice(h), &boardid)
+	return uint(boardid), errorString("nvmlDeviceGetBoardId", r)
+}
+
+func (cgoBackend) DeviceGetComputeMode(h DeviceHandle) (ComputeMode, error) {
+	if C.nvmlHandle == nil {
+		return ComputeModeDefault, errLibraryNotLoaded
+	}
+	var cm C.nvmlComputeMode_t
+	r := C.nvmlDeviceGetComputeMode(cgoDevice(h), &cm)
+	return ComputeMode(cm), errorString("nvmlDeviceGetComputeMode", r)
+}
+
+func (cgoBackend) DeviceSetComputeMode(h DeviceHandle, mode ComputeMode) error {
+	if C.nvmlHandle == nil {
+		return errLibraryNotLoaded
+	}
+	r := C.nvmlDeviceSetComputeMode(cgoDevice(h), C.nvmlComputeMode_t(mode))
+	return errorString("nvmlDeviceSetComputeMode", r)
+}
+
+func (cgoBackend) DeviceGetDisplayMode(h DeviceHandle) (EnableState, error) {
+	if C.nvmlHandle == nil {
+		return -1, errLibraryNotLoaded
+	}
+	var es C.nvmlEnableState_t
+	r := C.nvmlDeviceGetDisplayMode(cgoDevice(h), &es)
+	return EnableState(es), errorString("nvmlDeviceGetDisplayMode", r)
+}
+
+func (cgoBackend) DeviceGetDisplayActive(h DeviceHandle) (EnableState, error) {
+	if C.nvmlHandle == nil {
+		return -1, errLibraryNotLoaded
+	}
+	var es C.nvmlEnableState_t
+	r := C.nvmlDeviceGetDisplayActive(cgoDevice(h), &es)
+	return EnableState(es), errorString("nvmlDeviceGetDisplayActive", r)
+}
+
+func (cgoBackend) DeviceGetVbiosVersion(h DeviceHandle) (string, error) {
+	if C.nvmlHandle == nil {
+		return "", errLibraryNotLoaded
+	}
+	var version [szVBiosVersion]C.char
+	r := C.nvmlDeviceGetVbiosVersion(cgoDevice(h), &version[0], szVBiosVersion)
+	return C.GoString(&version[0]), errorString("nvmlDeviceGetVbiosVersion", r)
+}
+
+func (cgoBackend) DeviceGetCurrentClocksThrottleReasons(h DeviceHandle) (uint64, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var bitmap C.ulonglong
+	r := C.nvmlDeviceGetCurrentClocksThrottleReasons(cgoDevice(h), &bitmap)
+	return uint64(bitmap), errorString("nvmlDeviceGetCurrentClocksThrottleReasons", r)
+}
+
+func (cgoBackend) DeviceGetTotalEnergyConsumption(h DeviceHandle) (uint64, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var energy C.ulonglong
+	r := C.nvmlDeviceGetTotalEnergyConsumption(cgoDevice(h), &energy)
+	return uint64(energy), errorString("nvmlDeviceGetTotalEnergyConsumption", r)
+}
+
+func (cgoBackend) DeviceGetTotalEccErrors(h DeviceHandle, errorType MemoryErrorType, counterType EccCounterType) (uint64, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var count C.ulonglong
+	r := C.nvmlDeviceGetTotalEccErrors(cgoDevice(h), C.nvmlMemoryErrorType_t(errorType), C.nvmlEccCounterType_t(counterType), &count)
+	return uint64(count), errorString("nvmlDeviceGetTotalEccErrors", r)
+}
+
+func (cgoBackend) DeviceGetSerial(h DeviceHandle) (string, error) {
+	if C.nvmlHandle == nil {
+		return "", errLibraryNotLoaded
+	}
+	var serial [szDeviceSerial]C.char
+	r := C.nvmlDeviceGetSerial(cgoDevice(h), &serial[0], szDeviceSerial)
+	return C.GoString(&serial[0]), errorString("nvmlDeviceGetSerial", r)
+}
+
+func (cgoBackend) DeviceGetMinorNumber(h DeviceHandle) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlDeviceGetMinorNumber(cgoDevice(h), &n)
+	return uint(n), errorString("nvmlDeviceGetMinorNumber", r)
+}
+
+func (cgoBackend) DeviceGetPciInfo(h DeviceHandle) (PciInfo, error) {
+	if C.nvmlHandle == nil {
+		return PciInfo{}, errLibraryNotLoaded
+	}
+	var pci C.nvmlPciInfo_t
+	r := C.nvmlDeviceGetPciInfo(cgoDevice(h), &pci)
+	return pciInfo(&pci), errorString("nvmlDeviceGetPciInfo", r)
+}
+
+// pciInfo converts the nvmlPciInfo_t filled by NVML.
+func pciInfo(pci *C.nvmlPciInfo_t) PciInfo {
+	return PciInfo{
+		BusID:          C.GoString(&pci.busId[0]),
+		Domain:         uint(pci.domain),
+		Bus:            uint(pci.bus),
+		Device:         uint(pci.device),
+		PciDeviceID:    uint32(pci.pciDeviceId),
+		PciSubSystemID: uint32(pci.pciSubSystemId),
+	}
+}
+
+func (cgoBackend) DeviceGetUUID(h DeviceHandle) (string, error) {
+	if C.nvmlHandle == nil {
+		return "", errLibraryNotLoaded
+	}
+	var uuid [szUUID]C.char
+	r := C.nvmlDeviceGetUUID(cgoDevice(h), &uuid[0], szUUID)
+	return C.GoString(&uuid[0]), errorString("nvmlDeviceGetUUID", r)
+}
+
+func (cgoBackend) DeviceGetName(h DeviceHandle) (string, error) {
+	if C.nvmlHandle == nil {
+		return "", errLibraryNotLoaded
+	}
+	var name [szName]C.char
+	r := C.nvmlDeviceGetName(cgoDevice(h), &name[0], szName)
+	return C.GoString(&name[0]), errorString("nvmlDeviceGetName", r)
+}
+
+func (cgoBackend) DeviceGetPersistenceMode(h DeviceHandle) (EnableState, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var pm C.nvmlEnableState_t
+	r := C.nvmlDeviceGetPersistenceMode(cgoDevice(h), &pm)
+	return EnableState(pm), errorString("nvmlDeviceGetPersistenceMode", r)
+}
+
+func (cgoBackend) DeviceSetPersistenceMode(h DeviceHandle, mode EnableState) error {
+	if C.nvmlHandle == nil {
+		return errLibraryNotLoaded
+	}
+	r := C.nvmlDeviceSetPersistenceMode(cgoDevice(h), C.nvmlEnableState_t(mode))
+	return errorString("nvmlDeviceSetPersistenceMode", r)
+}
+
+func (cgoBackend) DeviceGetPerformanceState(h DeviceHandle) (PowerState, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var pstate C.nvmlPstates_t
+	r := C.nvmlDeviceGetPerformanceState(cgoDevice(h), &pstate)
+	return PowerState(pstate), errorString("nvmlDeviceGetPerformanceState", r)
+}
+
+func (cgoBackend) DeviceGetClockInfo(h DeviceHandle, clockType ClockType) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var clockMHz C.uint
+	r := C.nvmlDeviceGetClockInfo(cgoDevice(h), C.nvmlClockType_t(clockType), &clockMHz)
+	return uint(clockMHz), errorString("nvmlDeviceGetClockInfo", r)
+}
 
-  // We don't really use this because both the metrics we support
-  // averagePowerUsage and averageGPUUtilization are unsigned int.
-  nvmlValueType_t sampleValType;
+func (cgoBackend) DeviceGetMaxClockInfo(h DeviceHandle, clockType ClockType) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var clockMHz C.uint
+	r := C.nvmlDeviceGetMaxClockInfo(cgoDevice(h), C.nvmlClockType_t(clockType), &clockMHz)
+	return uint(clockMHz), errorString("nvmlDeviceGetMaxClockInfo", r)
+}
 
-  // This will be set to the number of samples that can be queried. We would
-  // need to allocate an array of this size to store the samples.
-  unsigned int sampleCount;
+func (cgoBackend) DeviceGetApplicationsClock(h DeviceHandle, clockType ClockType) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var clockMHz C.uint
+	r := C.nvmlDeviceGetApplicationsClock(cgoDevice(h), C.nvmlClockType_t(clockType), &clockMHz)
+	return uint(clockMHz), errorString("nvmlDeviceGetApplicationsClock", r)
+}
 
-  // Invoking this method with `samples` set to NULL sets the sampleCount.
-  nvmlReturn_t r = nvmlDeviceGetSamplesFunc(device, type, lastSeenTimeStamp, &sampleValType, &sampleCount, NULL);
-  if (r != NVML_SUCCESS) {
-    return r;
-  }
+func (cgoBackend) DeviceGetMemoryInfo(h DeviceHandle) (uint64, uint64, error) {
+	if C.nvmlHandle == nil {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var memory C.nvmlMemory_t
+	r := C.nvmlDeviceGetMemoryInfo(cgoDevice(h), &memory)
+	return uint64(memory.total), uint64(memory.used), errorString("nvmlDeviceGetMemoryInfo", r)
+}
 
-  // Allocate memory to store sampleCount samples.
-  // In my experiments, the sampleCount at this stage was always 120 for
-  // NVML_TOTAL_POWER_SAMPLES and 100 for NVML_GPU_UTILIZATION_SAMPLES
-  nvmlSample_t* samples = (nvmlSample_t*) malloc(sampleCount * sizeof(nvmlSample_t));
+func (cgoBackend) DeviceGetBAR1MemoryInfo(h DeviceHandle) (uint64, uint64, error) {
+	if C.nvmlHandle == nil {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var bar1 C.nvmlBAR1Memory_t
+	r := C.nvmlDeviceGetBAR1MemoryInfo(cgoDevice(h), &bar1)
+	return uint64(bar1.bar1Total), uint64(bar1.bar1Used), errorString("nvmlDeviceGetBAR1MemoryInfo", r)
+}
 
-  r = nvmlDeviceGetSamplesFunc(device, type, lastSeenTimeStamp, &sampleValType, &sampleCount, samples);
-  if (r != NVML_SUCCESS) {
-    free(samples);
-    return r;
-  }
+func (cgoBackend) DeviceGetUtilizationRates(h DeviceHandle) (uint, uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var utilization C.nvmlUtilization_t
+	r := C.nvmlDeviceGetUtilizationRates(cgoDevice(h), &utilization)
+	return uint(utilization.gpu), uint(utilization.memory), errorString("nvmlDeviceGetUtilizationRates", r)
+}
 
-  int i = 0;
-  unsigned int sum = 0;
-  for (; i < sampleCount; i++) {
-    sum += samples[i].sampleValue.uiVal;
-  }
-  *averageUsage = sum/sampleCount;
+func (cgoBackend) DeviceGetPowerUsage(h DeviceHandle) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlDeviceGetPowerUsage(cgoDevice(h), &n)
+	return uint(n), errorString("nvmlDeviceGetPowerUsage", r)
+}
 
-  free(samples);
-  return r;
+func (cgoBackend) DeviceGetPowerManagementLimitConstraints(h DeviceHandle) (uint, uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var min C.uint
+	var max C.uint
+	r := C.nvmlDeviceGetPowerManagementLimitConstraints(cgoDevice(h), &min, &max)
+	return uint(min), uint(max), errorString("nvmlDeviceGetPowerManagementLimitConstraints", r)
 }
-*/
-import "C"
 
-import (
-	"errors"
-	"fmt"
-	"strings"
-	"time"
-)
+func (cgoBackend) DeviceGetPowerManagementLimit(h DeviceHandle) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlDeviceGetPowerManagementLimit(cgoDevice(h), &n)
+	return uint(n), errorString("nvmlDeviceGetPowerManagementLimit", r)
+}
 
-const (
-	szDriver        = C.NVML_SYSTEM_DRIVER_VERSION_BUFFER_SIZE
-	szName          = C.NVML_DEVICE_NAME_BUFFER_SIZE
-	szUUID          = C.NVML_DEVICE_UUID_BUFFER_SIZE
-	ctGraphics      = C.NVML_CLOCK_GRAPHICS
-	ctSM            = C.NVML_CLOCK_SM
-	ctMemory        = C.NVML_CLOCK_MEM
-	ctVideo         = C.NVML_CLOCK_VIDEO
-	pcieUtilTx      = C.NVML_PCIE_UTIL_TX_BYTES
-	pcieUtilRx      = C.NVML_PCIE_UTIL_RX_BYTES
-	szNVML          = C.NVML_SYSTEM_NVML_VERSION_BUFFER_SIZE
-	szVBiosVersion  = C.NVML_DEVICE_VBIOS_VERSION_BUFFER_SIZE
-	szDeviceSerial  = C.NVML_DEVICE_SERIAL_BUFFER_SIZE
-)
+func (cgoBackend) DeviceGetPowerManagementDefaultLimit(h DeviceHandle) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlDeviceGetPowerManagementDefaultLimit(cgoDevice(h), &n)
+	return uint(n), errorString("nvmlDeviceGetPowerManagementDefaultLimit", r)
+}
 
-var errLibraryNotLoaded = errors.New("could not load NVML library")
+func (cgoBackend) DeviceGetEnforcedPowerLimit(h DeviceHandle) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlDeviceGetEnforcedPowerLimit(cgoDevice(h), &n)
+	return uint(n), errorString("nvmlDeviceGetEnforcedPowerLimit", r)
+}
 
-// Initialize initializes NVML.
-// Call this before calling any other methods.
-func Initialize() error {
-	return errorString(C.nvmlInit_dl())
+func (cgoBackend) DeviceGetPcieThroughput(h DeviceHandle, counter PcieUtilCounter) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlDeviceGetPcieThroughput(cgoDevice(h), C.nvmlPcieUtilCounter_t(counter), &n)
+	return uint(n), errorString("nvmlDeviceGetPcieThroughput", r)
 }
 
-// Shutdown shuts down NVML.
-// Call this once NVML is no longer being used.
-func Shutdown() error {
-	return errorString(C.nvmlShutdown_dl())
+func (cgoBackend) DeviceGetCurrPcieLinkGeneration(h DeviceHandle) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlDeviceGetCurrPcieLinkGeneration(cgoDevice(h), &n)
+	return uint(n), errorString("nvmlDeviceGetCurrPcieLinkGeneration", r)
 }
 
-// errorString takes a nvmlReturn_t and converts it into a golang error.
-// It uses a nvml method to convert to a user friendly error message.
-func errorString(ret C.nvmlReturn_t) error {
-	if ret == C.NVML_SUCCESS {
-		return nil
+func (cgoBackend) DeviceGetCurrPcieLinkWidth(h DeviceHandle) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
 	}
-	// We need to special case this because if nvml library is not found
-	// nvmlErrorString() method will not work.
-	if ret == C.NVML_ERROR_LIBRARY_NOT_FOUND || C.nvmlHandle == nil {
-		return errLibraryNotLoaded
+	var n C.uint
+	r := C.nvmlDeviceGetCurrPcieLinkWidth(cgoDevice(h), &n)
+	return uint(n), errorString("nvmlDeviceGetCurrPcieLinkWidth", r)
+}
+
+func (cgoBackend) DeviceGetMaxPcieLinkGeneration(h DeviceHandle) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
 	}
-	err := C.GoString(C.nvmlErrorString(ret))
-	return fmt.Errorf("NVML: %v", err)
+	var n C.uint
+	r := C.nvmlDeviceGetMaxPcieLinkGeneration(cgoDevice(h), &n)
+	return uint(n), errorString("nvmlDeviceGetMaxPcieLinkGeneration", r)
 }
 
-// SystemDriverVersion returns the the driver version on the system.
-func SystemDriverVersion() (string, error) {
+func (cgoBackend) DeviceGetMaxPcieLinkWidth(h DeviceHandle) (uint, error) {
 	if C.nvmlHandle == nil {
-		return "", errLibraryNotLoaded
+		return 0, errLibraryNotLoaded
 	}
-	var driver [szDriver]C.char
-	r := C.nvmlSystemGetDriverVersion(&driver[0], szDriver)
-	return C.GoString(&driver[0]), errorString(r)
+	var n C.uint
+	r := C.nvmlDeviceGetMaxPcieLinkWidth(cgoDevice(h), &n)
+	return uint(n), errorString("nvmlDeviceGetMaxPcieLinkWidth", r)
 }
 
-// SystemNVMLVersion returns the NVML Library Version being used.
-func SystemNVMLVersion() (string, error) {
+func (cgoBackend) DeviceGetTemperature(h DeviceHandle, sensor TemperatureSensor) (uint, error) {
 	if C.nvmlHandle == nil {
-		return "", errLibraryNotLoaded
+		return 0, errLibraryNotLoaded
 	}
-	var nvml [szNVML]C.char
-	r := C.nvmlSystemGetNVMLVersion(&nvml[0], szNVML)
-	return C.GoString(&nvml[0]), errorString(r)
+	var n C.uint
+	r := C.nvmlDeviceGetTemperature(cgoDevice(h), C.nvmlTemperatureSensors_t(sensor), &n)
+	return uint(n), errorString("nvmlDeviceGetTemperature", r)
 }
 
-// DeviceCount returns the number of nvidia devices on the system.
-func DeviceCount() (uint, error) {
+func (cgoBackend) DeviceGetTemperatureThreshold(h DeviceHandle, threshold TemperatureThreshold) (uint, error) {
 	if C.nvmlHandle == nil {
 		return 0, errLibraryNotLoaded
 	}
 	var n C.uint
-	r := C.nvmlDeviceGetCount(&n)
-	return uint(n), errorString(r)
+	r := C.nvmlDeviceGetTemperatureThreshold(cgoDevice(h), C.nvmlTemperatureThresholds_t(threshold), &n)
+	return uint(n), errorString("nvmlDeviceGetTemperatureThreshold", r)
 }
 
-// Device is the handle for the device.
-// This handle is obtained by calling DeviceHandleByIndex().
-type Device struct {
-	dev C.nvmlDevice_t
+func (cgoBackend) DeviceGetFanSpeed(h DeviceHandle) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var n C.uint
+	r := C.nvmlDeviceGetFanSpeed(cgoDevice(h), &n)
+	return uint(n), errorString("nvmlDeviceGetFanSpeed", r)
 }
 
-// Utilization is Structure to store utilization value and process Id
-type Utilization struct {
-	Pid       uint   //!< PID of process
-	timeStamp uint64 //!< CPU Timestamp in microseconds
-	SMUtil    uint   //!< SM (3D/Compute) Util Value
-	MemUtil   uint   //!< Frame Buffer Memory Util Value
-	EncUtil   uint   //!< Encoder Util Value
-	DecUtil   uint   //!< Decoder Util Value
+func (cgoBackend) DeviceGetEncoderUtilization(h DeviceHandle) (uint, uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var n, sp C.uint
+	r := C.nvmlDeviceGetEncoderUtilization(cgoDevice(h), &n, &sp)
+	return uint(n), uint(sp), errorString("nvmlDeviceGetEncoderUtilization", r)
 }
 
-// AccountingStats is a Structire to Store accounting Stats for every process
-type AccountingStats struct {
-	GPUUtilization uint
-	//!< Percent of time over the process's lifetime during which one or more kernels was executing on the GPU.
-	//! Utilization stats just like returned by \ref nvmlDeviceGetUtilizationRates but for the life time of a
-	//! process (not just the last sample period).
-	//! Set to NVML_VALUE_NOT_AVAILABLE if nvmlDeviceGetUtilizationRates is not supported
+func (cgoBackend) DeviceGetEncoderCapacity(h DeviceHandle, encoderType EncoderType) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var capacity C.uint
+	r := C.nvmlDeviceGetEncoderCapacity(cgoDevice(h), C.nvmlEncoderType_t(encoderType), &capacity)
+	return uint(capacity), errorString("nvmlDeviceGetEncoderCapacity", r)
+}
 
-	MemoryUtilization uint
-	//!< Percent of time over the process's lifetime during which global (device) memory was being read or written.
-	//! Set to NVML_VALUE_NOT_AVAILABLE if nvmlDeviceGetUtilizationRates is not supported
+func (cgoBackend) DeviceGetDecoderUtilization(h DeviceHandle) (uint, uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var n, sp C.uint
+	r := C.nvmlDeviceGetDecoderUtilization(cgoDevice(h), &n, &sp)
+	return uint(n), uint(sp), errorString("nvmlDeviceGetDecoderUtilization", r)
+}
 
-	MaxMemoryUsage uint64
-	//!< Maximum total memory in bytes that was ever allocated by the process.
-	//! Set to NVML_VALUE_NOT_AVAILABLE if nvmlProcessInfo_t->usedGpuMemory is not supported
+func (cgoBackend) DeviceGetSamples(h DeviceHandle, samplingType SamplingType, lastSeenTimeStamp uint64) ([]Sample, error) {
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
+	}
+	var valueType C.nvmlValueType_t
+	var n C.uint
+	// Called without a buffer, nvmlDeviceGetSamples returns the number of
+	// samples it holds.
+	r := C.nvmlDeviceGetSamples(cgoDevice(h), C.nvmlSamplingType_t(samplingType), C.ulonglong(lastSeenTimeStamp), &valueType, &n, nil)
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlDeviceGetSamples", r)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	csamples := make([]C.nvmlSample_t, n)
+	r = C.nvmlDeviceGetSamples(cgoDevice(h), C.nvmlSamplingType_t(samplingType), C.ulonglong(lastSeenTimeStamp), &valueType, &n, &csamples[0])
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlDeviceGetSamples", r)
+	}
+	samples := make([]Sample, n)
+	for i := range samples {
+		samples[i] = Sample{
+			Timestamp: time.Unix(0, int64(csamples[i].timeStamp)*1000),
+			Value:     cgoValue(valueType, &csamples[i].sampleValue),
+		}
+	}
+	return samples, nil
+}
 
-	Time uint64
-	//!< Amount of time in ms during which the compute context was active. The time is reported as 0 if
-	//!< the process is not terminated
+func (cgoBackend) DeviceGetAccountingMode(h DeviceHandle) (EnableState, error) {
+	var mode C.nvmlEnableState_t
+	if C.nvmlHandle == nil {
+		return EnableState(mode), errLibraryNotLoaded
+	}
+	r := C.nvmlDeviceGetAccountingMode(cgoDevice(h), &mode)
+	return EnableState(mode), errorString("nvmlDeviceGetAccountingMode", r)
+}
 
-	StartTime uint64
-	//!< CPU Timestamp in usec representing start time for the process
+func (cgoBackend) DeviceGetAccountingStats(h DeviceHandle, pid uint) (AccountingStats, error) {
+	if C.nvmlHandle == nil {
+		return AccountingStats{}, errLibraryNotLoaded
+	}
+	var stats C.nvmlAccountingStats_t
+	r := C.nvmlDeviceGetAccountingStats(cgoDevice(h), C.uint(pid), &stats)
 
-	IsRunning bool
-	//!< Flag to represent if the process is running (1 for running, 0 for terminated)
+	accountingStats := AccountingStats{
+		GPUUtilization:    uint(stats.gpuUtilization),
+		MemoryUtilization: uint(stats.memoryUtilization),
+		MaxMemoryUsage:    uint64(stats.maxMemoryUsage),
+		Time:              uint64(stats.time),
+		StartTime:         uint64(stats.startTime),
+		IsRunning:         uint(stats.isRunning) == 1,
+	}
 
-	Reserved [5]uint
-	// Reserved for
+	return accountingStats, errorString("nvmlDeviceGetAccountingStats", r)
 }
 
-// Process is the exported handle for a process instance
-type Process interface {
-	PID() uint
-	Memory() uint64
-}
+func (cgoBackend) DeviceGetAccountingPids(h DeviceHandle, count uint) ([]uint, uint, error) {
+	// init pids
+	cCount := C.uint(count)
+	if C.nvmlHandle == nil {
+		return nil, 0, errLibraryNotLoaded
+	}
+	if count == 0 {
+		r := C.nvmlDeviceGetAccountingPids(cgoDevice(h), &cCount, nil)
+		return nil, uint(cCount), errorString("nvmlDeviceGetAccountingPids", r)
+	}
 
-type process struct {
-	pid           uint
-	usedGpuMemory uint64
-}
+	cPids := make([]C.uint, count)
+	r := C.nvmlDeviceGetAccountingPids(cgoDevice(h), &cCount, &cPids[0])
 
-func (proc process) PID() uint {
-	return proc.pid
+	pids := make([]uint, count)
+	for i, pid := range cPids {
+		pids[i] = uint(pid)
+	}
+	return pids, uint(cCount), errorString("nvmlDeviceGetAccountingPids", r)
 }
 
-func (proc process) Memory() uint64 {
-	return proc.usedGpuMemory
+func (cgoBackend) DeviceGetAccountingBufferSize(h DeviceHandle) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var bufferSize C.uint
+	r := C.nvmlDeviceGetAccountingBufferSize(cgoDevice(h), &bufferSize)
+	return uint(bufferSize), errorString("nvmlDeviceGetAccountingBufferSize", r)
 }
 
-// DeviceBrand is the equivalent for nvmlBrandType_t.
-type DeviceBrand int
+func (cgoBackend) DeviceGetProcessUtilization(h DeviceHandle, processCount uint, lastSeenTimeStamp uint64) ([]*Utilization, error) {
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
+	}
 
-// Enumeration mapping for DeviceBrand to nvmlBrandType_t
-const (
-	DeviceBrandUnknown DeviceBrand = C.NVML_BRAND_UNKNOWN
-	DeviceBrandTesla   DeviceBrand = C.NVML_BRAND_TESLA
-	DeviceBrandNVS     DeviceBrand = C.NVML_BRAND_NVS
-	DeviceBrandGRID    DeviceBrand = C.NVML_BRAND_GRID
-	DeviceBrandGeForce DeviceBrand = C.NVML_BRAND_GEFORCE
-)
+	cUtilizations := make([]C.nvmlProcessUtilizationSample_t, processCount)
+	var runningProcess = C.uint(processCount)
 
-func (b DeviceBrand) String() string {
-	switch b {
-	case DeviceBrandTesla:
-		return "Tesla"
-	case DeviceBrandNVS:
-		return "NVS"
-	case DeviceBrandGRID:
-		return "GRID"
-	case DeviceBrandGeForce:
-		return "Geforce"
-	default:
-		return "unknown"
+	r := C.nvmlDeviceGetProcessUtilization(cgoDevice(h), &cUtilizations[0], &runningProcess, C.ulonglong(lastSeenTimeStamp))
+	if errorString("nvmlDeviceGetProcessUtilization", r) != nil {
+		return nil, errorString("nvmlDeviceGetProcessUtilization", r)
 	}
-}
 
-// ComputeMode is the quivalent for nvmlComputeMode_t.
-type ComputeMode int
+	statisticsProcess := uint(runningProcess)
+	if processCount < uint(runningProcess) {
+		statisticsProcess = processCount
+	}
 
-// Enumeration mapping for ComputeMode to nvmlComputeMode_t
-const (
-	ComputeModeDefault          ComputeMode = C.NVML_COMPUTEMODE_DEFAULT
-	ComputeModeExclusiveThread  ComputeMode = C.NVML_COMPUTEMODE_EXCLUSIVE_THREAD
-	ComputeModeProhibited       ComputeMode = C.NVML_COMPUTEMODE_PROHIBITED
-	ComputeModeExclusiveProcess ComputeMode = C.NVML_COMPUTEMODE_EXCLUSIVE_PROCESS
-)
+	utilizations := make([]*Utilization, statisticsProcess)
+	utilCount := 0
+	for _, utilization := range cUtilizations[:statisticsProcess] {
+		if utilization.pid <= 0 {
+			continue
+		}
+		u := &Utilization{
+			Pid:       uint(utilization.pid),
+			timeStamp: uint64(utilization.timeStamp),
+			SMUtil:    uint(utilization.smUtil),
+			MemUtil:   uint(utilization.memUtil),
+			EncUtil:   uint(utilization.encUtil),
+			DecUtil:   uint(utilization.decUtil),
+		}
+		utilizations[utilCount] = u
+		utilCount++
+	}
 
-func (cm ComputeMode) String() string {
-	switch cm {
-	case ComputeModeProhibited:
-		return "prohibited"
-	case ComputeModeExclusiveThread:
-		return "exclusive thread"
-	case ComputeModeExclusiveProcess:
-		return "exclusive process"
-	case ComputeModeDefault:
-		return "default"
-	default:
-		return "unkown"
+	return utilizations[:utilCount], errorString("nvmlDeviceGetProcessUtilization", r)
+}
+
+func (cgoBackend) DeviceGetComputeRunningProcesses(h DeviceHandle) ([]Process, error) {
+	var size = C.uint(2)
+	var cprocs []C.nvmlProcessInfo_t
+	var r C.nvmlReturn_t
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
+	}
+	for r = C.nvmlReturn_t(C.NVML_ERROR_INSUFFICIENT_SIZE); r == C.NVML_ERROR_INSUFFICIENT_SIZE; {
+		cprocs = make([]C.nvmlProcessInfo_t, uint(size))
+		r = C.nvmlDeviceGetComputeRunningProcesses(cgoDevice(h), &size, &cprocs[0])
 	}
+	return processes(cprocs, size), errorString("nvmlDeviceGetComputeRunningProcesses", r)
 }
 
-// EnableState is the quivalent for nvmlEnableState_t.
-type EnableState int
+func (cgoBackend) DeviceGetGraphicsRunningProcesses(h DeviceHandle) ([]Process, error) {
+	var size = C.uint(2)
+	var cprocs []C.nvmlProcessInfo_t
+	var r C.nvmlReturn_t
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
+	}
+	for r = C.nvmlReturn_t(C.NVML_ERROR_INSUFFICIENT_SIZE); r == C.NVML_ERROR_INSUFFICIENT_SIZE; {
+		cprocs = make([]C.nvmlProcessInfo_t, uint(size))
+		r = C.nvmlDeviceGetGraphicsRunningProcesses(cgoDevice(h), &size, &cprocs[0])
+	}
+	return processes(cprocs, size), errorString("nvmlDeviceGetGraphicsRunningProcesses", r)
+}
 
-// Enumeration mapping for ComputeMode to nvmlComputeMode_t
-const (
-	EnableStateFeatureEnabled  EnableState = C.NVML_FEATURE_ENABLED
-	EnableStateFeatureDisabled EnableState = C.NVML_FEATURE_DISABLED
-)
+// cgoEventSet returns the nvmlEventSet_t stored in s, or NULL if s was not
+// returned by this backend.
+func cgoEventSet(s EventSetHandle) C.nvmlEventSet_t {
+	set, _ := s.(C.nvmlEventSet_t)
+	return set
+}
 
-func (es EnableState) String() string {
-	switch es {
-	case EnableStateFeatureEnabled:
-		return "enabled"
-	case EnableStateFeatureDisabled:
-		return "disabled"
-	default:
-		return "unknown"
+func (cgoBackend) EventSetCreate() (EventSetHandle, error) {
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
+	}
+	var set C.nvmlEventSet_t
+	r := C.nvmlEventSetCreate(&set)
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlEventSetCreate", r)
 	}
+	return set, nil
 }
 
-// PowerState is the equivalent to nvmlPstates_t
-type PowerState int
-
-// Enumeration mapping for PowerState to nvmlPstates_t
-const (
-	PowerState0       PowerState = C.NVML_PSTATE_0
-	PowerState1       PowerState = C.NVML_PSTATE_1
-	PowerState2       PowerState = C.NVML_PSTATE_2
-	PowerState3       PowerState = C.NVML_PSTATE_3
-	PowerState4       PowerState = C.NVML_PSTATE_4
-	PowerState5       PowerState = C.NVML_PSTATE_5
-	PowerState6       PowerState = C.NVML_PSTATE_6
-	PowerState7       PowerState = C.NVML_PSTATE_7
-	PowerState8       PowerState = C.NVML_PSTATE_8
-	PowerState9       PowerState = C.NVML_PSTATE_9
-	PowerState10      PowerState = C.NVML_PSTATE_10
-	PowerState11      PowerState = C.NVML_PSTATE_11
-	PowerState12      PowerState = C.NVML_PSTATE_12
-	PowerState13      PowerState = C.NVML_PSTATE_13
-	PowerState14      PowerState = C.NVML_PSTATE_14
-	PowerState15      PowerState = C.NVML_PSTATE_15
-	PowerStateUnknown PowerState = C.NVML_PSTATE_UNKNOWN
-)
+func (cgoBackend) EventSetFree(s EventSetHandle) error {
+	if C.nvmlHandle == nil {
+		return errLibraryNotLoaded
+	}
+	return errorString("nvmlEventSetFree", C.nvmlEventSetFree(cgoEventSet(s)))
+}
 
-type ThrottlingReason int
-// Enumeration of reasons for throttling
-const (
-    ThrottlingReasonNone = 0
-    ThrottlingReasonIdle = 1
-    ThrottlingReasonApplicationClock = 2
-    ThrottlingReasonUserDefinedClocks = 3
-    ThrottlingReasonSwPowerCap = 4
-    ThrottlingReasonHwSlowdown = 5
-    ThrottlingReasonSyncBoost = 6
-    ThrottlingReasonSwThermalSlowdown = 7
-    ThrottlingReasonHwThermalSlowdown = 8
-    ThrottlingReasonHwPowerBrakeSlowdown = 9
-    ThrottlingReasonDisplayClockSetting = 10
-)
+func (cgoBackend) EventSetWait(s EventSetHandle, timeoutMs uint) (EventData, error) {
+	if C.nvmlHandle == nil {
+		return EventData{}, errLibraryNotLoaded
+	}
+	var data C.nvmlEventData_t
+	r := C.nvmlEventSetWait(cgoEventSet(s), &data, C.uint(timeoutMs))
+	if r != C.NVML_SUCCESS {
+		return EventData{}, errorString("nvmlEventSetWait_v2", r)
+	}
+	return EventData{
+		Device:            data.device,
+		Type:              EventType(data.eventType),
+		Data:              uint64(data.eventData),
+		GpuInstanceID:     uint(data.gpuInstanceId),
+		ComputeInstanceID: uint(data.computeInstanceId),
+	}, nil
+}
 
+func (cgoBackend) DeviceRegisterEvents(h DeviceHandle, eventTypes EventType, s EventSetHandle) error {
+	if C.nvmlHandle == nil {
+		return errLibraryNotLoaded
+	}
+	r := C.nvmlDeviceRegisterEvents(cgoDevice(h), C.ulonglong(eventTypes), cgoEventSet(s))
+	return errorString("nvmlDeviceRegisterEvents", r)
+}
 
+func (cgoBackend) DeviceGetSupportedEventTypes(h DeviceHandle) (EventType, error) {
+	if C.nvmlHandle == nil {
+		return EventTypeNone, errLibraryNotLoaded
+	}
+	var types C.ulonglong
+	r := C.nvmlDeviceGetSupportedEventTypes(cgoDevice(h), &types)
+	return EventType(types), errorString("nvmlDeviceGetSupportedEventTypes", r)
+}
 
-func (ps PowerState) String() string {
-	switch ps {
-	case PowerState0:
-		return "P0 - Maximum Performance"
-	case PowerState1:
-		return "P1"
-	case PowerState2:
-		return "P2"
-	case PowerState3:
-		return "P3"
-	case PowerState4:
-		return "P4"
-	case PowerState5:
-		return "P5"
-	case PowerState6:
-		return "P6"
-	case PowerState7:
-		return "P7"
-	case PowerState8:
-		return "P8"
-	case PowerState9:
-		return "P9"
-	case PowerState10:
-		return "P10"
-	case PowerState11:
-		return "P11"
-	case PowerState12:
-		return "P12"
-	case PowerState13:
-		return "P13"
-	case PowerState14:
-		return "P14"
-	case PowerState15:
-		return "P15 - Minimum Performance"
+func (cgoBackend) DeviceGetFieldValues(h DeviceHandle, values []FieldValue) error {
+	if C.nvmlHandle == nil {
+		return errLibraryNotLoaded
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	cValues := make([]C.nvmlFieldValue_t, len(values))
+	for i, v := range values {
+		cValues[i].fieldId = C.uint(v.FieldID)
+		cValues[i].scopeId = C.uint(v.ScopeID)
+	}
+	r := C.nvmlDeviceGetFieldValues(cgoDevice(h), C.int(len(cValues)), &cValues[0])
+	if r != C.NVML_SUCCESS {
+		return errorString("nvmlDeviceGetFieldValues", r)
+	}
+	for i, cv := range cValues {
+		values[i].Timestamp = time.Unix(0, int64(cv.timestamp)*int64(time.Microsecond))
+		values[i].Latency = time.Duration(cv.latencyUsec) * time.Microsecond
+		values[i].Value = cgoValue(cv.valueType, &cv.value)
+		values[i].Err = errorString("nvmlDeviceGetFieldValues", cv.nvmlReturn)
+	}
+	return nil
+}
+
+// cgoValue converts the nvmlValue_t v holding a value of type t.
+func cgoValue(t C.nvmlValueType_t, v *C.nvmlValue_t) Value {
+	p := unsafe.Pointer(v)
+	switch t {
+	case C.NVML_VALUE_TYPE_DOUBLE:
+		return DoubleValue(float64(*(*C.double)(p)))
+	case C.NVML_VALUE_TYPE_UNSIGNED_INT:
+		return UintValue(uint32(*(*C.uint)(p)))
+	case C.NVML_VALUE_TYPE_UNSIGNED_LONG:
+		return UlongValue(uint64(*(*C.ulong)(p)))
+	case C.NVML_VALUE_TYPE_SIGNED_LONG_LONG:
+		return Int64Value(int64(*(*C.longlong)(p)))
 	default:
-		return "unknown"
+		return Value{Type: ValueType(t), bits: uint64(*(*C.ulonglong)(p))}
 	}
 }
 
-// ClockType is the equivalent nvmlClockType_t
-type ClockType int
+func (cgoBackend) DeviceGetNvLinkState(h DeviceHandle, link uint) (EnableState, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var isActive C.nvmlEnableState_t
+	r := C.nvmlDeviceGetNvLinkState(cgoDevice(h), C.uint(link), &isActive)
+	return EnableState(isActive), errorString("nvmlDeviceGetNvLinkState", r)
+}
 
-//Enumeration mapping for ClockType to nvmlClockType_t
-const (
-	ClockTypeGraphics ClockType = C.NVML_CLOCK_GRAPHICS
-	ClockTypeSM       ClockType = C.NVML_CLOCK_SM
-	ClockTypeMem      ClockType = C.NVML_CLOCK_MEM
-	ClockTypeVideo    ClockType = C.NVML_CLOCK_VIDEO
-)
+func (cgoBackend) DeviceGetNvLinkVersion(h DeviceHandle, link uint) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
+	}
+	var version C.uint
+	r := C.nvmlDeviceGetNvLinkVersion(cgoDevice(h), C.uint(link), &version)
+	return uint(version), errorString("nvmlDeviceGetNvLinkVersion", r)
+}
 
-func (ct ClockType) String() string {
-	switch ct {
-	case ClockTypeGraphics:
-		return "graphics"
-	case ClockTypeSM:
-		return "sm"
-	case ClockTypeMem:
-		return "memory"
-	case ClockTypeVideo:
-		return "video"
-	default:
-		return "unknown"
+func (cgoBackend) DeviceGetNvLinkCapability(h DeviceHandle, link uint, capability NvLinkCapability) (uint, error) {
+	if C.nvmlHandle == nil {
+		return 0, errLibraryNotLoaded
 	}
+	var capResult C.uint
+	r := C.nvmlDeviceGetNvLinkCapability(cgoDevice(h), C.uint(link), C.nvmlNvLinkCapability_t(capability), &capResult)
+	return uint(capResult), errorString("nvmlDeviceGetNvLinkCapability", r)
 }
 
-// DeviceHandleByIndex returns the device handle for a particular index.
-// The indices range from 0 to DeviceCount()-1. The order in which NVML
-// enumerates devices has no guarantees of consistency between reboots.
-func DeviceHandleByIndex(idx uint) (Device, error) {
+func (cgoBackend) DeviceGetNvLinkRemotePciInfo(h DeviceHandle, link uint) (PciInfo, error) {
 	if C.nvmlHandle == nil {
-		return Device{}, errLibraryNotLoaded
+		return PciInfo{}, errLibraryNotLoaded
 	}
-	var dev C.nvmlDevice_t
-	r := C.nvmlDeviceGetHandleByIndex(C.uint(idx), &dev)
-	return Device{dev}, errorString(r)
+	var pci C.nvmlPciInfo_t
+	r := C.nvmlDeviceGetNvLinkRemotePciInfo(cgoDevice(h), C.uint(link), &pci)
+	return pciInfo(&pci), errorString("nvmlDeviceGetNvLinkRemotePciInfo_v2", r)
 }
 
-// Index return the index of the device
-func (d Device) Index() (uint, error) {
+func (cgoBackend) DeviceGetNvLinkErrorCounter(h DeviceHandle, link uint, counter NvLinkErrorCounter) (uint64, error) {
 	if C.nvmlHandle == nil {
 		return 0, errLibraryNotLoaded
 	}
-	var index C.uint
-	r := C.nvmlDeviceGetIndex(d.dev, &index)
-	return uint(index), errorString(r)
+	var value C.ulonglong
+	r := C.nvmlDeviceGetNvLinkErrorCounter(cgoDevice(h), C.uint(link), C.nvmlNvLinkErrorCounter_t(counter), &value)
+	return uint64(value), errorString("nvmlDeviceGetNvLinkErrorCounter", r)
 }
 
-// Brand returns the Product Brand of the device.
-func (d Device) Brand() (DeviceBrand, error) {
+func (cgoBackend) DeviceResetNvLinkErrorCounters(h DeviceHandle, link uint) error {
 	if C.nvmlHandle == nil {
-		return DeviceBrandUnknown, errLibraryNotLoaded
+		return errLibraryNotLoaded
 	}
-	var brand C.nvmlBrandType_t
-	r := C.nvmlDeviceGetBrand(d.dev, &brand)
-	return DeviceBrand(brand), errorString(r)
+	r := C.nvmlDeviceResetNvLinkErrorCounters(cgoDevice(h), C.uint(link))
+	return errorString("nvmlDeviceResetNvLinkErrorCounters", r)
 }
 
-// BoardID returns the Devices Board ID.
-// Devices with the same boardId indicate GPUs connected to the same PLX.
-func (d Device) BoardID() (uint, error) {
+func (cgoBackend) DeviceSetNvLinkUtilizationControl(h DeviceHandle, link, counter uint, control NvLinkUtilizationControl, reset bool) error {
 	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+		return errLibraryNotLoaded
 	}
-	var boardid C.uint
-	r := C.nvmlDeviceGetBoardId(d.dev, &boardid)
-	return uint(boardid), errorString(r)
+	cControl := C.nvmlNvLinkUtilizationControl_t{
+		units:     C.nvmlNvLinkUtilizationCountUnits_t(control.Units),
+		pktfilter: C.nvmlNvLinkUtilizationCountPktTypes_t(control.PacketFilter),
+	}
+	var cReset C.uint
+	if reset {
+		cReset = 1
+	}
+	r := C.nvmlDeviceSetNvLinkUtilizationControl(cgoDevice(h), C.uint(link), C.uint(counter), &cControl, cReset)
+	return errorString("nvmlDeviceSetNvLinkUtilizationControl", r)
 }
 
-// ComputeMode returns the current Compute Mode of the device.
-func (d Device) ComputeMode() (ComputeMode, error) {
+func (cgoBackend) DeviceGetNvLinkUtilizationControl(h DeviceHandle, link, counter uint) (NvLinkUtilizationControl, error) {
 	if C.nvmlHandle == nil {
-		return ComputeModeDefault, errLibraryNotLoaded
+		return NvLinkUtilizationControl{}, errLibraryNotLoaded
 	}
-	var cm C.nvmlComputeMode_t
-	r := C.nvmlDeviceGetComputeMode(d.dev, &cm)
-	return ComputeMode(cm), errorString(r)
+	var control C.nvmlNvLinkUtilizationControl_t
+	r := C.nvmlDeviceGetNvLinkUtilizationControl(cgoDevice(h), C.uint(link), C.uint(counter), &control)
+	return NvLinkUtilizationControl{
+		Units:        NvLinkCounterUnit(control.units),
+		PacketFilter: NvLinkPacketFilter(control.pktfilter),
+	}, errorString("nvmlDeviceGetNvLinkUtilizationControl", r)
 }
 
-// DisplayMode returns the current Display Mode of the device.
-func (d Device) DisplayMode() (EnableState, error) {
+func (cgoBackend) DeviceGetNvLinkUtilizationCounter(h DeviceHandle, link, counter uint) (uint64, uint64, error) {
 	if C.nvmlHandle == nil {
-		return -1, errLibraryNotLoaded
+		return 0, 0, errLibraryNotLoaded
 	}
-	var es C.nvmlEnableState_t
-	r := C.nvmlDeviceGetDisplayMode(d.dev, &es)
-	return EnableState(es), errorString(r)
+	var rx, tx C.ulonglong
+	r := C.nvmlDeviceGetNvLinkUtilizationCounter(cgoDevice(h), C.uint(link), C.uint(counter), &rx, &tx)
+	return uint64(rx), uint64(tx), errorString("nvmlDeviceGetNvLinkUtilizationCounter", r)
 }
 
-// DisplayActive returns if there currently is an active display attached.
-func (d Device) DisplayActive() (EnableState, error) {
+func (cgoBackend) DeviceFreezeNvLinkUtilizationCounter(h DeviceHandle, link, counter uint, freeze EnableState) error {
 	if C.nvmlHandle == nil {
-		return -1, errLibraryNotLoaded
+		return errLibraryNotLoaded
 	}
-	var es C.nvmlEnableState_t
-	r := C.nvmlDeviceGetDisplayActive(d.dev, &es)
-	return EnableState(es), errorString(r)
+	r := C.nvmlDeviceFreezeNvLinkUtilizationCounter(cgoDevice(h), C.uint(link), C.uint(counter), C.nvmlEnableState_t(freeze))
+	return errorString("nvmlDeviceFreezeNvLinkUtilizationCounter", r)
 }
 
-// VBiosVersion returns VBIOS version of the device.
-func (d Device) VBiosVersion() (string, error) {
+func (cgoBackend) DeviceResetNvLinkUtilizationCounter(h DeviceHandle, link, counter uint) error {
 	if C.nvmlHandle == nil {
-		return "", errLibraryNotLoaded
+		return errLibraryNotLoaded
 	}
-	var version [szVBiosVersion]C.char
-	r := C.nvmlDeviceGetVbiosVersion(d.dev, &version[0], szVBiosVersion)
-	return C.GoString(&version[0]), errorString(r)
+	r := C.nvmlDeviceResetNvLinkUtilizationCounter(cgoDevice(h), C.uint(link), C.uint(counter))
+	return errorString("nvmlDeviceResetNvLinkUtilizationCounter", r)
+}
+
+// cgoGpuInstance returns the nvmlGpuInstance_t stored in gi, or NULL if gi
+// was not returned by this backend.
+func cgoGpuInstance(gi GpuInstanceHandle) C.nvmlGpuInstance_t {
+	instance, _ := gi.(C.nvmlGpuInstance_t)
+	return instance
+}
+
+// cgoComputeInstance returns the nvmlComputeInstance_t stored in ci, or NULL
+// if ci was not returned by this backend.
+func cgoComputeInstance(ci ComputeInstanceHandle) C.nvmlComputeInstance_t {
+	instance, _ := ci.(C.nvmlComputeInstance_t)
+	return instance
 }
 
-// CurrentClocksThrottleReasons returns reasons (bitmap) for the GPU being throttled
-func (d Device) CurrentClocksThrottleReasons() (uint64, error) {
+func (cgoBackend) DeviceGetMigMode(h DeviceHandle) (EnableState, EnableState, error) {
 	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+		return 0, 0, errLibraryNotLoaded
 	}
-    var bitmap C.ulonglong
-	r := C.nvmlDeviceGetCurrentClocksThrottleReasons(d.dev, &bitmap)
-	return uint64(bitmap), errorString(r)
-}
-
-func (d Device) MostSeriousClocksThrottleReason() (int, error) {
-    var bitmap, err = d.CurrentClocksThrottleReasons ()
-    if (bitmap & C.nvmlClocksThrottleReasonDisplayClockSetting) != 0 {
-        return ThrottlingReasonDisplayClockSetting, err
-    }
-    if (bitmap & C.nvmlClocksThrottleReasonHwPowerBrakeSlowdown) != 0 {
-        return ThrottlingReasonHwPowerBrakeSlowdown, err
-    }
-    if (bitmap & C.nvmlClocksThrottleReasonHwThermalSlowdown) != 0 {
-        return ThrottlingReasonHwThermalSlowdown, err
-    }
-    if (bitmap & C.nvmlClocksThrottleReasonSwThermalSlowdown) != 0 {
-        return ThrottlingReasonSwThermalSlowdown, err
-    }
-    if (bitmap & C.nvmlClocksThrottleReasonSyncBoost) != 0 {
-        return ThrottlingReasonSyncBoost, err
-    }
-    if (bitmap & C.nvmlClocksThrottleReasonHwSlowdown) != 0 {
-        return ThrottlingReasonHwSlowdown, err
-    }
-    if (bitmap & C.nvmlClocksThrottleReasonSwPowerCap) != 0 {
-        return ThrottlingReasonSwPowerCap, err
-    }
-    if (bitmap & C.nvmlClocksThrottleReasonUserDefinedClocks) != 0 {
-        return ThrottlingReasonUserDefinedClocks, err
-    }
-    if (bitmap & C.nvmlClocksThrottleReasonApplicationsClocksSetting) != 0 {
-        return ThrottlingReasonApplicationClock, err
-    }
-    if (bitmap & C.nvmlClocksThrottleReasonGpuIdle) != 0 {
-        return ThrottlingReasonIdle, err
-    }
-    return ThrottlingReasonNone, err
-}
-
-// TotalEnergyConsumption total energy consumption for this GPU in millijoules (mJ) since the driver was last reloaded.
-func (d Device) TotalEnergyConsumption() (uint64, error) {
+	var current, pending C.uint
+	r := C.nvmlDeviceGetMigMode(cgoDevice(h), &current, &pending)
+	return EnableState(current), EnableState(pending), errorString("nvmlDeviceGetMigMode", r)
+}
+
+func (cgoBackend) DeviceSetMigMode(h DeviceHandle, mode EnableState) (Return, error) {
 	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+		return ReturnSuccess, errLibraryNotLoaded
 	}
-    var energy C.ulonglong
-	r := C.nvmlDeviceGetTotalEnergyConsumption(d.dev, &energy)
-	return uint64(energy), errorString(r)
+	var activationStatus C.nvmlReturn_t
+	r := C.nvmlDeviceSetMigMode(cgoDevice(h), C.uint(mode), &activationStatus)
+	return Return(activationStatus), errorString("nvmlDeviceSetMigMode", r)
 }
 
-// TotalEccErrors: Memory errors. 
-func (d Device) TotalEccErrors() (uint64, uint64, uint64, uint64, error) {
+func (cgoBackend) DeviceGetGpuInstanceProfileInfo(h DeviceHandle, profile GpuInstanceProfile) (GpuInstanceProfileInfo, error) {
 	if C.nvmlHandle == nil {
-		return 0, 0, 0, 0, errLibraryNotLoaded
+		return GpuInstanceProfileInfo{}, errLibraryNotLoaded
 	}
-    var e1,e2,e3,e4 C.ulonglong
-	r := C.nvmlDeviceGetTotalEccErrors(d.dev, C.NVML_MEMORY_ERROR_TYPE_CORRECTED, C.NVML_VOLATILE_ECC, &e1)
-    // Since the only possible error is that the function itself doesn't exist, if we get here we're probably OK
-	C.nvmlDeviceGetTotalEccErrors(d.dev, C.NVML_MEMORY_ERROR_TYPE_CORRECTED, C.NVML_AGGREGATE_ECC, &e2)
-	C.nvmlDeviceGetTotalEccErrors(d.dev, C.NVML_MEMORY_ERROR_TYPE_UNCORRECTED, C.NVML_VOLATILE_ECC, &e3)
-	C.nvmlDeviceGetTotalEccErrors(d.dev, C.NVML_MEMORY_ERROR_TYPE_UNCORRECTED, C.NVML_AGGREGATE_ECC, &e4)
-
-	return uint64(e1), uint64(e2), uint64(e3), uint64(e4), errorString(r)
+	var info C.nvmlGpuInstanceProfileInfo_t
+	r := C.nvmlDeviceGetGpuInstanceProfileInfo(cgoDevice(h), C.uint(profile), &info)
+	return GpuInstanceProfileInfo{
+		ID:                  uint(info.id),
+		IsP2pSupported:      info.isP2pSupported != 0,
+		SliceCount:          uint(info.sliceCount),
+		InstanceCount:       uint(info.instanceCount),
+		MultiprocessorCount: uint(info.multiprocessorCount),
+		CopyEngineCount:     uint(info.copyEngineCount),
+		DecoderCount:        uint(info.decoderCount),
+		EncoderCount:        uint(info.encoderCount),
+		JpegCount:           uint(info.jpegCount),
+		OfaCount:            uint(info.ofaCount),
+		MemorySizeMB:        uint64(info.memorySizeMB),
+	}, errorString("nvmlDeviceGetGpuInstanceProfileInfo", r)
 }
 
-// Serial returns the globally unique board serial number associated with this device's board.
-func (d Device) Serial() (string, error) {
+func (cgoBackend) DeviceGetGpuInstancePossiblePlacements(h DeviceHandle, profileID, count uint) ([]GpuInstancePlacement, error) {
 	if C.nvmlHandle == nil {
-		return "", errLibraryNotLoaded
+		return nil, errLibraryNotLoaded
 	}
-	var serial [szDeviceSerial]C.char
-	r := C.nvmlDeviceGetSerial(d.dev, &serial[0], szDeviceSerial)
-	return C.GoString(&serial[0]), errorString(r)
+	if count == 0 {
+		return nil, nil
+	}
+	cPlacements := make([]C.nvmlGpuInstancePlacement_t, count)
+	cCount := C.uint(count)
+	r := C.nvmlDeviceGetGpuInstancePossiblePlacements(cgoDevice(h), C.uint(profileID), &cPlacements[0], &cCount)
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlDeviceGetGpuInstancePossiblePlacements", r)
+	}
+	placements := make([]GpuInstancePlacement, cCount)
+	for i := range placements {
+		placements[i] = GpuInstancePlacement{uint(cPlacements[i].start), uint(cPlacements[i].size)}
+	}
+	return placements, nil
 }
 
-// MinorNumber returns the minor number for the device.
-// The minor number for the device is such that the Nvidia device node
-// file for each GPU will have the form /dev/nvidia[minor number].
-func (d Device) MinorNumber() (uint, error) {
+func (cgoBackend) DeviceGetGpuInstanceRemainingCapacity(h DeviceHandle, profileID uint) (uint, error) {
 	if C.nvmlHandle == nil {
 		return 0, errLibraryNotLoaded
 	}
-	var n C.uint
-	r := C.nvmlDeviceGetMinorNumber(d.dev, &n)
-	return uint(n), errorString(r)
+	var count C.uint
+	r := C.nvmlDeviceGetGpuInstanceRemainingCapacity(cgoDevice(h), C.uint(profileID), &count)
+	return uint(count), errorString("nvmlDeviceGetGpuInstanceRemainingCapacity", r)
 }
 
-// BusID returns the BusID
-func (d Device) BusID() (string, error) {
+func (cgoBackend) DeviceCreateGpuInstance(h DeviceHandle, profileID uint) (GpuInstanceHandle, error) {
 	if C.nvmlHandle == nil {
-		return "", errLibraryNotLoaded
+		return nil, errLibraryNotLoaded
 	}
-	var pci C.nvmlPciInfo_t
-
-	r := C.nvmlDeviceGetPciInfo(d.dev, &pci)
-	return C.GoString(&pci.busId[0]), errorString(r)
+	var gi C.nvmlGpuInstance_t
+	r := C.nvmlDeviceCreateGpuInstance(cgoDevice(h), C.uint(profileID), &gi)
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlDeviceCreateGpuInstance", r)
+	}
+	return gi, nil
 }
 
-// UUID returns the globally unique immutable UUID associated with this device.
-func (d Device) UUID() (string, error) {
+func (cgoBackend) DeviceGetGpuInstances(h DeviceHandle, profileID, count uint) ([]GpuInstanceHandle, error) {
 	if C.nvmlHandle == nil {
-		return "", errLibraryNotLoaded
+		return nil, errLibraryNotLoaded
 	}
-	var uuid [szUUID]C.char
-	r := C.nvmlDeviceGetUUID(d.dev, &uuid[0], szUUID)
-	return C.GoString(&uuid[0]), errorString(r)
+	if count == 0 {
+		return nil, nil
+	}
+	cInstances := make([]C.nvmlGpuInstance_t, count)
+	cCount := C.uint(count)
+	r := C.nvmlDeviceGetGpuInstances(cgoDevice(h), C.uint(profileID), &cInstances[0], &cCount)
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlDeviceGetGpuInstances", r)
+	}
+	instances := make([]GpuInstanceHandle, cCount)
+	for i := range instances {
+		instances[i] = cInstances[i]
+	}
+	return instances, nil
 }
 
-// Name returns the product name of the device.
-func (d Device) Name() (string, error) {
+func (cgoBackend) DeviceGetGpuInstanceById(h DeviceHandle, id uint) (GpuInstanceHandle, error) {
 	if C.nvmlHandle == nil {
-		return "", errLibraryNotLoaded
+		return nil, errLibraryNotLoaded
 	}
-	var name [szName]C.char
-	r := C.nvmlDeviceGetName(d.dev, &name[0], szName)
-	return C.GoString(&name[0]), errorString(r)
+	var gi C.nvmlGpuInstance_t
+	r := C.nvmlDeviceGetGpuInstanceById(cgoDevice(h), C.uint(id), &gi)
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlDeviceGetGpuInstanceById", r)
+	}
+	return gi, nil
 }
 
-// PersistenceMode returns the current driver persistence mode of the device.
-func (d Device) PersistenceMode() (uint, error) {
+func (cgoBackend) GpuInstanceDestroy(gi GpuInstanceHandle) error {
 	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+		return errLibraryNotLoaded
 	}
-	var pm C.nvmlEnableState_t
-	r := C.nvmlDeviceGetPersistenceMode(d.dev, &pm)
-	return uint(pm), errorString(r)
+	return errorString("nvmlGpuInstanceDestroy", C.nvmlGpuInstanceDestroy(cgoGpuInstance(gi)))
 }
 
-// SetPersistenceMode sets the current driver persistence mode of the device.
-func (d Device) SetPersistenceMode(mode uint) error {
+func (cgoBackend) GpuInstanceGetInfo(gi GpuInstanceHandle) (DeviceHandle, GpuInstanceInfo, error) {
 	if C.nvmlHandle == nil {
-		return errLibraryNotLoaded
+		return nil, GpuInstanceInfo{}, errLibraryNotLoaded
+	}
+	var info C.nvmlGpuInstanceInfo_t
+	r := C.nvmlGpuInstanceGetInfo(cgoGpuInstance(gi), &info)
+	if r != C.NVML_SUCCESS {
+		return nil, GpuInstanceInfo{}, errorString("nvmlGpuInstanceGetInfo", r)
 	}
-	r := C.nvmlDeviceSetPersistenceMode(d.dev, C.nvmlEnableState_t(mode))
-	return errorString(r)
+	return info.device, GpuInstanceInfo{
+		ID:        uint(info.id),
+		ProfileID: uint(info.profileId),
+		Placement: GpuInstancePlacement{uint(info.placement.start), uint(info.placement.size)},
+	}, nil
 }
 
-// SetComputeMode sets the current compute mode of the device.
-func (d Device) SetComputeMode(mode uint) error {
+func (cgoBackend) GpuInstanceGetComputeInstanceProfileInfo(gi GpuInstanceHandle, profile ComputeInstanceProfile, engProfile ComputeInstanceEngineProfile) (ComputeInstanceProfileInfo, error) {
 	if C.nvmlHandle == nil {
-		return errLibraryNotLoaded
+		return ComputeInstanceProfileInfo{}, errLibraryNotLoaded
 	}
-	r := C.nvmlDeviceSetComputeMode(d.dev, C.nvmlComputeMode_t(mode))
-	return errorString(r)
+	var info C.nvmlComputeInstanceProfileInfo_t
+	r := C.nvmlGpuInstanceGetComputeInstanceProfileInfo(cgoGpuInstance(gi), C.uint(profile), C.uint(engProfile), &info)
+	return ComputeInstanceProfileInfo{
+		ID:                    uint(info.id),
+		SliceCount:            uint(info.sliceCount),
+		InstanceCount:         uint(info.instanceCount),
+		MultiprocessorCount:   uint(info.multiprocessorCount),
+		SharedCopyEngineCount: uint(info.sharedCopyEngineCount),
+		SharedDecoderCount:    uint(info.sharedDecoderCount),
+		SharedEncoderCount:    uint(info.sharedEncoderCount),
+		SharedJpegCount:       uint(info.sharedJpegCount),
+		SharedOfaCount:        uint(info.sharedOfaCount),
+	}, errorString("nvmlGpuInstanceGetComputeInstanceProfileInfo", r)
 }
 
-// PerformanceState returns the current performance state of the device.
-func (d Device) PerformanceState() (uint, error) {
+func (cgoBackend) GpuInstanceGetComputeInstanceRemainingCapacity(gi GpuInstanceHandle, profileID uint) (uint, error) {
 	if C.nvmlHandle == nil {
 		return 0, errLibraryNotLoaded
 	}
-	var pstate C.nvmlPstates_t
-	r := C.nvmlDeviceGetPerformanceState(d.dev, &pstate)
-	return uint(pstate), errorString(r)
+	var count C.uint
+	r := C.nvmlGpuInstanceGetComputeInstanceRemainingCapacity(cgoGpuInstance(gi), C.uint(profileID), &count)
+	return uint(count), errorString("nvmlGpuInstanceGetComputeInstanceRemainingCapacity", r)
 }
 
-// GrClock returns the application graphics clock of the device.
-func (d Device) GrClock() (uint, error) {
+func (cgoBackend) GpuInstanceCreateComputeInstance(gi GpuInstanceHandle, profileID uint) (ComputeInstanceHandle, error) {
 	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+		return nil, errLibraryNotLoaded
 	}
-	var clockMHz C.uint
-	r := C.nvmlDeviceGetClockInfo(d.dev, ctGraphics, &clockMHz)
-	return uint(clockMHz), errorString(r)
+	var ci C.nvmlComputeInstance_t
+	r := C.nvmlGpuInstanceCreateComputeInstance(cgoGpuInstance(gi), C.uint(profileID), &ci)
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlGpuInstanceCreateComputeInstance", r)
+	}
+	return ci, nil
 }
 
-// SMClock returns the application SM clock of the device.
-func (d Device) SMClock() (uint, error) {
+func (cgoBackend) GpuInstanceGetComputeInstances(gi GpuInstanceHandle, profileID, count uint) ([]ComputeInstanceHandle, error) {
 	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+		return nil, errLibraryNotLoaded
 	}
-	var clockMHz C.uint
-	r := C.nvmlDeviceGetClockInfo(d.dev, ctSM, &clockMHz)
-	return uint(clockMHz), errorString(r)
+	if count == 0 {
+		return nil, nil
+	}
+	cInstances := make([]C.nvmlComputeInstance_t, count)
+	cCount := C.uint(count)
+	r := C.nvmlGpuInstanceGetComputeInstances(cgoGpuInstance(gi), C.uint(profileID), &cInstances[0], &cCount)
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlGpuInstanceGetComputeInstances", r)
+	}
+	instances := make([]ComputeInstanceHandle, cCount)
+	for i := range instances {
+		instances[i] = cInstances[i]
+	}
+	return instances, nil
 }
 
-// MemClock returns the memory clock of the device.
-func (d Device) MemClock() (uint, error) {
+func (cgoBackend) GpuInstanceGetComputeInstanceById(gi GpuInstanceHandle, id uint) (ComputeInstanceHandle, error) {
 	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+		return nil, errLibraryNotLoaded
 	}
-	var clockMHz C.uint
-	r := C.nvmlDeviceGetClockInfo(d.dev, ctMemory, &clockMHz)
-	return uint(clockMHz), errorString(r)
+	var ci C.nvmlComputeInstance_t
+	r := C.nvmlGpuInstanceGetComputeInstanceById(cgoGpuInstance(gi), C.uint(id), &ci)
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlGpuInstanceGetComputeInstanceById", r)
+	}
+	return ci, nil
 }
 
-// VideoClock returns the memory clock of the device.
-func (d Device) VideoClock() (uint, error) {
+func (cgoBackend) ComputeInstanceDestroy(ci ComputeInstanceHandle) error {
 	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+		return errLibraryNotLoaded
 	}
-	var clockMHz C.uint
-	r := C.nvmlDeviceGetClockInfo(d.dev, ctVideo, &clockMHz)
-	return uint(clockMHz), errorString(r)
+	return errorString("nvmlComputeInstanceDestroy", C.nvmlComputeInstanceDestroy(cgoComputeInstance(ci)))
 }
 
-// GrMaxClock returns the application graphics clock of the device.
-func (d Device) GrMaxClock() (uint, error) {
+func (cgoBackend) ComputeInstanceGetInfo(ci ComputeInstanceHandle) (DeviceHandle, GpuInstanceHandle, ComputeInstanceInfo, error) {
 	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+		return nil, nil, ComputeInstanceInfo{}, errLibraryNotLoaded
 	}
-	var clockMHz C.uint
-	r := C.nvmlDeviceGetMaxClockInfo(d.dev, ctGraphics, &clockMHz)
-	return uint(clockMHz), errorString(r)
+	var info C.nvmlComputeInstanceInfo_t
+	r := C.nvmlComputeInstanceGetInfo(cgoComputeInstance(ci), &info)
+	if r != C.NVML_SUCCESS {
+		return nil, nil, ComputeInstanceInfo{}, errorString("nvmlComputeInstanceGetInfo", r)
+	}
+	return info.device, info.gpuInstance, ComputeInstanceInfo{
+		ID:        uint(info.id),
+		ProfileID: uint(info.profileId),
+	}, nil
 }
 
-// SMMaxClock returns the application SM clock of the device.
-func (d Device) SMMaxClock() (uint, error) {
+func (cgoBackend) DeviceIsMigDeviceHandle(h DeviceHandle) (bool, error) {
 	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+		return false, errLibraryNotLoaded
 	}
-	var clockMHz C.uint
-	r := C.nvmlDeviceGetMaxClockInfo(d.dev, ctSM, &clockMHz)
-	return uint(clockMHz), errorString(r)
+	var isMigDevice C.uint
+	r := C.nvmlDeviceIsMigDeviceHandle(cgoDevice(h), &isMigDevice)
+	return isMigDevice != 0, errorString("nvmlDeviceIsMigDeviceHandle", r)
 }
 
-// MemMaxClock returns the application memory clock of the device.
-func (d Device) MemMaxClock() (uint, error) {
+func (cgoBackend) DeviceGetGpuInstanceId(h DeviceHandle) (uint, error) {
 	if C.nvmlHandle == nil {
 		return 0, errLibraryNotLoaded
 	}
-	var clockMHz C.uint
-	r := C.nvmlDeviceGetMaxClockInfo(d.dev, ctMemory, &clockMHz)
-	return uint(clockMHz), errorString(r)
+	var id C.uint
+	r := C.nvmlDeviceGetGpuInstanceId(cgoDevice(h), &id)
+	return uint(id), errorString("nvmlDeviceGetGpuInstanceId", r)
 }
 
-// VideoClock returns the memory clock of the device.
-func (d Device) VideoMaxClock() (uint, error) {
+func (cgoBackend) DeviceGetComputeInstanceId(h DeviceHandle) (uint, error) {
 	if C.nvmlHandle == nil {
 		return 0, errLibraryNotLoaded
 	}
-	var clockMHz C.uint
-	r := C.nvmlDeviceGetMaxClockInfo(d.dev, ctVideo, &clockMHz)
-	return uint(clockMHz), errorString(r)
+	var id C.uint
+	r := C.nvmlDeviceGetComputeInstanceId(cgoDevice(h), &id)
+	return uint(id), errorString("nvmlDeviceGetComputeInstanceId", r)
 }
 
-// MemoryInfo returns the total and used memory (in bytes) of the device.
-func (d Device) MemoryInfo() (uint64, uint64, error) {
+func (cgoBackend) DeviceGetMaxMigDeviceCount(h DeviceHandle) (uint, error) {
 	if C.nvmlHandle == nil {
-		return 0, 0, errLibraryNotLoaded
+		return 0, errLibraryNotLoaded
 	}
-	var memory C.nvmlMemory_t
-	r := C.nvmlDeviceGetMemoryInfo(d.dev, &memory)
-	return uint64(memory.total), uint64(memory.used), errorString(r)
+	var count C.uint
+	r := C.nvmlDeviceGetMaxMigDeviceCount(cgoDevice(h), &count)
+	return uint(count), errorString("nvmlDeviceGetMaxMigDeviceCount", r)
 }
 
-// Bar1MemoryInfo returns the total and used memory (in bytes) of the devices BAR1 Memory.
-// BAR1 is used to map the FB (device memory) so that it can be directly accessed by
-//  the CPU or by 3rd party devices (peer-to-peer on the PCIE bus).
-func (d Device) Bar1MemoryInfo() (uint64, uint64, error) {
+func (cgoBackend) DeviceGetMigDeviceHandleByIndex(h DeviceHandle, index uint) (DeviceHandle, error) {
 	if C.nvmlHandle == nil {
-		return 0, 0, errLibraryNotLoaded
+		return nil, errLibraryNotLoaded
 	}
-	var bar1 C.nvmlBAR1Memory_t
-	r := C.nvmlDeviceGetBAR1MemoryInfo(d.dev, &bar1)
-	return uint64(bar1.bar1Total), uint64(bar1.bar1Used), errorString(r)
+	var mig C.nvmlDevice_t
+	r := C.nvmlDeviceGetMigDeviceHandleByIndex(cgoDevice(h), C.uint(index), &mig)
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlDeviceGetMigDeviceHandleByIndex", r)
+	}
+	return mig, nil
 }
 
-// UtilizationRates returns the percent of time over the past sample period during which:
-// utilization.gpu: one or more kernels were executing on the GPU.
-// utilization.memory: global (device) memory was being read or written.
-func (d Device) UtilizationRates() (uint, uint, error) {
+func (cgoBackend) DeviceGetDeviceHandleFromMigDeviceHandle(h DeviceHandle) (DeviceHandle, error) {
 	if C.nvmlHandle == nil {
-		return 0, 0, errLibraryNotLoaded
+		return nil, errLibraryNotLoaded
 	}
-	var utilization C.nvmlUtilization_t
-	r := C.nvmlDeviceGetUtilizationRates(d.dev, &utilization)
-	return uint(utilization.gpu), uint(utilization.memory), errorString(r)
+	var dev C.nvmlDevice_t
+	r := C.nvmlDeviceGetDeviceHandleFromMigDeviceHandle(cgoDevice(h), &dev)
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlDeviceGetDeviceHandleFromMigDeviceHandle", r)
+	}
+	return dev, nil
 }
 
-// PowerUsage returns the power usage for this GPU and its associated circuitry
-// in milliwatts. The reading is accurate to within +/- 5% of current power draw.
-func (d Device) PowerUsage() (uint, error) {
+func (cgoBackend) DeviceGetDefaultApplicationsClock(h DeviceHandle, clockType ClockType) (uint, error) {
 	if C.nvmlHandle == nil {
 		return 0, errLibraryNotLoaded
 	}
-	var n C.uint
-	r := C.nvmlDeviceGetPowerUsage(d.dev, &n)
-	return uint(n), errorString(r)
+	var clockMHz C.uint
+	r := C.nvmlDeviceGetDefaultApplicationsClock(cgoDevice(h), C.nvmlClockType_t(clockType), &clockMHz)
+	return uint(clockMHz), errorString("nvmlDeviceGetDefaultApplicationsClock", r)
 }
 
-// PowerLimitConstraints retrieves information about possible values of power management limits on this device.
-func (d Device) PowerLimitConstraints() (uint, uint, error) {
+func (cgoBackend) DeviceGetMaxCustomerBoostClock(h DeviceHandle, clockType ClockType) (uint, error) {
 	if C.nvmlHandle == nil {
-		return 0, 0, errLibraryNotLoaded
+		return 0, errLibraryNotLoaded
 	}
-	var min C.uint
-	var max C.uint
-	r := C.nvmlDeviceGetPowerManagementLimitConstraints(d.dev, &min, &max)
-	return uint(min), uint(max), errorString(r)
+	var clockMHz C.uint
+	r := C.nvmlDeviceGetMaxCustomerBoostClock(cgoDevice(h), C.nvmlClockType_t(clockType), &clockMHz)
+	return uint(clockMHz), errorString("nvmlDeviceGetMaxCustomerBoostClock", r)
 }
 
-// PowerLimits returns the devices power Limits
-// management (first uint): The power limit defines the upper boundary for the
-//  card's power draw. If the card's total power draw reaches this limit the
-//  power management algorithm kicks in.
-// enforced (second uint): Get the effective power limit that the driver
-//  enforces after taking into account all limiters.
-//  Note: This can be different from the management limit if
-//  other limits are set elsewhere This includes the out of band power limit
-//  interface
-func (d Device) PowerLimits() (uint, uint, error) {
-	var errors []string
-
+func (cgoBackend) DeviceGetSupportedMemoryClocks(h DeviceHandle) ([]uint, error) {
+	var count = C.uint(32)
+	var clocks []C.uint
+	var r C.nvmlReturn_t
 	if C.nvmlHandle == nil {
-		return 0, 0, errLibraryNotLoaded
-	}
-	var management, enforced C.uint
-	r := C.nvmlDeviceGetEnforcedPowerLimit(d.dev, &enforced)
-	if errorString(r) != nil {
-		errors = append(errors, errorString(r).Error())
+		return nil, errLibraryNotLoaded
 	}
-	r = C.nvmlDeviceGetPowerManagementLimit(d.dev, &management)
-	if errorString(r) != nil {
-		errors = append(errors, errorString(r).Error())
+	for r = C.nvmlReturn_t(C.NVML_ERROR_INSUFFICIENT_SIZE); r == C.NVML_ERROR_INSUFFICIENT_SIZE; {
+		clocks = make([]C.uint, uint(count))
+		r = C.nvmlDeviceGetSupportedMemoryClocks(cgoDevice(h), &count, &clocks[0])
 	}
-
-	if len(errors) > 0 {
-		return uint(management), uint(enforced), fmt.Errorf(strings.Join(errors, "\n"))
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlDeviceGetSupportedMemoryClocks", r)
 	}
-	return uint(management), uint(enforced), nil
+	return cgoClocks(clocks, count), nil
 }
 
-// AveragePowerUsage returns the power usage for this GPU and its associated circuitry
-// in milliwatts averaged over the samples collected in the last `since` duration.
-func (d Device) AveragePowerUsage(since time.Duration) (uint, error) {
+func (cgoBackend) DeviceGetSupportedGraphicsClocks(h DeviceHandle, memoryClockMHz uint) ([]uint, error) {
+	var count = C.uint(128)
+	var clocks []C.uint
+	var r C.nvmlReturn_t
 	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+		return nil, errLibraryNotLoaded
 	}
-	lastTs := C.ulonglong(time.Now().Add(-1*since).UnixNano() / 1000)
-	var n C.uint
-	r := C.nvmlDeviceGetAverageUsage(d.dev, C.NVML_TOTAL_POWER_SAMPLES, lastTs, &n)
-	return uint(n), errorString(r)
+	for r = C.nvmlReturn_t(C.NVML_ERROR_INSUFFICIENT_SIZE); r == C.NVML_ERROR_INSUFFICIENT_SIZE; {
+		clocks = make([]C.uint, uint(count))
+		r = C.nvmlDeviceGetSupportedGraphicsClocks(cgoDevice(h), C.uint(memoryClockMHz), &count, &clocks[0])
+	}
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlDeviceGetSupportedGraphicsClocks", r)
+	}
+	return cgoClocks(clocks, count), nil
 }
 
-// PowerLimit returns the power limit for this GPU and its associated circuitry
-// in milliwatts
-func (d Device) PowerLimit() (uint, error) {
-	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+// cgoClocks converts the first count clocks filled by NVML.
+func cgoClocks(clocks []C.uint, count C.uint) []uint {
+	if uint(count) < uint(len(clocks)) {
+		clocks = clocks[:count]
 	}
-	var n C.uint
-	r := C.nvmlDeviceGetPowerManagementLimit(d.dev, &n)
-	return uint(n), errorString(r)
+	mhz := make([]uint, len(clocks))
+	for i, c := range clocks {
+		mhz[i] = uint(c)
+	}
+	return mhz
 }
 
-// DefaultPowerLimit returns the power limit for this GPU and its associated circuitry
-// in milliwatts
-func (d Device) PowerManagementDefaultLimit() (uint, error) {
+func (cgoBackend) DeviceSetApplicationsClocks(h DeviceHandle, memClockMHz, graphicsClockMHz uint) error {
 	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+		return errLibraryNotLoaded
 	}
-	var n C.uint
-	r := C.nvmlDeviceGetPowerManagementDefaultLimit(d.dev, &n)
-	return uint(n), errorString(r)
+	r := C.nvmlDeviceSetApplicationsClocks(cgoDevice(h), C.uint(memClockMHz), C.uint(graphicsClockMHz))
+	return errorString("nvmlDeviceSetApplicationsClocks", r)
 }
 
-// AverageGPUUtilization returns the utilization.gpu metric (percent of time
-// one of more kernels were executing on the GPU) averaged over the samples
-// collected in the last `since` duration.
-func (d Device) AverageGPUUtilization(since time.Duration) (uint, error) {
+func (cgoBackend) DeviceResetApplicationsClocks(h DeviceHandle) error {
 	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+		return errLibraryNotLoaded
 	}
-	lastTs := C.ulonglong(time.Now().Add(-1*since).UnixNano() / 1000)
-	var n C.uint
-	r := C.nvmlDeviceGetAverageUsage(d.dev, C.NVML_GPU_UTILIZATION_SAMPLES, lastTs, &n)
-	return uint(n), errorString(r)
+	r := C.nvmlDeviceResetApplicationsClocks(cgoDevice(h))
+	return errorString("nvmlDeviceResetApplicationsClocks", r)
 }
 
-// PcieTxThroughput returns the tx throughput in KB/s
-func (d Device) PcieTxThroughput() (uint, error) {
+func (cgoBackend) DeviceSetGpuLockedClocks(h DeviceHandle, minGpuClockMHz, maxGpuClockMHz uint) error {
 	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+		return errLibraryNotLoaded
 	}
-	var n C.uint
-	r := C.nvmlDeviceGetPcieThroughput(d.dev, pcieUtilTx, &n)
-	return uint(n), errorString(r)
+	r := C.nvmlDeviceSetGpuLockedClocks(cgoDevice(h), C.uint(minGpuClockMHz), C.uint(maxGpuClockMHz))
+	return errorString("nvmlDeviceSetGpuLockedClocks", r)
 }
 
-// PcieRxThroughput returns the rx throughput in KB/s
-func (d Device) PcieRxThroughput() (uint, error) {
+func (cgoBackend) DeviceResetGpuLockedClocks(h DeviceHandle) error {
 	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+		return errLibraryNotLoaded
 	}
-	var n C.uint
-	r := C.nvmlDeviceGetPcieThroughput(d.dev, pcieUtilRx, &n)
-	return uint(n), errorString(r)
+	r := C.nvmlDeviceResetGpuLockedClocks(cgoDevice(h))
+	return errorString("nvmlDeviceResetGpuLockedClocks", r)
 }
 
-// PcieGeneration returns the current PCIe link generation
-func (d Device) PcieGeneration() (uint, error) {
+func (cgoBackend) DeviceGetAutoBoostedClocksEnabled(h DeviceHandle) (EnableState, EnableState, error) {
 	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+		return 0, 0, errLibraryNotLoaded
 	}
-	var n C.uint
-	r := C.nvmlDeviceGetCurrPcieLinkGeneration(d.dev, &n)
-	return uint(n), errorString(r)
+	var enabled, defaultEnabled C.nvmlEnableState_t
+	r := C.nvmlDeviceGetAutoBoostedClocksEnabled(cgoDevice(h), &enabled, &defaultEnabled)
+	return EnableState(enabled), EnableState(defaultEnabled), errorString("nvmlDeviceGetAutoBoostedClocksEnabled", r)
 }
 
-// PcieWidth returns the current PCIe link width
-func (d Device) PcieWidth() (uint, error) {
+func (cgoBackend) DeviceSetAutoBoostedClocksEnabled(h DeviceHandle, enabled EnableState) error {
 	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+		return errLibraryNotLoaded
 	}
-	var n C.uint
-	r := C.nvmlDeviceGetCurrPcieLinkWidth(d.dev, &n)
-	return uint(n), errorString(r)
+	r := C.nvmlDeviceSetAutoBoostedClocksEnabled(cgoDevice(h), C.nvmlEnableState_t(enabled))
+	return errorString("nvmlDeviceSetAutoBoostedClocksEnabled", r)
 }
 
-// PcieMaxGeneration returns the current PCIe link generation
-func (d Device) PcieMaxGeneration() (uint, error) {
+func (cgoBackend) DeviceSetDefaultAutoBoostedClocksEnabled(h DeviceHandle, enabled EnableState, flags uint) error {
 	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+		return errLibraryNotLoaded
 	}
-	var n C.uint
-	r := C.nvmlDeviceGetMaxPcieLinkGeneration(d.dev, &n)
-	return uint(n), errorString(r)
+	r := C.nvmlDeviceSetDefaultAutoBoostedClocksEnabled(cgoDevice(h), C.nvmlEnableState_t(enabled), C.uint(flags))
+	return errorString("nvmlDeviceSetDefaultAutoBoostedClocksEnabled", r)
 }
 
-// PcieMaxWidth returns the current PCIe link width
-func (d Device) PcieMaxWidth() (uint, error) {
+func (cgoBackend) DeviceSetPowerManagementLimit(h DeviceHandle, limit uint) error {
 	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+		return errLibraryNotLoaded
 	}
-	var n C.uint
-	r := C.nvmlDeviceGetMaxPcieLinkWidth(d.dev, &n)
-	return uint(n), errorString(r)
+	r := C.nvmlDeviceSetPowerManagementLimit(cgoDevice(h), C.uint(limit))
+	return errorString("nvmlDeviceSetPowerManagementLimit", r)
 }
 
-// Temperature returns the temperature for this GPU in Celsius.
-func (d Device) Temperature() (uint, error) {
+func (cgoBackend) DeviceGetPowerManagementMode(h DeviceHandle) (EnableState, error) {
 	if C.nvmlHandle == nil {
 		return 0, errLibraryNotLoaded
 	}
-	var n C.uint
-	r := C.nvmlDeviceGetTemperature(d.dev, C.NVML_TEMPERATURE_GPU, &n)
-	return uint(n), errorString(r)
+	var mode C.nvmlEnableState_t
+	r := C.nvmlDeviceGetPowerManagementMode(cgoDevice(h), &mode)
+	return EnableState(mode), errorString("nvmlDeviceGetPowerManagementMode", r)
 }
 
-// TemperatureThresholds returns the temperature thresholds for this device in Celcius
-// first return argument is the shutdown threshold, second is the slowdown threshold
-func (d Device) TemperatureThresholds() (uint, uint, error) {
-	var errors []string
-
+func (cgoBackend) DeviceGetPowerState(h DeviceHandle) (PowerState, error) {
 	if C.nvmlHandle == nil {
-		return 0, 0, errLibraryNotLoaded
-	}
-
-	//shutdown type
-	var shutdown C.uint
-	r := C.nvmlDeviceGetTemperatureThreshold(d.dev, C.NVML_TEMPERATURE_THRESHOLD_SHUTDOWN, &shutdown)
-	if errorString(r) != nil {
-		errors = append(errors, errorString(r).Error())
+		return PowerStateUnknown, errLibraryNotLoaded
 	}
+	var pstate C.nvmlPstates_t
+	r := C.nvmlDeviceGetPowerState(cgoDevice(h), &pstate)
+	return PowerState(pstate), errorString("nvmlDeviceGetPowerState", r)
+}
 
-	//slowdown type
-	var slowdown C.uint
-	r = C.nvmlDeviceGetTemperatureThreshold(d.dev, C.NVML_TEMPERATURE_THRESHOLD_SLOWDOWN, &slowdown)
-	if errorString(r) != nil {
-		errors = append(errors, errorString(r).Error())
+func (cgoBackend) DeviceGetDetailedEccErrors(h DeviceHandle, errorType MemoryErrorType, counterType EccCounterType) (EccErrorCounts, error) {
+	if C.nvmlHandle == nil {
+		return EccErrorCounts{}, errLibraryNotLoaded
 	}
-
-	if len(errors) > 0 {
-		return uint(shutdown), uint(slowdown), fmt.Errorf(strings.Join(errors, "\n"))
+	var counts C.nvmlEccErrorCounts_t
+	r := C.nvmlDeviceGetDetailedEccErrors(cgoDevice(h), C.nvmlMemoryErrorType_t(errorType), C.nvmlEccCounterType_t(counterType), &counts)
+	if r != C.NVML_SUCCESS {
+		return EccErrorCounts{}, errorString("nvmlDeviceGetDetailedEccErrors", r)
 	}
-	return uint(shutdown), uint(slowdown), nil
+	return EccErrorCounts{
+		L1Cache:      uint64(counts.l1Cache),
+		L2Cache:      uint64(counts.l2Cache),
+		DeviceMemory: uint64(counts.deviceMemory),
+		RegisterFile: uint64(counts.registerFile),
+	}, nil
 }
 
-// FanSpeed returns the temperature for this GPU in the percentage of its full
-// speed, with 100 being the maximum.
-func (d Device) FanSpeed() (uint, error) {
+func (cgoBackend) DeviceGetMemoryErrorCounter(h DeviceHandle, errorType MemoryErrorType, counterType EccCounterType, location MemoryLocation) (uint64, error) {
 	if C.nvmlHandle == nil {
 		return 0, errLibraryNotLoaded
 	}
-	var n C.uint
-	r := C.nvmlDeviceGetFanSpeed(d.dev, &n)
-	return uint(n), errorString(r)
+	var count C.ulonglong
+	r := C.nvmlDeviceGetMemoryErrorCounter(cgoDevice(h), C.nvmlMemoryErrorType_t(errorType), C.nvmlEccCounterType_t(counterType), C.nvmlMemoryLocation_t(location), &count)
+	return uint64(count), errorString("nvmlDeviceGetMemoryErrorCounter", r)
 }
 
-// EncoderUtilization returns the percent of time over the last sample period during which the GPU video encoder was being used.
-// The sampling period is variable and is returned in the second return argument in microseconds.
-func (d Device) EncoderUtilization() (uint, uint, error) {
+func (cgoBackend) DeviceGetEccMode(h DeviceHandle) (EnableState, EnableState, error) {
 	if C.nvmlHandle == nil {
 		return 0, 0, errLibraryNotLoaded
 	}
-	var n, sp C.uint
-	r := C.nvmlDeviceGetEncoderUtilization(d.dev, &n, &sp)
-	return uint(n), uint(sp), errorString(r)
+	var current, pending C.nvmlEnableState_t
+	r := C.nvmlDeviceGetEccMode(cgoDevice(h), &current, &pending)
+	return EnableState(current), EnableState(pending), errorString("nvmlDeviceGetEccMode", r)
 }
 
-//  Retrieves the current capacity of the device's encoder, as a percentage of maximum encoder capacity with valid values in the range 0-100.
-func (d Device) EncoderCapacity() (uint, uint, error) {
+func (cgoBackend) DeviceSetEccMode(h DeviceHandle, mode EnableState) error {
 	if C.nvmlHandle == nil {
-		return 0, 0, errLibraryNotLoaded
+		return errLibraryNotLoaded
 	}
-	var capacity_h264, capacity_hevc C.uint
-	r := C.nvmlDeviceGetEncoderCapacity(d.dev, C.NVML_ENCODER_QUERY_H264, &capacity_h264)
-	C.nvmlDeviceGetEncoderCapacity(d.dev, C.NVML_ENCODER_QUERY_HEVC, &capacity_hevc)
-	return uint(capacity_h264), uint(capacity_h264), errorString(r)
+	r := C.nvmlDeviceSetEccMode(cgoDevice(h), C.nvmlEnableState_t(mode))
+	return errorString("nvmlDeviceSetEccMode", r)
 }
 
-// DecoderUtilization returns the percent of time over the last sample period during which the GPU video decoder was being used.
-// The sampling period is variable and is returned in the second return argument in microseconds.
-func (d Device) DecoderUtilization() (uint, uint, error) {
+func (cgoBackend) DeviceClearEccErrorCounts(h DeviceHandle, counterType EccCounterType) error {
 	if C.nvmlHandle == nil {
-		return 0, 0, errLibraryNotLoaded
+		return errLibraryNotLoaded
 	}
-	var n, sp C.uint
-	r := C.nvmlDeviceGetDecoderUtilization(d.dev, &n, &sp)
-	return uint(n), uint(sp), errorString(r)
+	r := C.nvmlDeviceClearEccErrorCounts(cgoDevice(h), C.nvmlEccCounterType_t(counterType))
+	return errorString("nvmlDeviceClearEccErrorCounts", r)
 }
 
-// DeviceGetAccountingMode Queries process's accounting stats
-// @return mode                                 Reference in which to return the current accounting mode
-func (d Device) AccountingMode() (C.nvmlEnableState_t, error) {
-	var stats C.nvmlEnableState_t
+func (cgoBackend) DeviceGetRetiredPages(h DeviceHandle, cause PageRetirementCause) ([]RetiredPage, error) {
+	var count = C.uint(64)
+	var addresses, timestamps []C.ulonglong
+	var r C.nvmlReturn_t
 	if C.nvmlHandle == nil {
-		return stats, errLibraryNotLoaded
+		return nil, errLibraryNotLoaded
+	}
+	for r = C.nvmlReturn_t(C.NVML_ERROR_INSUFFICIENT_SIZE); r == C.NVML_ERROR_INSUFFICIENT_SIZE; {
+		addresses = make([]C.ulonglong, uint(count))
+		timestamps = make([]C.ulonglong, uint(count))
+		r = C.nvmlDeviceGetRetiredPages_v2(cgoDevice(h), C.nvmlPageRetirementCause_t(cause), &count, &addresses[0], &timestamps[0])
+	}
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlDeviceGetRetiredPages_v2", r)
+	}
+	if uint(count) < uint(len(addresses)) {
+		addresses = addresses[:count]
+	}
+	pages := make([]RetiredPage, len(addresses))
+	for i := range pages {
+		pages[i] = RetiredPage{
+			Address:   uint64(addresses[i]),
+			Timestamp: time.Unix(int64(timestamps[i]), 0),
+			Cause:     cause,
+		}
 	}
-	r := C.nvmlDeviceGetAccountingMode(d.dev, &stats)
-	return stats, errorString(r)
+	return pages, nil
 }
 
-// DeviceGetAccountingStats Queries process's accounting stats.
-// @param pid                                  Process Id of the target process to query stats for
-// @return stats                               Reference in which to return the process's accounting stats
-func (d Device) AccountingStats(pid uint) (*AccountingStats, error) {
+func (cgoBackend) DeviceGetRetiredPagesPendingStatus(h DeviceHandle) (EnableState, error) {
 	if C.nvmlHandle == nil {
-		return nil, errLibraryNotLoaded
-	}
-	var stats C.nvmlAccountingStats_t
-	r := C.nvmlDeviceGetAccountingStats(d.dev, C.uint(pid), &stats)
-
-	accountingStats := &AccountingStats{
-		GPUUtilization:    uint(stats.gpuUtilization),
-		MemoryUtilization: uint(stats.memoryUtilization),
-		MaxMemoryUsage:    uint64(stats.maxMemoryUsage),
-		Time:              uint64(stats.time),
-		StartTime:         uint64(stats.startTime),
-		IsRunning:         uint(stats.isRunning) == 1,
+		return 0, errLibraryNotLoaded
 	}
-
-	return accountingStats, errorString(r)
+	var pending C.nvmlEnableState_t
+	r := C.nvmlDeviceGetRetiredPagesPendingStatus(cgoDevice(h), &pending)
+	return EnableState(pending), errorString("nvmlDeviceGetRetiredPagesPendingStatus", r)
 }
 
-// DeviceGetAccountingPids Queries list of processes that can be queried for accounting stats. The list of processes returned
-// @param count                                Maxnum pids
-// @return pids                                Pids result
-// @return count                               Queried pids num
-func (d Device) AccountingPids(count uint) ([]C.uint, uint, error) {
-	// init pids
-	cCount := C.uint(count)
+func (cgoBackend) DeviceGetRemappedRows(h DeviceHandle) (RemappedRows, error) {
 	if C.nvmlHandle == nil {
-		return nil, 0, errLibraryNotLoaded
+		return RemappedRows{}, errLibraryNotLoaded
 	}
-	if count == 0 {
-		r := C.nvmlDeviceGetAccountingPids(d.dev, &cCount, nil)
-		return nil, uint(cCount), errorString(r)
+	var corr, unc, pending, failure C.uint
+	r := C.nvmlDeviceGetRemappedRows(cgoDevice(h), &corr, &unc, &pending, &failure)
+	if r != C.NVML_SUCCESS {
+		return RemappedRows{}, errorString("nvmlDeviceGetRemappedRows", r)
 	}
+	return RemappedRows{
+		Correctable:   uint(corr),
+		Uncorrectable: uint(unc),
+		Pending:       pending != 0,
+		Failure:       failure != 0,
+	}, nil
+}
 
-	pids := make([]C.uint, count)
-	for index := range pids {
-		pids[index] = 0
+func (cgoBackend) DeviceGetRowRemapperHistogram(h DeviceHandle) (RowRemapperHistogram, error) {
+	if C.nvmlHandle == nil {
+		return RowRemapperHistogram{}, errLibraryNotLoaded
 	}
-
-	r := C.nvmlDeviceGetAccountingPids(d.dev, &cCount, &pids[0])
-	return pids, uint(cCount), errorString(r)
+	var values C.nvmlRowRemapperHistogramValues_t
+	r := C.nvmlDeviceGetRowRemapperHistogram(cgoDevice(h), &values)
+	if r != C.NVML_SUCCESS {
+		return RowRemapperHistogram{}, errorString("nvmlDeviceGetRowRemapperHistogram", r)
+	}
+	return RowRemapperHistogram{
+		Max:     uint(values.max),
+		High:    uint(values.high),
+		Partial: uint(values.partial),
+		Low:     uint(values.low),
+		None:    uint(values.none),
+	}, nil
 }
 
-// DeviceGetAccountingBufferSize Returns the number of processes that the circular buffer with accounting pids can hold.
-// @return buffersize                        buffersize
-func (d Device) AccountingBufferSize() (uint, error) {
+func (cgoBackend) DeviceGetTopologyCommonAncestor(h1, h2 DeviceHandle) (TopologyLevel, error) {
 	if C.nvmlHandle == nil {
 		return 0, errLibraryNotLoaded
 	}
-	var bufferSize C.uint
-	r := C.nvmlDeviceGetAccountingBufferSize(d.dev, &bufferSize)
-	return uint(bufferSize), errorString(r)
+	var level C.nvmlGpuTopologyLevel_t
+	r := C.nvmlDeviceGetTopologyCommonAncestor(cgoDevice(h1), cgoDevice(h2), &level)
+	return TopologyLevel(level), errorString("nvmlDeviceGetTopologyCommonAncestor", r)
 }
 
-// DeviceGetProcessUtilization Retrieves the current utilization and process ID
-// @param processCount                      Maxnum process buffersize
-// @param since                             The last query time for process
-// @return utilizations                     The utilizations for all process
-// @return processCount                     The queried utilizations
-func (d Device) ProcessUtilization(processCount uint, since time.Duration) ([]*Utilization, error) {
+func (cgoBackend) DeviceGetTopologyNearestGpus(h DeviceHandle, level TopologyLevel) ([]DeviceHandle, error) {
 	if C.nvmlHandle == nil {
 		return nil, errLibraryNotLoaded
 	}
-	if processCount <= 0 {
-		return nil, errors.New("Process Count Less than zero")
+	// Called with a zero count, nvmlDeviceGetTopologyNearestGpus returns
+	// the number of devices.
+	var count C.uint
+	r := C.nvmlDeviceGetTopologyNearestGpus(cgoDevice(h), C.nvmlGpuTopologyLevel_t(level), &count, nil)
+	if r != C.NVML_SUCCESS || count == 0 {
+		return nil, errorString("nvmlDeviceGetTopologyNearestGpus", r)
 	}
-
-	cUtilizations := make([]C.nvmlProcessUtilizationSample_t, processCount)
-	var runningProcess C.uint = C.uint(processCount * C.sizeof_nvmlProcessUtilizationSample_t)
-
-	lastTS := C.ulonglong(time.Now().Add(-1*since).UnixNano() / 1000)
-	r := C.nvmlDeviceGetProcessUtilization(d.dev, &cUtilizations[0], &runningProcess, lastTS)
-	if errorString(r) != nil {
-		return nil, errorString(r)
+	devices := make([]C.nvmlDevice_t, count)
+	r = C.nvmlDeviceGetTopologyNearestGpus(cgoDevice(h), C.nvmlGpuTopologyLevel_t(level), &count, &devices[0])
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlDeviceGetTopologyNearestGpus", r)
 	}
+	return cgoDevices(devices, count), nil
+}
 
-	statisticsProcess := uint(runningProcess)
-	if processCount < uint(runningProcess) {
-		statisticsProcess = processCount
+func (cgoBackend) SystemGetTopologyGpuSet(cpu uint) ([]DeviceHandle, error) {
+	if C.nvmlHandle == nil {
+		return nil, errLibraryNotLoaded
 	}
-
-	utilizations := make([]*Utilization, statisticsProcess)
-	utilCount := 0
-	for _, utilization := range cUtilizations[:statisticsProcess] {
-		if utilization.pid <= 0 {
-			continue
-		}
-		u := &Utilization{
-			Pid:       uint(utilization.pid),
-			timeStamp: uint64(utilization.timeStamp),
-			SMUtil:    uint(utilization.smUtil),
-			MemUtil:   uint(utilization.memUtil),
-			EncUtil:   uint(utilization.encUtil),
-			DecUtil:   uint(utilization.decUtil),
-		}
-		utilizations[utilCount] = u
-		utilCount++
+	var count C.uint
+	r := C.nvmlSystemGetTopologyGpuSet(C.uint(cpu), &count, nil)
+	if r != C.NVML_SUCCESS || count == 0 {
+		return nil, errorString("nvmlSystemGetTopologyGpuSet", r)
+	}
+	devices := make([]C.nvmlDevice_t, count)
+	r = C.nvmlSystemGetTopologyGpuSet(C.uint(cpu), &count, &devices[0])
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlSystemGetTopologyGpuSet", r)
 	}
+	return cgoDevices(devices, count), nil
+}
 
-	return utilizations[:utilCount], errorString(r)
+// cgoDevices returns the handles of the first count devices filled by NVML.
+func cgoDevices(devices []C.nvmlDevice_t, count C.uint) []DeviceHandle {
+	if uint(count) < uint(len(devices)) {
+		devices = devices[:count]
+	}
+	handles := make([]DeviceHandle, len(devices))
+	for i, dev := range devices {
+		handles[i] = dev
+	}
+	return handles
 }
 
-// SystemGetProcessName GetProcessName by pid
-// @param pid                      Process's id
-// @param buffersize               The process name's buffersize
-// @return name                    Process name
-func SystemGetProcessName(pid, buffersize uint) (string, error) {
+func (cgoBackend) DeviceGetP2PStatus(h1, h2 DeviceHandle, index P2PCapsIndex) (P2PStatus, error) {
 	if C.nvmlHandle == nil {
-		return "", errLibraryNotLoaded
+		return P2PStatusUnknown, errLibraryNotLoaded
 	}
-	c := make([]C.char, buffersize)
-	r := C.nvmlSystemGetProcessName(C.uint(pid), &c[0], C.uint(buffersize))
-	return C.GoString(&c[0]), errorString(r)
+	var status C.nvmlGpuP2PStatus_t
+	r := C.nvmlDeviceGetP2PStatus(cgoDevice(h1), cgoDevice(h2), C.nvmlGpuP2PCapsIndex_t(index), &status)
+	return P2PStatus(status), errorString("nvmlDeviceGetP2PStatus", r)
 }
 
-// PCIeThroughput returns the current PCIe tx and rx bytes
-// first uint is tx, second is rx in KB/s
-func (d Device) PCIeThroughput() (uint, uint, error) {
-	var errors []string
-
+func (cgoBackend) DeviceSetAccountingMode(h DeviceHandle, mode EnableState) error {
 	if C.nvmlHandle == nil {
-		return 0, 0, errLibraryNotLoaded
-	}
-	var rx, tx C.uint
-	r := C.nvmlDeviceGetPcieThroughput(d.dev, C.NVML_PCIE_UTIL_TX_BYTES, &tx)
-	if errorString(r) != nil {
-		errors = append(errors, "Unable to query PCIe TX utilization: "+errorString(r).Error())
-	}
-	r = C.nvmlDeviceGetPcieThroughput(d.dev, C.NVML_PCIE_UTIL_RX_BYTES, &rx)
-	if errorString(r) != nil {
-		errors = append(errors, "Unable to query PCIe RX utilization: "+errorString(r).Error())
+		return errLibraryNotLoaded
 	}
+	r := C.nvmlDeviceSetAccountingMode(cgoDevice(h), C.nvmlEnableState_t(mode))
+	return errorString("nvmlDeviceSetAccountingMode", r)
+}
 
-	if len(errors) > 0 {
-		return uint(tx), uint(rx), fmt.Errorf(strings.Join(errors, "\n"))
+func (cgoBackend) DeviceClearAccountingPids(h DeviceHandle) error {
+	if C.nvmlHandle == nil {
+		return errLibraryNotLoaded
 	}
-	return uint(tx), uint(rx), nil
+	r := C.nvmlDeviceClearAccountingPids(cgoDevice(h))
+	return errorString("nvmlDeviceClearAccountingPids", r)
 }
 
-// PCIeLinkGen returns the current PCIe Link generation
-// first uint ist the current generation, second is the maximum supported generation
-func (d Device) PCIeLinkGen() (uint, uint, error) {
-	var errors []string
+// cgoMaskBits is the number of ids in each unsigned long of an affinity
+// mask.
+const cgoMaskBits = 8 * uint(unsafe.Sizeof(C.ulong(0)))
 
+func (cgoBackend) DeviceGetCpuAffinityWithinScope(h DeviceHandle, scope AffinityScope) ([]uint, error) {
 	if C.nvmlHandle == nil {
-		return 0, 0, errLibraryNotLoaded
-	}
-	var curr, max C.uint
-	r := C.nvmlDeviceGetMaxPcieLinkGeneration(d.dev, &max)
-	if errorString(r) != nil {
-		errors = append(errors, "Unable to query PCIe max link generation: "+errorString(r).Error())
-	}
-	r = C.nvmlDeviceGetCurrPcieLinkGeneration(d.dev, &curr)
-	if errorString(r) != nil {
-		errors = append(errors, "Unable to query PCIe current link generation: "+errorString(r).Error())
+		return nil, errLibraryNotLoaded
 	}
-
-	if len(errors) > 0 {
-		return uint(curr), uint(max), fmt.Errorf(strings.Join(errors, "\n"))
+	var mask [maxAffinityIDs / cgoMaskBits]C.ulong
+	r := C.nvmlDeviceGetCpuAffinityWithinScope(cgoDevice(h), C.uint(len(mask)), &mask[0], C.nvmlAffinityScope_t(scope))
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlDeviceGetCpuAffinityWithinScope", r)
 	}
-	return uint(curr), uint(max), nil
+	return cgoMaskIDs(mask[:]), nil
 }
 
-// PCIeLinkWidth returns the current PCIe Link generation
-// first uint ist the current width, second is the maximum supported width
-func (d Device) PCIeLinkWidth() (uint, uint, error) {
-	var errors []string
-
+func (cgoBackend) DeviceGetCpuAffinity(h DeviceHandle) ([]uint, error) {
 	if C.nvmlHandle == nil {
-		return 0, 0, errLibraryNotLoaded
-	}
-	var curr, max C.uint
-	r := C.nvmlDeviceGetMaxPcieLinkWidth(d.dev, &max)
-	if errorString(r) != nil {
-		errors = append(errors, "Unable to query PCIe max link wdith: "+errorString(r).Error())
-	}
-	r = C.nvmlDeviceGetCurrPcieLinkWidth(d.dev, &curr)
-	if errorString(r) != nil {
-		errors = append(errors, "Unable to query PCIe current link width: "+errorString(r).Error())
+		return nil, errLibraryNotLoaded
 	}
-
-	if len(errors) > 0 {
-		return uint(curr), uint(max), fmt.Errorf(strings.Join(errors, "\n"))
+	var mask [maxAffinityIDs / cgoMaskBits]C.ulong
+	r := C.nvmlDeviceGetCpuAffinity(cgoDevice(h), C.uint(len(mask)), &mask[0])
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlDeviceGetCpuAffinity", r)
 	}
-	return uint(curr), uint(max), nil
+	return cgoMaskIDs(mask[:]), nil
 }
 
-// ApplicationClock returns the current clock of a device application in MHz.
-// the application should be specified in ct
-func (d Device) ApplicationClock(ct ClockType) (uint, error) {
+func (cgoBackend) DeviceGetMemoryAffinity(h DeviceHandle, scope AffinityScope) ([]uint, error) {
 	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+		return nil, errLibraryNotLoaded
+	}
+	var mask [maxAffinityIDs / cgoMaskBits]C.ulong
+	r := C.nvmlDeviceGetMemoryAffinity(cgoDevice(h), C.uint(len(mask)), &mask[0], C.nvmlAffinityScope_t(scope))
+	if r != C.NVML_SUCCESS {
+		return nil, errorString("nvmlDeviceGetMemoryAffinity", r)
 	}
-	var clock C.uint
-	r := C.nvmlDeviceGetApplicationsClock(d.dev, C.nvmlClockType_t(ct), &clock)
-	return uint(clock), errorString(r)
+	return cgoMaskIDs(mask[:]), nil
 }
 
-// Clock returns the current clock of a device application in MHz.
-// the application should be specified in ct
-func (d Device) Clock(ct ClockType) (uint, error) {
-	if C.nvmlHandle == nil {
-		return 0, errLibraryNotLoaded
+// cgoMaskIDs returns the ids of the bits set in an affinity mask filled by
+// NVML.
+func cgoMaskIDs(mask []C.ulong) []uint {
+	var ids []uint
+	for i, word := range mask {
+		ids = appendMaskIDs(ids, uint64(word), uint(i)*cgoMaskBits)
 	}
-	var clock C.uint
-	r := C.nvmlDeviceGetClockInfo(d.dev, C.nvmlClockType_t(ct), &clock)
-	return uint(clock), errorString(r)
+	return ids
 }
 
-// ComputeProcesses returns information about processes with a compute context on a device
-func (d Device) ComputeProcesses() ([]Process, error) {
-	var size = C.uint(2)
-	var cprocs []C.nvmlProcessInfo_t
-	var r C.nvmlReturn_t
+func (cgoBackend) DeviceSetCpuAffinity(h DeviceHandle) error {
 	if C.nvmlHandle == nil {
-		return nil, errLibraryNotLoaded
-	}
-	for r = C.nvmlReturn_t(C.NVML_ERROR_INSUFFICIENT_SIZE); r == C.NVML_ERROR_INSUFFICIENT_SIZE; {
-		cprocs = make([]C.nvmlProcessInfo_t, uint(size))
-		r = C.nvmlDeviceGetComputeRunningProcesses(d.dev, &size, &cprocs[0])
+		return errLibraryNotLoaded
 	}
-	if(uint(size) > 0){
-		procs := make([]process, len(cprocs))
-		for i, cproc := range cprocs {
-			procs[i].pid = uint(cproc.pid)
-			procs[i].usedGpuMemory = uint64(cproc.usedGpuMemory)
-		}
-
-		Procs := make([]Process, len(procs))
-		for i, tmp := range procs {
-			Procs[i] = tmp
-		}
+	r := C.nvmlDeviceSetCpuAffinity(cgoDevice(h))
+	return errorString("nvmlDeviceSetCpuAffinity", r)
+}
 
-		return Procs, errorString(r)
+func (cgoBackend) DeviceClearCpuAffinity(h DeviceHandle) error {
+	if C.nvmlHandle == nil {
+		return errLibraryNotLoaded
 	}
-	return nil, errorString(r)
+	r := C.nvmlDeviceClearCpuAffinity(cgoDevice(h))
+	return errorString("nvmlDeviceClearCpuAffinity", r)
 }
 
-// GraphicsProcesses returns information about processes with a graphics context on a device
-func (d Device) GraphicsProcesses() ([]Process, error) {
-	var size = C.uint(2)
-	var cprocs []C.nvmlProcessInfo_t
-	var r C.nvmlReturn_t
-	if C.nvmlHandle == nil {
-		return nil, errLibraryNotLoaded
+// processes converts the first size nvmlProcessInfo_t filled by NVML into
+// Process values.
+func processes(cprocs []C.nvmlProcessInfo_t, size C.uint) []Process {
+	if uint(size) < uint(len(cprocs)) {
+		cprocs = cprocs[:size]
 	}
-	for r = C.nvmlReturn_t(C.NVML_ERROR_INSUFFICIENT_SIZE); r == C.NVML_ERROR_INSUFFICIENT_SIZE; {
-		cprocs = make([]C.nvmlProcessInfo_t, uint(size))
-		r = C.nvmlDeviceGetGraphicsRunningProcesses(d.dev, &size, &cprocs[0])
+	if len(cprocs) == 0 {
+		return nil
 	}
-
-	if(uint(size) > 0){
-		procs := make([]process, len(cprocs))
-		for i, cproc := range cprocs {
-			procs[i].pid = uint(cproc.pid)
-			procs[i].usedGpuMemory = uint64(cproc.usedGpuMemory)
-		}
-
-		Procs := make([]Process, len(procs))
-		for i, tmp := range procs {
-			Procs[i] = tmp
+	procs := make([]Process, len(cprocs))
+	for i, cproc := range cprocs {
+		procs[i] = process{
+			pid:           uint(cproc.pid),
+			usedGpuMemory: uint64(cproc.usedGpuMemory),
 		}
-
-		return Procs, errorString(r)
 	}
-	return nil, errorString(r)
+	return procs
 }
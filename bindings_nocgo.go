@@ -1,115 +1,558 @@
+//go:build !cgo && !(linux && (amd64 || arm64))
 // +build !cgo
+// +build !linux !amd64,!arm64
 
 package gonvml
 
 import (
-	"errors"
-	"time"
+	"fmt"
+	"runtime"
 )
 
-var errNoCgo = errors.New("this binary is built without CGO, NVML is disabled")
+var errNoCgo = fmt.Errorf("this binary is built without CGO, which NVML requires on %s/%s: %w", runtime.GOOS, runtime.GOARCH, ErrLibraryNotFound)
 
-// Initialize initializes NVML.
-// Call this before calling any other methods.
-func Initialize() error {
-	return errNoCgo
+func newDefaultBackend() Backend {
+	return unsupportedBackend{errNoCgo}
 }
 
-// Shutdown shuts down NVML.
-// Call this once NVML is no longer being used.
-func Shutdown() error {
-	return errNoCgo
+// unsupportedBackend is the Backend used when NVML cannot be loaded at all.
+// Every method fails with err.
+type unsupportedBackend struct {
+	err error
 }
 
-// SystemDriverVersion returns the the driver version on the system.
-func SystemDriverVersion() (string, error) {
-	return "", errNoCgo
+func (b unsupportedBackend) Init(libraries []string, flags InitFlags) (string, error) {
+	return "", b.err
 }
 
-// DeviceCount returns the number of nvidia devices on the system.
-func DeviceCount() (uint, error) {
-	return 0, errNoCgo
+func (b unsupportedBackend) Shutdown() error {
+	return b.err
 }
 
-// Device is the handle for the device.
-// This handle is obtained by calling DeviceHandleByIndex().
-type Device struct {
+func (b unsupportedBackend) SupportedFunctions() ([]string, error) {
+	return nil, b.err
 }
 
-// DeviceHandleByIndex returns the device handle for a particular index.
-// The indices range from 0 to DeviceCount()-1. The order in which NVML
-// enumerates devices has no guarantees of consistency between reboots.
-func DeviceHandleByIndex(idx uint) (Device, error) {
-	return Device{}, errNoCgo
+func (b unsupportedBackend) SystemGetDriverVersion() (string, error) {
+	return "", b.err
 }
 
-// MinorNumber returns the minor number for the device.
-// The minor number for the device is such that the Nvidia device node
-// file for each GPU will have the form /dev/nvidia[minor number].
-func (d Device) MinorNumber() (uint, error) {
-	return 0, errNoCgo
+func (b unsupportedBackend) SystemGetNVMLVersion() (string, error) {
+	return "", b.err
 }
 
-// UUID returns the globally unique immutable UUID associated with this device.
-func (d Device) UUID() (string, error) {
-	return "", errNoCgo
+func (b unsupportedBackend) SystemGetProcessName(pid, length uint) (string, error) {
+	return "", b.err
 }
 
-// Name returns the product name of the device.
-func (d Device) Name() (string, error) {
-	return "", errNoCgo
+func (b unsupportedBackend) DeviceGetCount() (uint, error) {
+	return 0, b.err
 }
 
-// MemoryInfo returns the total and used memory (in bytes) of the device.
-func (d Device) MemoryInfo() (uint64, uint64, error) {
-	return 0, 0, errNoCgo
+func (b unsupportedBackend) DeviceGetHandleByIndex(idx uint) (DeviceHandle, error) {
+	return nil, b.err
 }
 
-// UtilizationRates returns the percent of time over the past sample period during which:
-// utilization.gpu: one or more kernels were executing on the GPU.
-// utilizatoin.memory: global (device) memory was being read or written.
-func (d Device) UtilizationRates() (uint, uint, error) {
-	return 0, 0, errNoCgo
+func (b unsupportedBackend) DeviceGetHandleByUUID(uuid string) (DeviceHandle, error) {
+	return nil, b.err
 }
 
-// PowerUsage returns the power usage for this GPU and its associated circuitry
-// in milliwatts. The reading is accurate to within +/- 5% of current power draw.
-func (d Device) PowerUsage() (uint, error) {
-	return 0, errNoCgo
+func (b unsupportedBackend) DeviceGetHandleByPciBusId(busID string) (DeviceHandle, error) {
+	return nil, b.err
 }
 
-// AveragePowerUsage returns the power usage for this GPU and its associated circuitry
-// in milliwatts averaged over the samples collected in the last `since` duration.
-func (d Device) AveragePowerUsage(since time.Duration) (uint, error) {
-	return 0, errNoCgo
+func (b unsupportedBackend) DeviceGetHandleBySerial(serial string) (DeviceHandle, error) {
+	return nil, b.err
 }
 
-// AverageGPUUtilization returns the utilization.gpu metric (percent of time
-// one of more kernels were executing on the GPU) averaged over the samples
-// collected in the last `since` duration.
-func (d Device) AverageGPUUtilization(since time.Duration) (uint, error) {
-	return 0, errNoCgo
+func (b unsupportedBackend) DeviceGetIndex(h DeviceHandle) (uint, error) {
+	return 0, b.err
 }
 
-// Temperature returns the temperature for this GPU in Celsius.
-func (d Device) Temperature() (uint, error) {
-	return 0, errNoCgo
+func (b unsupportedBackend) DeviceGetBrand(h DeviceHandle) (DeviceBrand, error) {
+	return 0, b.err
 }
 
-// FanSpeed returns the temperature for this GPU in the percentage of its full
-// speed, with 100 being the maximum.
-func (d Device) FanSpeed() (uint, error) {
-	return 0, errNoCgo
+func (b unsupportedBackend) DeviceGetBoardId(h DeviceHandle) (uint, error) {
+	return 0, b.err
 }
 
-// EncoderUtilization returns the percent of time over the last sample period during which the GPU video encoder was being used.
-// The sampling period is variable and is returned in the second return argument in microseconds.
-func (d Device) EncoderUtilization() (uint, uint, error) {
-	return 0, 0, errNoCgo
+func (b unsupportedBackend) DeviceGetComputeMode(h DeviceHandle) (ComputeMode, error) {
+	return 0, b.err
 }
 
-// DecoderUtilization returns the percent of time over the last sample period during which the GPU video decoder was being used.
-// The sampling period is variable and is returned in the second return argument in microseconds.
-func (d Device) DecoderUtilization() (uint, uint, error) {
-	return 0, 0, errNoCgo
+func (b unsupportedBackend) DeviceSetComputeMode(h DeviceHandle, mode ComputeMode) error {
+	return b.err
+}
+
+func (b unsupportedBackend) DeviceGetDisplayMode(h DeviceHandle) (EnableState, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetDisplayActive(h DeviceHandle) (EnableState, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetVbiosVersion(h DeviceHandle) (string, error) {
+	return "", b.err
+}
+
+func (b unsupportedBackend) DeviceGetCurrentClocksThrottleReasons(h DeviceHandle) (uint64, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetTotalEnergyConsumption(h DeviceHandle) (uint64, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetTotalEccErrors(h DeviceHandle, errorType MemoryErrorType, counterType EccCounterType) (uint64, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetSerial(h DeviceHandle) (string, error) {
+	return "", b.err
+}
+
+func (b unsupportedBackend) DeviceGetMinorNumber(h DeviceHandle) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetPciInfo(h DeviceHandle) (PciInfo, error) {
+	return PciInfo{}, b.err
+}
+
+func (b unsupportedBackend) DeviceGetUUID(h DeviceHandle) (string, error) {
+	return "", b.err
+}
+
+func (b unsupportedBackend) DeviceGetName(h DeviceHandle) (string, error) {
+	return "", b.err
+}
+
+func (b unsupportedBackend) DeviceGetPersistenceMode(h DeviceHandle) (EnableState, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceSetPersistenceMode(h DeviceHandle, mode EnableState) error {
+	return b.err
+}
+
+func (b unsupportedBackend) DeviceGetPerformanceState(h DeviceHandle) (PowerState, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetClockInfo(h DeviceHandle, clockType ClockType) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetMaxClockInfo(h DeviceHandle, clockType ClockType) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetApplicationsClock(h DeviceHandle, clockType ClockType) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetMemoryInfo(h DeviceHandle) (total, used uint64, err error) {
+	return 0, 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetBAR1MemoryInfo(h DeviceHandle) (total, used uint64, err error) {
+	return 0, 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetUtilizationRates(h DeviceHandle) (gpu, memory uint, err error) {
+	return 0, 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetPowerUsage(h DeviceHandle) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetPowerManagementLimitConstraints(h DeviceHandle) (min, max uint, err error) {
+	return 0, 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetPowerManagementLimit(h DeviceHandle) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetPowerManagementDefaultLimit(h DeviceHandle) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetEnforcedPowerLimit(h DeviceHandle) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetPcieThroughput(h DeviceHandle, counter PcieUtilCounter) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetCurrPcieLinkGeneration(h DeviceHandle) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetCurrPcieLinkWidth(h DeviceHandle) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetMaxPcieLinkGeneration(h DeviceHandle) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetMaxPcieLinkWidth(h DeviceHandle) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetTemperature(h DeviceHandle, sensor TemperatureSensor) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetTemperatureThreshold(h DeviceHandle, threshold TemperatureThreshold) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetFanSpeed(h DeviceHandle) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetEncoderUtilization(h DeviceHandle) (utilization, samplingPeriodUs uint, err error) {
+	return 0, 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetEncoderCapacity(h DeviceHandle, encoderType EncoderType) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetDecoderUtilization(h DeviceHandle) (utilization, samplingPeriodUs uint, err error) {
+	return 0, 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetSamples(h DeviceHandle, samplingType SamplingType, lastSeenTimeStamp uint64) ([]Sample, error) {
+	return nil, b.err
+}
+
+func (b unsupportedBackend) DeviceGetAccountingMode(h DeviceHandle) (EnableState, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetAccountingStats(h DeviceHandle, pid uint) (AccountingStats, error) {
+	return AccountingStats{}, b.err
+}
+
+func (b unsupportedBackend) DeviceGetAccountingPids(h DeviceHandle, count uint) ([]uint, uint, error) {
+	return nil, 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetAccountingBufferSize(h DeviceHandle) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetProcessUtilization(h DeviceHandle, processCount uint, lastSeenTimeStamp uint64) ([]*Utilization, error) {
+	return nil, b.err
+}
+
+func (b unsupportedBackend) DeviceGetComputeRunningProcesses(h DeviceHandle) ([]Process, error) {
+	return nil, b.err
+}
+
+func (b unsupportedBackend) DeviceGetGraphicsRunningProcesses(h DeviceHandle) ([]Process, error) {
+	return nil, b.err
+}
+
+func (b unsupportedBackend) EventSetCreate() (EventSetHandle, error) {
+	return nil, b.err
+}
+
+func (b unsupportedBackend) EventSetFree(set EventSetHandle) error {
+	return b.err
+}
+
+func (b unsupportedBackend) EventSetWait(set EventSetHandle, timeoutMs uint) (EventData, error) {
+	return EventData{}, b.err
+}
+
+func (b unsupportedBackend) DeviceRegisterEvents(h DeviceHandle, eventTypes EventType, set EventSetHandle) error {
+	return b.err
+}
+
+func (b unsupportedBackend) DeviceGetSupportedEventTypes(h DeviceHandle) (EventType, error) {
+	return EventTypeNone, b.err
+}
+
+func (b unsupportedBackend) DeviceGetFieldValues(h DeviceHandle, values []FieldValue) error {
+	return b.err
+}
+
+func (b unsupportedBackend) DeviceGetNvLinkState(h DeviceHandle, link uint) (EnableState, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetNvLinkVersion(h DeviceHandle, link uint) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetNvLinkCapability(h DeviceHandle, link uint, capability NvLinkCapability) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetNvLinkRemotePciInfo(h DeviceHandle, link uint) (PciInfo, error) {
+	return PciInfo{}, b.err
+}
+
+func (b unsupportedBackend) DeviceGetNvLinkErrorCounter(h DeviceHandle, link uint, counter NvLinkErrorCounter) (uint64, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceResetNvLinkErrorCounters(h DeviceHandle, link uint) error {
+	return b.err
+}
+
+func (b unsupportedBackend) DeviceSetNvLinkUtilizationControl(h DeviceHandle, link, counter uint, control NvLinkUtilizationControl, reset bool) error {
+	return b.err
+}
+
+func (b unsupportedBackend) DeviceGetNvLinkUtilizationControl(h DeviceHandle, link, counter uint) (NvLinkUtilizationControl, error) {
+	return NvLinkUtilizationControl{}, b.err
+}
+
+func (b unsupportedBackend) DeviceGetNvLinkUtilizationCounter(h DeviceHandle, link, counter uint) (rx, tx uint64, err error) {
+	return 0, 0, b.err
+}
+
+func (b unsupportedBackend) DeviceFreezeNvLinkUtilizationCounter(h DeviceHandle, link, counter uint, freeze EnableState) error {
+	return b.err
+}
+
+func (b unsupportedBackend) DeviceResetNvLinkUtilizationCounter(h DeviceHandle, link, counter uint) error {
+	return b.err
+}
+
+func (b unsupportedBackend) DeviceGetMigMode(h DeviceHandle) (current, pending EnableState, err error) {
+	return 0, 0, b.err
+}
+
+func (b unsupportedBackend) DeviceSetMigMode(h DeviceHandle, mode EnableState) (activationStatus Return, err error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetGpuInstanceProfileInfo(h DeviceHandle, profile GpuInstanceProfile) (GpuInstanceProfileInfo, error) {
+	return GpuInstanceProfileInfo{}, b.err
+}
+
+func (b unsupportedBackend) DeviceGetGpuInstancePossiblePlacements(h DeviceHandle, profileID, count uint) ([]GpuInstancePlacement, error) {
+	return nil, b.err
+}
+
+func (b unsupportedBackend) DeviceGetGpuInstanceRemainingCapacity(h DeviceHandle, profileID uint) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceCreateGpuInstance(h DeviceHandle, profileID uint) (GpuInstanceHandle, error) {
+	return nil, b.err
+}
+
+func (b unsupportedBackend) DeviceGetGpuInstances(h DeviceHandle, profileID, count uint) ([]GpuInstanceHandle, error) {
+	return nil, b.err
+}
+
+func (b unsupportedBackend) DeviceGetGpuInstanceById(h DeviceHandle, id uint) (GpuInstanceHandle, error) {
+	return nil, b.err
+}
+
+func (b unsupportedBackend) GpuInstanceDestroy(gi GpuInstanceHandle) error {
+	return b.err
+}
+
+func (b unsupportedBackend) GpuInstanceGetInfo(gi GpuInstanceHandle) (DeviceHandle, GpuInstanceInfo, error) {
+	return nil, GpuInstanceInfo{}, b.err
+}
+
+func (b unsupportedBackend) GpuInstanceGetComputeInstanceProfileInfo(gi GpuInstanceHandle, profile ComputeInstanceProfile, engProfile ComputeInstanceEngineProfile) (ComputeInstanceProfileInfo, error) {
+	return ComputeInstanceProfileInfo{}, b.err
+}
+
+func (b unsupportedBackend) GpuInstanceGetComputeInstanceRemainingCapacity(gi GpuInstanceHandle, profileID uint) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) GpuInstanceCreateComputeInstance(gi GpuInstanceHandle, profileID uint) (ComputeInstanceHandle, error) {
+	return nil, b.err
+}
+
+func (b unsupportedBackend) GpuInstanceGetComputeInstances(gi GpuInstanceHandle, profileID, count uint) ([]ComputeInstanceHandle, error) {
+	return nil, b.err
+}
+
+func (b unsupportedBackend) GpuInstanceGetComputeInstanceById(gi GpuInstanceHandle, id uint) (ComputeInstanceHandle, error) {
+	return nil, b.err
+}
+
+func (b unsupportedBackend) ComputeInstanceDestroy(ci ComputeInstanceHandle) error {
+	return b.err
+}
+
+func (b unsupportedBackend) ComputeInstanceGetInfo(ci ComputeInstanceHandle) (DeviceHandle, GpuInstanceHandle, ComputeInstanceInfo, error) {
+	return nil, nil, ComputeInstanceInfo{}, b.err
+}
+
+func (b unsupportedBackend) DeviceIsMigDeviceHandle(h DeviceHandle) (bool, error) {
+	return false, b.err
+}
+
+func (b unsupportedBackend) DeviceGetGpuInstanceId(h DeviceHandle) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetComputeInstanceId(h DeviceHandle) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetMaxMigDeviceCount(h DeviceHandle) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetMigDeviceHandleByIndex(h DeviceHandle, index uint) (DeviceHandle, error) {
+	return nil, b.err
+}
+
+func (b unsupportedBackend) DeviceGetDeviceHandleFromMigDeviceHandle(h DeviceHandle) (DeviceHandle, error) {
+	return nil, b.err
+}
+
+func (b unsupportedBackend) DeviceGetDefaultApplicationsClock(h DeviceHandle, clockType ClockType) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetMaxCustomerBoostClock(h DeviceHandle, clockType ClockType) (uint, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetSupportedMemoryClocks(h DeviceHandle) ([]uint, error) {
+	return nil, b.err
+}
+
+func (b unsupportedBackend) DeviceGetSupportedGraphicsClocks(h DeviceHandle, memoryClockMHz uint) ([]uint, error) {
+	return nil, b.err
+}
+
+func (b unsupportedBackend) DeviceSetApplicationsClocks(h DeviceHandle, memClockMHz, graphicsClockMHz uint) error {
+	return b.err
+}
+
+func (b unsupportedBackend) DeviceResetApplicationsClocks(h DeviceHandle) error {
+	return b.err
+}
+
+func (b unsupportedBackend) DeviceSetGpuLockedClocks(h DeviceHandle, minGpuClockMHz, maxGpuClockMHz uint) error {
+	return b.err
+}
+
+func (b unsupportedBackend) DeviceResetGpuLockedClocks(h DeviceHandle) error {
+	return b.err
+}
+
+func (b unsupportedBackend) DeviceGetAutoBoostedClocksEnabled(h DeviceHandle) (EnableState, EnableState, error) {
+	return 0, 0, b.err
+}
+
+func (b unsupportedBackend) DeviceSetAutoBoostedClocksEnabled(h DeviceHandle, enabled EnableState) error {
+	return b.err
+}
+
+func (b unsupportedBackend) DeviceSetDefaultAutoBoostedClocksEnabled(h DeviceHandle, enabled EnableState, flags uint) error {
+	return b.err
+}
+
+func (b unsupportedBackend) DeviceSetPowerManagementLimit(h DeviceHandle, limit uint) error {
+	return b.err
+}
+
+func (b unsupportedBackend) DeviceGetPowerManagementMode(h DeviceHandle) (EnableState, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetPowerState(h DeviceHandle) (PowerState, error) {
+	return PowerStateUnknown, b.err
+}
+
+func (b unsupportedBackend) DeviceGetDetailedEccErrors(h DeviceHandle, errorType MemoryErrorType, counterType EccCounterType) (EccErrorCounts, error) {
+	return EccErrorCounts{}, b.err
+}
+
+func (b unsupportedBackend) DeviceGetMemoryErrorCounter(h DeviceHandle, errorType MemoryErrorType, counterType EccCounterType, location MemoryLocation) (uint64, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetEccMode(h DeviceHandle) (EnableState, EnableState, error) {
+	return 0, 0, b.err
+}
+
+func (b unsupportedBackend) DeviceSetEccMode(h DeviceHandle, mode EnableState) error {
+	return b.err
+}
+
+func (b unsupportedBackend) DeviceClearEccErrorCounts(h DeviceHandle, counterType EccCounterType) error {
+	return b.err
+}
+
+func (b unsupportedBackend) DeviceGetRetiredPages(h DeviceHandle, cause PageRetirementCause) ([]RetiredPage, error) {
+	return nil, b.err
+}
+
+func (b unsupportedBackend) DeviceGetRetiredPagesPendingStatus(h DeviceHandle) (EnableState, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetRemappedRows(h DeviceHandle) (RemappedRows, error) {
+	return RemappedRows{}, b.err
+}
+
+func (b unsupportedBackend) DeviceGetRowRemapperHistogram(h DeviceHandle) (RowRemapperHistogram, error) {
+	return RowRemapperHistogram{}, b.err
+}
+
+func (b unsupportedBackend) DeviceGetTopologyCommonAncestor(h1, h2 DeviceHandle) (TopologyLevel, error) {
+	return 0, b.err
+}
+
+func (b unsupportedBackend) DeviceGetTopologyNearestGpus(h DeviceHandle, level TopologyLevel) ([]DeviceHandle, error) {
+	return nil, b.err
+}
+
+func (b unsupportedBackend) SystemGetTopologyGpuSet(cpu uint) ([]DeviceHandle, error) {
+	return nil, b.err
+}
+
+func (b unsupportedBackend) DeviceGetP2PStatus(h1, h2 DeviceHandle, index P2PCapsIndex) (P2PStatus, error) {
+	return P2PStatusUnknown, b.err
+}
+
+func (b unsupportedBackend) DeviceGetCpuAffinityWithinScope(h DeviceHandle, scope AffinityScope) ([]uint, error) {
+	return nil, b.err
+}
+
+func (b unsupportedBackend) DeviceGetCpuAffinity(h DeviceHandle) ([]uint, error) {
+	return nil, b.err
+}
+
+func (b unsupportedBackend) DeviceGetMemoryAffinity(h DeviceHandle, scope AffinityScope) ([]uint, error) {
+	return nil, b.err
+}
+
+func (b unsupportedBackend) DeviceSetCpuAffinity(h DeviceHandle) error {
+	return b.err
+}
+
+func (b unsupportedBackend) DeviceClearCpuAffinity(h DeviceHandle) error {
+	return b.err
+}
+
+func (b unsupportedBackend) DeviceSetAccountingMode(h DeviceHandle, mode EnableState) error {
+	return b.err
+}
+
+func (b unsupportedBackend) DeviceClearAccountingPids(h DeviceHandle) error {
+	return b.err
 }
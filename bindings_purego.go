@@ -0,0 +1,1871 @@
+//go:build linux && (amd64 || arm64) && (!cgo || nvml_purego)
+// +build linux
+// +build amd64 arm64
+// +build !cgo nvml_purego
+
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"bytes"
+	"math"
+	"time"
+	"unsafe"
+
+	"github.com/ebitengine/purego"
+)
+
+// This file is the cgo-free equivalent of bindings.go: libnvidia-ml.so.1 is
+// loaded with purego, which calls the C functions through assembly
+// trampolines following the platform calling convention. It is used instead
+// of bindings.go in the binaries built with CGO_ENABLED=0, or built with the
+// nvml_purego tag.
+//
+// The NVML structs are mirrored by Go structs of identical layout, which the
+// Go and C alignment rules guarantee on 64-bit Linux.
+
+const (
+	szDriver       = 80 // NVML_SYSTEM_DRIVER_VERSION_BUFFER_SIZE
+	szName         = 64 // NVML_DEVICE_NAME_BUFFER_SIZE
+	szUUID         = 80 // NVML_DEVICE_UUID_BUFFER_SIZE
+	szNVML         = 80 // NVML_SYSTEM_NVML_VERSION_BUFFER_SIZE
+	szVBiosVersion = 32 // NVML_DEVICE_VBIOS_VERSION_BUFFER_SIZE
+	szDeviceSerial = 30 // NVML_DEVICE_SERIAL_BUFFER_SIZE
+)
+
+var (
+	// nvmlLib is the handle of the loaded libnvidia-ml.so.1, 0 if it is not
+	// loaded.
+	nvmlLib uintptr
+	// nvmlSymbols maps the names of the NVML functions to their address.
+	nvmlSymbols map[string]uintptr
+)
+
+// nvmlCall calls the NVML function sym and returns its nvmlReturn_t, or
+// NVML_ERROR_FUNCTION_NOT_FOUND if the library doesn't have it. Like for
+// syscall.Syscall, pointers have to be converted to uintptr in the argument
+// list of the call so that they are kept alive.
+//
+//go:uintptrescapes
+func nvmlCall(sym string, args ...uintptr) Return {
+	fn := nvmlSymbols[sym]
+	if fn == 0 {
+		return ReturnErrorFunctionNotFound
+	}
+	r, _, _ := purego.SyscallN(fn, args...)
+	return Return(int32(r))
+}
+
+// The opaque NVML handles.
+type (
+	nvmlDevice          uintptr
+	nvmlEventSet        uintptr
+	nvmlGpuInstance     uintptr
+	nvmlComputeInstance uintptr
+)
+
+// The mirrors of the NVML structs.
+type (
+	nvmlPciInfo struct {
+		busIdLegacy    [16]byte
+		domain         uint32
+		bus            uint32
+		device         uint32
+		pciDeviceId    uint32
+		pciSubSystemId uint32
+		busId          [32]byte
+	}
+
+	nvmlMemory struct {
+		total uint64
+		free  uint64
+		used  uint64
+	}
+
+	nvmlBAR1Memory struct {
+		bar1Total uint64
+		bar1Free  uint64
+		bar1Used  uint64
+	}
+
+	nvmlUtilization struct {
+		gpu    uint32
+		memory uint32
+	}
+
+	nvmlProcessInfo struct {
+		pid           uint32
+		usedGpuMemory uint64
+	}
+
+	nvmlProcessUtilizationSample struct {
+		pid       uint32
+		timeStamp uint64
+		smUtil    uint32
+		memUtil   uint32
+		encUtil   uint32
+		decUtil   uint32
+	}
+
+	nvmlAccountingStats struct {
+		gpuUtilization    uint32
+		memoryUtilization uint32
+		maxMemoryUsage    uint64
+		time              uint64
+		startTime         uint64
+		isRunning         uint32
+		reserved          [5]uint32
+	}
+
+	nvmlEventData struct {
+		device            nvmlDevice
+		eventType         uint64
+		eventData         uint64
+		gpuInstanceId     uint32
+		computeInstanceId uint32
+	}
+
+	// nvmlValue is the nvmlValue_t union, in the byte order of the
+	// supported platforms.
+	nvmlValue [8]byte
+
+	nvmlFieldValue struct {
+		fieldId     uint32
+		scopeId     uint32
+		timestamp   int64
+		latencyUsec int64
+		valueType   int32
+		nvmlReturn  int32
+		value       nvmlValue
+	}
+
+	nvmlSample struct {
+		timeStamp   uint64
+		sampleValue nvmlValue
+	}
+
+	nvmlNvLinkUtilizationControl struct {
+		units     int32
+		pktfilter int32
+	}
+
+	nvmlGpuInstancePlacement struct {
+		start uint32
+		size  uint32
+	}
+
+	nvmlGpuInstanceProfileInfo struct {
+		id                  uint32
+		isP2pSupported      uint32
+		sliceCount          uint32
+		instanceCount       uint32
+		multiprocessorCount uint32
+		copyEngineCount     uint32
+		decoderCount        uint32
+		encoderCount        uint32
+		jpegCount           uint32
+		ofaCount            uint32
+		memorySizeMB        uint64
+	}
+
+	nvmlGpuInstanceInfo struct {
+		device    nvmlDevice
+		id        uint32
+		profileId uint32
+		placement nvmlGpuInstancePlacement
+	}
+
+	nvmlComputeInstanceProfileInfo struct {
+		id                    uint32
+		sliceCount            uint32
+		instanceCount         uint32
+		multiprocessorCount   uint32
+		sharedCopyEngineCount uint32
+		sharedDecoderCount    uint32
+		sharedEncoderCount    uint32
+		sharedJpegCount       uint32
+		sharedOfaCount        uint32
+	}
+
+	nvmlComputeInstanceInfo struct {
+		device      nvmlDevice
+		gpuInstance nvmlGpuInstance
+		id          uint32
+		profileId   uint32
+	}
+)
+
+// uint64 returns the unsigned long long member of the union.
+func (v *nvmlValue) uint64() uint64 {
+	return *(*uint64)(unsafe.Pointer(v))
+}
+
+// uint32 returns the unsigned int member of the union.
+func (v *nvmlValue) uint32() uint32 {
+	return *(*uint32)(unsafe.Pointer(v))
+}
+
+// puregoBackend is the Backend calling into the NVML library loaded with
+// purego.
+type puregoBackend struct{}
+
+func newDefaultBackend() Backend {
+	return puregoBackend{}
+}
+
+// puregoDevice returns the nvmlDevice_t stored in h. Handles that were not
+// returned by this backend yield a NULL device, which NVML rejects with
+// NVML_ERROR_INVALID_ARGUMENT.
+func puregoDevice(h DeviceHandle) uintptr {
+	dev, _ := h.(nvmlDevice)
+	return uintptr(dev)
+}
+
+// puregoEventSet returns the nvmlEventSet_t stored in s, or NULL if s was not
+// returned by this backend.
+func puregoEventSet(s EventSetHandle) uintptr {
+	set, _ := s.(nvmlEventSet)
+	return uintptr(set)
+}
+
+// puregoGpuInstance returns the nvmlGpuInstance_t stored in gi, or NULL if gi
+// was not returned by this backend.
+func puregoGpuInstance(gi GpuInstanceHandle) uintptr {
+	instance, _ := gi.(nvmlGpuInstance)
+	return uintptr(instance)
+}
+
+// puregoComputeInstance returns the nvmlComputeInstance_t stored in ci, or
+// NULL if ci was not returned by this backend.
+func puregoComputeInstance(ci ComputeInstanceHandle) uintptr {
+	instance, _ := ci.(nvmlComputeInstance)
+	return uintptr(instance)
+}
+
+// goString converts the NUL terminated string in buf.
+func goString(buf []byte) string {
+	if i := bytes.IndexByte(buf, 0); i >= 0 {
+		buf = buf[:i]
+	}
+	return string(buf)
+}
+
+// cString returns s as a NUL terminated string.
+func cString(s string) []byte {
+	return append([]byte(s), 0)
+}
+
+func (puregoBackend) Init(libraries []string, flags InitFlags) (string, error) {
+	result := ReturnErrorLibraryNotFound
+	for _, library := range libraries {
+		r := nvmlInit(library, flags)
+		if r == ReturnSuccess {
+			return libraryFile(library, nvmlSymbols["nvmlInit_v2"]), nil
+		}
+		// Keep the reason the first library found failed to load, but try the
+		// remaining candidates all the same.
+		if result == ReturnErrorLibraryNotFound {
+			result = r
+		}
+	}
+	return "", newError("nvmlInit", result)
+}
+
+// nvmlInit loads the NVML library at path, resolves its symbols and
+// initializes NVML, like nvmlInit_dl does. Initializing again while the
+// library is loaded only increments the NVML reference count.
+func nvmlInit(path string, flags InitFlags) Return {
+	if nvmlLib != 0 {
+		return nvmlInitCall(flags)
+	}
+	lib, err := purego.Dlopen(path, purego.RTLD_LAZY)
+	if err != nil {
+		return ReturnErrorLibraryNotFound
+	}
+	symbols := make(map[string]uintptr, len(nvmlRequiredSymbols)+len(nvmlOptionalSymbols))
+	for _, name := range nvmlRequiredSymbols {
+		sym, err := purego.Dlsym(lib, name)
+		if err != nil {
+			purego.Dlclose(lib)
+			return ReturnErrorFunctionNotFound
+		}
+		symbols[name] = sym
+	}
+	for _, name := range nvmlOptionalSymbols {
+		if sym, err := purego.Dlsym(lib, name); err == nil {
+			symbols[name] = sym
+		}
+	}
+	nvmlLib, nvmlSymbols = lib, symbols
+	if r := nvmlInitCall(flags); r != ReturnSuccess {
+		purego.Dlclose(lib)
+		nvmlLib, nvmlSymbols = 0, nil
+		return r
+	}
+	return ReturnSuccess
+}
+
+// nvmlInitCall calls nvmlInitWithFlags, or nvmlInit_v2 if flags is 0.
+func nvmlInitCall(flags InitFlags) Return {
+	if flags == 0 {
+		return nvmlCall("nvmlInit_v2")
+	}
+	return nvmlCall("nvmlInitWithFlags", uintptr(flags))
+}
+
+func (puregoBackend) Shutdown() error {
+	if nvmlLib == 0 {
+		return nil
+	}
+	if r := nvmlCall("nvmlShutdown"); r != ReturnSuccess {
+		return newError("nvmlShutdown", r)
+	}
+	err := purego.Dlclose(nvmlLib)
+	nvmlLib, nvmlSymbols = 0, nil
+	if err != nil {
+		return newError("nvmlShutdown", ReturnErrorUnknown)
+	}
+	return nil
+}
+
+func (puregoBackend) SupportedFunctions() ([]string, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	var names []string
+	for _, fn := range nvmlFunctions() {
+		if nvmlSymbols[fn] != 0 {
+			names = append(names, fn)
+		}
+	}
+	return names, nil
+}
+
+func (puregoBackend) SystemGetDriverVersion() (string, error) {
+	if nvmlLib == 0 {
+		return "", errLibraryNotLoaded
+	}
+	var driver [szDriver]byte
+	r := nvmlCall("nvmlSystemGetDriverVersion", uintptr(unsafe.Pointer(&driver[0])), szDriver)
+	return goString(driver[:]), newError("nvmlSystemGetDriverVersion", r)
+}
+
+func (puregoBackend) SystemGetNVMLVersion() (string, error) {
+	if nvmlLib == 0 {
+		return "", errLibraryNotLoaded
+	}
+	var nvml [szNVML]byte
+	r := nvmlCall("nvmlSystemGetNVMLVersion", uintptr(unsafe.Pointer(&nvml[0])), szNVML)
+	return goString(nvml[:]), newError("nvmlSystemGetNVMLVersion", r)
+}
+
+func (puregoBackend) SystemGetProcessName(pid, length uint) (string, error) {
+	if nvmlLib == 0 {
+		return "", errLibraryNotLoaded
+	}
+	c := make([]byte, length)
+	r := nvmlCall("nvmlSystemGetProcessName", uintptr(pid), uintptr(unsafe.Pointer(&c[0])), uintptr(length))
+	return goString(c), newError("nvmlSystemGetProcessName", r)
+}
+
+func (puregoBackend) DeviceGetCount() (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var n uint32
+	r := nvmlCall("nvmlDeviceGetCount_v2", uintptr(unsafe.Pointer(&n)))
+	return uint(n), newError("nvmlDeviceGetCount", r)
+}
+
+func (puregoBackend) DeviceGetHandleByIndex(idx uint) (DeviceHandle, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	var dev nvmlDevice
+	r := nvmlCall("nvmlDeviceGetHandleByIndex_v2", uintptr(idx), uintptr(unsafe.Pointer(&dev)))
+	return dev, newError("nvmlDeviceGetHandleByIndex", r)
+}
+
+func (puregoBackend) DeviceGetHandleByUUID(uuid string) (DeviceHandle, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	cuuid := cString(uuid)
+	var dev nvmlDevice
+	r := nvmlCall("nvmlDeviceGetHandleByUUID", uintptr(unsafe.Pointer(&cuuid[0])), uintptr(unsafe.Pointer(&dev)))
+	if r != ReturnSuccess {
+		return nil, newError("nvmlDeviceGetHandleByUUID", r)
+	}
+	return dev, nil
+}
+
+func (puregoBackend) DeviceGetHandleByPciBusId(busID string) (DeviceHandle, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	cbusID := cString(busID)
+	var dev nvmlDevice
+	r := nvmlCall("nvmlDeviceGetHandleByPciBusId_v2", uintptr(unsafe.Pointer(&cbusID[0])), uintptr(unsafe.Pointer(&dev)))
+	if r != ReturnSuccess {
+		return nil, newError("nvmlDeviceGetHandleByPciBusId_v2", r)
+	}
+	return dev, nil
+}
+
+func (puregoBackend) DeviceGetHandleBySerial(serial string) (DeviceHandle, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	cserial := cString(serial)
+	var dev nvmlDevice
+	r := nvmlCall("nvmlDeviceGetHandleBySerial", uintptr(unsafe.Pointer(&cserial[0])), uintptr(unsafe.Pointer(&dev)))
+	if r != ReturnSuccess {
+		return nil, newError("nvmlDeviceGetHandleBySerial", r)
+	}
+	return dev, nil
+}
+
+func (puregoBackend) DeviceGetIndex(h DeviceHandle) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var index uint32
+	r := nvmlCall("nvmlDeviceGetIndex", puregoDevice(h), uintptr(unsafe.Pointer(&index)))
+	return uint(index), newError("nvmlDeviceGetIndex", r)
+}
+
+func (puregoBackend) DeviceGetBrand(h DeviceHandle) (DeviceBrand, error) {
+	if nvmlLib == 0 {
+		return DeviceBrandUnknown, errLibraryNotLoaded
+	}
+	var brand int32
+	r := nvmlCall("nvmlDeviceGetBrand", puregoDevice(h), uintptr(unsafe.Pointer(&brand)))
+	return DeviceBrand(brand), newError("nvmlDeviceGetBrand", r)
+}
+
+func (puregoBackend) DeviceGetBoardId(h DeviceHandle) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var boardid uint32
+	r := nvmlCall("nvmlDeviceGetBoardId", puregoDevice(h), uintptr(unsafe.Pointer(&boardid)))
+	return uint(boardid), newError("nvmlDeviceGetBoardId", r)
+}
+
+func (puregoBackend) DeviceGetComputeMode(h DeviceHandle) (ComputeMode, error) {
+	if nvmlLib == 0 {
+		return ComputeModeDefault, errLibraryNotLoaded
+	}
+	var cm int32
+	r := nvmlCall("nvmlDeviceGetComputeMode", puregoDevice(h), uintptr(unsafe.Pointer(&cm)))
+	return ComputeMode(cm), newError("nvmlDeviceGetComputeMode", r)
+}
+
+func (puregoBackend) DeviceSetComputeMode(h DeviceHandle, mode ComputeMode) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	r := nvmlCall("nvmlDeviceSetComputeMode", puregoDevice(h), uintptr(mode))
+	return newError("nvmlDeviceSetComputeMode", r)
+}
+
+func (puregoBackend) DeviceGetDisplayMode(h DeviceHandle) (EnableState, error) {
+	if nvmlLib == 0 {
+		return -1, errLibraryNotLoaded
+	}
+	var es int32
+	r := nvmlCall("nvmlDeviceGetDisplayMode", puregoDevice(h), uintptr(unsafe.Pointer(&es)))
+	return EnableState(es), newError("nvmlDeviceGetDisplayMode", r)
+}
+
+func (puregoBackend) DeviceGetDisplayActive(h DeviceHandle) (EnableState, error) {
+	if nvmlLib == 0 {
+		return -1, errLibraryNotLoaded
+	}
+	var es int32
+	r := nvmlCall("nvmlDeviceGetDisplayActive", puregoDevice(h), uintptr(unsafe.Pointer(&es)))
+	return EnableState(es), newError("nvmlDeviceGetDisplayActive", r)
+}
+
+func (puregoBackend) DeviceGetVbiosVersion(h DeviceHandle) (string, error) {
+	if nvmlLib == 0 {
+		return "", errLibraryNotLoaded
+	}
+	var version [szVBiosVersion]byte
+	r := nvmlCall("nvmlDeviceGetVbiosVersion", puregoDevice(h), uintptr(unsafe.Pointer(&version[0])), szVBiosVersion)
+	return goString(version[:]), newError("nvmlDeviceGetVbiosVersion", r)
+}
+
+func (puregoBackend) DeviceGetCurrentClocksThrottleReasons(h DeviceHandle) (uint64, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var bitmap uint64
+	r := nvmlCall("nvmlDeviceGetCurrentClocksThrottleReasons", puregoDevice(h), uintptr(unsafe.Pointer(&bitmap)))
+	return bitmap, newError("nvmlDeviceGetCurrentClocksThrottleReasons", r)
+}
+
+func (puregoBackend) DeviceGetTotalEnergyConsumption(h DeviceHandle) (uint64, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var energy uint64
+	r := nvmlCall("nvmlDeviceGetTotalEnergyConsumption", puregoDevice(h), uintptr(unsafe.Pointer(&energy)))
+	return energy, newError("nvmlDeviceGetTotalEnergyConsumption", r)
+}
+
+func (puregoBackend) DeviceGetTotalEccErrors(h DeviceHandle, errorType MemoryErrorType, counterType EccCounterType) (uint64, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var count uint64
+	r := nvmlCall("nvmlDeviceGetTotalEccErrors", puregoDevice(h), uintptr(errorType), uintptr(counterType), uintptr(unsafe.Pointer(&count)))
+	return count, newError("nvmlDeviceGetTotalEccErrors", r)
+}
+
+func (puregoBackend) DeviceGetSerial(h DeviceHandle) (string, error) {
+	if nvmlLib == 0 {
+		return "", errLibraryNotLoaded
+	}
+	var serial [szDeviceSerial]byte
+	r := nvmlCall("nvmlDeviceGetSerial", puregoDevice(h), uintptr(unsafe.Pointer(&serial[0])), szDeviceSerial)
+	return goString(serial[:]), newError("nvmlDeviceGetSerial", r)
+}
+
+func (puregoBackend) DeviceGetMinorNumber(h DeviceHandle) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var n uint32
+	r := nvmlCall("nvmlDeviceGetMinorNumber", puregoDevice(h), uintptr(unsafe.Pointer(&n)))
+	return uint(n), newError("nvmlDeviceGetMinorNumber", r)
+}
+
+func (puregoBackend) DeviceGetPciInfo(h DeviceHandle) (PciInfo, error) {
+	if nvmlLib == 0 {
+		return PciInfo{}, errLibraryNotLoaded
+	}
+	// nvmlDeviceGetPciInfo_v2 fills in the same nvmlPciInfo_t as _v3, unlike
+	// the unversioned nvmlDeviceGetPciInfo.
+	sym := "nvmlDeviceGetPciInfo_v3"
+	if nvmlSymbols[sym] == 0 {
+		sym = "nvmlDeviceGetPciInfo_v2"
+	}
+	var pci nvmlPciInfo
+	r := nvmlCall(sym, puregoDevice(h), uintptr(unsafe.Pointer(&pci)))
+	return puregoPciInfo(&pci), newError("nvmlDeviceGetPciInfo", r)
+}
+
+// puregoPciInfo converts the nvmlPciInfo_t filled by NVML.
+func puregoPciInfo(pci *nvmlPciInfo) PciInfo {
+	return PciInfo{
+		BusID:          goString(pci.busId[:]),
+		Domain:         uint(pci.domain),
+		Bus:            uint(pci.bus),
+		Device:         uint(pci.device),
+		PciDeviceID:    pci.pciDeviceId,
+		PciSubSystemID: pci.pciSubSystemId,
+	}
+}
+
+func (puregoBackend) DeviceGetUUID(h DeviceHandle) (string, error) {
+	if nvmlLib == 0 {
+		return "", errLibraryNotLoaded
+	}
+	var uuid [szUUID]byte
+	r := nvmlCall("nvmlDeviceGetUUID", puregoDevice(h), uintptr(unsafe.Pointer(&uuid[0])), szUUID)
+	return goString(uuid[:]), newError("nvmlDeviceGetUUID", r)
+}
+
+func (puregoBackend) DeviceGetName(h DeviceHandle) (string, error) {
+	if nvmlLib == 0 {
+		return "", errLibraryNotLoaded
+	}
+	var name [szName]byte
+	r := nvmlCall("nvmlDeviceGetName", puregoDevice(h), uintptr(unsafe.Pointer(&name[0])), szName)
+	return goString(name[:]), newError("nvmlDeviceGetName", r)
+}
+
+func (puregoBackend) DeviceGetPersistenceMode(h DeviceHandle) (EnableState, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var pm int32
+	r := nvmlCall("nvmlDeviceGetPersistenceMode", puregoDevice(h), uintptr(unsafe.Pointer(&pm)))
+	return EnableState(pm), newError("nvmlDeviceGetPersistenceMode", r)
+}
+
+func (puregoBackend) DeviceSetPersistenceMode(h DeviceHandle, mode EnableState) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	r := nvmlCall("nvmlDeviceSetPersistenceMode", puregoDevice(h), uintptr(mode))
+	return newError("nvmlDeviceSetPersistenceMode", r)
+}
+
+func (puregoBackend) DeviceGetPerformanceState(h DeviceHandle) (PowerState, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var pstate int32
+	r := nvmlCall("nvmlDeviceGetPerformanceState", puregoDevice(h), uintptr(unsafe.Pointer(&pstate)))
+	return PowerState(pstate), newError("nvmlDeviceGetPerformanceState", r)
+}
+
+func (puregoBackend) DeviceGetClockInfo(h DeviceHandle, clockType ClockType) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var clockMHz uint32
+	r := nvmlCall("nvmlDeviceGetClockInfo", puregoDevice(h), uintptr(clockType), uintptr(unsafe.Pointer(&clockMHz)))
+	return uint(clockMHz), newError("nvmlDeviceGetClockInfo", r)
+}
+
+func (puregoBackend) DeviceGetMaxClockInfo(h DeviceHandle, clockType ClockType) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var clockMHz uint32
+	r := nvmlCall("nvmlDeviceGetMaxClockInfo", puregoDevice(h), uintptr(clockType), uintptr(unsafe.Pointer(&clockMHz)))
+	return uint(clockMHz), newError("nvmlDeviceGetMaxClockInfo", r)
+}
+
+func (puregoBackend) DeviceGetApplicationsClock(h DeviceHandle, clockType ClockType) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var clockMHz uint32
+	r := nvmlCall("nvmlDeviceGetApplicationsClock", puregoDevice(h), uintptr(clockType), uintptr(unsafe.Pointer(&clockMHz)))
+	return uint(clockMHz), newError("nvmlDeviceGetApplicationsClock", r)
+}
+
+func (puregoBackend) DeviceGetMemoryInfo(h DeviceHandle) (uint64, uint64, error) {
+	if nvmlLib == 0 {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var memory nvmlMemory
+	r := nvmlCall("nvmlDeviceGetMemoryInfo", puregoDevice(h), uintptr(unsafe.Pointer(&memory)))
+	return memory.total, memory.used, newError("nvmlDeviceGetMemoryInfo", r)
+}
+
+func (puregoBackend) DeviceGetBAR1MemoryInfo(h DeviceHandle) (uint64, uint64, error) {
+	if nvmlLib == 0 {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var bar1 nvmlBAR1Memory
+	r := nvmlCall("nvmlDeviceGetBAR1MemoryInfo", puregoDevice(h), uintptr(unsafe.Pointer(&bar1)))
+	return bar1.bar1Total, bar1.bar1Used, newError("nvmlDeviceGetBAR1MemoryInfo", r)
+}
+
+func (puregoBackend) DeviceGetUtilizationRates(h DeviceHandle) (uint, uint, error) {
+	if nvmlLib == 0 {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var utilization nvmlUtilization
+	r := nvmlCall("nvmlDeviceGetUtilizationRates", puregoDevice(h), uintptr(unsafe.Pointer(&utilization)))
+	return uint(utilization.gpu), uint(utilization.memory), newError("nvmlDeviceGetUtilizationRates", r)
+}
+
+func (puregoBackend) DeviceGetPowerUsage(h DeviceHandle) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var n uint32
+	r := nvmlCall("nvmlDeviceGetPowerUsage", puregoDevice(h), uintptr(unsafe.Pointer(&n)))
+	return uint(n), newError("nvmlDeviceGetPowerUsage", r)
+}
+
+func (puregoBackend) DeviceGetPowerManagementLimitConstraints(h DeviceHandle) (uint, uint, error) {
+	if nvmlLib == 0 {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var min, max uint32
+	r := nvmlCall("nvmlDeviceGetPowerManagementLimitConstraints", puregoDevice(h), uintptr(unsafe.Pointer(&min)), uintptr(unsafe.Pointer(&max)))
+	return uint(min), uint(max), newError("nvmlDeviceGetPowerManagementLimitConstraints", r)
+}
+
+func (puregoBackend) DeviceGetPowerManagementLimit(h DeviceHandle) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var n uint32
+	r := nvmlCall("nvmlDeviceGetPowerManagementLimit", puregoDevice(h), uintptr(unsafe.Pointer(&n)))
+	return uint(n), newError("nvmlDeviceGetPowerManagementLimit", r)
+}
+
+func (puregoBackend) DeviceGetPowerManagementDefaultLimit(h DeviceHandle) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var n uint32
+	r := nvmlCall("nvmlDeviceGetPowerManagementDefaultLimit", puregoDevice(h), uintptr(unsafe.Pointer(&n)))
+	return uint(n), newError("nvmlDeviceGetPowerManagementDefaultLimit", r)
+}
+
+func (puregoBackend) DeviceGetEnforcedPowerLimit(h DeviceHandle) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var n uint32
+	r := nvmlCall("nvmlDeviceGetEnforcedPowerLimit", puregoDevice(h), uintptr(unsafe.Pointer(&n)))
+	return uint(n), newError("nvmlDeviceGetEnforcedPowerLimit", r)
+}
+
+func (puregoBackend) DeviceGetPcieThroughput(h DeviceHandle, counter PcieUtilCounter) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var n uint32
+	r := nvmlCall("nvmlDeviceGetPcieThroughput", puregoDevice(h), uintptr(counter), uintptr(unsafe.Pointer(&n)))
+	return uint(n), newError("nvmlDeviceGetPcieThroughput", r)
+}
+
+func (puregoBackend) DeviceGetCurrPcieLinkGeneration(h DeviceHandle) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var n uint32
+	r := nvmlCall("nvmlDeviceGetCurrPcieLinkGeneration", puregoDevice(h), uintptr(unsafe.Pointer(&n)))
+	return uint(n), newError("nvmlDeviceGetCurrPcieLinkGeneration", r)
+}
+
+func (puregoBackend) DeviceGetCurrPcieLinkWidth(h DeviceHandle) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var n uint32
+	r := nvmlCall("nvmlDeviceGetCurrPcieLinkWidth", puregoDevice(h), uintptr(unsafe.Pointer(&n)))
+	return uint(n), newError("nvmlDeviceGetCurrPcieLinkWidth", r)
+}
+
+func (puregoBackend) DeviceGetMaxPcieLinkGeneration(h DeviceHandle) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var n uint32
+	r := nvmlCall("nvmlDeviceGetMaxPcieLinkGeneration", puregoDevice(h), uintptr(unsafe.Pointer(&n)))
+	return uint(n), newError("nvmlDeviceGetMaxPcieLinkGeneration", r)
+}
+
+func (puregoBackend) DeviceGetMaxPcieLinkWidth(h DeviceHandle) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var n uint32
+	r := nvmlCall("nvmlDeviceGetMaxPcieLinkWidth", puregoDevice(h), uintptr(unsafe.Pointer(&n)))
+	return uint(n), newError("nvmlDeviceGetMaxPcieLinkWidth", r)
+}
+
+func (puregoBackend) DeviceGetTemperature(h DeviceHandle, sensor TemperatureSensor) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var n uint32
+	r := nvmlCall("nvmlDeviceGetTemperature", puregoDevice(h), uintptr(sensor), uintptr(unsafe.Pointer(&n)))
+	return uint(n), newError("nvmlDeviceGetTemperature", r)
+}
+
+func (puregoBackend) DeviceGetTemperatureThreshold(h DeviceHandle, threshold TemperatureThreshold) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var n uint32
+	r := nvmlCall("nvmlDeviceGetTemperatureThreshold", puregoDevice(h), uintptr(threshold), uintptr(unsafe.Pointer(&n)))
+	return uint(n), newError("nvmlDeviceGetTemperatureThreshold", r)
+}
+
+func (puregoBackend) DeviceGetFanSpeed(h DeviceHandle) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var n uint32
+	r := nvmlCall("nvmlDeviceGetFanSpeed", puregoDevice(h), uintptr(unsafe.Pointer(&n)))
+	return uint(n), newError("nvmlDeviceGetFanSpeed", r)
+}
+
+func (puregoBackend) DeviceGetEncoderUtilization(h DeviceHandle) (uint, uint, error) {
+	if nvmlLib == 0 {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var n, sp uint32
+	r := nvmlCall("nvmlDeviceGetEncoderUtilization", puregoDevice(h), uintptr(unsafe.Pointer(&n)), uintptr(unsafe.Pointer(&sp)))
+	return uint(n), uint(sp), newError("nvmlDeviceGetEncoderUtilization", r)
+}
+
+func (puregoBackend) DeviceGetEncoderCapacity(h DeviceHandle, encoderType EncoderType) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var capacity uint32
+	r := nvmlCall("nvmlDeviceGetEncoderCapacity", puregoDevice(h), uintptr(encoderType), uintptr(unsafe.Pointer(&capacity)))
+	return uint(capacity), newError("nvmlDeviceGetEncoderCapacity", r)
+}
+
+func (puregoBackend) DeviceGetDecoderUtilization(h DeviceHandle) (uint, uint, error) {
+	if nvmlLib == 0 {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var n, sp uint32
+	r := nvmlCall("nvmlDeviceGetDecoderUtilization", puregoDevice(h), uintptr(unsafe.Pointer(&n)), uintptr(unsafe.Pointer(&sp)))
+	return uint(n), uint(sp), newError("nvmlDeviceGetDecoderUtilization", r)
+}
+
+func (puregoBackend) DeviceGetSamples(h DeviceHandle, samplingType SamplingType, lastSeenTimeStamp uint64) ([]Sample, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	var valueType int32
+	var n uint32
+	r := nvmlCall("nvmlDeviceGetSamples", puregoDevice(h), uintptr(samplingType), uintptr(lastSeenTimeStamp),
+		uintptr(unsafe.Pointer(&valueType)), uintptr(unsafe.Pointer(&n)), 0)
+	if r != ReturnSuccess {
+		return nil, newError("nvmlDeviceGetSamples", r)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	nsamples := make([]nvmlSample, n)
+	r = nvmlCall("nvmlDeviceGetSamples", puregoDevice(h), uintptr(samplingType), uintptr(lastSeenTimeStamp),
+		uintptr(unsafe.Pointer(&valueType)), uintptr(unsafe.Pointer(&n)), uintptr(unsafe.Pointer(&nsamples[0])))
+	if r != ReturnSuccess {
+		return nil, newError("nvmlDeviceGetSamples", r)
+	}
+	samples := make([]Sample, n)
+	for i := range samples {
+		samples[i] = Sample{
+			Timestamp: time.Unix(0, int64(nsamples[i].timeStamp)*1000),
+			Value:     puregoValue(ValueType(valueType), &nsamples[i].sampleValue),
+		}
+	}
+	return samples, nil
+}
+
+func (puregoBackend) DeviceGetAccountingMode(h DeviceHandle) (EnableState, error) {
+	var mode int32
+	if nvmlLib == 0 {
+		return EnableState(mode), errLibraryNotLoaded
+	}
+	r := nvmlCall("nvmlDeviceGetAccountingMode", puregoDevice(h), uintptr(unsafe.Pointer(&mode)))
+	return EnableState(mode), newError("nvmlDeviceGetAccountingMode", r)
+}
+
+func (puregoBackend) DeviceGetAccountingStats(h DeviceHandle, pid uint) (AccountingStats, error) {
+	if nvmlLib == 0 {
+		return AccountingStats{}, errLibraryNotLoaded
+	}
+	var stats nvmlAccountingStats
+	r := nvmlCall("nvmlDeviceGetAccountingStats", puregoDevice(h), uintptr(pid), uintptr(unsafe.Pointer(&stats)))
+	return AccountingStats{
+		GPUUtilization:    uint(stats.gpuUtilization),
+		MemoryUtilization: uint(stats.memoryUtilization),
+		MaxMemoryUsage:    stats.maxMemoryUsage,
+		Time:              stats.time,
+		StartTime:         stats.startTime,
+		IsRunning:         stats.isRunning == 1,
+	}, newError("nvmlDeviceGetAccountingStats", r)
+}
+
+func (puregoBackend) DeviceGetAccountingPids(h DeviceHandle, count uint) ([]uint, uint, error) {
+	if nvmlLib == 0 {
+		return nil, 0, errLibraryNotLoaded
+	}
+	cCount := uint32(count)
+	if count == 0 {
+		r := nvmlCall("nvmlDeviceGetAccountingPids", puregoDevice(h), uintptr(unsafe.Pointer(&cCount)), 0)
+		return nil, uint(cCount), newError("nvmlDeviceGetAccountingPids", r)
+	}
+	cPids := make([]uint32, count)
+	r := nvmlCall("nvmlDeviceGetAccountingPids", puregoDevice(h), uintptr(unsafe.Pointer(&cCount)), uintptr(unsafe.Pointer(&cPids[0])))
+	pids := make([]uint, count)
+	for i, pid := range cPids {
+		pids[i] = uint(pid)
+	}
+	return pids, uint(cCount), newError("nvmlDeviceGetAccountingPids", r)
+}
+
+func (puregoBackend) DeviceGetAccountingBufferSize(h DeviceHandle) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var bufferSize uint32
+	r := nvmlCall("nvmlDeviceGetAccountingBufferSize", puregoDevice(h), uintptr(unsafe.Pointer(&bufferSize)))
+	return uint(bufferSize), newError("nvmlDeviceGetAccountingBufferSize", r)
+}
+
+func (puregoBackend) DeviceGetProcessUtilization(h DeviceHandle, processCount uint, lastSeenTimeStamp uint64) ([]*Utilization, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	if processCount == 0 {
+		return nil, nil
+	}
+	samples := make([]nvmlProcessUtilizationSample, processCount)
+	runningProcess := uint32(processCount)
+	r := nvmlCall("nvmlDeviceGetProcessUtilization", puregoDevice(h), uintptr(unsafe.Pointer(&samples[0])),
+		uintptr(unsafe.Pointer(&runningProcess)), uintptr(lastSeenTimeStamp))
+	if r != ReturnSuccess {
+		return nil, newError("nvmlDeviceGetProcessUtilization", r)
+	}
+	if uint(runningProcess) < processCount {
+		samples = samples[:runningProcess]
+	}
+	var utilizations []*Utilization
+	for _, sample := range samples {
+		if sample.pid == 0 {
+			continue
+		}
+		utilizations = append(utilizations, &Utilization{
+			Pid:       uint(sample.pid),
+			timeStamp: sample.timeStamp,
+			SMUtil:    uint(sample.smUtil),
+			MemUtil:   uint(sample.memUtil),
+			EncUtil:   uint(sample.encUtil),
+			DecUtil:   uint(sample.decUtil),
+		})
+	}
+	return utilizations, nil
+}
+
+func (puregoBackend) DeviceGetComputeRunningProcesses(h DeviceHandle) ([]Process, error) {
+	return puregoProcesses(h, "nvmlDeviceGetComputeRunningProcesses")
+}
+
+func (puregoBackend) DeviceGetGraphicsRunningProcesses(h DeviceHandle) ([]Process, error) {
+	return puregoProcesses(h, "nvmlDeviceGetGraphicsRunningProcesses")
+}
+
+// puregoProcesses returns the processes listed by the NVML function fn,
+// growing the buffer until they all fit.
+func puregoProcesses(h DeviceHandle, fn string) ([]Process, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	size := uint32(2)
+	var infos []nvmlProcessInfo
+	r := ReturnErrorInsufficientSize
+	for r == ReturnErrorInsufficientSize {
+		infos = make([]nvmlProcessInfo, size)
+		r = nvmlCall(fn, puregoDevice(h), uintptr(unsafe.Pointer(&size)), uintptr(unsafe.Pointer(&infos[0])))
+	}
+	if uint(size) < uint(len(infos)) {
+		infos = infos[:size]
+	}
+	if len(infos) == 0 {
+		return nil, newError(fn, r)
+	}
+	procs := make([]Process, len(infos))
+	for i, info := range infos {
+		procs[i] = process{
+			pid:           uint(info.pid),
+			usedGpuMemory: info.usedGpuMemory,
+		}
+	}
+	return procs, newError(fn, r)
+}
+
+func (puregoBackend) EventSetCreate() (EventSetHandle, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	var set nvmlEventSet
+	r := nvmlCall("nvmlEventSetCreate", uintptr(unsafe.Pointer(&set)))
+	if r != ReturnSuccess {
+		return nil, newError("nvmlEventSetCreate", r)
+	}
+	return set, nil
+}
+
+func (puregoBackend) EventSetFree(s EventSetHandle) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	return newError("nvmlEventSetFree", nvmlCall("nvmlEventSetFree", puregoEventSet(s)))
+}
+
+func (puregoBackend) EventSetWait(s EventSetHandle, timeoutMs uint) (EventData, error) {
+	if nvmlLib == 0 {
+		return EventData{}, errLibraryNotLoaded
+	}
+	var data nvmlEventData
+	r := nvmlCall("nvmlEventSetWait_v2", puregoEventSet(s), uintptr(unsafe.Pointer(&data)), uintptr(timeoutMs))
+	if r != ReturnSuccess {
+		return EventData{}, newError("nvmlEventSetWait_v2", r)
+	}
+	return EventData{
+		Device:            data.device,
+		Type:              EventType(data.eventType),
+		Data:              data.eventData,
+		GpuInstanceID:     uint(data.gpuInstanceId),
+		ComputeInstanceID: uint(data.computeInstanceId),
+	}, nil
+}
+
+func (puregoBackend) DeviceRegisterEvents(h DeviceHandle, eventTypes EventType, s EventSetHandle) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	r := nvmlCall("nvmlDeviceRegisterEvents", puregoDevice(h), uintptr(eventTypes), puregoEventSet(s))
+	return newError("nvmlDeviceRegisterEvents", r)
+}
+
+func (puregoBackend) DeviceGetSupportedEventTypes(h DeviceHandle) (EventType, error) {
+	if nvmlLib == 0 {
+		return EventTypeNone, errLibraryNotLoaded
+	}
+	var types uint64
+	r := nvmlCall("nvmlDeviceGetSupportedEventTypes", puregoDevice(h), uintptr(unsafe.Pointer(&types)))
+	return EventType(types), newError("nvmlDeviceGetSupportedEventTypes", r)
+}
+
+func (puregoBackend) DeviceGetFieldValues(h DeviceHandle, values []FieldValue) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	cValues := make([]nvmlFieldValue, len(values))
+	for i, v := range values {
+		cValues[i].fieldId = uint32(v.FieldID)
+		cValues[i].scopeId = uint32(v.ScopeID)
+	}
+	r := nvmlCall("nvmlDeviceGetFieldValues", puregoDevice(h), uintptr(len(cValues)), uintptr(unsafe.Pointer(&cValues[0])))
+	if r != ReturnSuccess {
+		return newError("nvmlDeviceGetFieldValues", r)
+	}
+	for i, cv := range cValues {
+		values[i].Timestamp = time.Unix(0, cv.timestamp*int64(time.Microsecond))
+		values[i].Latency = time.Duration(cv.latencyUsec) * time.Microsecond
+		values[i].Value = puregoValue(ValueType(cv.valueType), &cv.value)
+		values[i].Err = newError("nvmlDeviceGetFieldValues", Return(cv.nvmlReturn))
+	}
+	return nil
+}
+
+// puregoValue converts the nvmlValue_t v holding a value of type t.
+func puregoValue(t ValueType, v *nvmlValue) Value {
+	switch t {
+	case ValueTypeDouble:
+		return DoubleValue(math.Float64frombits(v.uint64()))
+	case ValueTypeUnsignedInt:
+		return UintValue(v.uint32())
+	default:
+		return Value{Type: t, bits: v.uint64()}
+	}
+}
+
+func (puregoBackend) DeviceGetNvLinkState(h DeviceHandle, link uint) (EnableState, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var isActive int32
+	r := nvmlCall("nvmlDeviceGetNvLinkState", puregoDevice(h), uintptr(link), uintptr(unsafe.Pointer(&isActive)))
+	return EnableState(isActive), newError("nvmlDeviceGetNvLinkState", r)
+}
+
+func (puregoBackend) DeviceGetNvLinkVersion(h DeviceHandle, link uint) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var version uint32
+	r := nvmlCall("nvmlDeviceGetNvLinkVersion", puregoDevice(h), uintptr(link), uintptr(unsafe.Pointer(&version)))
+	return uint(version), newError("nvmlDeviceGetNvLinkVersion", r)
+}
+
+func (puregoBackend) DeviceGetNvLinkCapability(h DeviceHandle, link uint, capability NvLinkCapability) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var capResult uint32
+	r := nvmlCall("nvmlDeviceGetNvLinkCapability", puregoDevice(h), uintptr(link), uintptr(capability), uintptr(unsafe.Pointer(&capResult)))
+	return uint(capResult), newError("nvmlDeviceGetNvLinkCapability", r)
+}
+
+func (puregoBackend) DeviceGetNvLinkRemotePciInfo(h DeviceHandle, link uint) (PciInfo, error) {
+	if nvmlLib == 0 {
+		return PciInfo{}, errLibraryNotLoaded
+	}
+	var pci nvmlPciInfo
+	r := nvmlCall("nvmlDeviceGetNvLinkRemotePciInfo_v2", puregoDevice(h), uintptr(link), uintptr(unsafe.Pointer(&pci)))
+	return puregoPciInfo(&pci), newError("nvmlDeviceGetNvLinkRemotePciInfo_v2", r)
+}
+
+func (puregoBackend) DeviceGetNvLinkErrorCounter(h DeviceHandle, link uint, counter NvLinkErrorCounter) (uint64, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var value uint64
+	r := nvmlCall("nvmlDeviceGetNvLinkErrorCounter", puregoDevice(h), uintptr(link), uintptr(counter), uintptr(unsafe.Pointer(&value)))
+	return value, newError("nvmlDeviceGetNvLinkErrorCounter", r)
+}
+
+func (puregoBackend) DeviceResetNvLinkErrorCounters(h DeviceHandle, link uint) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	r := nvmlCall("nvmlDeviceResetNvLinkErrorCounters", puregoDevice(h), uintptr(link))
+	return newError("nvmlDeviceResetNvLinkErrorCounters", r)
+}
+
+func (puregoBackend) DeviceSetNvLinkUtilizationControl(h DeviceHandle, link, counter uint, control NvLinkUtilizationControl, reset bool) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	cControl := nvmlNvLinkUtilizationControl{
+		units:     int32(control.Units),
+		pktfilter: int32(control.PacketFilter),
+	}
+	var cReset uintptr
+	if reset {
+		cReset = 1
+	}
+	r := nvmlCall("nvmlDeviceSetNvLinkUtilizationControl", puregoDevice(h), uintptr(link), uintptr(counter), uintptr(unsafe.Pointer(&cControl)), cReset)
+	return newError("nvmlDeviceSetNvLinkUtilizationControl", r)
+}
+
+func (puregoBackend) DeviceGetNvLinkUtilizationControl(h DeviceHandle, link, counter uint) (NvLinkUtilizationControl, error) {
+	if nvmlLib == 0 {
+		return NvLinkUtilizationControl{}, errLibraryNotLoaded
+	}
+	var control nvmlNvLinkUtilizationControl
+	r := nvmlCall("nvmlDeviceGetNvLinkUtilizationControl", puregoDevice(h), uintptr(link), uintptr(counter), uintptr(unsafe.Pointer(&control)))
+	return NvLinkUtilizationControl{
+		Units:        NvLinkCounterUnit(control.units),
+		PacketFilter: NvLinkPacketFilter(control.pktfilter),
+	}, newError("nvmlDeviceGetNvLinkUtilizationControl", r)
+}
+
+func (puregoBackend) DeviceGetNvLinkUtilizationCounter(h DeviceHandle, link, counter uint) (uint64, uint64, error) {
+	if nvmlLib == 0 {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var rx, tx uint64
+	r := nvmlCall("nvmlDeviceGetNvLinkUtilizationCounter", puregoDevice(h), uintptr(link), uintptr(counter), uintptr(unsafe.Pointer(&rx)), uintptr(unsafe.Pointer(&tx)))
+	return rx, tx, newError("nvmlDeviceGetNvLinkUtilizationCounter", r)
+}
+
+func (puregoBackend) DeviceFreezeNvLinkUtilizationCounter(h DeviceHandle, link, counter uint, freeze EnableState) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	r := nvmlCall("nvmlDeviceFreezeNvLinkUtilizationCounter", puregoDevice(h), uintptr(link), uintptr(counter), uintptr(freeze))
+	return newError("nvmlDeviceFreezeNvLinkUtilizationCounter", r)
+}
+
+func (puregoBackend) DeviceResetNvLinkUtilizationCounter(h DeviceHandle, link, counter uint) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	r := nvmlCall("nvmlDeviceResetNvLinkUtilizationCounter", puregoDevice(h), uintptr(link), uintptr(counter))
+	return newError("nvmlDeviceResetNvLinkUtilizationCounter", r)
+}
+
+func (puregoBackend) DeviceGetMigMode(h DeviceHandle) (EnableState, EnableState, error) {
+	if nvmlLib == 0 {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var current, pending uint32
+	r := nvmlCall("nvmlDeviceGetMigMode", puregoDevice(h), uintptr(unsafe.Pointer(&current)), uintptr(unsafe.Pointer(&pending)))
+	return EnableState(current), EnableState(pending), newError("nvmlDeviceGetMigMode", r)
+}
+
+func (puregoBackend) DeviceSetMigMode(h DeviceHandle, mode EnableState) (Return, error) {
+	if nvmlLib == 0 {
+		return ReturnSuccess, errLibraryNotLoaded
+	}
+	var activationStatus int32
+	r := nvmlCall("nvmlDeviceSetMigMode", puregoDevice(h), uintptr(mode), uintptr(unsafe.Pointer(&activationStatus)))
+	return Return(activationStatus), newError("nvmlDeviceSetMigMode", r)
+}
+
+func (puregoBackend) DeviceGetGpuInstanceProfileInfo(h DeviceHandle, profile GpuInstanceProfile) (GpuInstanceProfileInfo, error) {
+	if nvmlLib == 0 {
+		return GpuInstanceProfileInfo{}, errLibraryNotLoaded
+	}
+	var info nvmlGpuInstanceProfileInfo
+	r := nvmlCall("nvmlDeviceGetGpuInstanceProfileInfo", puregoDevice(h), uintptr(profile), uintptr(unsafe.Pointer(&info)))
+	return GpuInstanceProfileInfo{
+		ID:                  uint(info.id),
+		IsP2pSupported:      info.isP2pSupported != 0,
+		SliceCount:          uint(info.sliceCount),
+		InstanceCount:       uint(info.instanceCount),
+		MultiprocessorCount: uint(info.multiprocessorCount),
+		CopyEngineCount:     uint(info.copyEngineCount),
+		DecoderCount:        uint(info.decoderCount),
+		EncoderCount:        uint(info.encoderCount),
+		JpegCount:           uint(info.jpegCount),
+		OfaCount:            uint(info.ofaCount),
+		MemorySizeMB:        info.memorySizeMB,
+	}, newError("nvmlDeviceGetGpuInstanceProfileInfo", r)
+}
+
+func (puregoBackend) DeviceGetGpuInstancePossiblePlacements(h DeviceHandle, profileID, count uint) ([]GpuInstancePlacement, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	cPlacements := make([]nvmlGpuInstancePlacement, count)
+	cCount := uint32(count)
+	r := nvmlCall("nvmlDeviceGetGpuInstancePossiblePlacements", puregoDevice(h), uintptr(profileID), uintptr(unsafe.Pointer(&cPlacements[0])), uintptr(unsafe.Pointer(&cCount)))
+	if r != ReturnSuccess {
+		return nil, newError("nvmlDeviceGetGpuInstancePossiblePlacements", r)
+	}
+	placements := make([]GpuInstancePlacement, cCount)
+	for i := range placements {
+		placements[i] = GpuInstancePlacement{uint(cPlacements[i].start), uint(cPlacements[i].size)}
+	}
+	return placements, nil
+}
+
+func (puregoBackend) DeviceGetGpuInstanceRemainingCapacity(h DeviceHandle, profileID uint) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var count uint32
+	r := nvmlCall("nvmlDeviceGetGpuInstanceRemainingCapacity", puregoDevice(h), uintptr(profileID), uintptr(unsafe.Pointer(&count)))
+	return uint(count), newError("nvmlDeviceGetGpuInstanceRemainingCapacity", r)
+}
+
+func (puregoBackend) DeviceCreateGpuInstance(h DeviceHandle, profileID uint) (GpuInstanceHandle, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	var gi nvmlGpuInstance
+	r := nvmlCall("nvmlDeviceCreateGpuInstance", puregoDevice(h), uintptr(profileID), uintptr(unsafe.Pointer(&gi)))
+	if r != ReturnSuccess {
+		return nil, newError("nvmlDeviceCreateGpuInstance", r)
+	}
+	return gi, nil
+}
+
+func (puregoBackend) DeviceGetGpuInstances(h DeviceHandle, profileID, count uint) ([]GpuInstanceHandle, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	cInstances := make([]nvmlGpuInstance, count)
+	cCount := uint32(count)
+	r := nvmlCall("nvmlDeviceGetGpuInstances", puregoDevice(h), uintptr(profileID), uintptr(unsafe.Pointer(&cInstances[0])), uintptr(unsafe.Pointer(&cCount)))
+	if r != ReturnSuccess {
+		return nil, newError("nvmlDeviceGetGpuInstances", r)
+	}
+	instances := make([]GpuInstanceHandle, cCount)
+	for i := range instances {
+		instances[i] = cInstances[i]
+	}
+	return instances, nil
+}
+
+func (puregoBackend) DeviceGetGpuInstanceById(h DeviceHandle, id uint) (GpuInstanceHandle, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	var gi nvmlGpuInstance
+	r := nvmlCall("nvmlDeviceGetGpuInstanceById", puregoDevice(h), uintptr(id), uintptr(unsafe.Pointer(&gi)))
+	if r != ReturnSuccess {
+		return nil, newError("nvmlDeviceGetGpuInstanceById", r)
+	}
+	return gi, nil
+}
+
+func (puregoBackend) GpuInstanceDestroy(gi GpuInstanceHandle) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	return newError("nvmlGpuInstanceDestroy", nvmlCall("nvmlGpuInstanceDestroy", puregoGpuInstance(gi)))
+}
+
+func (puregoBackend) GpuInstanceGetInfo(gi GpuInstanceHandle) (DeviceHandle, GpuInstanceInfo, error) {
+	if nvmlLib == 0 {
+		return nil, GpuInstanceInfo{}, errLibraryNotLoaded
+	}
+	var info nvmlGpuInstanceInfo
+	r := nvmlCall("nvmlGpuInstanceGetInfo", puregoGpuInstance(gi), uintptr(unsafe.Pointer(&info)))
+	if r != ReturnSuccess {
+		return nil, GpuInstanceInfo{}, newError("nvmlGpuInstanceGetInfo", r)
+	}
+	return info.device, GpuInstanceInfo{
+		ID:        uint(info.id),
+		ProfileID: uint(info.profileId),
+		Placement: GpuInstancePlacement{uint(info.placement.start), uint(info.placement.size)},
+	}, nil
+}
+
+func (puregoBackend) GpuInstanceGetComputeInstanceProfileInfo(gi GpuInstanceHandle, profile ComputeInstanceProfile, engProfile ComputeInstanceEngineProfile) (ComputeInstanceProfileInfo, error) {
+	if nvmlLib == 0 {
+		return ComputeInstanceProfileInfo{}, errLibraryNotLoaded
+	}
+	var info nvmlComputeInstanceProfileInfo
+	r := nvmlCall("nvmlGpuInstanceGetComputeInstanceProfileInfo", puregoGpuInstance(gi), uintptr(profile), uintptr(engProfile), uintptr(unsafe.Pointer(&info)))
+	return ComputeInstanceProfileInfo{
+		ID:                    uint(info.id),
+		SliceCount:            uint(info.sliceCount),
+		InstanceCount:         uint(info.instanceCount),
+		MultiprocessorCount:   uint(info.multiprocessorCount),
+		SharedCopyEngineCount: uint(info.sharedCopyEngineCount),
+		SharedDecoderCount:    uint(info.sharedDecoderCount),
+		SharedEncoderCount:    uint(info.sharedEncoderCount),
+		SharedJpegCount:       uint(info.sharedJpegCount),
+		SharedOfaCount:        uint(info.sharedOfaCount),
+	}, newError("nvmlGpuInstanceGetComputeInstanceProfileInfo", r)
+}
+
+func (puregoBackend) GpuInstanceGetComputeInstanceRemainingCapacity(gi GpuInstanceHandle, profileID uint) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var count uint32
+	r := nvmlCall("nvmlGpuInstanceGetComputeInstanceRemainingCapacity", puregoGpuInstance(gi), uintptr(profileID), uintptr(unsafe.Pointer(&count)))
+	return uint(count), newError("nvmlGpuInstanceGetComputeInstanceRemainingCapacity", r)
+}
+
+func (puregoBackend) GpuInstanceCreateComputeInstance(gi GpuInstanceHandle, profileID uint) (ComputeInstanceHandle, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	var ci nvmlComputeInstance
+	r := nvmlCall("nvmlGpuInstanceCreateComputeInstance", puregoGpuInstance(gi), uintptr(profileID), uintptr(unsafe.Pointer(&ci)))
+	if r != ReturnSuccess {
+		return nil, newError("nvmlGpuInstanceCreateComputeInstance", r)
+	}
+	return ci, nil
+}
+
+func (puregoBackend) GpuInstanceGetComputeInstances(gi GpuInstanceHandle, profileID, count uint) ([]ComputeInstanceHandle, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	if count == 0 {
+		return nil, nil
+	}
+	cInstances := make([]nvmlComputeInstance, count)
+	cCount := uint32(count)
+	r := nvmlCall("nvmlGpuInstanceGetComputeInstances", puregoGpuInstance(gi), uintptr(profileID), uintptr(unsafe.Pointer(&cInstances[0])), uintptr(unsafe.Pointer(&cCount)))
+	if r != ReturnSuccess {
+		return nil, newError("nvmlGpuInstanceGetComputeInstances", r)
+	}
+	instances := make([]ComputeInstanceHandle, cCount)
+	for i := range instances {
+		instances[i] = cInstances[i]
+	}
+	return instances, nil
+}
+
+func (puregoBackend) GpuInstanceGetComputeInstanceById(gi GpuInstanceHandle, id uint) (ComputeInstanceHandle, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	var ci nvmlComputeInstance
+	r := nvmlCall("nvmlGpuInstanceGetComputeInstanceById", puregoGpuInstance(gi), uintptr(id), uintptr(unsafe.Pointer(&ci)))
+	if r != ReturnSuccess {
+		return nil, newError("nvmlGpuInstanceGetComputeInstanceById", r)
+	}
+	return ci, nil
+}
+
+func (puregoBackend) ComputeInstanceDestroy(ci ComputeInstanceHandle) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	return newError("nvmlComputeInstanceDestroy", nvmlCall("nvmlComputeInstanceDestroy", puregoComputeInstance(ci)))
+}
+
+func (puregoBackend) ComputeInstanceGetInfo(ci ComputeInstanceHandle) (DeviceHandle, GpuInstanceHandle, ComputeInstanceInfo, error) {
+	if nvmlLib == 0 {
+		return nil, nil, ComputeInstanceInfo{}, errLibraryNotLoaded
+	}
+	var info nvmlComputeInstanceInfo
+	r := nvmlCall("nvmlComputeInstanceGetInfo", puregoComputeInstance(ci), uintptr(unsafe.Pointer(&info)))
+	if r != ReturnSuccess {
+		return nil, nil, ComputeInstanceInfo{}, newError("nvmlComputeInstanceGetInfo", r)
+	}
+	return info.device, info.gpuInstance, ComputeInstanceInfo{
+		ID:        uint(info.id),
+		ProfileID: uint(info.profileId),
+	}, nil
+}
+
+func (puregoBackend) DeviceIsMigDeviceHandle(h DeviceHandle) (bool, error) {
+	if nvmlLib == 0 {
+		return false, errLibraryNotLoaded
+	}
+	var isMigDevice uint32
+	r := nvmlCall("nvmlDeviceIsMigDeviceHandle", puregoDevice(h), uintptr(unsafe.Pointer(&isMigDevice)))
+	return isMigDevice != 0, newError("nvmlDeviceIsMigDeviceHandle", r)
+}
+
+func (puregoBackend) DeviceGetGpuInstanceId(h DeviceHandle) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var id uint32
+	r := nvmlCall("nvmlDeviceGetGpuInstanceId", puregoDevice(h), uintptr(unsafe.Pointer(&id)))
+	return uint(id), newError("nvmlDeviceGetGpuInstanceId", r)
+}
+
+func (puregoBackend) DeviceGetComputeInstanceId(h DeviceHandle) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var id uint32
+	r := nvmlCall("nvmlDeviceGetComputeInstanceId", puregoDevice(h), uintptr(unsafe.Pointer(&id)))
+	return uint(id), newError("nvmlDeviceGetComputeInstanceId", r)
+}
+
+func (puregoBackend) DeviceGetMaxMigDeviceCount(h DeviceHandle) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var count uint32
+	r := nvmlCall("nvmlDeviceGetMaxMigDeviceCount", puregoDevice(h), uintptr(unsafe.Pointer(&count)))
+	return uint(count), newError("nvmlDeviceGetMaxMigDeviceCount", r)
+}
+
+func (puregoBackend) DeviceGetMigDeviceHandleByIndex(h DeviceHandle, index uint) (DeviceHandle, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	var mig nvmlDevice
+	r := nvmlCall("nvmlDeviceGetMigDeviceHandleByIndex", puregoDevice(h), uintptr(index), uintptr(unsafe.Pointer(&mig)))
+	if r != ReturnSuccess {
+		return nil, newError("nvmlDeviceGetMigDeviceHandleByIndex", r)
+	}
+	return mig, nil
+}
+
+func (puregoBackend) DeviceGetDeviceHandleFromMigDeviceHandle(h DeviceHandle) (DeviceHandle, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	var dev nvmlDevice
+	r := nvmlCall("nvmlDeviceGetDeviceHandleFromMigDeviceHandle", puregoDevice(h), uintptr(unsafe.Pointer(&dev)))
+	if r != ReturnSuccess {
+		return nil, newError("nvmlDeviceGetDeviceHandleFromMigDeviceHandle", r)
+	}
+	return dev, nil
+}
+
+func (puregoBackend) DeviceGetDefaultApplicationsClock(h DeviceHandle, clockType ClockType) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var clockMHz uint32
+	r := nvmlCall("nvmlDeviceGetDefaultApplicationsClock", puregoDevice(h), uintptr(clockType), uintptr(unsafe.Pointer(&clockMHz)))
+	return uint(clockMHz), newError("nvmlDeviceGetDefaultApplicationsClock", r)
+}
+
+func (puregoBackend) DeviceGetMaxCustomerBoostClock(h DeviceHandle, clockType ClockType) (uint, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var clockMHz uint32
+	r := nvmlCall("nvmlDeviceGetMaxCustomerBoostClock", puregoDevice(h), uintptr(clockType), uintptr(unsafe.Pointer(&clockMHz)))
+	return uint(clockMHz), newError("nvmlDeviceGetMaxCustomerBoostClock", r)
+}
+
+func (puregoBackend) DeviceGetSupportedMemoryClocks(h DeviceHandle) ([]uint, error) {
+	return puregoClocks("nvmlDeviceGetSupportedMemoryClocks", 32, puregoDevice(h))
+}
+
+func (puregoBackend) DeviceGetSupportedGraphicsClocks(h DeviceHandle, memoryClockMHz uint) ([]uint, error) {
+	return puregoClocks("nvmlDeviceGetSupportedGraphicsClocks", 128, puregoDevice(h), uintptr(memoryClockMHz))
+}
+
+// puregoClocks returns the clocks listed by the NVML function fn called with
+// args followed by the count and the buffer, growing the buffer from size
+// entries until they all fit.
+func puregoClocks(fn string, size uint32, args ...uintptr) ([]uint, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	var clocks []uint32
+	r := ReturnErrorInsufficientSize
+	for r == ReturnErrorInsufficientSize {
+		clocks = make([]uint32, size)
+		r = nvmlCall(fn, append(args, uintptr(unsafe.Pointer(&size)), uintptr(unsafe.Pointer(&clocks[0])))...)
+	}
+	if r != ReturnSuccess {
+		return nil, newError(fn, r)
+	}
+	if uint(size) < uint(len(clocks)) {
+		clocks = clocks[:size]
+	}
+	mhz := make([]uint, len(clocks))
+	for i, c := range clocks {
+		mhz[i] = uint(c)
+	}
+	return mhz, nil
+}
+
+func (puregoBackend) DeviceSetApplicationsClocks(h DeviceHandle, memClockMHz, graphicsClockMHz uint) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	r := nvmlCall("nvmlDeviceSetApplicationsClocks", puregoDevice(h), uintptr(memClockMHz), uintptr(graphicsClockMHz))
+	return newError("nvmlDeviceSetApplicationsClocks", r)
+}
+
+func (puregoBackend) DeviceResetApplicationsClocks(h DeviceHandle) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	r := nvmlCall("nvmlDeviceResetApplicationsClocks", puregoDevice(h))
+	return newError("nvmlDeviceResetApplicationsClocks", r)
+}
+
+func (puregoBackend) DeviceSetGpuLockedClocks(h DeviceHandle, minGpuClockMHz, maxGpuClockMHz uint) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	r := nvmlCall("nvmlDeviceSetGpuLockedClocks", puregoDevice(h), uintptr(minGpuClockMHz), uintptr(maxGpuClockMHz))
+	return newError("nvmlDeviceSetGpuLockedClocks", r)
+}
+
+func (puregoBackend) DeviceResetGpuLockedClocks(h DeviceHandle) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	r := nvmlCall("nvmlDeviceResetGpuLockedClocks", puregoDevice(h))
+	return newError("nvmlDeviceResetGpuLockedClocks", r)
+}
+
+func (puregoBackend) DeviceGetAutoBoostedClocksEnabled(h DeviceHandle) (EnableState, EnableState, error) {
+	if nvmlLib == 0 {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var enabled, defaultEnabled uint32
+	r := nvmlCall("nvmlDeviceGetAutoBoostedClocksEnabled", puregoDevice(h), uintptr(unsafe.Pointer(&enabled)), uintptr(unsafe.Pointer(&defaultEnabled)))
+	return EnableState(enabled), EnableState(defaultEnabled), newError("nvmlDeviceGetAutoBoostedClocksEnabled", r)
+}
+
+func (puregoBackend) DeviceSetAutoBoostedClocksEnabled(h DeviceHandle, enabled EnableState) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	r := nvmlCall("nvmlDeviceSetAutoBoostedClocksEnabled", puregoDevice(h), uintptr(enabled))
+	return newError("nvmlDeviceSetAutoBoostedClocksEnabled", r)
+}
+
+func (puregoBackend) DeviceSetDefaultAutoBoostedClocksEnabled(h DeviceHandle, enabled EnableState, flags uint) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	r := nvmlCall("nvmlDeviceSetDefaultAutoBoostedClocksEnabled", puregoDevice(h), uintptr(enabled), uintptr(flags))
+	return newError("nvmlDeviceSetDefaultAutoBoostedClocksEnabled", r)
+}
+
+func (puregoBackend) DeviceSetPowerManagementLimit(h DeviceHandle, limit uint) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	r := nvmlCall("nvmlDeviceSetPowerManagementLimit", puregoDevice(h), uintptr(limit))
+	return newError("nvmlDeviceSetPowerManagementLimit", r)
+}
+
+func (puregoBackend) DeviceGetPowerManagementMode(h DeviceHandle) (EnableState, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var mode uint32
+	r := nvmlCall("nvmlDeviceGetPowerManagementMode", puregoDevice(h), uintptr(unsafe.Pointer(&mode)))
+	return EnableState(mode), newError("nvmlDeviceGetPowerManagementMode", r)
+}
+
+func (puregoBackend) DeviceGetPowerState(h DeviceHandle) (PowerState, error) {
+	if nvmlLib == 0 {
+		return PowerStateUnknown, errLibraryNotLoaded
+	}
+	var pstate int32
+	r := nvmlCall("nvmlDeviceGetPowerState", puregoDevice(h), uintptr(unsafe.Pointer(&pstate)))
+	return PowerState(pstate), newError("nvmlDeviceGetPowerState", r)
+}
+
+// nvmlEccErrorCounts is the layout of nvmlEccErrorCounts_t.
+type nvmlEccErrorCounts struct {
+	l1Cache      uint64
+	l2Cache      uint64
+	deviceMemory uint64
+	registerFile uint64
+}
+
+func (puregoBackend) DeviceGetDetailedEccErrors(h DeviceHandle, errorType MemoryErrorType, counterType EccCounterType) (EccErrorCounts, error) {
+	if nvmlLib == 0 {
+		return EccErrorCounts{}, errLibraryNotLoaded
+	}
+	var counts nvmlEccErrorCounts
+	r := nvmlCall("nvmlDeviceGetDetailedEccErrors", puregoDevice(h), uintptr(errorType), uintptr(counterType), uintptr(unsafe.Pointer(&counts)))
+	if r != ReturnSuccess {
+		return EccErrorCounts{}, newError("nvmlDeviceGetDetailedEccErrors", r)
+	}
+	return EccErrorCounts{
+		L1Cache:      counts.l1Cache,
+		L2Cache:      counts.l2Cache,
+		DeviceMemory: counts.deviceMemory,
+		RegisterFile: counts.registerFile,
+	}, nil
+}
+
+func (puregoBackend) DeviceGetMemoryErrorCounter(h DeviceHandle, errorType MemoryErrorType, counterType EccCounterType, location MemoryLocation) (uint64, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var count uint64
+	r := nvmlCall("nvmlDeviceGetMemoryErrorCounter", puregoDevice(h), uintptr(errorType), uintptr(counterType), uintptr(location), uintptr(unsafe.Pointer(&count)))
+	return count, newError("nvmlDeviceGetMemoryErrorCounter", r)
+}
+
+func (puregoBackend) DeviceGetEccMode(h DeviceHandle) (EnableState, EnableState, error) {
+	if nvmlLib == 0 {
+		return 0, 0, errLibraryNotLoaded
+	}
+	var current, pending uint32
+	r := nvmlCall("nvmlDeviceGetEccMode", puregoDevice(h), uintptr(unsafe.Pointer(&current)), uintptr(unsafe.Pointer(&pending)))
+	return EnableState(current), EnableState(pending), newError("nvmlDeviceGetEccMode", r)
+}
+
+func (puregoBackend) DeviceSetEccMode(h DeviceHandle, mode EnableState) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	r := nvmlCall("nvmlDeviceSetEccMode", puregoDevice(h), uintptr(mode))
+	return newError("nvmlDeviceSetEccMode", r)
+}
+
+func (puregoBackend) DeviceClearEccErrorCounts(h DeviceHandle, counterType EccCounterType) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	r := nvmlCall("nvmlDeviceClearEccErrorCounts", puregoDevice(h), uintptr(counterType))
+	return newError("nvmlDeviceClearEccErrorCounts", r)
+}
+
+func (puregoBackend) DeviceGetRetiredPages(h DeviceHandle, cause PageRetirementCause) ([]RetiredPage, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	count := uint32(64)
+	var addresses, timestamps []uint64
+	r := ReturnErrorInsufficientSize
+	for r == ReturnErrorInsufficientSize {
+		addresses = make([]uint64, count)
+		timestamps = make([]uint64, count)
+		r = nvmlCall("nvmlDeviceGetRetiredPages_v2", puregoDevice(h), uintptr(cause), uintptr(unsafe.Pointer(&count)),
+			uintptr(unsafe.Pointer(&addresses[0])), uintptr(unsafe.Pointer(&timestamps[0])))
+	}
+	if r != ReturnSuccess {
+		return nil, newError("nvmlDeviceGetRetiredPages_v2", r)
+	}
+	if uint(count) < uint(len(addresses)) {
+		addresses = addresses[:count]
+	}
+	pages := make([]RetiredPage, len(addresses))
+	for i := range pages {
+		pages[i] = RetiredPage{
+			Address:   addresses[i],
+			Timestamp: time.Unix(int64(timestamps[i]), 0),
+			Cause:     cause,
+		}
+	}
+	return pages, nil
+}
+
+func (puregoBackend) DeviceGetRetiredPagesPendingStatus(h DeviceHandle) (EnableState, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var pending uint32
+	r := nvmlCall("nvmlDeviceGetRetiredPagesPendingStatus", puregoDevice(h), uintptr(unsafe.Pointer(&pending)))
+	return EnableState(pending), newError("nvmlDeviceGetRetiredPagesPendingStatus", r)
+}
+
+func (puregoBackend) DeviceGetRemappedRows(h DeviceHandle) (RemappedRows, error) {
+	if nvmlLib == 0 {
+		return RemappedRows{}, errLibraryNotLoaded
+	}
+	var corr, unc, pending, failure uint32
+	r := nvmlCall("nvmlDeviceGetRemappedRows", puregoDevice(h), uintptr(unsafe.Pointer(&corr)), uintptr(unsafe.Pointer(&unc)),
+		uintptr(unsafe.Pointer(&pending)), uintptr(unsafe.Pointer(&failure)))
+	if r != ReturnSuccess {
+		return RemappedRows{}, newError("nvmlDeviceGetRemappedRows", r)
+	}
+	return RemappedRows{
+		Correctable:   uint(corr),
+		Uncorrectable: uint(unc),
+		Pending:       pending != 0,
+		Failure:       failure != 0,
+	}, nil
+}
+
+// nvmlRowRemapperHistogramValues is the layout of
+// nvmlRowRemapperHistogramValues_t.
+type nvmlRowRemapperHistogramValues struct {
+	max, high, partial, low, none uint32
+}
+
+func (puregoBackend) DeviceGetRowRemapperHistogram(h DeviceHandle) (RowRemapperHistogram, error) {
+	if nvmlLib == 0 {
+		return RowRemapperHistogram{}, errLibraryNotLoaded
+	}
+	var values nvmlRowRemapperHistogramValues
+	r := nvmlCall("nvmlDeviceGetRowRemapperHistogram", puregoDevice(h), uintptr(unsafe.Pointer(&values)))
+	if r != ReturnSuccess {
+		return RowRemapperHistogram{}, newError("nvmlDeviceGetRowRemapperHistogram", r)
+	}
+	return RowRemapperHistogram{
+		Max:     uint(values.max),
+		High:    uint(values.high),
+		Partial: uint(values.partial),
+		Low:     uint(values.low),
+		None:    uint(values.none),
+	}, nil
+}
+
+func (puregoBackend) DeviceGetTopologyCommonAncestor(h1, h2 DeviceHandle) (TopologyLevel, error) {
+	if nvmlLib == 0 {
+		return 0, errLibraryNotLoaded
+	}
+	var level int32
+	r := nvmlCall("nvmlDeviceGetTopologyCommonAncestor", puregoDevice(h1), puregoDevice(h2), uintptr(unsafe.Pointer(&level)))
+	return TopologyLevel(level), newError("nvmlDeviceGetTopologyCommonAncestor", r)
+}
+
+func (puregoBackend) DeviceGetTopologyNearestGpus(h DeviceHandle, level TopologyLevel) ([]DeviceHandle, error) {
+	return puregoDevices("nvmlDeviceGetTopologyNearestGpus", puregoDevice(h), uintptr(level))
+}
+
+func (puregoBackend) SystemGetTopologyGpuSet(cpu uint) ([]DeviceHandle, error) {
+	return puregoDevices("nvmlSystemGetTopologyGpuSet", uintptr(cpu))
+}
+
+// puregoDevices returns the devices listed by the NVML function fn called
+// with args followed by the count and the device array, first with a zero
+// count to get the number of devices.
+func puregoDevices(fn string, args ...uintptr) ([]DeviceHandle, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	var count uint32
+	r := nvmlCall(fn, append(args, uintptr(unsafe.Pointer(&count)), 0)...)
+	if r != ReturnSuccess || count == 0 {
+		return nil, newError(fn, r)
+	}
+	devices := make([]nvmlDevice, count)
+	r = nvmlCall(fn, append(args, uintptr(unsafe.Pointer(&count)), uintptr(unsafe.Pointer(&devices[0])))...)
+	if r != ReturnSuccess {
+		return nil, newError(fn, r)
+	}
+	if uint(count) < uint(len(devices)) {
+		devices = devices[:count]
+	}
+	handles := make([]DeviceHandle, len(devices))
+	for i, dev := range devices {
+		handles[i] = dev
+	}
+	return handles, nil
+}
+
+func (puregoBackend) DeviceGetP2PStatus(h1, h2 DeviceHandle, index P2PCapsIndex) (P2PStatus, error) {
+	if nvmlLib == 0 {
+		return P2PStatusUnknown, errLibraryNotLoaded
+	}
+	var status int32
+	r := nvmlCall("nvmlDeviceGetP2PStatus", puregoDevice(h1), puregoDevice(h2), uintptr(index), uintptr(unsafe.Pointer(&status)))
+	return P2PStatus(status), newError("nvmlDeviceGetP2PStatus", r)
+}
+
+func (puregoBackend) DeviceGetCpuAffinityWithinScope(h DeviceHandle, scope AffinityScope) ([]uint, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	var mask [maxAffinityIDs / 64]uint64
+	r := nvmlCall("nvmlDeviceGetCpuAffinityWithinScope", puregoDevice(h), uintptr(len(mask)), uintptr(unsafe.Pointer(&mask[0])), uintptr(scope))
+	if r != ReturnSuccess {
+		return nil, newError("nvmlDeviceGetCpuAffinityWithinScope", r)
+	}
+	return puregoMaskIDs(mask[:]), nil
+}
+
+func (puregoBackend) DeviceGetCpuAffinity(h DeviceHandle) ([]uint, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	var mask [maxAffinityIDs / 64]uint64
+	r := nvmlCall("nvmlDeviceGetCpuAffinity", puregoDevice(h), uintptr(len(mask)), uintptr(unsafe.Pointer(&mask[0])))
+	if r != ReturnSuccess {
+		return nil, newError("nvmlDeviceGetCpuAffinity", r)
+	}
+	return puregoMaskIDs(mask[:]), nil
+}
+
+func (puregoBackend) DeviceGetMemoryAffinity(h DeviceHandle, scope AffinityScope) ([]uint, error) {
+	if nvmlLib == 0 {
+		return nil, errLibraryNotLoaded
+	}
+	var mask [maxAffinityIDs / 64]uint64
+	r := nvmlCall("nvmlDeviceGetMemoryAffinity", puregoDevice(h), uintptr(len(mask)), uintptr(unsafe.Pointer(&mask[0])), uintptr(scope))
+	if r != ReturnSuccess {
+		return nil, newError("nvmlDeviceGetMemoryAffinity", r)
+	}
+	return puregoMaskIDs(mask[:]), nil
+}
+
+// puregoMaskIDs returns the ids of the bits set in an affinity mask filled by
+// NVML, whose unsigned longs are 64 bits on the supported platforms.
+func puregoMaskIDs(mask []uint64) []uint {
+	var ids []uint
+	for i, word := range mask {
+		ids = appendMaskIDs(ids, word, uint(i)*64)
+	}
+	return ids
+}
+
+func (puregoBackend) DeviceSetCpuAffinity(h DeviceHandle) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	r := nvmlCall("nvmlDeviceSetCpuAffinity", puregoDevice(h))
+	return newError("nvmlDeviceSetCpuAffinity", r)
+}
+
+func (puregoBackend) DeviceClearCpuAffinity(h DeviceHandle) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	r := nvmlCall("nvmlDeviceClearCpuAffinity", puregoDevice(h))
+	return newError("nvmlDeviceClearCpuAffinity", r)
+}
+
+func (puregoBackend) DeviceSetAccountingMode(h DeviceHandle, mode EnableState) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	r := nvmlCall("nvmlDeviceSetAccountingMode", puregoDevice(h), uintptr(mode))
+	return newError("nvmlDeviceSetAccountingMode", r)
+}
+
+func (puregoBackend) DeviceClearAccountingPids(h DeviceHandle) error {
+	if nvmlLib == 0 {
+		return errLibraryNotLoaded
+	}
+	r := nvmlCall("nvmlDeviceClearAccountingPids", puregoDevice(h))
+	return newError("nvmlDeviceClearAccountingPids", r)
+}
@@ -0,0 +1,353 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// nvmlRequiredSymbols are the NVML functions the backends fail to initialize
+// without.
+var nvmlRequiredSymbols = []string{
+	"nvmlInit_v2",
+	"nvmlShutdown",
+	"nvmlErrorString",
+	"nvmlDeviceGetHandleByIndex_v2",
+	"nvmlDeviceGetIndex",
+	"nvmlSystemGetDriverVersion",
+	"nvmlSystemGetNVMLVersion",
+	"nvmlDeviceGetCount_v2",
+	"nvmlDeviceGetBrand",
+	"nvmlDeviceGetBoardId",
+	"nvmlDeviceGetComputeMode",
+	"nvmlDeviceGetDisplayMode",
+	"nvmlDeviceGetDisplayActive",
+	"nvmlDeviceGetVbiosVersion",
+	"nvmlDeviceGetCurrentClocksThrottleReasons",
+	"nvmlDeviceGetTotalEnergyConsumption",
+	"nvmlDeviceGetTotalEccErrors",
+	"nvmlDeviceGetSerial",
+	"nvmlDeviceGetBAR1MemoryInfo",
+	"nvmlDeviceGetPcieThroughput",
+	"nvmlDeviceGetCurrPcieLinkGeneration",
+	"nvmlDeviceGetMaxPcieLinkGeneration",
+	"nvmlDeviceGetMaxPcieLinkWidth",
+	"nvmlDeviceGetCurrPcieLinkWidth",
+	"nvmlDeviceGetMinorNumber",
+	"nvmlDeviceGetUUID",
+	"nvmlDeviceGetName",
+	"nvmlDeviceGetPersistenceMode",
+	"nvmlDeviceSetPersistenceMode",
+	"nvmlDeviceSetComputeMode",
+	"nvmlDeviceGetPerformanceState",
+	"nvmlDeviceGetClockInfo",
+	"nvmlDeviceGetMaxClockInfo",
+	"nvmlDeviceGetMemoryInfo",
+	"nvmlDeviceGetUtilizationRates",
+	"nvmlDeviceGetPowerUsage",
+	"nvmlDeviceGetPowerManagementLimitConstraints",
+	"nvmlDeviceGetPowerManagementDefaultLimit",
+	"nvmlDeviceGetPowerManagementLimit",
+	"nvmlDeviceGetEnforcedPowerLimit",
+	"nvmlDeviceGetTemperature",
+	"nvmlDeviceGetTemperatureThreshold",
+	"nvmlDeviceGetFanSpeed",
+	"nvmlDeviceGetSamples",
+	"nvmlDeviceGetEncoderUtilization",
+	"nvmlDeviceGetEncoderCapacity",
+	"nvmlDeviceGetDecoderUtilization",
+	"nvmlSystemGetProcessName",
+	"nvmlDeviceGetAccountingMode",
+	"nvmlDeviceGetAccountingStats",
+	"nvmlDeviceGetAccountingPids",
+	"nvmlDeviceGetAccountingBufferSize",
+	"nvmlDeviceGetProcessUtilization",
+	"nvmlDeviceGetApplicationsClock",
+	"nvmlDeviceGetComputeRunningProcesses",
+	"nvmlDeviceGetGraphicsRunningProcesses",
+}
+
+// nvmlOptionalSymbols are missing from older drivers, in which case calling
+// them fails with NVML_ERROR_FUNCTION_NOT_FOUND.
+var nvmlOptionalSymbols = []string{
+	// The backends use nvmlDeviceGetPciInfo_v2 when _v3 is missing.
+	"nvmlDeviceGetPciInfo_v3",
+	"nvmlDeviceGetPciInfo_v2",
+	"nvmlEventSetCreate",
+	"nvmlEventSetFree",
+	"nvmlEventSetWait_v2",
+	"nvmlDeviceRegisterEvents",
+	"nvmlDeviceGetSupportedEventTypes",
+	"nvmlDeviceGetFieldValues",
+	"nvmlDeviceGetNvLinkState",
+	"nvmlDeviceGetNvLinkVersion",
+	"nvmlDeviceGetNvLinkCapability",
+	"nvmlDeviceGetNvLinkRemotePciInfo_v2",
+	"nvmlDeviceGetNvLinkErrorCounter",
+	"nvmlDeviceResetNvLinkErrorCounters",
+	"nvmlDeviceSetNvLinkUtilizationControl",
+	"nvmlDeviceGetNvLinkUtilizationControl",
+	"nvmlDeviceGetNvLinkUtilizationCounter",
+	"nvmlDeviceFreezeNvLinkUtilizationCounter",
+	"nvmlDeviceResetNvLinkUtilizationCounter",
+	"nvmlDeviceSetMigMode",
+	"nvmlDeviceGetMigMode",
+	"nvmlDeviceGetGpuInstanceProfileInfo",
+	"nvmlDeviceGetGpuInstancePossiblePlacements",
+	"nvmlDeviceGetGpuInstanceRemainingCapacity",
+	"nvmlDeviceCreateGpuInstance",
+	"nvmlGpuInstanceDestroy",
+	"nvmlDeviceGetGpuInstances",
+	"nvmlDeviceGetGpuInstanceById",
+	"nvmlGpuInstanceGetInfo",
+	"nvmlGpuInstanceGetComputeInstanceProfileInfo",
+	"nvmlGpuInstanceGetComputeInstanceRemainingCapacity",
+	"nvmlGpuInstanceCreateComputeInstance",
+	"nvmlComputeInstanceDestroy",
+	"nvmlGpuInstanceGetComputeInstances",
+	"nvmlGpuInstanceGetComputeInstanceById",
+	"nvmlComputeInstanceGetInfo",
+	"nvmlDeviceIsMigDeviceHandle",
+	"nvmlDeviceGetGpuInstanceId",
+	"nvmlDeviceGetComputeInstanceId",
+	"nvmlDeviceGetMaxMigDeviceCount",
+	"nvmlDeviceGetMigDeviceHandleByIndex",
+	"nvmlDeviceGetDeviceHandleFromMigDeviceHandle",
+	"nvmlDeviceGetHandleByUUID",
+	"nvmlDeviceGetHandleByPciBusId_v2",
+	"nvmlDeviceGetHandleBySerial",
+	"nvmlInitWithFlags",
+	"nvmlDeviceGetSupportedMemoryClocks",
+	"nvmlDeviceGetSupportedGraphicsClocks",
+	"nvmlDeviceSetApplicationsClocks",
+	"nvmlDeviceResetApplicationsClocks",
+	"nvmlDeviceGetDefaultApplicationsClock",
+	"nvmlDeviceSetGpuLockedClocks",
+	"nvmlDeviceResetGpuLockedClocks",
+	"nvmlDeviceGetMaxCustomerBoostClock",
+	"nvmlDeviceGetAutoBoostedClocksEnabled",
+	"nvmlDeviceSetAutoBoostedClocksEnabled",
+	"nvmlDeviceSetDefaultAutoBoostedClocksEnabled",
+	"nvmlDeviceSetPowerManagementLimit",
+	"nvmlDeviceGetPowerManagementMode",
+	"nvmlDeviceGetPowerState",
+	"nvmlDeviceGetDetailedEccErrors",
+	"nvmlDeviceGetMemoryErrorCounter",
+	"nvmlDeviceGetEccMode",
+	"nvmlDeviceSetEccMode",
+	"nvmlDeviceClearEccErrorCounts",
+	"nvmlDeviceGetRetiredPages_v2",
+	"nvmlDeviceGetRetiredPagesPendingStatus",
+	"nvmlDeviceGetRemappedRows",
+	"nvmlDeviceGetRowRemapperHistogram",
+	"nvmlDeviceGetTopologyCommonAncestor",
+	"nvmlDeviceGetTopologyNearestGpus",
+	"nvmlSystemGetTopologyGpuSet",
+	"nvmlDeviceGetP2PStatus",
+	"nvmlDeviceGetCpuAffinityWithinScope",
+	"nvmlDeviceGetCpuAffinity",
+	"nvmlDeviceGetMemoryAffinity",
+	"nvmlDeviceSetCpuAffinity",
+	"nvmlDeviceClearCpuAffinity",
+	"nvmlDeviceSetAccountingMode",
+	"nvmlDeviceClearAccountingPids",
+}
+
+// nvmlFunctions returns the names of all the NVML functions used by this
+// package.
+func nvmlFunctions() []string {
+	names := make([]string, 0, len(nvmlRequiredSymbols)+len(nvmlOptionalSymbols))
+	names = append(names, nvmlRequiredSymbols...)
+	return append(names, nvmlOptionalSymbols...)
+}
+
+// SupportedFunctions returns the sorted names of the NVML functions used by
+// this package that the loaded library exports, e.g. "nvmlDeviceGetFieldValues".
+// Calling a method that needs one of the other functions fails with
+// ErrFunctionNotFound.
+func SupportedFunctions() ([]string, error) {
+	names, err := backend.SupportedFunctions()
+	if err != nil {
+		return nil, err
+	}
+	names = append([]string(nil), names...)
+	sort.Strings(names)
+	return names, nil
+}
+
+// LibraryCapabilities lists which of the NVML functions used by this package
+// the loaded library exports.
+type LibraryCapabilities struct {
+	Library LibraryInfo
+	// Functions maps the name of every NVML function used by this package
+	// to whether the library exports it.
+	Functions map[string]bool
+}
+
+// Supports reports whether the library exports the NVML function fn.
+func (c LibraryCapabilities) Supports(fn string) bool {
+	return c.Functions[fn]
+}
+
+// Missing returns the sorted names of the NVML functions used by this package
+// that the library doesn't export.
+func (c LibraryCapabilities) Missing() []string {
+	var missing []string
+	for fn, ok := range c.Functions {
+		if !ok {
+			missing = append(missing, fn)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// Capabilities returns the NVML functions the loaded library exports. Older
+// drivers lack the functions introduced after them, which makes the methods
+// calling them fail with ErrFunctionNotFound.
+func Capabilities() (LibraryCapabilities, error) {
+	info, err := LoadedLibrary()
+	if err != nil {
+		return LibraryCapabilities{}, err
+	}
+	supported, err := backend.SupportedFunctions()
+	if err != nil {
+		return LibraryCapabilities{}, err
+	}
+	c := LibraryCapabilities{Library: info, Functions: make(map[string]bool)}
+	for _, fn := range nvmlFunctions() {
+		c.Functions[fn] = false
+	}
+	for _, fn := range supported {
+		c.Functions[fn] = true
+	}
+	return c, nil
+}
+
+// deviceProbes are the Device getters probed by Device.Capabilities.
+var deviceProbes = []struct {
+	name  string
+	probe func(d Device) error
+}{
+	{"Brand", func(d Device) error { _, err := d.Brand(); return err }},
+	{"BoardID", func(d Device) error { _, err := d.BoardID(); return err }},
+	{"ComputeMode", func(d Device) error { _, err := d.ComputeMode(); return err }},
+	{"DisplayMode", func(d Device) error { _, err := d.DisplayMode(); return err }},
+	{"DisplayActive", func(d Device) error { _, err := d.DisplayActive(); return err }},
+	{"VBiosVersion", func(d Device) error { _, err := d.VBiosVersion(); return err }},
+	{"CurrentClocksThrottleReasons", func(d Device) error { _, err := d.CurrentClocksThrottleReasons(); return err }},
+	{"TotalEnergyConsumption", func(d Device) error { _, err := d.TotalEnergyConsumption(); return err }},
+	{"TotalEccErrors", func(d Device) error { _, _, _, _, err := d.TotalEccErrors(); return err }},
+	{"EccMode", func(d Device) error { _, _, err := d.EccMode(); return err }},
+	{"MemoryErrorCounter", func(d Device) error { _, err := d.MemoryErrorCounter(MemoryLocationDeviceMemory); return err }},
+	{"RetiredPages", func(d Device) error { _, err := d.RetiredPages(PageRetirementCauseDoubleBitEccError); return err }},
+	{"RemappedRows", func(d Device) error { _, err := d.RemappedRows(); return err }},
+	{"Serial", func(d Device) error { _, err := d.Serial(); return err }},
+	{"MinorNumber", func(d Device) error { _, err := d.MinorNumber(); return err }},
+	{"PciInfo", func(d Device) error { _, err := d.PciInfo(); return err }},
+	{"UUID", func(d Device) error { _, err := d.UUID(); return err }},
+	{"Name", func(d Device) error { _, err := d.Name(); return err }},
+	{"PersistenceMode", func(d Device) error { _, err := d.PersistenceMode(); return err }},
+	{"PerformanceState", func(d Device) error { _, err := d.PerformanceState(); return err }},
+	{"GrClock", func(d Device) error { _, err := d.GrClock(); return err }},
+	{"SMClock", func(d Device) error { _, err := d.SMClock(); return err }},
+	{"MemClock", func(d Device) error { _, err := d.MemClock(); return err }},
+	{"VideoClock", func(d Device) error { _, err := d.VideoClock(); return err }},
+	{"GrMaxClock", func(d Device) error { _, err := d.GrMaxClock(); return err }},
+	{"SMMaxClock", func(d Device) error { _, err := d.SMMaxClock(); return err }},
+	{"MemMaxClock", func(d Device) error { _, err := d.MemMaxClock(); return err }},
+	{"VideoMaxClock", func(d Device) error { _, err := d.VideoMaxClock(); return err }},
+	{"ApplicationClock", func(d Device) error { _, err := d.ApplicationClock(ClockTypeGraphics); return err }},
+	{"DefaultApplicationsClock", func(d Device) error { _, err := d.DefaultApplicationsClock(ClockTypeGraphics); return err }},
+	{"MaxCustomerBoostClock", func(d Device) error { _, err := d.MaxCustomerBoostClock(ClockTypeGraphics); return err }},
+	{"SupportedMemoryClocks", func(d Device) error { _, err := d.SupportedMemoryClocks(); return err }},
+	{"AutoBoostedClocksEnabled", func(d Device) error { _, _, err := d.AutoBoostedClocksEnabled(); return err }},
+	{"MemoryInfo", func(d Device) error { _, _, err := d.MemoryInfo(); return err }},
+	{"Bar1MemoryInfo", func(d Device) error { _, _, err := d.Bar1MemoryInfo(); return err }},
+	{"UtilizationRates", func(d Device) error { _, _, err := d.UtilizationRates(); return err }},
+	{"AverageGPUUtilization", func(d Device) error { _, err := d.AverageGPUUtilization(time.Second); return err }},
+	{"PowerUsage", func(d Device) error { _, err := d.PowerUsage(); return err }},
+	{"AveragePowerUsage", func(d Device) error { _, err := d.AveragePowerUsage(time.Second); return err }},
+	{"PowerLimitConstraints", func(d Device) error { _, _, err := d.PowerLimitConstraints(); return err }},
+	{"PowerLimits", func(d Device) error { _, _, err := d.PowerLimits(); return err }},
+	{"PowerManagementDefaultLimit", func(d Device) error { _, err := d.PowerManagementDefaultLimit(); return err }},
+	{"PowerManagementMode", func(d Device) error { _, err := d.PowerManagementMode(); return err }},
+	{"PowerState", func(d Device) error { _, err := d.PowerState(); return err }},
+	{"PCIeThroughput", func(d Device) error { _, _, err := d.PCIeThroughput(); return err }},
+	{"PCIeLinkGen", func(d Device) error { _, _, err := d.PCIeLinkGen(); return err }},
+	{"PCIeLinkWidth", func(d Device) error { _, _, err := d.PCIeLinkWidth(); return err }},
+	{"Temperature", func(d Device) error { _, err := d.Temperature(); return err }},
+	{"TemperatureThresholds", func(d Device) error { _, _, err := d.TemperatureThresholds(); return err }},
+	{"FanSpeed", func(d Device) error { _, err := d.FanSpeed(); return err }},
+	{"EncoderUtilization", func(d Device) error { _, _, err := d.EncoderUtilization(); return err }},
+	{"EncoderCapacity", func(d Device) error { _, _, err := d.EncoderCapacity(); return err }},
+	{"DecoderUtilization", func(d Device) error { _, _, err := d.DecoderUtilization(); return err }},
+	{"AccountingBufferSize", func(d Device) error { _, err := d.AccountingBufferSize(); return err }},
+	{"ComputeProcesses", func(d Device) error { _, err := d.ComputeProcesses(); return err }},
+	{"GraphicsProcesses", func(d Device) error { _, err := d.GraphicsProcesses(); return err }},
+	{"SupportedEventTypes", func(d Device) error { _, err := d.SupportedEventTypes(); return err }},
+	{"NvLinks", func(d Device) error { _, err := d.NvLinks(); return err }},
+	{"CpuAffinity", func(d Device) error { _, err := d.CpuAffinity(); return err }},
+	{"MemoryAffinity", func(d Device) error { _, err := d.MemoryAffinity(AffinityScopeNode); return err }},
+	{"MigMode", func(d Device) error { _, _, err := d.MigMode(); return err }},
+}
+
+// DeviceCapabilities reports which Device getters work on a device, as probed
+// by Device.Capabilities. Getters are identified by their method name, e.g.
+// "PowerUsage".
+type DeviceCapabilities struct {
+	// Supported are the getters that succeeded, or failed with ErrNoData
+	// because no samples were collected yet.
+	Supported []string
+	// Unsupported are the getters that failed with ErrNotSupported or
+	// ErrFunctionNotFound.
+	Unsupported []string
+	// Errors holds the errors of the other getters, whose support can't be
+	// told, e.g. because they failed with ErrNoPermission.
+	Errors map[string]error
+}
+
+// Supports reports whether the getter succeeded when probed.
+func (c DeviceCapabilities) Supports(getter string) bool {
+	for _, name := range c.Supported {
+		if name == getter {
+			return true
+		}
+	}
+	return false
+}
+
+// Capabilities calls each of the read-only getters of d once and reports
+// which of them the device supports. It is meant to be called once at
+// startup, e.g. by an exporter deciding which metrics to export.
+func (d Device) Capabilities() DeviceCapabilities {
+	c := DeviceCapabilities{Errors: make(map[string]error)}
+	for _, p := range deviceProbes {
+		err := p.probe(d)
+		switch {
+		case err == nil || errors.Is(err, ErrNoData):
+			c.Supported = append(c.Supported, p.name)
+		case errors.Is(err, ErrNotSupported) || errors.Is(err, ErrFunctionNotFound):
+			c.Unsupported = append(c.Unsupported, p.name)
+		default:
+			c.Errors[p.name] = err
+		}
+	}
+	return c
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCapabilities(t *testing.T) {
+	f := useFakeBackend()
+	defer SetBackend(nil)
+	f.Errors = map[string]Return{
+		"nvmlDeviceGetRemappedRows": ReturnErrorFunctionNotFound,
+		"nvmlDeviceGetPciInfo_v3":   ReturnErrorFunctionNotFound,
+		// Not used by the package.
+		"nvmlDeviceGetUnknownThing": ReturnErrorFunctionNotFound,
+	}
+	if _, err := Capabilities(); !errors.Is(err, ErrLibraryNotFound) {
+		t.Errorf("Capabilities() before Initialize = %v, want ErrLibraryNotFound", err)
+	}
+	if err := Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer Shutdown()
+
+	c, err := Capabilities()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Library.DriverVersion != "450.80.02" {
+		t.Errorf("Library = %+v, want the driver of the fake", c.Library)
+	}
+	if len(c.Functions) != len(nvmlFunctions()) {
+		t.Errorf("got %d functions, want all the %d used by the package", len(c.Functions), len(nvmlFunctions()))
+	}
+	want := []string{"nvmlDeviceGetPciInfo_v3", "nvmlDeviceGetRemappedRows"}
+	if missing := c.Missing(); !reflect.DeepEqual(missing, want) {
+		t.Errorf("Missing() = %q, want %q", missing, want)
+	}
+	if !c.Supports("nvmlDeviceGetFieldValues") || c.Supports("nvmlDeviceGetRemappedRows") || c.Supports("nvmlNotAFunction") {
+		t.Errorf("Supports() disagrees with Functions %v", c.Functions)
+	}
+}
+
+func TestDeviceCapabilities(t *testing.T) {
+	_, devices := initFakeDevices(t, &FakeDevice{
+		Errors: map[string]Return{
+			"nvmlDeviceGetPowerUsage":   ReturnErrorNotSupported,
+			"nvmlDeviceGetRemappedRows": ReturnErrorFunctionNotFound,
+			"nvmlDeviceGetSerial":       ReturnErrorNoPermission,
+			"nvmlDeviceGetFanSpeed":     ReturnErrorUnknown,
+		},
+	})
+	defer SetBackend(nil)
+	defer Shutdown()
+
+	c := devices[0].Capabilities()
+	if want := []string{"RemappedRows", "PowerUsage"}; !reflect.DeepEqual(c.Unsupported, want) {
+		t.Errorf("Unsupported = %q, want %q", c.Unsupported, want)
+	}
+	if len(c.Errors) != 2 || !errors.Is(c.Errors["Serial"], ErrNoPermission) || !errors.Is(c.Errors["FanSpeed"], ErrUnknown) {
+		t.Errorf("Errors = %v, want Serial: ErrNoPermission and FanSpeed: ErrUnknown", c.Errors)
+	}
+	if len(c.Supported)+len(c.Unsupported)+len(c.Errors) != len(deviceProbes) {
+		t.Errorf("%d supported, %d unsupported and %d errors; want %d getters in all", len(c.Supported), len(c.Unsupported), len(c.Errors), len(deviceProbes))
+	}
+	// The fake has no samples, which doesn't make the averages unsupported.
+	for _, getter := range []string{"Name", "AverageGPUUtilization", "AveragePowerUsage", "NvLinks"} {
+		if !c.Supports(getter) {
+			t.Errorf("Supports(%q) = false, want true", getter)
+		}
+	}
+	if c.Supports("PowerUsage") || c.Supports("Serial") {
+		t.Errorf("Supports() is true for getters that failed")
+	}
+}
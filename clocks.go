@@ -0,0 +1,231 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import "fmt"
+
+// SupportedMemoryClocks returns the memory clocks in MHz the applications
+// clocks can be set to, highest first.
+func (d Device) SupportedMemoryClocks() ([]uint, error) {
+	return backend.DeviceGetSupportedMemoryClocks(d.handle)
+}
+
+// SupportedGraphicsClocks returns the graphics clocks in MHz that can be set
+// along with the memory clock memClockMHz, highest first.
+func (d Device) SupportedGraphicsClocks(memClockMHz uint) ([]uint, error) {
+	return backend.DeviceGetSupportedGraphicsClocks(d.handle, memClockMHz)
+}
+
+// SetApplicationsClocks sets the clocks in MHz applications run at, which
+// must be one of the pairs returned by SupportedMemoryClocks and
+// SupportedGraphicsClocks. It requires root unless the applications clocks
+// restriction is lifted with nvidia-smi, and lasts until the driver is
+// unloaded or ResetApplicationsClocks is called.
+func (d Device) SetApplicationsClocks(memClockMHz, graphicsClockMHz uint) error {
+	return backend.DeviceSetApplicationsClocks(d.handle, memClockMHz, graphicsClockMHz)
+}
+
+// ResetApplicationsClocks sets the applications clocks back to their
+// defaults, see DefaultApplicationsClock.
+func (d Device) ResetApplicationsClocks() error {
+	return backend.DeviceResetApplicationsClocks(d.handle)
+}
+
+// DefaultApplicationsClock returns the default applications clock of the
+// given type in MHz.
+func (d Device) DefaultApplicationsClock(ct ClockType) (uint, error) {
+	return backend.DeviceGetDefaultApplicationsClock(d.handle, ct)
+}
+
+// SetGpuLockedClocks locks the GPU clock to the range [minMHz, maxMHz]
+// until ResetGpuLockedClocks is called. It requires root and a Volta or
+// newer device.
+func (d Device) SetGpuLockedClocks(minMHz, maxMHz uint) error {
+	return backend.DeviceSetGpuLockedClocks(d.handle, minMHz, maxMHz)
+}
+
+// ResetGpuLockedClocks unlocks the GPU clock locked by SetGpuLockedClocks.
+func (d Device) ResetGpuLockedClocks() error {
+	return backend.DeviceResetGpuLockedClocks(d.handle)
+}
+
+// MaxCustomerBoostClock returns the highest clock of the given type in MHz
+// the device is guaranteed to boost to.
+func (d Device) MaxCustomerBoostClock(ct ClockType) (uint, error) {
+	return backend.DeviceGetMaxCustomerBoostClock(d.handle, ct)
+}
+
+// AutoBoostedClocksEnabled returns whether the clocks are automatically
+// boosted, and whether they are by default. Pascal and newer devices control
+// auto-boost through the applications clocks instead.
+func (d Device) AutoBoostedClocksEnabled() (enabled, defaultEnabled EnableState, err error) {
+	return backend.DeviceGetAutoBoostedClocksEnabled(d.handle)
+}
+
+// SetAutoBoostedClocksEnabled enables or disables auto-boosted clocks until
+// the last process using the device exits.
+func (d Device) SetAutoBoostedClocksEnabled(enabled EnableState) error {
+	return backend.DeviceSetAutoBoostedClocksEnabled(d.handle, enabled)
+}
+
+// SetDefaultAutoBoostedClocksEnabled sets whether the clocks are
+// auto-boosted by default. It requires root.
+func (d Device) SetDefaultAutoBoostedClocksEnabled(enabled EnableState) error {
+	return backend.DeviceSetDefaultAutoBoostedClocksEnabled(d.handle, enabled, 0)
+}
+
+// ClockProfile is a set of clock settings, e.g. to run benchmarks at fixed
+// clocks. The zero value of each setting leaves it unchanged.
+type ClockProfile struct {
+	// MemoryClock and GraphicsClock are the applications clocks in MHz.
+	// Both or neither have to be set.
+	MemoryClock   uint
+	GraphicsClock uint
+	// MinLockedClock and MaxLockedClock are the range in MHz the GPU
+	// clock is locked to. Both or neither have to be set.
+	MinLockedClock uint
+	MaxLockedClock uint
+	// AutoBoost is set as the auto-boosted clocks state if SetAutoBoost is
+	// true.
+	SetAutoBoost bool
+	AutoBoost    EnableState
+}
+
+func (p ClockProfile) validate() error {
+	if (p.MemoryClock == 0) != (p.GraphicsClock == 0) {
+		return fmt.Errorf("applications clocks need both a memory and a graphics clock: %w", ErrInvalidArgument)
+	}
+	if (p.MinLockedClock == 0) != (p.MaxLockedClock == 0) {
+		return fmt.Errorf("locked clocks need both a min and a max clock: %w", ErrInvalidArgument)
+	}
+	if p.MinLockedClock > p.MaxLockedClock {
+		return fmt.Errorf("locked clocks min %d MHz above max %d MHz: %w", p.MinLockedClock, p.MaxLockedClock, ErrInvalidArgument)
+	}
+	return nil
+}
+
+// AppliedClockProfile is a ClockProfile applied to a device. It holds the
+// settings the profile replaced so that they can be restored.
+type AppliedClockProfile struct {
+	Device  Device
+	Profile ClockProfile
+
+	// The settings changed so far, with the state they are restored to.
+	applications       bool
+	memClock, grClock  uint
+	defaultApplication bool
+	locked             bool
+	autoBoost          bool
+	origAutoBoost      EnableState
+}
+
+// ApplyClockProfile captures the current clock settings changed by p and
+// applies p. If a setting fails, the ones applied before it are restored and
+// the error is returned. Call Restore on the result to go back to the
+// captured settings.
+//
+// NVML can't report the locked clocks, which Restore therefore unlocks
+// rather than setting back to a previously locked range.
+func (d Device) ApplyClockProfile(p ClockProfile) (*AppliedClockProfile, error) {
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	a := &AppliedClockProfile{Device: d, Profile: p}
+
+	var origMem, origGr uint
+	defaults := false
+	if p.MemoryClock != 0 {
+		var err error
+		if origMem, err = d.ApplicationClock(ClockTypeMem); err != nil {
+			return nil, err
+		}
+		if origGr, err = d.ApplicationClock(ClockTypeGraphics); err != nil {
+			return nil, err
+		}
+		defMem, memErr := d.DefaultApplicationsClock(ClockTypeMem)
+		defGr, grErr := d.DefaultApplicationsClock(ClockTypeGraphics)
+		defaults = memErr == nil && grErr == nil && defMem == origMem && defGr == origGr
+	}
+	var origAutoBoost EnableState
+	if p.SetAutoBoost {
+		var err error
+		if origAutoBoost, _, err = d.AutoBoostedClocksEnabled(); err != nil {
+			return nil, err
+		}
+	}
+
+	fail := func(err error) (*AppliedClockProfile, error) {
+		if rerr := a.Restore(); rerr != nil {
+			return nil, errorList{err, rerr}
+		}
+		return nil, err
+	}
+	if p.MemoryClock != 0 {
+		if err := d.SetApplicationsClocks(p.MemoryClock, p.GraphicsClock); err != nil {
+			return fail(err)
+		}
+		a.applications, a.memClock, a.grClock, a.defaultApplication = true, origMem, origGr, defaults
+	}
+	if p.MaxLockedClock != 0 {
+		if err := d.SetGpuLockedClocks(p.MinLockedClock, p.MaxLockedClock); err != nil {
+			return fail(err)
+		}
+		a.locked = true
+	}
+	if p.SetAutoBoost {
+		if err := d.SetAutoBoostedClocksEnabled(p.AutoBoost); err != nil {
+			return fail(err)
+		}
+		a.autoBoost, a.origAutoBoost = true, origAutoBoost
+	}
+	return a, nil
+}
+
+// Restore puts back the clock settings replaced by the profile, in the
+// reverse order they were applied. The settings restored successfully are
+// not restored again by later calls.
+func (a *AppliedClockProfile) Restore() error {
+	var errs errorList
+	if a.autoBoost {
+		if err := a.Device.SetAutoBoostedClocksEnabled(a.origAutoBoost); err != nil {
+			errs = append(errs, err)
+		} else {
+			a.autoBoost = false
+		}
+	}
+	if a.locked {
+		if err := a.Device.ResetGpuLockedClocks(); err != nil {
+			errs = append(errs, err)
+		} else {
+			a.locked = false
+		}
+	}
+	if a.applications {
+		var err error
+		if a.defaultApplication {
+			err = a.Device.ResetApplicationsClocks()
+		} else {
+			err = a.Device.SetApplicationsClocks(a.memClock, a.grClock)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			a.applications = false
+		}
+	}
+	return errs.err()
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"errors"
+	"testing"
+)
+
+// clockDevice returns a device running at its default applications clocks
+// of 5001 and 1590 MHz, with auto-boost enabled.
+func clockDevice() *FakeDevice {
+	return &FakeDevice{
+		ApplicationsClocks:        map[ClockType]uint{ClockTypeMem: 5001, ClockTypeGraphics: 1590},
+		DefaultApplicationsClocks: map[ClockType]uint{ClockTypeMem: 5001, ClockTypeGraphics: 1590},
+		SupportedClocks: map[uint][]uint{
+			5001: {1590, 1200, 900},
+			405:  {405},
+		},
+		AutoBoost:        EnableStateFeatureEnabled,
+		DefaultAutoBoost: EnableStateFeatureEnabled,
+	}
+}
+
+// clockState is the clock settings of a FakeDevice.
+type clockState struct {
+	mem, graphics uint
+	locked        [2]uint
+	autoBoost     EnableState
+}
+
+func fakeClockState(f *FakeBackend, d *FakeDevice) clockState {
+	f.Lock()
+	defer f.Unlock()
+	return clockState{
+		mem:       d.ApplicationsClocks[ClockTypeMem],
+		graphics:  d.ApplicationsClocks[ClockTypeGraphics],
+		locked:    d.LockedClocks,
+		autoBoost: d.AutoBoost,
+	}
+}
+
+func TestApplyClockProfile(t *testing.T) {
+	fake := clockDevice()
+	// Not the defaults, so Restore has to set them back explicitly.
+	fake.ApplicationsClocks[ClockTypeGraphics] = 1200
+	f, devices := initFakeDevices(t, fake)
+	defer SetBackend(nil)
+	defer Shutdown()
+
+	p := ClockProfile{
+		MemoryClock:    5001,
+		GraphicsClock:  900,
+		MinLockedClock: 900,
+		MaxLockedClock: 1200,
+		SetAutoBoost:   true,
+		AutoBoost:      EnableStateFeatureDisabled,
+	}
+	a, err := devices[0].ApplyClockProfile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := clockState{5001, 900, [2]uint{900, 1200}, EnableStateFeatureDisabled}
+	if got := fakeClockState(f, fake); got != want {
+		t.Errorf("applied state = %+v, want %+v", got, want)
+	}
+
+	if err := a.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	want = clockState{5001, 1200, [2]uint{}, EnableStateFeatureEnabled}
+	if got := fakeClockState(f, fake); got != want {
+		t.Errorf("restored state = %+v, want %+v", got, want)
+	}
+
+	// Everything was restored, so another Restore does nothing.
+	f.Lock()
+	fake.AutoBoost = EnableStateFeatureDisabled
+	f.Unlock()
+	if err := a.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	if got := fakeClockState(f, fake); got.autoBoost != EnableStateFeatureDisabled {
+		t.Errorf("second Restore() set auto-boost to %v", got.autoBoost)
+	}
+}
+
+func TestApplyClockProfileResetsDefaultClocks(t *testing.T) {
+	fake := clockDevice()
+	f, devices := initFakeDevices(t, fake)
+	defer SetBackend(nil)
+	defer Shutdown()
+
+	a, err := devices[0].ApplyClockProfile(ClockProfile{MemoryClock: 405, GraphicsClock: 405})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The clocks were the defaults, so Restore resets them rather than set
+	// them.
+	f.Lock()
+	fake.Errors = map[string]Return{"nvmlDeviceSetApplicationsClocks": ReturnErrorNoPermission}
+	f.Unlock()
+	if err := a.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	want := clockState{5001, 1590, [2]uint{}, EnableStateFeatureEnabled}
+	if got := fakeClockState(f, fake); got != want {
+		t.Errorf("restored state = %+v, want %+v", got, want)
+	}
+}
+
+func TestApplyClockProfileRollback(t *testing.T) {
+	fake := clockDevice()
+	fake.Errors = map[string]Return{"nvmlDeviceSetAutoBoostedClocksEnabled": ReturnErrorNotSupported}
+	f, devices := initFakeDevices(t, fake)
+	defer SetBackend(nil)
+	defer Shutdown()
+
+	a, err := devices[0].ApplyClockProfile(ClockProfile{
+		MemoryClock:    405,
+		GraphicsClock:  405,
+		MinLockedClock: 300,
+		MaxLockedClock: 405,
+		SetAutoBoost:   true,
+		AutoBoost:      EnableStateFeatureDisabled,
+	})
+	if !errors.Is(err, ErrNotSupported) || a != nil {
+		t.Fatalf("ApplyClockProfile() = %v, %v; want ErrNotSupported", a, err)
+	}
+	want := clockState{5001, 1590, [2]uint{}, EnableStateFeatureEnabled}
+	if got := fakeClockState(f, fake); got != want {
+		t.Errorf("state after the rollback = %+v, want %+v", got, want)
+	}
+}
+
+func TestApplyClockProfileInvalid(t *testing.T) {
+	fake := clockDevice()
+	f, devices := initFakeDevices(t, fake)
+	defer SetBackend(nil)
+	defer Shutdown()
+
+	for _, p := range []ClockProfile{
+		{MemoryClock: 5001},
+		{MaxLockedClock: 1200},
+		{MinLockedClock: 1200, MaxLockedClock: 900},
+	} {
+		if _, err := devices[0].ApplyClockProfile(p); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("ApplyClockProfile(%+v) = %v, want ErrInvalidArgument", p, err)
+		}
+	}
+	want := clockState{5001, 1590, [2]uint{}, EnableStateFeatureEnabled}
+	if got := fakeClockState(f, fake); got != want {
+		t.Errorf("state after the invalid profiles = %+v, want %+v", got, want)
+	}
+}
@@ -361,7 +361,6 @@ func main() {
 		} else {
 			fmt.Printf("\tProcess count: %v\n", len(utilizations))
 
-			utilizations = utilizations
 			for _, sample := range utilizations {
 				fmt.Printf("\t\tProcess: %v", sample.Pid)
 				fmt.Printf(", SM  util: %v", sample.SMUtil)
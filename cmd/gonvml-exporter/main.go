@@ -0,0 +1,81 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command gonvml-exporter serves the metrics of the NVIDIA devices of the
+// machine in the Prometheus text exposition format.
+//
+//	gonvml-exporter -listen=:9445 -processes -mig
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/cfsmp3/gonvml"
+	"github.com/cfsmp3/gonvml/exporter"
+)
+
+func main() {
+	listen := flag.String("listen", ":9445", "address to listen on")
+	path := flag.String("path", "/metrics", "HTTP path serving the metrics")
+	namespace := flag.String("namespace", "nvml", "prefix of the metric names")
+	processes := flag.Bool("processes", false, "export the GPU memory used by each process")
+	mig := flag.Bool("mig", false, "export the MIG devices of the GPUs in MIG mode")
+	libraryPath := flag.String("library", "", "path of libnvidia-ml.so.1, looked up by the dynamic loader if empty")
+	flag.Parse()
+
+	var opts []gonvml.Option
+	if *libraryPath != "" {
+		opts = append(opts, gonvml.WithLibraryPath(*libraryPath))
+	}
+	session, err := gonvml.NewSession(opts...)
+	if err != nil {
+		log.Fatalf("Initializing NVML: %v", err)
+	}
+	defer session.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle(*path, exporter.New(exporter.Options{
+		Namespace: *namespace,
+		Processes: *processes,
+		MIG:       *mig,
+	}))
+	server := &http.Server{Addr: *listen, Handler: mux}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+
+	log.Printf("Serving metrics on %s%s", *listen, *path)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		log.Printf("Serving metrics: %v", err)
+		return
+	}
+	<-done
+}
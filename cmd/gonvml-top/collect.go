@@ -0,0 +1,137 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cfsmp3/gonvml"
+)
+
+// frame is the state of the devices shown on one refresh.
+type frame struct {
+	time          time.Time
+	driverVersion string
+	gpus          []gpuStat
+	procs         []procStat
+}
+
+// gpuStat is the state of a device.
+type gpuStat struct {
+	index       uint
+	status      gonvml.DeviceStatus
+	utilization *uint // percent
+	memoryUsed  uint64
+	memoryTotal uint64
+	power       *uint // milliwatts
+	powerLimit  *uint // milliwatts
+	temperature *uint // degrees Celsius
+}
+
+// procStat is a process using a device.
+type procStat struct {
+	pid     uint
+	gpu     uint
+	user    string
+	command string
+	types   string // "C" for compute, "G" for graphics, "C+G" for both
+	sm      *uint  // percent
+	mem     *uint  // percent
+	enc     *uint  // percent
+	dec     *uint  // percent
+	used    uint64 // bytes
+}
+
+// collector reads frames. procRoot is the directory procfs is mounted on,
+// where the users and command lines of the processes are read from.
+type collector struct {
+	devices  []gonvml.Device
+	procRoot string
+}
+
+func newCollector(devices []gonvml.Device, procRoot string) *collector {
+	return &collector{devices: devices, procRoot: procRoot}
+}
+
+// collect reads the state of the devices, with the latest per-process
+// utilization sampled within the last interval.
+func (c *collector) collect(ctx context.Context, interval time.Duration) (frame, error) {
+	f := frame{time: time.Now()}
+	f.driverVersion, _ = gonvml.SystemDriverVersion()
+	for i, d := range c.devices {
+		status, err := d.Snapshot(ctx, gonvml.SnapshotOptions{IgnoreUnsupported: true})
+		if err != nil {
+			return f, err
+		}
+		g := gpuStat{index: uint(i), status: status}
+		if status.Identity.Index != nil {
+			g.index = *status.Identity.Index
+		}
+		g.utilization = status.Utilization.GPU
+		if m := status.Memory; m != nil {
+			g.memoryUsed, g.memoryTotal = m.UsedBytes, m.TotalBytes
+		}
+		g.power, g.powerLimit = status.Power.UsageMilliwatts, status.Power.EnforcedLimitMilliwatts
+		g.temperature = status.Thermal.Temperature
+		f.gpus = append(f.gpus, g)
+		f.procs = append(f.procs, c.processes(d, g.index, interval)...)
+	}
+	return f, nil
+}
+
+// processes returns the processes using the device with the given index,
+// with their latest utilization sampled within the last interval.
+func (c *collector) processes(d gonvml.Device, gpu uint, interval time.Duration) []procStat {
+	// The processes that could be read are shown even if the others, or
+	// some of their details, failed.
+	infos, _ := d.Processes(gonvml.ProcessOptions{
+		Names:             true,
+		Utilization:       true,
+		UtilizationWindow: interval,
+		Host:              true,
+		ProcRoot:          c.procRoot,
+	})
+	procs := make([]procStat, len(infos))
+	for i, p := range infos {
+		procs[i] = procStat{pid: p.PID, gpu: gpu, command: p.Name, used: p.UsedMemory}
+		switch p.Type {
+		case gonvml.ProcessTypeCompute:
+			procs[i].types = "C"
+		case gonvml.ProcessTypeGraphics:
+			procs[i].types = "G"
+		default:
+			procs[i].types = "C+G"
+		}
+		if u := p.Utilization; u != nil {
+			sm, mem, enc, dec := u.SMUtil, u.MemUtil, u.EncUtil, u.DecUtil
+			procs[i].sm, procs[i].mem, procs[i].enc, procs[i].dec = &sm, &mem, &enc, &dec
+		}
+		if h := p.Host; h != nil {
+			procs[i].user = h.User
+			if procs[i].user == "" {
+				procs[i].user = strconv.FormatUint(uint64(h.UID), 10)
+			}
+			if len(h.Cmdline) > 0 {
+				procs[i].command = strings.Join(h.Cmdline, " ")
+			}
+		}
+	}
+	return procs
+}
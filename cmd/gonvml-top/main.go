@@ -0,0 +1,225 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command gonvml-top shows the utilization, memory and power of the NVIDIA
+// devices of the machine and the processes using them, refreshed like top.
+//
+// In a terminal, the process table is sorted interactively with the keys
+// listed at the bottom of the screen. With -snapshot, or when the output is
+// not a terminal, a single frame is printed as plain text.
+//
+// In a container, NVML reports the PIDs of the host, so -proc has to be where
+// the proc filesystem of the host is mounted for the users and command lines
+// of the processes to be found.
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cfsmp3/gonvml"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	interval := flag.Duration("d", 2*time.Second, "refresh `interval`")
+	sortKey := flag.String("sort", "used", "column to sort the processes by: pid, user, gpu, sm, mem, enc, dec, used or command")
+	reverse := flag.Bool("r", false, "reverse the default order of the sort column")
+	ids := flag.String("i", "", "comma separated list of the `devices` to show, by index or UUID, all if empty")
+	snapshot := flag.Bool("snapshot", false, "print a single frame as plain text and exit")
+	procRoot := flag.String("proc", "/proc", "`directory` the proc filesystem of the host is mounted on, to read the users and command lines of the processes from")
+	flag.Parse()
+
+	if !validSortKey(*sortKey) {
+		fmt.Fprintf(os.Stderr, "unknown sort column %q\n", *sortKey)
+		return 2
+	}
+	if *interval <= 0 {
+		fmt.Fprintln(os.Stderr, "the refresh interval must be positive")
+		return 2
+	}
+	o := order{key: *sortKey, reverse: defaultReverse(*sortKey) != *reverse}
+
+	session, err := gonvml.NewSession()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Initializing NVML: %v\n", err)
+		return 1
+	}
+	defer session.Close()
+
+	if *ids == "" {
+		*ids = "all"
+	}
+	devices, err := gonvml.ParseVisibleDevices(*ids)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	c := newCollector(devices, *procRoot)
+
+	if *snapshot || !isTerminal(os.Stdout) {
+		f, err := c.collect(context.Background(), *interval)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		render(os.Stdout, f, o, 0, false)
+		return 0
+	}
+	return interactive(c, *interval, o)
+}
+
+// defaultReverse reports whether the column is sorted in descending order
+// by default, which is the case of the usage columns.
+func defaultReverse(key string) bool {
+	switch key {
+	case "sm", "mem", "enc", "dec", "used":
+		return true
+	}
+	return false
+}
+
+// isTerminal reports whether f is a character device.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// stty runs stty on the terminal and returns its output.
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// terminalWidth returns the number of columns of the terminal, 0 if
+// unknown. It runs stty, so it is only called again when notifyResize
+// reports that the terminal was resized.
+func terminalWidth() int {
+	size, err := stty("size")
+	if err != nil {
+		return 0
+	}
+	fields := strings.Fields(size)
+	if len(fields) != 2 {
+		return 0
+	}
+	width, _ := strconv.Atoi(fields[1])
+	return width
+}
+
+// interactive refreshes the screen every interval until q is pressed or the
+// program is interrupted.
+func interactive(c *collector, interval time.Duration, o order) int {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		<-signals
+		cancel()
+	}()
+
+	// Read the keys as they are pressed, without echoing them.
+	keys := make(chan byte)
+	if saved, err := stty("-g"); err == nil && isTerminal(os.Stdin) {
+		if _, err := stty("-icanon", "-echo", "min", "1"); err == nil {
+			defer stty(saved)
+			go func() {
+				buf := make([]byte, 1)
+				for {
+					if n, err := os.Stdin.Read(buf); err != nil || n == 0 {
+						return
+					}
+					keys <- buf[0]
+				}
+			}()
+		}
+	}
+
+	// Draw on the alternate screen, with the cursor hidden.
+	os.Stdout.WriteString("\x1b[?1049h\x1b[?25l")
+	defer os.Stdout.WriteString("\x1b[?25h\x1b[?1049l")
+
+	width := terminalWidth()
+	resized := make(chan os.Signal, 1)
+	notifyResize(resized)
+	defer signal.Stop(resized)
+
+	var f frame
+	refresh := time.NewTicker(interval)
+	defer refresh.Stop()
+	collect := true
+	for {
+		if collect {
+			var err error
+			if f, err = c.collect(ctx, interval); err != nil {
+				if ctx.Err() != nil {
+					return 0
+				}
+				os.Stdout.WriteString("\x1b[?25h\x1b[?1049l")
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+		}
+		var b bytes.Buffer
+		b.WriteString("\x1b[H")
+		render(&b, f, o, width, true)
+		b.WriteString("\x1b[J")
+		os.Stdout.Write(b.Bytes())
+
+		select {
+		case <-ctx.Done():
+			return 0
+		case <-refresh.C:
+			collect = true
+		case <-resized:
+			width = terminalWidth()
+			collect = false
+		case key := <-keys:
+			collect = false
+			switch key {
+			case 'q':
+				return 0
+			case 'r':
+				o.reverse = !o.reverse
+			default:
+				for _, k := range sortKeys {
+					if k.key != key {
+						continue
+					}
+					if o.key == k.name {
+						o.reverse = !o.reverse
+					} else {
+						o = order{key: k.name, reverse: defaultReverse(k.name)}
+					}
+				}
+			}
+		}
+	}
+}
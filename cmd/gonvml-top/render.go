@@ -0,0 +1,186 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// sortKeys are the columns the process table can be sorted by, with the key
+// selecting them in interactive mode.
+var sortKeys = []struct {
+	name string
+	key  byte
+	less func(a, b *procStat) bool
+}{
+	{"pid", 'p', func(a, b *procStat) bool { return a.pid < b.pid }},
+	{"user", 'u', func(a, b *procStat) bool { return a.user < b.user }},
+	{"gpu", 'g', func(a, b *procStat) bool { return a.gpu < b.gpu }},
+	{"sm", 's', func(a, b *procStat) bool { return percent(a.sm) < percent(b.sm) }},
+	{"mem", 'm', func(a, b *procStat) bool { return percent(a.mem) < percent(b.mem) }},
+	{"enc", 'e', func(a, b *procStat) bool { return percent(a.enc) < percent(b.enc) }},
+	{"dec", 'd', func(a, b *procStat) bool { return percent(a.dec) < percent(b.dec) }},
+	{"used", 'U', func(a, b *procStat) bool { return a.used < b.used }},
+	{"command", 'c', func(a, b *procStat) bool { return a.command < b.command }},
+}
+
+// order is how the process table is sorted.
+type order struct {
+	key     string
+	reverse bool
+}
+
+func validSortKey(key string) bool {
+	for _, k := range sortKeys {
+		if k.name == key {
+			return true
+		}
+	}
+	return false
+}
+
+// percent returns *p, or -1 if p is nil so that unknown values sort first.
+func percent(p *uint) int {
+	if p == nil {
+		return -1
+	}
+	return int(*p)
+}
+
+// sortProcs sorts procs by o, with the ties broken by PID.
+func sortProcs(procs []procStat, o order) {
+	var less func(a, b *procStat) bool
+	for _, k := range sortKeys {
+		if k.name == o.key {
+			less = k.less
+		}
+	}
+	sort.SliceStable(procs, func(i, j int) bool {
+		a, b := &procs[i], &procs[j]
+		if o.reverse {
+			a, b = b, a
+		}
+		if less != nil && less(a, b) != less(b, a) {
+			return less(a, b)
+		}
+		return procs[i].pid < procs[j].pid
+	})
+}
+
+const barWidth = 30
+
+// bar draws value out of max as a bar of barWidth characters.
+func bar(label string, value, max float64, detail string) string {
+	filled := 0
+	ratio := 0.0
+	if max > 0 {
+		ratio = value / max
+		if ratio > 1 {
+			ratio = 1
+		}
+		filled = int(ratio*barWidth + 0.5)
+	}
+	line := fmt.Sprintf("  %-5s [%s%s] %3.0f%%  %s", label,
+		strings.Repeat("|", filled), strings.Repeat(" ", barWidth-filled), ratio*100, detail)
+	return strings.TrimRight(line, " ")
+}
+
+func formatPercent(p *uint) string {
+	if p == nil {
+		return "-"
+	}
+	return fmt.Sprint(*p)
+}
+
+func formatBytes(b uint64) string {
+	const gib = 1 << 30
+	if b >= gib {
+		return fmt.Sprintf("%.1fGiB", float64(b)/gib)
+	}
+	return fmt.Sprintf("%dMiB", b>>20)
+}
+
+// render writes f with the processes sorted by o, truncating the lines to
+// width columns if width is positive. When interactive, a line describing
+// the keys ends the frame.
+func render(w io.Writer, f frame, o order, width int, interactive bool) {
+	var lines []string
+	direction := "ascending"
+	if o.reverse {
+		direction = "descending"
+	}
+	lines = append(lines, fmt.Sprintf("gonvml-top  %s  driver %s  sort: %s (%s)",
+		f.time.Format("2006-01-02 15:04:05"), f.driverVersion, o.key, direction), "")
+
+	for _, g := range f.gpus {
+		header := fmt.Sprintf("GPU %d  %s", g.index, g.status.Identity.Name)
+		if g.temperature != nil {
+			header += fmt.Sprintf("  %dC", *g.temperature)
+		}
+		lines = append(lines, header)
+		if g.utilization != nil {
+			lines = append(lines, bar("util", float64(*g.utilization), 100, ""))
+		} else {
+			lines = append(lines, "  util  n/a")
+		}
+		lines = append(lines, bar("mem", float64(g.memoryUsed), float64(g.memoryTotal),
+			formatBytes(g.memoryUsed)+" / "+formatBytes(g.memoryTotal)))
+		if g.power != nil && g.powerLimit != nil {
+			lines = append(lines, bar("power", float64(*g.power), float64(*g.powerLimit),
+				fmt.Sprintf("%.0fW / %.0fW", float64(*g.power)/1000, float64(*g.powerLimit)/1000)))
+		} else {
+			lines = append(lines, "  power n/a")
+		}
+		lines = append(lines, "")
+	}
+
+	procs := append([]procStat(nil), f.procs...)
+	sortProcs(procs, o)
+	lines = append(lines, fmt.Sprintf("%7s %-10s %3s %-3s %4s %4s %4s %4s %9s  %s",
+		"PID", "USER", "GPU", "T", "SM%", "MEM%", "ENC%", "DEC%", "USED", "COMMAND"))
+	for _, p := range procs {
+		lines = append(lines, fmt.Sprintf("%7d %-10.10s %3d %-3s %4s %4s %4s %4s %9s  %s",
+			p.pid, p.user, p.gpu, p.types, formatPercent(p.sm), formatPercent(p.mem),
+			formatPercent(p.enc), formatPercent(p.dec), formatBytes(p.used), p.command))
+	}
+	if len(procs) == 0 {
+		lines = append(lines, "  no processes")
+	}
+	if interactive {
+		var keys []string
+		for _, k := range sortKeys {
+			keys = append(keys, fmt.Sprintf("%c:%s", k.key, k.name))
+		}
+		lines = append(lines, "", "sort by "+strings.Join(keys, " ")+"  r:reverse  q:quit")
+	}
+
+	for _, line := range lines {
+		if width > 0 && len(line) > width {
+			line = line[:width]
+		}
+		io.WriteString(w, line)
+		if interactive {
+			// Clear what remains of the previous frame on the line.
+			io.WriteString(w, "\x1b[K\r\n")
+		} else {
+			io.WriteString(w, "\n")
+		}
+	}
+}
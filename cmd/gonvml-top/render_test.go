@@ -0,0 +1,149 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cfsmp3/gonvml"
+)
+
+// writeProc writes the files of the process pid in the proc filesystem at
+// root, mapped by name to their content.
+func writeProc(t *testing.T, root, pid string, files map[string]string) {
+	dir := filepath.Join(root, pid)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// userName returns the name of the user uid on this system, or uid if it has
+// none, like the USER column shows it.
+func userName(uid string) string {
+	u, err := user.LookupId(uid)
+	if err != nil {
+		return uid
+	}
+	return u.Username
+}
+
+// wantSnapshot is the frame of TestRenderSnapshot on a system where UID 0 is
+// root and UID 54321 has no name.
+const wantSnapshot = `gonvml-top  2020-11-02 15:04:05  driver 450.80.02  sort: used (descending)
+
+GPU 0  Tesla T4  55C
+  util  [||||||||||||                  ]  40%
+  mem   [||||||||                      ]  25%  4.0GiB / 16.0GiB
+  power [|||||||||||||||               ]  50%  35W / 70W
+
+GPU 1  Tesla V100
+  util  n/a
+  mem   [                              ]   0%  0MiB / 32.0GiB
+  power n/a
+
+    PID USER       GPU T    SM% MEM% ENC% DEC%      USED  COMMAND
+    200 54321        1 C      -    -    -    -    3.0GiB  /opt/app/serve --port=8000
+    300              0 G      -    -    -    -    2.0GiB  Xorg
+    100 root         0 C+G   30   10    0    0    1.0GiB  python3 train.py --epochs=10
+`
+
+func TestRenderSnapshot(t *testing.T) {
+	root, err := ioutil.TempDir("", "gonvml-top-proc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	writeProc(t, root, "100", map[string]string{
+		"status":  "Name:\tpython3\nUid:\t0\t0\t0\t0\n",
+		"cmdline": "python3\x00train.py\x00--epochs=10\x00",
+		"cgroup":  "0::/user.slice\n",
+	})
+	writeProc(t, root, "200", map[string]string{
+		"status":  "Name:\tserve\nUid:\t54321\t54321\t54321\t54321\n",
+		"cmdline": "/opt/app/serve\x00--port=8000\x00",
+		"cgroup":  "0::/system.slice/serve.service\n",
+	})
+	// Process 300 is missing from the proc filesystem, e.g. because it
+	// runs in another PID namespace, and is shown by its NVML name.
+
+	unsupported := map[string]gonvml.Return{
+		"nvmlDeviceGetUtilizationRates":   gonvml.ReturnErrorNotSupported,
+		"nvmlDeviceGetPowerUsage":         gonvml.ReturnErrorNotSupported,
+		"nvmlDeviceGetEnforcedPowerLimit": gonvml.ReturnErrorNotSupported,
+		"nvmlDeviceGetTemperature":        gonvml.ReturnErrorNotSupported,
+	}
+	gonvml.SetBackend(gonvml.NewFakeBackend(
+		&gonvml.FakeDevice{
+			Name:               "Tesla T4",
+			MemoryTotal:        16 << 30,
+			MemoryUsed:         4 << 30,
+			GPUUtilization:     40,
+			PowerUsage:         35000,
+			EnforcedPowerLimit: 70000,
+			Temperature:        55,
+			ComputeProcesses:   []gonvml.FakeProcess{{Pid: 100, Name: "python3", UsedGpuMemory: 1 << 30}},
+			GraphicsProcesses: []gonvml.FakeProcess{
+				{Pid: 100, Name: "python3", UsedGpuMemory: 1 << 20},
+				{Pid: 300, Name: "Xorg", UsedGpuMemory: 2 << 30},
+			},
+			ProcessUtilization: []gonvml.Utilization{{Pid: 100, SMUtil: 30, MemUtil: 10}},
+		},
+		&gonvml.FakeDevice{
+			Name:             "Tesla V100",
+			MemoryTotal:      32 << 30,
+			ComputeProcesses: []gonvml.FakeProcess{{Pid: 200, Name: "serve", UsedGpuMemory: 3 << 30}},
+			Errors:           unsupported,
+		},
+	))
+	defer gonvml.SetBackend(nil)
+	if err := gonvml.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer gonvml.Shutdown()
+	devices, err := gonvml.ParseVisibleDevices("all")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := newCollector(devices, root).collect(context.Background(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.time = time.Date(2020, 11, 2, 15, 4, 5, 0, time.Local)
+	var b strings.Builder
+	render(&b, f, order{key: "used", reverse: true}, 0, false)
+	want := strings.NewReplacer(
+		"    100 root      ", fmt.Sprintf("    100 %-10.10s", userName("0")),
+		"    200 54321     ", fmt.Sprintf("    200 %-10.10s", userName("54321")),
+	).Replace(wantSnapshot)
+	if got := b.String(); got != want {
+		t.Errorf("render() =\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,298 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command gonvml queries the NVIDIA devices of the machine like nvidia-smi
+// does, with the values read through gonvml:
+//
+//	gonvml --query-gpu=index,name,temperature.gpu,power.draw --format=csv
+//	gonvml --query-compute-apps=gpu_uuid,pid,used_memory --format=json -i 0 -l 5
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cfsmp3/gonvml"
+)
+
+// outputFormat is the parsed --format flag.
+type outputFormat struct {
+	json     bool
+	noheader bool
+	nounits  bool
+}
+
+func parseFormat(s string) (outputFormat, error) {
+	var f outputFormat
+	for _, opt := range strings.Split(s, ",") {
+		switch strings.TrimSpace(opt) {
+		case "csv":
+		case "json":
+			f.json = true
+		case "noheader":
+			f.noheader = true
+		case "nounits":
+			f.nounits = true
+		default:
+			return f, fmt.Errorf("unknown format option %q", opt)
+		}
+	}
+	return f, nil
+}
+
+func main() {
+	os.Exit(run())
+}
+
+func run() int {
+	queryGpu := flag.String("query-gpu", "", "comma separated list of the device `fields` to print")
+	queryApps := flag.String("query-compute-apps", "", "comma separated list of the compute process `fields` to print")
+	formatFlag := flag.String("format", "csv", "output `format`: csv, with noheader and nounits options, or json")
+	id := flag.String("i", "", "comma separated list of the `devices` to query, by index, UUID, UUID prefix, MIG index (0:1) or PCI bus ID")
+	loop := flag.Int("l", 0, "repeat the query every `seconds` until interrupted")
+	list := flag.Bool("L", false, "list the devices")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options]\n\nOptions:\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprintf(flag.CommandLine.Output(), "\nFields of --query-gpu:\n  %s\n", fieldNames(gpuFields))
+		fmt.Fprintf(flag.CommandLine.Output(), "\nFields of --query-compute-apps:\n  %s\n", fieldNames(appFields))
+	}
+	flag.Parse()
+
+	format, err := parseFormat(*formatFlag)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	var fields []field
+	switch {
+	case *queryGpu != "" && *queryApps != "":
+		fmt.Fprintln(os.Stderr, "--query-gpu and --query-compute-apps are exclusive")
+		return 2
+	case *queryGpu != "":
+		fields, err = parseFields(*queryGpu, gpuFields)
+	case *queryApps != "":
+		fields, err = parseFields(*queryApps, appFields)
+	case !*list:
+		flag.Usage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	session, err := gonvml.NewSession()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Initializing NVML: %v\n", err)
+		return 1
+	}
+	defer session.Close()
+
+	devices, err := selectDevices(*id)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if *list {
+		return listDevices(os.Stdout, devices)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		<-signals
+		cancel()
+	}()
+	header := !format.noheader
+	for {
+		rows, err := queryRows(ctx, devices, *queryApps != "")
+		if err != nil {
+			if ctx.Err() != nil {
+				return 0
+			}
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if format.json {
+			err = writeJSON(os.Stdout, fields, rows)
+		} else {
+			err = writeCSV(os.Stdout, fields, rows, header, format.nounits)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		header = false
+		if *loop <= 0 {
+			return 0
+		}
+		select {
+		case <-time.After(time.Duration(*loop) * time.Second):
+		case <-ctx.Done():
+			return 0
+		}
+	}
+}
+
+func fieldNames(fields []field) string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.name
+	}
+	return strings.Join(names, ", ")
+}
+
+// selectDevices returns the devices in the comma separated list ids, see
+// gonvml.ParseVisibleDevices, or all the devices if ids is empty.
+func selectDevices(ids string) ([]gonvml.Device, error) {
+	if strings.TrimSpace(ids) == "" {
+		ids = "all"
+	}
+	return gonvml.ParseVisibleDevices(ids)
+}
+
+func listDevices(w io.Writer, devices []gonvml.Device) int {
+	for _, d := range devices {
+		index, err := d.Index()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		name, _ := d.Name()
+		uuid, _ := d.UUID()
+		fmt.Fprintf(w, "GPU %d: %s (UUID: %s)\n", index, name, uuid)
+	}
+	return 0
+}
+
+// queryRows reads the rows to print: one per device, or with processes one
+// per compute process of the devices.
+func queryRows(ctx context.Context, devices []gonvml.Device, processes bool) ([]row, error) {
+	driverVersion, _ := gonvml.SystemDriverVersion()
+	now := time.Now()
+	var rows []row
+	for _, d := range devices {
+		status, err := d.Snapshot(ctx, gonvml.SnapshotOptions{Processes: processes, ProcessNames: processes})
+		if err != nil {
+			return nil, err
+		}
+		r := row{time: now, driverVersion: driverVersion, status: status}
+		if !processes {
+			rows = append(rows, r)
+			continue
+		}
+		for _, p := range status.Processes {
+			if p.Type != "compute" {
+				continue
+			}
+			r.process = p
+			rows = append(rows, r)
+		}
+	}
+	return rows, nil
+}
+
+// missing returns what to print for the field f with no value in r.
+func missing(r *row, f field) string {
+	for _, e := range r.status.Errors {
+		if e.Field == f.path && (errors.Is(e.Err, gonvml.ErrNotSupported) || errors.Is(e.Err, gonvml.ErrFunctionNotFound)) {
+			return "[Not Supported]"
+		}
+	}
+	return "[N/A]"
+}
+
+func writeCSV(w io.Writer, fields []field, rows []row, header, nounits bool) error {
+	var b bytes.Buffer
+	if header {
+		for i, f := range fields {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(f.name)
+			if f.unit != "" {
+				b.WriteString(" [" + f.unit + "]")
+			}
+		}
+		b.WriteByte('\n')
+	}
+	for i := range rows {
+		for j, f := range fields {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			v := f.value(&rows[i])
+			if v == nil {
+				b.WriteString(missing(&rows[i], f))
+				continue
+			}
+			if fv, ok := v.(float64); ok {
+				b.WriteString(strconv.FormatFloat(fv, 'f', 2, 64))
+			} else {
+				fmt.Fprint(&b, v)
+			}
+			if f.unit != "" && !nounits {
+				b.WriteString(" " + f.unit)
+			}
+		}
+		b.WriteByte('\n')
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// writeJSON writes the rows as a JSON array of objects with the fields in
+// the order they were queried. Missing values are null.
+func writeJSON(w io.Writer, fields []field, rows []row) error {
+	var b bytes.Buffer
+	b.WriteString("[")
+	for i := range rows {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString("\n  {")
+		for j, f := range fields {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			name, _ := json.Marshal(f.name)
+			value, err := json.Marshal(f.value(&rows[i]))
+			if err != nil {
+				return err
+			}
+			b.Write(name)
+			b.WriteString(": ")
+			b.Write(value)
+		}
+		b.WriteString("}")
+	}
+	b.WriteString("\n]\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
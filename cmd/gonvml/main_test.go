@@ -0,0 +1,119 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cfsmp3/gonvml"
+)
+
+// testRows returns two rows: the first one has a fan speed that isn't
+// supported and a temperature that failed, the second one a power usage
+// that the driver doesn't have and no fan speed at all.
+func testRows() []row {
+	index0, index1 := uint(0), uint(1)
+	power := uint(35000)
+	return []row{{
+		status: gonvml.DeviceStatus{
+			Identity: gonvml.DeviceIdentity{Index: &index0, Name: "Tesla T4"},
+			Power:    gonvml.PowerStatus{UsageMilliwatts: &power},
+			Memory:   &gonvml.MemoryUsage{TotalBytes: 16 << 30, UsedBytes: 4 << 30, FreeBytes: 12 << 30},
+			Errors: []gonvml.FieldError{
+				{Field: "thermal.fan_speed_percent", Err: gonvml.ErrNotSupported},
+				{Field: "thermal.temperature_c", Err: gonvml.ErrUnknown},
+			},
+		},
+	}, {
+		status: gonvml.DeviceStatus{
+			Identity: gonvml.DeviceIdentity{Index: &index1, Name: "Tesla V100"},
+			Errors: []gonvml.FieldError{
+				{Field: "power.usage_mw", Err: gonvml.ErrFunctionNotFound},
+			},
+		},
+	}}
+}
+
+func TestWriteCSV(t *testing.T) {
+	fields, err := parseFields("index,name,power.draw,fan.speed,temperature.gpu,memory.used", gpuFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		header, nounits bool
+		want            string
+	}{
+		{true, false, `index, name, power.draw [W], fan.speed [%], temperature.gpu, memory.used [MiB]
+0, Tesla T4, 35.00 W, [Not Supported], [N/A], 4096 MiB
+1, Tesla V100, [Not Supported], [N/A], [N/A], [N/A]
+`},
+		{false, true, `0, Tesla T4, 35.00, [Not Supported], [N/A], 4096
+1, Tesla V100, [Not Supported], [N/A], [N/A], [N/A]
+`},
+	} {
+		var b bytes.Buffer
+		if err := writeCSV(&b, fields, testRows(), test.header, test.nounits); err != nil {
+			t.Fatal(err)
+		}
+		if got := b.String(); got != test.want {
+			t.Errorf("writeCSV(header=%v, nounits=%v) =\n%s\nwant\n%s", test.header, test.nounits, got, test.want)
+		}
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	fields, err := parseFields("index,name,power.draw,fan.speed,memory.used", gpuFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := writeJSON(&b, fields, testRows()); err != nil {
+		t.Fatal(err)
+	}
+	want := `[
+  {"index": 0, "name": "Tesla T4", "power.draw": 35, "fan.speed": null, "memory.used": 4096},
+  {"index": 1, "name": "Tesla V100", "power.draw": null, "fan.speed": null, "memory.used": null}
+]
+`
+	if got := b.String(); got != want {
+		t.Errorf("writeJSON() =\n%s\nwant\n%s", got, want)
+	}
+
+	b.Reset()
+	if err := writeJSON(&b, fields, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != "[\n]\n" {
+		t.Errorf("writeJSON() without rows = %q, want %q", got, "[\n]\n")
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	fields, err := parseFields(" pid, used_memory,", appFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fieldNames(fields); got != "pid, used_memory" {
+		t.Errorf("parseFields() = %s, want pid, used_memory", got)
+	}
+	for _, query := range []string{"pid,temperature.gpu", " , "} {
+		if _, err := parseFields(query, appFields); err == nil {
+			t.Errorf("parseFields(%q) succeeded, want an error", query)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cfsmp3/gonvml"
+)
+
+// row is what the values of a line of output are read from: a device, and
+// for --query-compute-apps one of its processes.
+type row struct {
+	time          time.Time
+	driverVersion string
+	status        gonvml.DeviceStatus
+	process       gonvml.ProcessStatus
+}
+
+// field is a property that can be queried, named like its nvidia-smi
+// counterpart.
+type field struct {
+	name string
+	unit string
+	// path is the gonvml.DeviceStatus field the value comes from, to tell
+	// why it is missing.
+	path string
+	// value returns the value, an uint, uint64, float64 or string, or nil
+	// if it is not available.
+	value func(r *row) interface{}
+}
+
+const mib = 1 << 20
+
+func uintValue(v *uint) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func uint64Value(v *uint64) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func stringValue(v string) interface{} {
+	if v == "" {
+		return nil
+	}
+	return v
+}
+
+// milliValue converts a value in thousandths of unit, e.g. milliwatts, to
+// the unit.
+func milliValue(v *uint) interface{} {
+	if v == nil {
+		return nil
+	}
+	return float64(*v) / 1000
+}
+
+func memoryValue(m *gonvml.MemoryUsage, get func(*gonvml.MemoryUsage) uint64) interface{} {
+	if m == nil {
+		return nil
+	}
+	return get(m) / mib
+}
+
+// gpuFields are the fields of --query-gpu.
+var gpuFields = []field{
+	{"timestamp", "", "", func(r *row) interface{} { return r.time.Format("2006/01/02 15:04:05.000") }},
+	{"driver_version", "", "", func(r *row) interface{} { return stringValue(r.driverVersion) }},
+	{"index", "", "identity.index", func(r *row) interface{} { return uintValue(r.status.Identity.Index) }},
+	{"name", "", "identity.name", func(r *row) interface{} { return stringValue(r.status.Identity.Name) }},
+	{"uuid", "", "identity.uuid", func(r *row) interface{} { return stringValue(r.status.Identity.UUID) }},
+	{"serial", "", "identity.serial", func(r *row) interface{} { return stringValue(r.status.Identity.Serial) }},
+	{"pci.bus_id", "", "identity.pci_bus_id", func(r *row) interface{} { return stringValue(r.status.Identity.PciBusID) }},
+	{"vbios_version", "", "identity.vbios_version", func(r *row) interface{} { return stringValue(r.status.Identity.VBiosVersion) }},
+	{"persistence_mode", "", "identity.persistence_mode", func(r *row) interface{} { return stringValue(r.status.Identity.PersistenceMode) }},
+	{"compute_mode", "", "identity.compute_mode", func(r *row) interface{} { return stringValue(r.status.Identity.ComputeMode) }},
+	{"pstate", "", "identity.performance_state", func(r *row) interface{} {
+		if p := r.status.Identity.PerformanceState; p != nil {
+			return fmt.Sprintf("P%d", *p)
+		}
+		return nil
+	}},
+	{"temperature.gpu", "", "thermal.temperature_c", func(r *row) interface{} { return uintValue(r.status.Thermal.Temperature) }},
+	{"fan.speed", "%", "thermal.fan_speed_percent", func(r *row) interface{} { return uintValue(r.status.Thermal.FanSpeed) }},
+	{"power.draw", "W", "power.usage_mw", func(r *row) interface{} { return milliValue(r.status.Power.UsageMilliwatts) }},
+	{"power.limit", "W", "power.limit_mw", func(r *row) interface{} { return milliValue(r.status.Power.LimitMilliwatts) }},
+	{"enforced.power.limit", "W", "power.enforced_limit_mw", func(r *row) interface{} { return milliValue(r.status.Power.EnforcedLimitMilliwatts) }},
+	{"power.default_limit", "W", "power.default_limit_mw", func(r *row) interface{} { return milliValue(r.status.Power.DefaultLimitMilliwatts) }},
+	{"power.min_limit", "W", "power.min_limit_mw", func(r *row) interface{} { return milliValue(r.status.Power.MinLimitMilliwatts) }},
+	{"power.max_limit", "W", "power.min_limit_mw", func(r *row) interface{} { return milliValue(r.status.Power.MaxLimitMilliwatts) }},
+	{"utilization.gpu", "%", "utilization.gpu_percent", func(r *row) interface{} { return uintValue(r.status.Utilization.GPU) }},
+	{"utilization.memory", "%", "utilization.gpu_percent", func(r *row) interface{} { return uintValue(r.status.Utilization.Memory) }},
+	{"utilization.encoder", "%", "utilization.encoder_percent", func(r *row) interface{} { return uintValue(r.status.Utilization.Encoder) }},
+	{"utilization.decoder", "%", "utilization.decoder_percent", func(r *row) interface{} { return uintValue(r.status.Utilization.Decoder) }},
+	{"memory.total", "MiB", "memory", func(r *row) interface{} {
+		return memoryValue(r.status.Memory, func(m *gonvml.MemoryUsage) uint64 { return m.TotalBytes })
+	}},
+	{"memory.used", "MiB", "memory", func(r *row) interface{} {
+		return memoryValue(r.status.Memory, func(m *gonvml.MemoryUsage) uint64 { return m.UsedBytes })
+	}},
+	{"memory.free", "MiB", "memory", func(r *row) interface{} {
+		return memoryValue(r.status.Memory, func(m *gonvml.MemoryUsage) uint64 { return m.FreeBytes })
+	}},
+	{"clocks.gr", "MHz", "clocks.current.graphics_mhz", func(r *row) interface{} { return uintValue(r.status.Clocks.Current.Graphics) }},
+	{"clocks.sm", "MHz", "clocks.current.sm_mhz", func(r *row) interface{} { return uintValue(r.status.Clocks.Current.SM) }},
+	{"clocks.mem", "MHz", "clocks.current.memory_mhz", func(r *row) interface{} { return uintValue(r.status.Clocks.Current.Memory) }},
+	{"clocks.video", "MHz", "clocks.current.video_mhz", func(r *row) interface{} { return uintValue(r.status.Clocks.Current.Video) }},
+	{"clocks.applications.graphics", "MHz", "clocks.applications.graphics_mhz", func(r *row) interface{} { return uintValue(r.status.Clocks.Applications.Graphics) }},
+	{"clocks.applications.memory", "MHz", "clocks.applications.memory_mhz", func(r *row) interface{} { return uintValue(r.status.Clocks.Applications.Memory) }},
+	{"clocks.max.gr", "MHz", "clocks.max.graphics_mhz", func(r *row) interface{} { return uintValue(r.status.Clocks.Max.Graphics) }},
+	{"clocks.max.sm", "MHz", "clocks.max.sm_mhz", func(r *row) interface{} { return uintValue(r.status.Clocks.Max.SM) }},
+	{"clocks.max.mem", "MHz", "clocks.max.memory_mhz", func(r *row) interface{} { return uintValue(r.status.Clocks.Max.Memory) }},
+	{"pcie.link.gen.current", "", "pcie.link_generation", func(r *row) interface{} { return uintValue(r.status.PCIe.LinkGeneration) }},
+	{"pcie.link.gen.max", "", "pcie.max_link_generation", func(r *row) interface{} { return uintValue(r.status.PCIe.MaxLinkGeneration) }},
+	{"pcie.link.width.current", "", "pcie.link_width", func(r *row) interface{} { return uintValue(r.status.PCIe.LinkWidth) }},
+	{"pcie.link.width.max", "", "pcie.max_link_width", func(r *row) interface{} { return uintValue(r.status.PCIe.MaxLinkWidth) }},
+	{"ecc.errors.corrected.volatile.total", "", "ecc.volatile_corrected", func(r *row) interface{} { return uint64Value(r.status.ECC.VolatileCorrected) }},
+	{"ecc.errors.uncorrected.volatile.total", "", "ecc.volatile_uncorrected", func(r *row) interface{} { return uint64Value(r.status.ECC.VolatileUncorrected) }},
+	{"ecc.errors.corrected.aggregate.total", "", "ecc.aggregate_corrected", func(r *row) interface{} { return uint64Value(r.status.ECC.AggregateCorrected) }},
+	{"ecc.errors.uncorrected.aggregate.total", "", "ecc.aggregate_uncorrected", func(r *row) interface{} { return uint64Value(r.status.ECC.AggregateUncorrected) }},
+	{"clocks_throttle_reasons.active", "", "throttle_reasons", func(r *row) interface{} {
+		if t := r.status.ThrottleReasons; t != nil {
+			return fmt.Sprintf("0x%016X", t.Bitmap)
+		}
+		return nil
+	}},
+}
+
+// appFields are the fields of --query-compute-apps.
+var appFields = []field{
+	{"timestamp", "", "", func(r *row) interface{} { return r.time.Format("2006/01/02 15:04:05.000") }},
+	{"gpu_name", "", "identity.name", func(r *row) interface{} { return stringValue(r.status.Identity.Name) }},
+	{"gpu_bus_id", "", "identity.pci_bus_id", func(r *row) interface{} { return stringValue(r.status.Identity.PciBusID) }},
+	{"gpu_serial", "", "identity.serial", func(r *row) interface{} { return stringValue(r.status.Identity.Serial) }},
+	{"gpu_uuid", "", "identity.uuid", func(r *row) interface{} { return stringValue(r.status.Identity.UUID) }},
+	{"pid", "", "", func(r *row) interface{} { return r.process.PID }},
+	{"process_name", "", "", func(r *row) interface{} { return stringValue(r.process.Name) }},
+	{"used_memory", "MiB", "", func(r *row) interface{} { return r.process.UsedMemoryBytes / mib }},
+}
+
+// parseFields returns the fields named in the comma separated list query.
+func parseFields(query string, fields []field) ([]field, error) {
+	var selected []field
+	for _, name := range strings.Split(query, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		found := false
+		for _, f := range fields {
+			if f.name == name {
+				selected = append(selected, f)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("no fields in %q", query)
+	}
+	return selected, nil
+}
@@ -0,0 +1,490 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Device is the handle for the device.
+// This handle is obtained by calling DeviceHandleByIndex().
+type Device struct {
+	handle DeviceHandle
+}
+
+// Index return the index of the device
+func (d Device) Index() (uint, error) {
+	return backend.DeviceGetIndex(d.handle)
+}
+
+// Brand returns the Product Brand of the device.
+func (d Device) Brand() (DeviceBrand, error) {
+	return backend.DeviceGetBrand(d.handle)
+}
+
+// BoardID returns the Devices Board ID.
+// Devices with the same boardId indicate GPUs connected to the same PLX.
+func (d Device) BoardID() (uint, error) {
+	return backend.DeviceGetBoardId(d.handle)
+}
+
+// ComputeMode returns the current Compute Mode of the device.
+func (d Device) ComputeMode() (ComputeMode, error) {
+	return backend.DeviceGetComputeMode(d.handle)
+}
+
+// DisplayMode returns the current Display Mode of the device.
+func (d Device) DisplayMode() (EnableState, error) {
+	return backend.DeviceGetDisplayMode(d.handle)
+}
+
+// DisplayActive returns if there currently is an active display attached.
+func (d Device) DisplayActive() (EnableState, error) {
+	return backend.DeviceGetDisplayActive(d.handle)
+}
+
+// VBiosVersion returns VBIOS version of the device.
+func (d Device) VBiosVersion() (string, error) {
+	return backend.DeviceGetVbiosVersion(d.handle)
+}
+
+// CurrentClocksThrottleReasons returns reasons (bitmap) for the GPU being throttled
+func (d Device) CurrentClocksThrottleReasons() (uint64, error) {
+	return backend.DeviceGetCurrentClocksThrottleReasons(d.handle)
+}
+
+// MostSeriousClocksThrottleReason returns the most serious of the reasons
+// reported by CurrentClocksThrottleReasons as one of the ThrottlingReason
+// values.
+func (d Device) MostSeriousClocksThrottleReason() (int, error) {
+	var bitmap, err = d.CurrentClocksThrottleReasons()
+	if (bitmap & clocksThrottleReasonDisplayClockSetting) != 0 {
+		return ThrottlingReasonDisplayClockSetting, err
+	}
+	if (bitmap & clocksThrottleReasonHwPowerBrakeSlowdown) != 0 {
+		return ThrottlingReasonHwPowerBrakeSlowdown, err
+	}
+	if (bitmap & clocksThrottleReasonHwThermalSlowdown) != 0 {
+		return ThrottlingReasonHwThermalSlowdown, err
+	}
+	if (bitmap & clocksThrottleReasonSwThermalSlowdown) != 0 {
+		return ThrottlingReasonSwThermalSlowdown, err
+	}
+	if (bitmap & clocksThrottleReasonSyncBoost) != 0 {
+		return ThrottlingReasonSyncBoost, err
+	}
+	if (bitmap & clocksThrottleReasonHwSlowdown) != 0 {
+		return ThrottlingReasonHwSlowdown, err
+	}
+	if (bitmap & clocksThrottleReasonSwPowerCap) != 0 {
+		return ThrottlingReasonSwPowerCap, err
+	}
+	if (bitmap & clocksThrottleReasonUserDefinedClocks) != 0 {
+		return ThrottlingReasonUserDefinedClocks, err
+	}
+	if (bitmap & clocksThrottleReasonApplicationsClocksSetting) != 0 {
+		return ThrottlingReasonApplicationClock, err
+	}
+	if (bitmap & clocksThrottleReasonGpuIdle) != 0 {
+		return ThrottlingReasonIdle, err
+	}
+	return ThrottlingReasonNone, err
+}
+
+// TotalEnergyConsumption total energy consumption for this GPU in millijoules (mJ) since the driver was last reloaded.
+func (d Device) TotalEnergyConsumption() (uint64, error) {
+	return backend.DeviceGetTotalEnergyConsumption(d.handle)
+}
+
+// TotalEccErrors returns the total ECC error counts: corrected volatile,
+// corrected aggregate, uncorrected volatile and uncorrected aggregate. See
+// EccCounts.
+func (d Device) TotalEccErrors() (uint64, uint64, uint64, uint64, error) {
+	c, err := d.EccCounts()
+	return c.CorrectedVolatile, c.CorrectedAggregate, c.UncorrectedVolatile, c.UncorrectedAggregate, err
+}
+
+// Serial returns the globally unique board serial number associated with this device's board.
+func (d Device) Serial() (string, error) {
+	return backend.DeviceGetSerial(d.handle)
+}
+
+// MinorNumber returns the minor number for the device.
+// The minor number for the device is such that the Nvidia device node
+// file for each GPU will have the form /dev/nvidia[minor number].
+func (d Device) MinorNumber() (uint, error) {
+	return backend.DeviceGetMinorNumber(d.handle)
+}
+
+// PciInfo returns the PCI attributes of the device.
+func (d Device) PciInfo() (PciInfo, error) {
+	return backend.DeviceGetPciInfo(d.handle)
+}
+
+// BusID returns the BusID
+func (d Device) BusID() (string, error) {
+	pci, err := backend.DeviceGetPciInfo(d.handle)
+	return pci.BusID, err
+}
+
+// UUID returns the globally unique immutable UUID associated with this device.
+func (d Device) UUID() (string, error) {
+	return backend.DeviceGetUUID(d.handle)
+}
+
+// Name returns the product name of the device.
+func (d Device) Name() (string, error) {
+	return backend.DeviceGetName(d.handle)
+}
+
+// PersistenceMode returns the current driver persistence mode of the device.
+func (d Device) PersistenceMode() (uint, error) {
+	pm, err := backend.DeviceGetPersistenceMode(d.handle)
+	return uint(pm), err
+}
+
+// SetPersistenceMode sets the current driver persistence mode of the device.
+func (d Device) SetPersistenceMode(mode uint) error {
+	return backend.DeviceSetPersistenceMode(d.handle, EnableState(mode))
+}
+
+// SetComputeMode sets the current compute mode of the device.
+func (d Device) SetComputeMode(mode uint) error {
+	return backend.DeviceSetComputeMode(d.handle, ComputeMode(mode))
+}
+
+// PerformanceState returns the current performance state of the device.
+func (d Device) PerformanceState() (uint, error) {
+	pstate, err := backend.DeviceGetPerformanceState(d.handle)
+	return uint(pstate), err
+}
+
+// GrClock returns the application graphics clock of the device.
+func (d Device) GrClock() (uint, error) {
+	return backend.DeviceGetClockInfo(d.handle, ClockTypeGraphics)
+}
+
+// SMClock returns the application SM clock of the device.
+func (d Device) SMClock() (uint, error) {
+	return backend.DeviceGetClockInfo(d.handle, ClockTypeSM)
+}
+
+// MemClock returns the memory clock of the device.
+func (d Device) MemClock() (uint, error) {
+	return backend.DeviceGetClockInfo(d.handle, ClockTypeMem)
+}
+
+// VideoClock returns the memory clock of the device.
+func (d Device) VideoClock() (uint, error) {
+	return backend.DeviceGetClockInfo(d.handle, ClockTypeVideo)
+}
+
+// GrMaxClock returns the application graphics clock of the device.
+func (d Device) GrMaxClock() (uint, error) {
+	return backend.DeviceGetMaxClockInfo(d.handle, ClockTypeGraphics)
+}
+
+// SMMaxClock returns the application SM clock of the device.
+func (d Device) SMMaxClock() (uint, error) {
+	return backend.DeviceGetMaxClockInfo(d.handle, ClockTypeSM)
+}
+
+// MemMaxClock returns the application memory clock of the device.
+func (d Device) MemMaxClock() (uint, error) {
+	return backend.DeviceGetMaxClockInfo(d.handle, ClockTypeMem)
+}
+
+// VideoMaxClock returns the memory clock of the device.
+func (d Device) VideoMaxClock() (uint, error) {
+	return backend.DeviceGetMaxClockInfo(d.handle, ClockTypeVideo)
+}
+
+// MemoryInfo returns the total and used memory (in bytes) of the device.
+func (d Device) MemoryInfo() (uint64, uint64, error) {
+	return backend.DeviceGetMemoryInfo(d.handle)
+}
+
+// Bar1MemoryInfo returns the total and used memory (in bytes) of the devices BAR1 Memory.
+// BAR1 is used to map the FB (device memory) so that it can be directly accessed by
+// the CPU or by 3rd party devices (peer-to-peer on the PCIE bus).
+func (d Device) Bar1MemoryInfo() (uint64, uint64, error) {
+	return backend.DeviceGetBAR1MemoryInfo(d.handle)
+}
+
+// UtilizationRates returns the percent of time over the past sample period during which:
+// utilization.gpu: one or more kernels were executing on the GPU.
+// utilization.memory: global (device) memory was being read or written.
+func (d Device) UtilizationRates() (uint, uint, error) {
+	return backend.DeviceGetUtilizationRates(d.handle)
+}
+
+// PowerUsage returns the power usage for this GPU and its associated circuitry
+// in milliwatts. The reading is accurate to within +/- 5% of current power draw.
+func (d Device) PowerUsage() (uint, error) {
+	return backend.DeviceGetPowerUsage(d.handle)
+}
+
+// PowerLimitConstraints retrieves information about possible values of power management limits on this device.
+func (d Device) PowerLimitConstraints() (uint, uint, error) {
+	return backend.DeviceGetPowerManagementLimitConstraints(d.handle)
+}
+
+// PowerLimits returns the devices power Limits
+// management (first uint): The power limit defines the upper boundary for the
+// card's power draw. If the card's total power draw reaches this limit the
+// power management algorithm kicks in.
+// enforced (second uint): Get the effective power limit that the driver
+// enforces after taking into account all limiters.
+// Note: This can be different from the management limit if
+// other limits are set elsewhere This includes the out of band power limit
+// interface
+func (d Device) PowerLimits() (uint, uint, error) {
+	var errs errorList
+
+	enforced, err := backend.DeviceGetEnforcedPowerLimit(d.handle)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	management, err := backend.DeviceGetPowerManagementLimit(d.handle)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return management, enforced, errs.err()
+}
+
+// AveragePowerUsage returns the power usage for this GPU and its associated circuitry
+// in milliwatts averaged over the samples collected in the last `since` duration.
+func (d Device) AveragePowerUsage(since time.Duration) (uint, error) {
+	return d.averageUsage(SamplingTypeTotalPower, since)
+}
+
+// PowerLimit returns the power limit for this GPU and its associated circuitry
+// in milliwatts
+func (d Device) PowerLimit() (uint, error) {
+	return backend.DeviceGetPowerManagementLimit(d.handle)
+}
+
+// PowerManagementDefaultLimit returns the power limit for this GPU and its associated circuitry
+// in milliwatts
+func (d Device) PowerManagementDefaultLimit() (uint, error) {
+	return backend.DeviceGetPowerManagementDefaultLimit(d.handle)
+}
+
+// AverageGPUUtilization returns the utilization.gpu metric (percent of time
+// one of more kernels were executing on the GPU) averaged over the samples
+// collected in the last `since` duration.
+func (d Device) AverageGPUUtilization(since time.Duration) (uint, error) {
+	return d.averageUsage(SamplingTypeGPUUtilization, since)
+}
+
+// PcieTxThroughput returns the tx throughput in KB/s
+func (d Device) PcieTxThroughput() (uint, error) {
+	return backend.DeviceGetPcieThroughput(d.handle, PcieUtilCounterTxBytes)
+}
+
+// PcieRxThroughput returns the rx throughput in KB/s
+func (d Device) PcieRxThroughput() (uint, error) {
+	return backend.DeviceGetPcieThroughput(d.handle, PcieUtilCounterRxBytes)
+}
+
+// PcieGeneration returns the current PCIe link generation
+func (d Device) PcieGeneration() (uint, error) {
+	return backend.DeviceGetCurrPcieLinkGeneration(d.handle)
+}
+
+// PcieWidth returns the current PCIe link width
+func (d Device) PcieWidth() (uint, error) {
+	return backend.DeviceGetCurrPcieLinkWidth(d.handle)
+}
+
+// PcieMaxGeneration returns the current PCIe link generation
+func (d Device) PcieMaxGeneration() (uint, error) {
+	return backend.DeviceGetMaxPcieLinkGeneration(d.handle)
+}
+
+// PcieMaxWidth returns the current PCIe link width
+func (d Device) PcieMaxWidth() (uint, error) {
+	return backend.DeviceGetMaxPcieLinkWidth(d.handle)
+}
+
+// Temperature returns the temperature for this GPU in Celsius.
+func (d Device) Temperature() (uint, error) {
+	return backend.DeviceGetTemperature(d.handle, TemperatureSensorGPU)
+}
+
+// TemperatureThresholds returns the temperature thresholds for this device in Celcius
+// first return argument is the shutdown threshold, second is the slowdown threshold
+func (d Device) TemperatureThresholds() (uint, uint, error) {
+	var errs errorList
+
+	//shutdown type
+	shutdown, err := backend.DeviceGetTemperatureThreshold(d.handle, TemperatureThresholdShutdown)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	//slowdown type
+	slowdown, err := backend.DeviceGetTemperatureThreshold(d.handle, TemperatureThresholdSlowdown)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	return shutdown, slowdown, errs.err()
+}
+
+// FanSpeed returns the temperature for this GPU in the percentage of its full
+// speed, with 100 being the maximum.
+func (d Device) FanSpeed() (uint, error) {
+	return backend.DeviceGetFanSpeed(d.handle)
+}
+
+// EncoderUtilization returns the percent of time over the last sample period during which the GPU video encoder was being used.
+// The sampling period is variable and is returned in the second return argument in microseconds.
+func (d Device) EncoderUtilization() (uint, uint, error) {
+	return backend.DeviceGetEncoderUtilization(d.handle)
+}
+
+// EncoderCapacity retrieves the current capacity of the device's encoder, as a percentage of maximum encoder capacity with valid values in the range 0-100.
+func (d Device) EncoderCapacity() (uint, uint, error) {
+	capacityH264, err := backend.DeviceGetEncoderCapacity(d.handle, EncoderTypeH264)
+	backend.DeviceGetEncoderCapacity(d.handle, EncoderTypeHEVC)
+	return capacityH264, capacityH264, err
+}
+
+// DecoderUtilization returns the percent of time over the last sample period during which the GPU video decoder was being used.
+// The sampling period is variable and is returned in the second return argument in microseconds.
+func (d Device) DecoderUtilization() (uint, uint, error) {
+	return backend.DeviceGetDecoderUtilization(d.handle)
+}
+
+// AccountingMode returns whether accounting is enabled on the device.
+func (d Device) AccountingMode() (EnableState, error) {
+	return backend.DeviceGetAccountingMode(d.handle)
+}
+
+// AccountingPids returns the processes that have accounting stats on the
+// device, in a slice of count pids of which the first n are valid. With a
+// count of 0, it only returns n, which is at most AccountingBufferSize.
+func (d Device) AccountingPids(count uint) (pids []uint, n uint, err error) {
+	return backend.DeviceGetAccountingPids(d.handle, count)
+}
+
+// AccountingStats Queries process's accounting stats.
+// @param pid                                  Process Id of the target process to query stats for
+// @return stats                               Reference in which to return the process's accounting stats
+func (d Device) AccountingStats(pid uint) (*AccountingStats, error) {
+	stats, err := backend.DeviceGetAccountingStats(d.handle, pid)
+	if errors.Is(err, errLibraryNotLoaded) {
+		return nil, err
+	}
+	return &stats, err
+}
+
+// AccountingBufferSize Returns the number of processes that the circular buffer with accounting pids can hold.
+// @return buffersize                        buffersize
+func (d Device) AccountingBufferSize() (uint, error) {
+	return backend.DeviceGetAccountingBufferSize(d.handle)
+}
+
+// ProcessUtilization Retrieves the current utilization and process ID
+// @param processCount                      Maxnum process buffersize
+// @param since                             The last query time for process
+// @return utilizations                     The utilizations for all process
+// @return processCount                     The queried utilizations
+func (d Device) ProcessUtilization(processCount uint, since time.Duration) ([]*Utilization, error) {
+	if processCount <= 0 {
+		return nil, errors.New("Process Count Less than zero")
+	}
+	lastTS := uint64(time.Now().Add(-1*since).UnixNano() / 1000)
+	return backend.DeviceGetProcessUtilization(d.handle, processCount, lastTS)
+}
+
+// PCIeThroughput returns the current PCIe tx and rx bytes
+// first uint is tx, second is rx in KB/s
+func (d Device) PCIeThroughput() (uint, uint, error) {
+	var errs errorList
+
+	tx, err := backend.DeviceGetPcieThroughput(d.handle, PcieUtilCounterTxBytes)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("Unable to query PCIe TX utilization: %w", err))
+	}
+	rx, err := backend.DeviceGetPcieThroughput(d.handle, PcieUtilCounterRxBytes)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("Unable to query PCIe RX utilization: %w", err))
+	}
+
+	return tx, rx, errs.err()
+}
+
+// PCIeLinkGen returns the current PCIe Link generation
+// first uint ist the current generation, second is the maximum supported generation
+func (d Device) PCIeLinkGen() (uint, uint, error) {
+	var errs errorList
+
+	max, err := backend.DeviceGetMaxPcieLinkGeneration(d.handle)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("Unable to query PCIe max link generation: %w", err))
+	}
+	curr, err := backend.DeviceGetCurrPcieLinkGeneration(d.handle)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("Unable to query PCIe current link generation: %w", err))
+	}
+
+	return curr, max, errs.err()
+}
+
+// PCIeLinkWidth returns the current PCIe Link generation
+// first uint ist the current width, second is the maximum supported width
+func (d Device) PCIeLinkWidth() (uint, uint, error) {
+	var errs errorList
+
+	max, err := backend.DeviceGetMaxPcieLinkWidth(d.handle)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("Unable to query PCIe max link width: %w", err))
+	}
+	curr, err := backend.DeviceGetCurrPcieLinkWidth(d.handle)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("Unable to query PCIe current link width: %w", err))
+	}
+
+	return curr, max, errs.err()
+}
+
+// ApplicationClock returns the current clock of a device application in MHz.
+// the application should be specified in ct
+func (d Device) ApplicationClock(ct ClockType) (uint, error) {
+	return backend.DeviceGetApplicationsClock(d.handle, ct)
+}
+
+// Clock returns the current clock of a device application in MHz.
+// the application should be specified in ct
+func (d Device) Clock(ct ClockType) (uint, error) {
+	return backend.DeviceGetClockInfo(d.handle, ct)
+}
+
+// ComputeProcesses returns information about processes with a compute context on a device
+func (d Device) ComputeProcesses() ([]Process, error) {
+	return backend.DeviceGetComputeRunningProcesses(d.handle)
+}
+
+// GraphicsProcesses returns information about processes with a graphics context on a device
+func (d Device) GraphicsProcesses() ([]Process, error) {
+	return backend.DeviceGetGraphicsRunningProcesses(d.handle)
+}
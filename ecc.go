@@ -0,0 +1,124 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+// EccCounts are ECC error counts by error type and counter type. Volatile
+// counts are reset when the driver is loaded, aggregate ones persist across
+// reboots.
+type EccCounts struct {
+	CorrectedVolatile    uint64
+	CorrectedAggregate   uint64
+	UncorrectedVolatile  uint64
+	UncorrectedAggregate uint64
+}
+
+// Count returns the count of the given error type and counter type.
+func (c EccCounts) Count(errorType MemoryErrorType, counterType EccCounterType) uint64 {
+	return *c.count(errorType, counterType)
+}
+
+func (c *EccCounts) count(errorType MemoryErrorType, counterType EccCounterType) *uint64 {
+	switch {
+	case errorType == MemoryErrorTypeCorrected && counterType == EccCounterTypeVolatile:
+		return &c.CorrectedVolatile
+	case errorType == MemoryErrorTypeCorrected:
+		return &c.CorrectedAggregate
+	case counterType == EccCounterTypeVolatile:
+		return &c.UncorrectedVolatile
+	default:
+		return &c.UncorrectedAggregate
+	}
+}
+
+// eccCounters are the error type and counter type combinations of EccCounts.
+var eccCounters = []struct {
+	errorType   MemoryErrorType
+	counterType EccCounterType
+}{
+	{MemoryErrorTypeCorrected, EccCounterTypeVolatile},
+	{MemoryErrorTypeCorrected, EccCounterTypeAggregate},
+	{MemoryErrorTypeUncorrected, EccCounterTypeVolatile},
+	{MemoryErrorTypeUncorrected, EccCounterTypeAggregate},
+}
+
+// EccCounts returns the total ECC error counts of the device. The counts of
+// the calls that fail are left at zero and their errors are combined in the
+// returned error.
+func (d Device) EccCounts() (EccCounts, error) {
+	var counts EccCounts
+	var errs errorList
+	for _, c := range eccCounters {
+		n, err := backend.DeviceGetTotalEccErrors(d.handle, c.errorType, c.counterType)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		*counts.count(c.errorType, c.counterType) = n
+	}
+	return counts, errs.err()
+}
+
+// EccErrorCounts is the equivalent of nvmlEccErrorCounts_t.
+type EccErrorCounts struct {
+	L1Cache      uint64
+	L2Cache      uint64
+	DeviceMemory uint64
+	RegisterFile uint64
+}
+
+// DetailedEccErrors returns the ECC error counts of the given types in the
+// main memory locations. It is deprecated by NVML in favor of
+// MemoryErrorCounter, which covers more locations.
+func (d Device) DetailedEccErrors(errorType MemoryErrorType, counterType EccCounterType) (EccErrorCounts, error) {
+	return backend.DeviceGetDetailedEccErrors(d.handle, errorType, counterType)
+}
+
+// MemoryErrorCounter returns the ECC error counts of the memory location,
+// e.g. MemoryLocationDeviceMemory for DRAM or MemoryLocationSRAM. The counts
+// of the calls that fail are left at zero and their errors are combined in
+// the returned error.
+func (d Device) MemoryErrorCounter(location MemoryLocation) (EccCounts, error) {
+	var counts EccCounts
+	var errs errorList
+	for _, c := range eccCounters {
+		n, err := backend.DeviceGetMemoryErrorCounter(d.handle, c.errorType, c.counterType, location)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		*counts.count(c.errorType, c.counterType) = n
+	}
+	return counts, errs.err()
+}
+
+// EccMode returns whether ECC is enabled, and whether it will be after the
+// next reboot.
+func (d Device) EccMode() (current, pending EnableState, err error) {
+	return backend.DeviceGetEccMode(d.handle)
+}
+
+// SetEccMode enables or disables ECC after the next reboot. It requires
+// root.
+func (d Device) SetEccMode(mode EnableState) error {
+	return backend.DeviceSetEccMode(d.handle, mode)
+}
+
+// ClearEccErrorCounts resets the ECC error counts of the given counter type,
+// total and per location. It requires root.
+func (d Device) ClearEccErrorCounts(counterType EccCounterType) error {
+	return backend.DeviceClearEccErrorCounts(d.handle, counterType)
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Return is the equivalent of nvmlReturn_t.
+type Return int
+
+// Enumeration mapping for Return to nvmlReturn_t
+const (
+	ReturnSuccess                    Return = 0
+	ReturnErrorUninitialized         Return = 1
+	ReturnErrorInvalidArgument       Return = 2
+	ReturnErrorNotSupported          Return = 3
+	ReturnErrorNoPermission          Return = 4
+	ReturnErrorAlreadyInitialized    Return = 5
+	ReturnErrorNotFound              Return = 6
+	ReturnErrorInsufficientSize      Return = 7
+	ReturnErrorInsufficientPower     Return = 8
+	ReturnErrorDriverNotLoaded       Return = 9
+	ReturnErrorTimeout               Return = 10
+	ReturnErrorIrqIssue              Return = 11
+	ReturnErrorLibraryNotFound       Return = 12
+	ReturnErrorFunctionNotFound      Return = 13
+	ReturnErrorCorruptedInforom      Return = 14
+	ReturnErrorGpuIsLost             Return = 15
+	ReturnErrorResetRequired         Return = 16
+	ReturnErrorOperatingSystem       Return = 17
+	ReturnErrorLibRmVersionMismatch  Return = 18
+	ReturnErrorInUse                 Return = 19
+	ReturnErrorMemory                Return = 20
+	ReturnErrorNoData                Return = 21
+	ReturnErrorVgpuEccNotSupported   Return = 22
+	ReturnErrorInsufficientResources Return = 23
+	ReturnErrorUnknown               Return = 999
+)
+
+// String returns the same message as nvmlErrorString does for r.
+func (r Return) String() string {
+	switch r {
+	case ReturnSuccess:
+		return "Success"
+	case ReturnErrorUninitialized:
+		return "Uninitialized"
+	case ReturnErrorInvalidArgument:
+		return "Invalid Argument"
+	case ReturnErrorNotSupported:
+		return "Not Supported"
+	case ReturnErrorNoPermission:
+		return "Insufficient Permissions"
+	case ReturnErrorAlreadyInitialized:
+		return "Already Initialized"
+	case ReturnErrorNotFound:
+		return "Not Found"
+	case ReturnErrorInsufficientSize:
+		return "Insufficient Size"
+	case ReturnErrorInsufficientPower:
+		return "Insufficient External Power"
+	case ReturnErrorDriverNotLoaded:
+		return "Driver Not Loaded"
+	case ReturnErrorTimeout:
+		return "Timeout"
+	case ReturnErrorIrqIssue:
+		return "Interrupt request issue"
+	case ReturnErrorLibraryNotFound:
+		return "NVML Shared Library Not Found"
+	case ReturnErrorFunctionNotFound:
+		return "Function Not Found"
+	case ReturnErrorCorruptedInforom:
+		return "Corrupted infoROM"
+	case ReturnErrorGpuIsLost:
+		return "GPU is lost"
+	case ReturnErrorResetRequired:
+		return "GPU requires restart"
+	case ReturnErrorOperatingSystem:
+		return "The operating system has blocked the request."
+	case ReturnErrorLibRmVersionMismatch:
+		return "RM has detected an NVML/RM version mismatch."
+	case ReturnErrorInUse:
+		return "In use by another client"
+	case ReturnErrorMemory:
+		return "Insufficient Memory"
+	case ReturnErrorNoData:
+		return "No data"
+	case ReturnErrorVgpuEccNotSupported:
+		return "The requested vgpu operation is not available on target device, becasue ECC is enabled"
+	case ReturnErrorInsufficientResources:
+		return "Ran out of critical resources, other than memory"
+	case ReturnErrorUnknown:
+		return "Unknown Error"
+	default:
+		return fmt.Sprintf("Unknown Error (%d)", int(r))
+	}
+}
+
+// Error is the error returned when an NVML function fails. It records the
+// name of the function and the code it returned:
+//
+//	var nvmlErr *gonvml.Error
+//	if errors.As(err, &nvmlErr) {
+//		log.Printf("%s returned %d", nvmlErr.Func, nvmlErr.Code)
+//	}
+//
+// An Error matches the sentinel error of its code with errors.Is, e.g.
+// errors.Is(err, gonvml.ErrNotSupported).
+type Error struct {
+	Func string // NVML function that failed, e.g. "nvmlDeviceGetTemperature"
+	Code Return
+}
+
+func (e *Error) Error() string {
+	if e.Func == "" {
+		return fmt.Sprintf("NVML: %v", e.Code)
+	}
+	return fmt.Sprintf("NVML: %s: %v", e.Func, e.Code)
+}
+
+// Is reports whether target is an *Error with the same code and either the
+// same function or no function at all, as is the case for the sentinel
+// errors.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	return t.Code == e.Code && (t.Func == "" || t.Func == e.Func)
+}
+
+// Sentinel errors for every NVML return code, to be used with errors.Is.
+var (
+	ErrUninitialized         error = &Error{Code: ReturnErrorUninitialized}
+	ErrInvalidArgument       error = &Error{Code: ReturnErrorInvalidArgument}
+	ErrNotSupported          error = &Error{Code: ReturnErrorNotSupported}
+	ErrNoPermission          error = &Error{Code: ReturnErrorNoPermission}
+	ErrAlreadyInitialized    error = &Error{Code: ReturnErrorAlreadyInitialized}
+	ErrNotFound              error = &Error{Code: ReturnErrorNotFound}
+	ErrInsufficientSize      error = &Error{Code: ReturnErrorInsufficientSize}
+	ErrInsufficientPower     error = &Error{Code: ReturnErrorInsufficientPower}
+	ErrDriverNotLoaded       error = &Error{Code: ReturnErrorDriverNotLoaded}
+	ErrTimeout               error = &Error{Code: ReturnErrorTimeout}
+	ErrIrqIssue              error = &Error{Code: ReturnErrorIrqIssue}
+	ErrLibraryNotFound       error = &Error{Code: ReturnErrorLibraryNotFound}
+	ErrFunctionNotFound      error = &Error{Code: ReturnErrorFunctionNotFound}
+	ErrCorruptedInforom      error = &Error{Code: ReturnErrorCorruptedInforom}
+	ErrGpuIsLost             error = &Error{Code: ReturnErrorGpuIsLost}
+	ErrResetRequired         error = &Error{Code: ReturnErrorResetRequired}
+	ErrOperatingSystem       error = &Error{Code: ReturnErrorOperatingSystem}
+	ErrLibRmVersionMismatch  error = &Error{Code: ReturnErrorLibRmVersionMismatch}
+	ErrInUse                 error = &Error{Code: ReturnErrorInUse}
+	ErrMemory                error = &Error{Code: ReturnErrorMemory}
+	ErrNoData                error = &Error{Code: ReturnErrorNoData}
+	ErrVgpuEccNotSupported   error = &Error{Code: ReturnErrorVgpuEccNotSupported}
+	ErrInsufficientResources error = &Error{Code: ReturnErrorInsufficientResources}
+	ErrUnknown               error = &Error{Code: ReturnErrorUnknown}
+)
+
+// errLibraryNotLoaded is returned by the functions called while the NVML
+// library is not loaded, i.e. before Initialize or after Shutdown.
+var errLibraryNotLoaded = ErrLibraryNotFound
+
+// newError returns the error for the code r returned by the NVML function fn,
+// or nil if r is ReturnSuccess.
+func newError(fn string, r Return) error {
+	if r == ReturnSuccess {
+		return nil
+	}
+	return &Error{Func: fn, Code: r}
+}
+
+// errorList combines the errors of several NVML calls made by a single
+// method. It matches any of them with errors.Is and errors.As.
+type errorList []error
+
+func (l errorList) Error() string {
+	msgs := make([]string, len(l))
+	for i, err := range l {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+func (l errorList) Is(target error) bool {
+	for _, err := range l {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l errorList) As(target interface{}) bool {
+	for _, err := range l {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// err returns nil if l is empty, its only error if it has one, or l itself.
+func (l errorList) err() error {
+	switch len(l) {
+	case 0:
+		return nil
+	case 1:
+		return l[0]
+	default:
+		return l
+	}
+}
@@ -0,0 +1,228 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// EventType is the bitmask of the nvmlEventType* defines. Types can be
+// combined with the bitwise or operator.
+type EventType uint64
+
+// Enumeration mapping for EventType to the nvmlEventType* defines
+const (
+	EventTypeSingleBitEccError EventType = 0x0000000000000001
+	EventTypeDoubleBitEccError EventType = 0x0000000000000002
+	EventTypePState            EventType = 0x0000000000000004
+	EventTypeXidCriticalError  EventType = 0x0000000000000008
+	EventTypeClock             EventType = 0x0000000000000010
+	EventTypePowerSourceChange EventType = 0x0000000000000080
+	EventTypeNone              EventType = 0x0000000000000000
+	EventTypeAll                         = EventTypeSingleBitEccError | EventTypeDoubleBitEccError |
+		EventTypePState | EventTypeXidCriticalError | EventTypeClock | EventTypePowerSourceChange
+)
+
+var eventTypeNames = []struct {
+	t    EventType
+	name string
+}{
+	{EventTypeSingleBitEccError, "single bit ecc error"},
+	{EventTypeDoubleBitEccError, "double bit ecc error"},
+	{EventTypePState, "pstate change"},
+	{EventTypeXidCriticalError, "xid critical error"},
+	{EventTypeClock, "clock change"},
+	{EventTypePowerSourceChange, "power source change"},
+}
+
+func (t EventType) String() string {
+	if t == EventTypeNone {
+		return "none"
+	}
+	var names []string
+	for _, n := range eventTypeNames {
+		if t&n.t != 0 {
+			names = append(names, n.name)
+			t &^= n.t
+		}
+	}
+	if t != 0 {
+		names = append(names, "unknown")
+	}
+	return strings.Join(names, "|")
+}
+
+// NoInstanceID is the GPU or compute instance id of the events that cannot be
+// attributed to a MIG instance.
+const NoInstanceID = 0xFFFFFFFF
+
+// EventSetHandle is the backend specific value identifying an event set. It
+// is opaque to everything but the Backend that returned it.
+type EventSetHandle interface{}
+
+// EventData is the equivalent of nvmlEventData_t.
+type EventData struct {
+	Device            DeviceHandle
+	Type              EventType
+	Data              uint64
+	GpuInstanceID     uint
+	ComputeInstanceID uint
+}
+
+// Event is an event reported by NVML.
+type Event struct {
+	Device Device
+	Type   EventType // a single type, never a combination
+	// Xid is the XID of EventTypeXidCriticalError events, 999 if NVML
+	// doesn't know it, and 0 for the other types.
+	Xid uint64
+	// GpuInstanceID and ComputeInstanceID identify the MIG instance an XID
+	// is attributable to, NoInstanceID otherwise.
+	GpuInstanceID     uint
+	ComputeInstanceID uint
+	// Err is only set on the last event delivered by Watch when waiting for
+	// events failed, and the other fields are zero then.
+	Err error
+}
+
+// EventSet is the handle of a set of events registered for one or more
+// devices. Events are read with Wait. The set has to be released with Free.
+type EventSet struct {
+	handle EventSetHandle
+}
+
+// NewEventSet creates an empty EventSet.
+func NewEventSet() (EventSet, error) {
+	h, err := backend.EventSetCreate()
+	return EventSet{h}, err
+}
+
+// Register starts recording the events of the given types that happen on
+// device d.
+func (s EventSet) Register(d Device, eventTypes EventType) error {
+	return backend.DeviceRegisterEvents(d.handle, eventTypes, s.handle)
+}
+
+// Wait returns the next event of the set. If none is ready, it waits for at
+// most timeout and fails with an error matching ErrTimeout if nothing arrived.
+func (s EventSet) Wait(timeout time.Duration) (Event, error) {
+	data, err := backend.EventSetWait(s.handle, uint(timeout/time.Millisecond))
+	if err != nil {
+		return Event{}, err
+	}
+	e := Event{
+		Device:            Device{data.Device},
+		Type:              data.Type,
+		GpuInstanceID:     data.GpuInstanceID,
+		ComputeInstanceID: data.ComputeInstanceID,
+	}
+	if data.Type == EventTypeXidCriticalError {
+		e.Xid = data.Data
+	}
+	return e, nil
+}
+
+// Free releases the set.
+func (s EventSet) Free() error {
+	return backend.EventSetFree(s.handle)
+}
+
+// SupportedEventTypes returns the event types that can be registered for the
+// device.
+func (d Device) SupportedEventTypes() (EventType, error) {
+	return backend.DeviceGetSupportedEventTypes(d.handle)
+}
+
+// watchPollInterval bounds how long Watch takes to notice that its context is
+// done, as NVML can only wait for events with a timeout.
+var watchPollInterval = 500 * time.Millisecond
+
+// Watch registers the given event types for the devices and delivers the
+// events on the returned channel until ctx is done:
+//
+//	events, err := gonvml.Watch(ctx, devices, gonvml.EventTypeXidCriticalError)
+//	for e := range events {
+//		if e.Err != nil {
+//			return e.Err
+//		}
+//		if e.Xid == 48 || e.Xid == 79 {
+//			drain(e.Device)
+//		}
+//	}
+//
+// Each device only gets the types it supports registered; Watch fails with
+// an error matching ErrNotSupported if none of the devices supports any of
+// them. The channel is closed once ctx is done. If waiting for events fails
+// first, e.g. because NVML was shut down, the channel delivers a last Event
+// with the error in Err before being closed.
+func Watch(ctx context.Context, devices []Device, eventTypes EventType) (<-chan Event, error) {
+	set, err := NewEventSet()
+	if err != nil {
+		return nil, err
+	}
+	registered := false
+	for _, d := range devices {
+		types := eventTypes
+		if supported, err := d.SupportedEventTypes(); err == nil {
+			types &= supported
+		}
+		if types == EventTypeNone {
+			continue
+		}
+		err := set.Register(d, types)
+		if errors.Is(err, ErrNotSupported) {
+			continue
+		}
+		if err != nil {
+			set.Free()
+			return nil, err
+		}
+		registered = true
+	}
+	if !registered {
+		set.Free()
+		return nil, ErrNotSupported
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		defer set.Free()
+		for ctx.Err() == nil {
+			e, err := set.Wait(watchPollInterval)
+			if errors.Is(err, ErrTimeout) {
+				continue
+			}
+			if err != nil {
+				select {
+				case events <- Event{Err: err}:
+				case <-ctx.Done():
+				}
+				return
+			}
+			select {
+			case events <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
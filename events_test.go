@@ -0,0 +1,94 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	defer func(interval time.Duration) { watchPollInterval = interval }(watchPollInterval)
+	watchPollInterval = 10 * time.Millisecond
+	a := &FakeDevice{SupportedEventTypes: EventTypeXidCriticalError}
+	b := &FakeDevice{}
+	f, devices := initFakeDevices(t, a, b)
+	defer SetBackend(nil)
+	defer Shutdown()
+
+	events, err := Watch(context.Background(), devices, EventTypeXidCriticalError|EventTypePState)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.InjectEvent(a, EventTypeXidCriticalError, 79)
+	e := <-events
+	want := Event{
+		Device:            devices[0],
+		Type:              EventTypeXidCriticalError,
+		Xid:               79,
+		GpuInstanceID:     NoInstanceID,
+		ComputeInstanceID: NoInstanceID,
+	}
+	if e != want {
+		t.Errorf("got event %+v, want %+v", e, want)
+	}
+
+	// Shutting NVML down makes waiting fail, which ends the watch.
+	if err := Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	e, ok := <-events
+	if !ok {
+		t.Fatal("events closed without reporting the error")
+	}
+	if !errors.Is(e.Err, ErrLibraryNotFound) || e.Type != EventTypeNone {
+		t.Errorf("last event = %+v, want an ErrLibraryNotFound error", e)
+	}
+	if e, ok := <-events; ok {
+		t.Errorf("got event %+v after the error, want the channel closed", e)
+	}
+}
+
+func TestWatchCanceled(t *testing.T) {
+	defer func(interval time.Duration) { watchPollInterval = interval }(watchPollInterval)
+	watchPollInterval = 10 * time.Millisecond
+	_, devices := initFakeDevices(t, &FakeDevice{SupportedEventTypes: EventTypeAll})
+	defer SetBackend(nil)
+	defer Shutdown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events, err := Watch(ctx, devices, EventTypeAll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+	if e, ok := <-events; ok {
+		t.Errorf("got event %+v after cancel, want the channel closed", e)
+	}
+}
+
+func TestWatchNotSupported(t *testing.T) {
+	_, devices := initFakeDevices(t, &FakeDevice{SupportedEventTypes: EventTypePState})
+	defer SetBackend(nil)
+	defer Shutdown()
+
+	if _, err := Watch(context.Background(), devices, EventTypeXidCriticalError); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("Watch() = %v, want ErrNotSupported", err)
+	}
+}
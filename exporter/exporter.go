@@ -0,0 +1,318 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package exporter exports the metrics of the NVIDIA devices read through
+// gonvml in the Prometheus text exposition format. It implements the format
+// itself, so it doesn't depend on the Prometheus client library:
+//
+//	if err := gonvml.Initialize(); err != nil {
+//		log.Fatal(err)
+//	}
+//	defer gonvml.Shutdown()
+//	http.Handle("/metrics", exporter.New(exporter.Options{Processes: true}))
+//
+// The device metrics are labeled with the index (gpu), UUID and name of the
+// device, and for MIG devices with their gpu_instance and compute_instance
+// IDs. Process metrics add the pid, process_name and type of the process.
+package exporter
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/cfsmp3/gonvml"
+)
+
+// Options configures a Collector.
+type Options struct {
+	// Namespace prefixes the metric names, "nvml" if empty.
+	Namespace string
+	// Processes exports the GPU memory used by the processes running on
+	// the devices.
+	Processes bool
+	// MIG exports the MIG devices of the GPUs in MIG mode.
+	MIG bool
+}
+
+// metric describes a metric family.
+type metric struct {
+	name string
+	help string
+	typ  MetricType
+}
+
+// The metrics exported, in the order they are written.
+var (
+	upMetric                 = metric{"up", "Whether NVML could be queried (1) or not (0).", Gauge}
+	driverInfoMetric         = metric{"driver_info", "Version of the NVIDIA driver and NVML library.", Gauge}
+	deviceInfoMetric         = metric{"device_info", "Information about the device.", Gauge}
+	temperatureMetric        = metric{"temperature_celsius", "GPU temperature in degrees Celsius.", Gauge}
+	fanSpeedMetric           = metric{"fan_speed_ratio", "Fan speed as a fraction of its maximum.", Gauge}
+	powerUsageMetric         = metric{"power_usage_watts", "Power draw of the device in watts.", Gauge}
+	powerLimitMetric         = metric{"power_limit_watts", "Power management limit of the device in watts.", Gauge}
+	energyMetric             = metric{"energy_consumption_joules_total", "Energy consumed since the driver was loaded in joules.", Counter}
+	gpuUtilizationMetric     = metric{"utilization_gpu_ratio", "Fraction of the time one or more kernels were running on the GPU.", Gauge}
+	memUtilizationMetric     = metric{"utilization_memory_ratio", "Fraction of the time device memory was being read or written.", Gauge}
+	encoderUtilizationMetric = metric{"utilization_encoder_ratio", "Fraction of the time the video encoder was in use.", Gauge}
+	decoderUtilizationMetric = metric{"utilization_decoder_ratio", "Fraction of the time the video decoder was in use.", Gauge}
+	memoryTotalMetric        = metric{"memory_total_bytes", "Total device memory in bytes.", Gauge}
+	memoryUsedMetric         = metric{"memory_used_bytes", "Used device memory in bytes.", Gauge}
+	memoryFreeMetric         = metric{"memory_free_bytes", "Free device memory in bytes.", Gauge}
+	bar1TotalMetric          = metric{"bar1_memory_total_bytes", "Total BAR1 memory in bytes.", Gauge}
+	bar1UsedMetric           = metric{"bar1_memory_used_bytes", "Used BAR1 memory in bytes.", Gauge}
+	clockMetric              = metric{"clock_hertz", "Current clock speed in hertz.", Gauge}
+	maxClockMetric           = metric{"max_clock_hertz", "Maximum clock speed in hertz.", Gauge}
+	performanceStateMetric   = metric{"performance_state", "Performance state, from 0 (maximum) to 15 (minimum performance).", Gauge}
+	pcieTxMetric             = metric{"pcie_tx_bytes_per_second", "PCIe transmit throughput in bytes per second.", Gauge}
+	pcieRxMetric             = metric{"pcie_rx_bytes_per_second", "PCIe receive throughput in bytes per second.", Gauge}
+	pcieGenerationMetric     = metric{"pcie_link_generation", "Current PCIe link generation.", Gauge}
+	pcieWidthMetric          = metric{"pcie_link_width", "Current PCIe link width.", Gauge}
+	eccErrorsMetric          = metric{"ecc_errors", "ECC error counts since the driver was loaded or the counts were cleared.", Gauge}
+	eccAggregateErrorsMetric = metric{"ecc_aggregate_errors_total", "ECC error counts over the lifetime of the device.", Counter}
+	throttleReasonMetric     = metric{"clocks_throttle_reason", "Whether the clocks are throttled for the reason (1) or not (0).", Gauge}
+	processMemoryMetric      = metric{"process_used_memory_bytes", "Device memory used by the process in bytes.", Gauge}
+
+	metrics = []metric{
+		upMetric, driverInfoMetric, deviceInfoMetric,
+		temperatureMetric, fanSpeedMetric,
+		powerUsageMetric, powerLimitMetric, energyMetric,
+		gpuUtilizationMetric, memUtilizationMetric, encoderUtilizationMetric, decoderUtilizationMetric,
+		memoryTotalMetric, memoryUsedMetric, memoryFreeMetric, bar1TotalMetric, bar1UsedMetric,
+		clockMetric, maxClockMetric, performanceStateMetric,
+		pcieTxMetric, pcieRxMetric, pcieGenerationMetric, pcieWidthMetric,
+		eccErrorsMetric, eccAggregateErrorsMetric, throttleReasonMetric, processMemoryMetric,
+	}
+)
+
+// throttleReasons are the values of the reason label of
+// clocks_throttle_reason, see gonvml.ThrottleReasons.
+var throttleReasons = []string{
+	"gpu_idle",
+	"applications_clocks_setting",
+	"sw_power_cap",
+	"hw_slowdown",
+	"sync_boost",
+	"sw_thermal_slowdown",
+	"hw_thermal_slowdown",
+	"hw_power_brake_slowdown",
+	"display_clock_setting",
+}
+
+// Collector reads the metrics of all the devices through gonvml. It is safe
+// for concurrent use, and serves the metrics over HTTP as an http.Handler.
+// NVML has to be initialized while it is in use.
+type Collector struct {
+	opts Options
+}
+
+// New returns a Collector with the given options.
+func New(opts Options) *Collector {
+	if opts.Namespace == "" {
+		opts.Namespace = "nvml"
+	}
+	return &Collector{opts: opts}
+}
+
+// familySet accumulates the samples of the metrics.
+type familySet struct {
+	families []Family
+	index    map[string]int
+}
+
+func newFamilySet(namespace string) *familySet {
+	s := &familySet{index: make(map[string]int)}
+	for i, m := range metrics {
+		s.families = append(s.families, Family{Name: namespace + "_" + m.name, Help: m.help, Type: m.typ})
+		s.index[m.name] = i
+	}
+	return s
+}
+
+func (s *familySet) add(m metric, value float64, labels []Label) {
+	f := &s.families[s.index[m.name]]
+	f.Samples = append(f.Samples, Sample{Labels: labels, Value: value})
+}
+
+// addUint adds *v multiplied by scale, if v is not nil.
+func (s *familySet) addUint(m metric, v *uint, scale float64, labels []Label) {
+	if v != nil {
+		s.add(m, float64(*v)*scale, labels)
+	}
+}
+
+// addUint64 adds *v multiplied by scale, if v is not nil.
+func (s *familySet) addUint64(m metric, v *uint64, scale float64, labels []Label) {
+	if v != nil {
+		s.add(m, float64(*v)*scale, labels)
+	}
+}
+
+// withLabels returns a copy of labels followed by the label pairs in kv.
+func withLabels(labels []Label, kv ...string) []Label {
+	l := make([]Label, len(labels), len(labels)+len(kv)/2)
+	copy(l, labels)
+	for i := 0; i+1 < len(kv); i += 2 {
+		l = append(l, Label{Name: kv[i], Value: kv[i+1]})
+	}
+	return l
+}
+
+// Collect reads the metrics of all the devices. Metrics that can't be read,
+// e.g. because a device doesn't support them, are left out. If the devices
+// can't be listed at all, only the up metric is returned, set to 0.
+func (c *Collector) Collect(ctx context.Context) []Family {
+	s := newFamilySet(c.opts.Namespace)
+	count, err := gonvml.DeviceCount()
+	if err != nil {
+		s.add(upMetric, 0, nil)
+		return s.families
+	}
+	s.add(upMetric, 1, nil)
+	driverVersion, _ := gonvml.SystemDriverVersion()
+	nvmlVersion, _ := gonvml.SystemNVMLVersion()
+	s.add(driverInfoMetric, 1, withLabels(nil, "driver_version", driverVersion, "nvml_version", nvmlVersion))
+
+	for i := uint(0); i < count; i++ {
+		if ctx.Err() != nil {
+			break
+		}
+		d, err := gonvml.DeviceHandleByIndex(i)
+		if err != nil {
+			continue
+		}
+		index := strconv.FormatUint(uint64(i), 10)
+		c.collectDevice(ctx, s, d, index, nil)
+		if !c.opts.MIG {
+			continue
+		}
+		if mode, _, err := d.MigMode(); err != nil || mode != gonvml.EnableStateFeatureEnabled {
+			continue
+		}
+		migs, err := d.MigDevices()
+		if err != nil {
+			continue
+		}
+		for _, mig := range migs {
+			gi, err := mig.GpuInstanceID()
+			if err != nil {
+				continue
+			}
+			ci, err := mig.ComputeInstanceID()
+			if err != nil {
+				continue
+			}
+			c.collectDevice(ctx, s, mig, index, withLabels(nil,
+				"gpu_instance", strconv.FormatUint(uint64(gi), 10),
+				"compute_instance", strconv.FormatUint(uint64(ci), 10)))
+		}
+	}
+	return s.families
+}
+
+// collectDevice adds the metrics of d, the device or a MIG device of the
+// device with the given index. migLabels are the labels identifying the MIG
+// device.
+func (c *Collector) collectDevice(ctx context.Context, s *familySet, d gonvml.Device, index string, migLabels []Label) {
+	st, err := d.Snapshot(ctx, gonvml.SnapshotOptions{
+		Processes:         c.opts.Processes,
+		ProcessNames:      c.opts.Processes,
+		IgnoreUnsupported: true,
+	})
+	if err != nil {
+		return
+	}
+	id := st.Identity
+	labels := withLabels(nil, "gpu", index, "uuid", id.UUID, "name", id.Name)
+	labels = append(labels, migLabels...)
+
+	s.add(deviceInfoMetric, 1, withLabels(labels,
+		"pci_bus_id", id.PciBusID, "vbios_version", id.VBiosVersion, "brand", id.Brand))
+	s.addUint(temperatureMetric, st.Thermal.Temperature, 1, labels)
+	s.addUint(fanSpeedMetric, st.Thermal.FanSpeed, 0.01, labels)
+	s.addUint(powerUsageMetric, st.Power.UsageMilliwatts, 0.001, labels)
+	s.addUint(powerLimitMetric, st.Power.LimitMilliwatts, 0.001, labels)
+	s.addUint64(energyMetric, st.Power.EnergyMillijoules, 0.001, labels)
+	s.addUint(gpuUtilizationMetric, st.Utilization.GPU, 0.01, labels)
+	s.addUint(memUtilizationMetric, st.Utilization.Memory, 0.01, labels)
+	s.addUint(encoderUtilizationMetric, st.Utilization.Encoder, 0.01, labels)
+	s.addUint(decoderUtilizationMetric, st.Utilization.Decoder, 0.01, labels)
+	if m := st.Memory; m != nil {
+		s.add(memoryTotalMetric, float64(m.TotalBytes), labels)
+		s.add(memoryUsedMetric, float64(m.UsedBytes), labels)
+		s.add(memoryFreeMetric, float64(m.FreeBytes), labels)
+	}
+	if m := st.BAR1; m != nil {
+		s.add(bar1TotalMetric, float64(m.TotalBytes), labels)
+		s.add(bar1UsedMetric, float64(m.UsedBytes), labels)
+	}
+	for _, clock := range []struct {
+		name     string
+		cur, max *uint
+	}{
+		{"graphics", st.Clocks.Current.Graphics, st.Clocks.Max.Graphics},
+		{"sm", st.Clocks.Current.SM, st.Clocks.Max.SM},
+		{"memory", st.Clocks.Current.Memory, st.Clocks.Max.Memory},
+		{"video", st.Clocks.Current.Video, st.Clocks.Max.Video},
+	} {
+		clockLabels := withLabels(labels, "clock", clock.name)
+		s.addUint(clockMetric, clock.cur, 1e6, clockLabels)
+		s.addUint(maxClockMetric, clock.max, 1e6, clockLabels)
+	}
+	if p := id.PerformanceState; p != nil {
+		s.add(performanceStateMetric, float64(*p), labels)
+	}
+	// NVML reports the PCIe throughput in KB/s.
+	s.addUint(pcieTxMetric, st.PCIe.TxKBps, 1024, labels)
+	s.addUint(pcieRxMetric, st.PCIe.RxKBps, 1024, labels)
+	s.addUint(pcieGenerationMetric, st.PCIe.LinkGeneration, 1, labels)
+	s.addUint(pcieWidthMetric, st.PCIe.LinkWidth, 1, labels)
+	// The volatile counts can be cleared, only the aggregate ones are
+	// counters.
+	s.addUint64(eccErrorsMetric, st.ECC.VolatileCorrected, 1, withLabels(labels, "error_type", "corrected"))
+	s.addUint64(eccErrorsMetric, st.ECC.VolatileUncorrected, 1, withLabels(labels, "error_type", "uncorrected"))
+	s.addUint64(eccAggregateErrorsMetric, st.ECC.AggregateCorrected, 1, withLabels(labels, "error_type", "corrected"))
+	s.addUint64(eccAggregateErrorsMetric, st.ECC.AggregateUncorrected, 1, withLabels(labels, "error_type", "uncorrected"))
+	if t := st.ThrottleReasons; t != nil {
+		active := make(map[string]bool, len(t.Reasons))
+		for _, r := range t.Reasons {
+			active[r] = true
+		}
+		for _, r := range throttleReasons {
+			v := 0.0
+			if active[r] {
+				v = 1
+			}
+			s.add(throttleReasonMetric, v, withLabels(labels, "reason", r))
+		}
+	}
+	for _, p := range st.Processes {
+		s.add(processMemoryMetric, float64(p.UsedMemoryBytes), withLabels(labels,
+			"pid", strconv.FormatUint(uint64(p.PID), 10), "process_name", p.Name, "type", p.Type))
+	}
+}
+
+// ServeHTTP writes the metrics collected for the request in the text
+// exposition format. Errors writing the response, e.g. because the client
+// went away, are logged with the standard logger.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	families := c.Collect(r.Context())
+	w.Header().Set("Content-Type", ContentType)
+	if err := WriteText(w, families); err != nil {
+		log.Printf("Writing metrics: %v", err)
+	}
+}
@@ -0,0 +1,328 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"math"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/cfsmp3/gonvml"
+)
+
+// deviceFuncs are the NVML functions the device metrics are read with.
+var deviceFuncs = []string{
+	"nvmlDeviceGetPerformanceState",
+	"nvmlDeviceGetClockInfo",
+	"nvmlDeviceGetMaxClockInfo",
+	"nvmlDeviceGetApplicationsClock",
+	"nvmlDeviceGetMemoryInfo",
+	"nvmlDeviceGetBAR1MemoryInfo",
+	"nvmlDeviceGetUtilizationRates",
+	"nvmlDeviceGetEncoderUtilization",
+	"nvmlDeviceGetDecoderUtilization",
+	"nvmlDeviceGetPowerUsage",
+	"nvmlDeviceGetPowerManagementLimit",
+	"nvmlDeviceGetEnforcedPowerLimit",
+	"nvmlDeviceGetPowerManagementDefaultLimit",
+	"nvmlDeviceGetPowerManagementLimitConstraints",
+	"nvmlDeviceGetTotalEnergyConsumption",
+	"nvmlDeviceGetTemperature",
+	"nvmlDeviceGetTemperatureThreshold",
+	"nvmlDeviceGetFanSpeed",
+	"nvmlDeviceGetPcieThroughput",
+	"nvmlDeviceGetCurrPcieLinkGeneration",
+	"nvmlDeviceGetCurrPcieLinkWidth",
+	"nvmlDeviceGetMaxPcieLinkGeneration",
+	"nvmlDeviceGetMaxPcieLinkWidth",
+	"nvmlDeviceGetTotalEccErrors",
+	"nvmlDeviceGetCurrentClocksThrottleReasons",
+}
+
+// unsupportedExcept returns the Errors of a FakeDevice that doesn't support
+// the device metrics but those read with the supported functions.
+func unsupportedExcept(supported ...string) map[string]gonvml.Return {
+	errs := make(map[string]gonvml.Return)
+	for _, fn := range deviceFuncs {
+		errs[fn] = gonvml.ReturnErrorNotSupported
+	}
+	for _, fn := range supported {
+		delete(errs, fn)
+	}
+	return errs
+}
+
+// newTestBackend returns a FakeBackend with a GPU running a process, and a
+// GPU in MIG mode with a single MIG device.
+func newTestBackend() *gonvml.FakeBackend {
+	mig := &gonvml.FakeDevice{
+		Name:        "A100 MIG 1g.5gb",
+		UUID:        "MIG-11111111-2222-3333-4444-555555555555",
+		MemoryTotal: 5 << 30,
+		MemoryUsed:  1 << 30,
+		Errors:      unsupportedExcept("nvmlDeviceGetMemoryInfo"),
+	}
+	// The temperature of the GPU in MIG mode fails with an error other
+	// than ReturnErrorNotSupported, it has to be left out all the same.
+	a100Errors := unsupportedExcept("nvmlDeviceGetPowerUsage", "nvmlDeviceGetTemperature")
+	a100Errors["nvmlDeviceGetTemperature"] = gonvml.ReturnErrorUnknown
+	return gonvml.NewFakeBackend(
+		&gonvml.FakeDevice{
+			Name:                   `Tesla "T4"`,
+			UUID:                   "GPU-aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",
+			VbiosVersion:           `90.04\38.00.03`,
+			PciInfo:                gonvml.PciInfo{BusID: "00000000:3B:00.0"},
+			Brand:                  gonvml.DeviceBrandTesla,
+			PerformanceState:       8,
+			Clocks:                 map[gonvml.ClockType]uint{gonvml.ClockTypeGraphics: 585, gonvml.ClockTypeSM: 585, gonvml.ClockTypeMem: 5000, gonvml.ClockTypeVideo: 540},
+			MemoryTotal:            16 << 30,
+			MemoryUsed:             4 << 30,
+			GPUUtilization:         40,
+			MemoryUtilization:      25,
+			PowerUsage:             70500,
+			TotalEnergyConsumption: 123456,
+			Temperature:            55,
+			EccErrors: map[gonvml.FakeEccCounter]uint64{
+				{ErrorType: gonvml.MemoryErrorTypeCorrected, CounterType: gonvml.EccCounterTypeVolatile}:    2,
+				{ErrorType: gonvml.MemoryErrorTypeCorrected, CounterType: gonvml.EccCounterTypeAggregate}:   10,
+				{ErrorType: gonvml.MemoryErrorTypeUncorrected, CounterType: gonvml.EccCounterTypeAggregate}: 1,
+			},
+			ComputeProcesses: []gonvml.FakeProcess{
+				{Pid: 4242, Name: "/usr/bin/python3 \"train\"\n", UsedGpuMemory: 1 << 30},
+			},
+			Errors: unsupportedExcept(
+				"nvmlDeviceGetPerformanceState",
+				"nvmlDeviceGetClockInfo",
+				"nvmlDeviceGetMemoryInfo",
+				"nvmlDeviceGetUtilizationRates",
+				"nvmlDeviceGetPowerUsage",
+				"nvmlDeviceGetTotalEnergyConsumption",
+				"nvmlDeviceGetTemperature",
+				"nvmlDeviceGetTotalEccErrors",
+			),
+		},
+		&gonvml.FakeDevice{
+			Name:       "A100-SXM4-40GB",
+			UUID:       "GPU-ffffffff-0000-1111-2222-333333333333",
+			PowerUsage: 90000,
+			MigMode:    gonvml.EnableStateFeatureEnabled,
+			GpuInstances: []*gonvml.FakeGpuInstance{{
+				ID:               7,
+				ComputeInstances: []*gonvml.FakeComputeInstance{{ID: 0, Device: mig}},
+			}},
+			Errors: a100Errors,
+		},
+	)
+}
+
+const wantMetrics = `# HELP nvml_up Whether NVML could be queried (1) or not (0).
+# TYPE nvml_up gauge
+nvml_up 1
+# HELP nvml_driver_info Version of the NVIDIA driver and NVML library.
+# TYPE nvml_driver_info gauge
+nvml_driver_info{driver_version="450.80.02",nvml_version="11.450.80.02"} 1
+# HELP nvml_device_info Information about the device.
+# TYPE nvml_device_info gauge
+nvml_device_info{gpu="0",uuid="GPU-aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",name="Tesla \"T4\"",pci_bus_id="00000000:3B:00.0",vbios_version="90.04\\38.00.03",brand="Tesla"} 1
+nvml_device_info{gpu="1",uuid="GPU-ffffffff-0000-1111-2222-333333333333",name="A100-SXM4-40GB",pci_bus_id="",vbios_version="",brand="unknown"} 1
+nvml_device_info{gpu="1",uuid="MIG-11111111-2222-3333-4444-555555555555",name="A100 MIG 1g.5gb",gpu_instance="7",compute_instance="0",pci_bus_id="",vbios_version="",brand="unknown"} 1
+# HELP nvml_temperature_celsius GPU temperature in degrees Celsius.
+# TYPE nvml_temperature_celsius gauge
+nvml_temperature_celsius{gpu="0",uuid="GPU-aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",name="Tesla \"T4\""} 55
+# HELP nvml_power_usage_watts Power draw of the device in watts.
+# TYPE nvml_power_usage_watts gauge
+nvml_power_usage_watts{gpu="0",uuid="GPU-aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",name="Tesla \"T4\""} 70.5
+nvml_power_usage_watts{gpu="1",uuid="GPU-ffffffff-0000-1111-2222-333333333333",name="A100-SXM4-40GB"} 90
+# HELP nvml_energy_consumption_joules_total Energy consumed since the driver was loaded in joules.
+# TYPE nvml_energy_consumption_joules_total counter
+nvml_energy_consumption_joules_total{gpu="0",uuid="GPU-aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",name="Tesla \"T4\""} 123.456
+# HELP nvml_utilization_gpu_ratio Fraction of the time one or more kernels were running on the GPU.
+# TYPE nvml_utilization_gpu_ratio gauge
+nvml_utilization_gpu_ratio{gpu="0",uuid="GPU-aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",name="Tesla \"T4\""} 0.4
+# HELP nvml_utilization_memory_ratio Fraction of the time device memory was being read or written.
+# TYPE nvml_utilization_memory_ratio gauge
+nvml_utilization_memory_ratio{gpu="0",uuid="GPU-aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",name="Tesla \"T4\""} 0.25
+# HELP nvml_memory_total_bytes Total device memory in bytes.
+# TYPE nvml_memory_total_bytes gauge
+nvml_memory_total_bytes{gpu="0",uuid="GPU-aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",name="Tesla \"T4\""} 1.7179869184e+10
+nvml_memory_total_bytes{gpu="1",uuid="MIG-11111111-2222-3333-4444-555555555555",name="A100 MIG 1g.5gb",gpu_instance="7",compute_instance="0"} 5.36870912e+09
+# HELP nvml_memory_used_bytes Used device memory in bytes.
+# TYPE nvml_memory_used_bytes gauge
+nvml_memory_used_bytes{gpu="0",uuid="GPU-aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",name="Tesla \"T4\""} 4.294967296e+09
+nvml_memory_used_bytes{gpu="1",uuid="MIG-11111111-2222-3333-4444-555555555555",name="A100 MIG 1g.5gb",gpu_instance="7",compute_instance="0"} 1.073741824e+09
+# HELP nvml_memory_free_bytes Free device memory in bytes.
+# TYPE nvml_memory_free_bytes gauge
+nvml_memory_free_bytes{gpu="0",uuid="GPU-aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",name="Tesla \"T4\""} 1.2884901888e+10
+nvml_memory_free_bytes{gpu="1",uuid="MIG-11111111-2222-3333-4444-555555555555",name="A100 MIG 1g.5gb",gpu_instance="7",compute_instance="0"} 4.294967296e+09
+# HELP nvml_clock_hertz Current clock speed in hertz.
+# TYPE nvml_clock_hertz gauge
+nvml_clock_hertz{gpu="0",uuid="GPU-aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",name="Tesla \"T4\"",clock="graphics"} 5.85e+08
+nvml_clock_hertz{gpu="0",uuid="GPU-aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",name="Tesla \"T4\"",clock="sm"} 5.85e+08
+nvml_clock_hertz{gpu="0",uuid="GPU-aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",name="Tesla \"T4\"",clock="memory"} 5e+09
+nvml_clock_hertz{gpu="0",uuid="GPU-aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",name="Tesla \"T4\"",clock="video"} 5.4e+08
+# HELP nvml_performance_state Performance state, from 0 (maximum) to 15 (minimum performance).
+# TYPE nvml_performance_state gauge
+nvml_performance_state{gpu="0",uuid="GPU-aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",name="Tesla \"T4\""} 8
+# HELP nvml_ecc_errors ECC error counts since the driver was loaded or the counts were cleared.
+# TYPE nvml_ecc_errors gauge
+nvml_ecc_errors{gpu="0",uuid="GPU-aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",name="Tesla \"T4\"",error_type="corrected"} 2
+nvml_ecc_errors{gpu="0",uuid="GPU-aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",name="Tesla \"T4\"",error_type="uncorrected"} 0
+# HELP nvml_ecc_aggregate_errors_total ECC error counts over the lifetime of the device.
+# TYPE nvml_ecc_aggregate_errors_total counter
+nvml_ecc_aggregate_errors_total{gpu="0",uuid="GPU-aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",name="Tesla \"T4\"",error_type="corrected"} 10
+nvml_ecc_aggregate_errors_total{gpu="0",uuid="GPU-aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",name="Tesla \"T4\"",error_type="uncorrected"} 1
+# HELP nvml_process_used_memory_bytes Device memory used by the process in bytes.
+# TYPE nvml_process_used_memory_bytes gauge
+nvml_process_used_memory_bytes{gpu="0",uuid="GPU-aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",name="Tesla \"T4\"",pid="4242",process_name="/usr/bin/python3 \"train\"\n",type="compute"} 1.073741824e+09
+`
+
+func TestCollector(t *testing.T) {
+	gonvml.SetBackend(newTestBackend())
+	defer gonvml.SetBackend(nil)
+	if err := gonvml.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer gonvml.Shutdown()
+
+	rec := httptest.NewRecorder()
+	New(Options{Processes: true, MIG: true}).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if got := rec.Header().Get("Content-Type"); got != ContentType {
+		t.Errorf("Content-Type = %q, want %q", got, ContentType)
+	}
+	if got := rec.Body.String(); got != wantMetrics {
+		t.Errorf("metrics:\n%s\nwant:\n%s", got, wantMetrics)
+	}
+}
+
+// brokenResponse is a ResponseWriter whose client went away.
+type brokenResponse struct {
+	*httptest.ResponseRecorder
+}
+
+func (brokenResponse) Write([]byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestCollectorWriteError(t *testing.T) {
+	gonvml.SetBackend(newTestBackend())
+	defer gonvml.SetBackend(nil)
+	if err := gonvml.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer gonvml.Shutdown()
+
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+	New(Options{}).ServeHTTP(brokenResponse{httptest.NewRecorder()}, httptest.NewRequest("GET", "/metrics", nil))
+	if got := logged.String(); !strings.Contains(got, "Writing metrics: broken pipe") {
+		t.Errorf("logged %q, want the write error", got)
+	}
+}
+
+func TestCollectorOptions(t *testing.T) {
+	gonvml.SetBackend(newTestBackend())
+	defer gonvml.SetBackend(nil)
+	if err := gonvml.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer gonvml.Shutdown()
+
+	var b strings.Builder
+	if err := WriteText(&b, New(Options{Namespace: "gpu"}).Collect(context.Background())); err != nil {
+		t.Fatal(err)
+	}
+	got := b.String()
+	for _, unwanted := range []string{"# TYPE nvml_", "gpu_instance=", "pid="} {
+		if strings.Contains(got, unwanted) {
+			t.Errorf("metrics contain %q:\n%s", unwanted, got)
+		}
+	}
+	if !strings.Contains(got, "\ngpu_up 1\n") {
+		t.Errorf("metrics miss gpu_up:\n%s", got)
+	}
+}
+
+func TestCollectorDeviceErrors(t *testing.T) {
+	f := newTestBackend()
+	gonvml.SetBackend(f)
+	defer gonvml.SetBackend(nil)
+	if err := gonvml.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer gonvml.Shutdown()
+
+	// The devices whose handle can't be read are left out, the others
+	// are still exported.
+	f.Devices[1].Errors["nvmlDeviceGetMigDeviceHandleByIndex"] = gonvml.ReturnErrorUnknown
+	var b strings.Builder
+	WriteText(&b, New(Options{MIG: true}).Collect(context.Background()))
+	got := b.String()
+	if strings.Contains(got, "MIG-") {
+		t.Errorf("metrics contain the MIG device:\n%s", got)
+	}
+	if want := `nvml_power_usage_watts{gpu="1",uuid="GPU-ffffffff-0000-1111-2222-333333333333",name="A100-SXM4-40GB"} 90`; !strings.Contains(got, want) {
+		t.Errorf("metrics miss %s:\n%s", want, got)
+	}
+
+	f.Errors = map[string]gonvml.Return{"nvmlDeviceGetHandleByIndex": gonvml.ReturnErrorGpuIsLost}
+	b.Reset()
+	WriteText(&b, New(Options{}).Collect(context.Background()))
+	if got := b.String(); strings.Contains(got, "device_info") || !strings.Contains(got, "\nnvml_up 1\n") {
+		t.Errorf("metrics of lost GPUs:\n%s", got)
+	}
+
+	// Nothing but up is exported if the devices can't be listed.
+	f.Errors = map[string]gonvml.Return{"nvmlDeviceGetCount": gonvml.ReturnErrorUnknown}
+	b.Reset()
+	WriteText(&b, New(Options{}).Collect(context.Background()))
+	if got, want := b.String(), "# HELP nvml_up Whether NVML could be queried (1) or not (0).\n# TYPE nvml_up gauge\nnvml_up 0\n"; got != want {
+		t.Errorf("metrics:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	families := []Family{
+		{Name: "empty", Help: "Left out.", Type: Gauge},
+		{Name: "values", Help: "Back\\slash and\nnewline.", Type: Counter, Samples: []Sample{
+			{Value: math.NaN()},
+			{Labels: []Label{{"a", "1"}, {"b", "x\\y"}}, Value: math.Inf(1)},
+			{Labels: []Label{{"a", "2"}}, Value: math.Inf(-1)},
+			{Labels: []Label{{"a", "3"}}, Value: 1e-3},
+		}},
+	}
+	want := `# HELP values Back\\slash and\nnewline.
+# TYPE values counter
+values NaN
+values{a="1",b="x\\y"} +Inf
+values{a="2"} -Inf
+values{a="3"} 0.001
+`
+	var b strings.Builder
+	if err := WriteText(&b, families); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != want {
+		t.Errorf("WriteText:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,2304 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FakeBackend is an in-memory Backend serving the devices, metrics and
+// processes it has been programmed with, so that code using this package can
+// be tested on machines without GPUs:
+//
+//	fake := gonvml.NewFakeBackend(&gonvml.FakeDevice{Name: "Tesla T4", Temperature: 40})
+//	gonvml.SetBackend(fake)
+//	defer gonvml.SetBackend(nil)
+//
+// NVML failures are simulated by setting the return code of a function, keyed
+// by its NVML name (e.g. "nvmlDeviceGetTemperature"), in Errors for the
+// system level functions or in FakeDevice.Errors for the device ones.
+//
+// Like NVML, the fake has to be initialized with Initialize before use. The
+// exported fields may be changed at any time, but the FakeBackend has to be
+// locked while doing so if it is in use by other goroutines.
+type FakeBackend struct {
+	sync.Mutex
+
+	DriverVersion string
+	NVMLVersion   string
+	Devices       []*FakeDevice
+	Errors        map[string]Return
+
+	// LibraryPath is the library file Init reports to have loaded. If it
+	// is empty, Init reports the first candidate library file.
+	LibraryPath string
+	// InitFlags are the flags passed to the last Init.
+	InitFlags InitFlags
+	// CpuAffinity are the CPUs the calling thread was bound to by the last
+	// nvmlDeviceSetCpuAffinity, nil if none or cleared.
+	CpuAffinity []uint
+
+	initialized bool
+	eventSets   []*fakeEventSet
+}
+
+// FakeDevice is a device served by a FakeBackend. The fields hold the values
+// returned by the NVML functions of the same name.
+type FakeDevice struct {
+	Name             string
+	UUID             string
+	Serial           string
+	VbiosVersion     string
+	PciInfo          PciInfo
+	MinorNumber      uint
+	BoardID          uint
+	Brand            DeviceBrand
+	ComputeMode      ComputeMode
+	DisplayMode      EnableState
+	DisplayActive    EnableState
+	PersistenceMode  EnableState
+	PerformanceState PowerState
+
+	Clocks             map[ClockType]uint
+	MaxClocks          map[ClockType]uint
+	ApplicationsClocks map[ClockType]uint
+	ThrottleReasons    uint64
+	// SupportedClocks maps the supported memory clocks to the graphics
+	// clocks supported with each of them. If it is nil, any applications
+	// clocks can be set.
+	SupportedClocks           map[uint][]uint
+	DefaultApplicationsClocks map[ClockType]uint
+	MaxCustomerBoostClocks    map[ClockType]uint
+	// LockedClocks are the min and max GPU clocks set by
+	// SetGpuLockedClocks, or zero if the clocks are not locked.
+	LockedClocks     [2]uint
+	AutoBoost        EnableState
+	DefaultAutoBoost EnableState
+
+	MemoryTotal uint64
+	MemoryUsed  uint64
+	BAR1Total   uint64
+	BAR1Used    uint64
+	EccErrors   map[FakeEccCounter]uint64
+	// MemoryErrors are the ECC error counts of each memory location, the
+	// detailed ECC errors are derived from them.
+	MemoryErrors   map[MemoryLocation]EccCounts
+	EccMode        EnableState
+	PendingEccMode EnableState
+
+	RetiredPages         []RetiredPage
+	RetiredPagesPending  EnableState
+	RemappedRows         RemappedRows
+	RowRemapperHistogram RowRemapperHistogram
+
+	GPUUtilization    uint
+	MemoryUtilization uint
+	// Samples are the samples of each type held by NVML, in the order of
+	// its ring buffer. The slots not written yet have the timestamp
+	// time.Unix(0, 0).
+	Samples map[SamplingType][]Sample
+
+	PowerUsage             uint
+	PowerLimit             uint
+	EnforcedPowerLimit     uint
+	DefaultPowerLimit      uint
+	MinPowerLimit          uint
+	MaxPowerLimit          uint
+	PowerManagementMode    EnableState
+	TotalEnergyConsumption uint64
+
+	PcieThroughput        map[PcieUtilCounter]uint
+	PcieLinkGeneration    uint
+	PcieLinkWidth         uint
+	MaxPcieLinkGeneration uint
+	MaxPcieLinkWidth      uint
+
+	Temperature           uint
+	TemperatureThresholds map[TemperatureThreshold]uint
+	FanSpeed              uint
+
+	EncoderUtilization uint
+	DecoderUtilization uint
+	SamplingPeriodUs   uint
+	EncoderCapacity    map[EncoderType]uint
+
+	AccountingMode       EnableState
+	AccountingBufferSize uint
+	AccountingStats      map[uint]AccountingStats
+
+	ComputeProcesses   []FakeProcess
+	GraphicsProcesses  []FakeProcess
+	ProcessUtilization []Utilization
+
+	// SupportedEventTypes are the event types that can be registered for
+	// the device, see FakeBackend.InjectEvent.
+	SupportedEventTypes EventType
+
+	// FieldValues are returned by FieldValues, the missing fields fail with
+	// ReturnErrorNotSupported.
+	FieldValues map[FieldID]Value
+	// ScopedFieldValues are the values of fields queried with a given
+	// ScopeID. They take precedence over FieldValues.
+	ScopedFieldValues map[FakeFieldScope]Value
+
+	// NvLinks are the links of the device by link number. Like NVML, the
+	// nil ones fail with ReturnErrorNotSupported and those beyond the end
+	// with ReturnErrorInvalidArgument.
+	NvLinks []*FakeNvLink
+
+	// Topology maps the other devices to their common ancestor with this
+	// one, TopologySystem if missing. Setting it on either device of a
+	// pair is enough, and so it is for P2P.
+	Topology map[*FakeDevice]TopologyLevel
+	// P2P is the P2P status with the other devices, P2PStatusNotSupported
+	// if missing.
+	P2P map[FakeP2PCaps]P2PStatus
+	// CPUs are the CPUs of the NUMA node the device has an affinity with
+	// and SocketCPUs those of its CPU socket, the CPUs if empty. NumaNodes
+	// and SocketNumaNodes are the same for the memory affinity.
+	CPUs            []uint
+	SocketCPUs      []uint
+	NumaNodes       []uint
+	SocketNumaNodes []uint
+
+	MigMode        EnableState
+	PendingMigMode EnableState
+	// GpuInstanceProfiles are the GPU instance profiles that can be created
+	// in MIG mode. Each compute instance of the GpuInstances has a MIG
+	// device, which is a FakeDevice of its own.
+	GpuInstanceProfiles map[GpuInstanceProfile]GpuInstanceProfileInfo
+	GpuInstances        []*FakeGpuInstance
+
+	Errors map[string]Return
+}
+
+// FakeEccCounter identifies one of the ECC error counters of a FakeDevice.
+type FakeEccCounter struct {
+	ErrorType   MemoryErrorType
+	CounterType EccCounterType
+}
+
+// FakeFieldScope identifies a field of a FakeDevice queried with ScopeID.
+type FakeFieldScope struct {
+	FieldID FieldID
+	ScopeID uint
+}
+
+// FakeP2PCaps identifies a P2P capability between a FakeDevice and Device.
+type FakeP2PCaps struct {
+	Device *FakeDevice
+	Index  P2PCapsIndex
+}
+
+// FakeNvLink is an NvLink link of a FakeDevice. Its utilization counters are
+// indexed by counter number.
+type FakeNvLink struct {
+	State               EnableState
+	Version             uint
+	Capabilities        map[NvLinkCapability]bool
+	RemotePciInfo       PciInfo
+	ErrorCounters       map[NvLinkErrorCounter]uint64
+	UtilizationControls [2]NvLinkUtilizationControl
+	RxCounters          [2]uint64
+	TxCounters          [2]uint64
+	Frozen              [2]bool
+}
+
+// FakeGpuInstance is a MIG GPU instance of a FakeDevice.
+type FakeGpuInstance struct {
+	ID                      uint
+	ProfileID               uint
+	Placement               GpuInstancePlacement
+	ComputeInstanceProfiles map[ComputeInstanceProfile]ComputeInstanceProfileInfo
+	ComputeInstances        []*FakeComputeInstance
+}
+
+// FakeComputeInstance is a MIG compute instance of a FakeGpuInstance.
+type FakeComputeInstance struct {
+	ID        uint
+	ProfileID uint
+	// Device is the MIG device of the compute instance, if any.
+	Device *FakeDevice
+}
+
+// FakeProcess is a process running on a FakeDevice.
+type FakeProcess struct {
+	Pid           uint
+	Name          string
+	UsedGpuMemory uint64
+}
+
+// PID returns the process id.
+func (p FakeProcess) PID() uint {
+	return p.Pid
+}
+
+// Memory returns the GPU memory used by the process in bytes.
+func (p FakeProcess) Memory() uint64 {
+	return p.UsedGpuMemory
+}
+
+// NewFakeBackend returns a FakeBackend serving the given devices.
+func NewFakeBackend(devices ...*FakeDevice) *FakeBackend {
+	return &FakeBackend{
+		DriverVersion: "450.80.02",
+		NVMLVersion:   "11.450.80.02",
+		Devices:       devices,
+	}
+}
+
+// check returns the error that fn has to fail with.
+func (f *FakeBackend) check(fn string) error {
+	if !f.initialized {
+		return errLibraryNotLoaded
+	}
+	return newError(fn, f.Errors[fn])
+}
+
+// device returns the FakeDevice identified by h, or the error that fn has to
+// fail with.
+func (f *FakeBackend) device(h DeviceHandle, fn string) (*FakeDevice, error) {
+	if !f.initialized {
+		return nil, errLibraryNotLoaded
+	}
+	d, ok := h.(*FakeDevice)
+	if !ok || d == nil {
+		return nil, newError(fn, ReturnErrorInvalidArgument)
+	}
+	if err := newError(fn, d.Errors[fn]); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (f *FakeBackend) Init(libraries []string, flags InitFlags) (string, error) {
+	f.Lock()
+	defer f.Unlock()
+	if err := newError("nvmlInit", f.Errors["nvmlInit"]); err != nil {
+		return "", err
+	}
+	f.initialized = true
+	f.InitFlags = flags
+	if f.LibraryPath != "" {
+		return f.LibraryPath, nil
+	}
+	if len(libraries) == 0 {
+		return defaultLibrary, nil
+	}
+	return libraries[0], nil
+}
+
+func (f *FakeBackend) Shutdown() error {
+	f.Lock()
+	defer f.Unlock()
+	if !f.initialized {
+		return nil
+	}
+	if err := newError("nvmlShutdown", f.Errors["nvmlShutdown"]); err != nil {
+		return err
+	}
+	f.initialized = false
+	return nil
+}
+
+// SupportedFunctions reports every NVML function as exported, except those
+// whose Errors entry is ReturnErrorFunctionNotFound.
+func (f *FakeBackend) SupportedFunctions() ([]string, error) {
+	f.Lock()
+	defer f.Unlock()
+	if !f.initialized {
+		return nil, errLibraryNotLoaded
+	}
+	var names []string
+	for _, fn := range nvmlFunctions() {
+		if f.Errors[fn] != ReturnErrorFunctionNotFound {
+			names = append(names, fn)
+		}
+	}
+	return names, nil
+}
+
+func (f *FakeBackend) SystemGetDriverVersion() (string, error) {
+	f.Lock()
+	defer f.Unlock()
+	if err := f.check("nvmlSystemGetDriverVersion"); err != nil {
+		return "", err
+	}
+	return f.DriverVersion, nil
+}
+
+func (f *FakeBackend) SystemGetNVMLVersion() (string, error) {
+	f.Lock()
+	defer f.Unlock()
+	if err := f.check("nvmlSystemGetNVMLVersion"); err != nil {
+		return "", err
+	}
+	return f.NVMLVersion, nil
+}
+
+func (f *FakeBackend) SystemGetProcessName(pid, length uint) (string, error) {
+	f.Lock()
+	defer f.Unlock()
+	if err := f.check("nvmlSystemGetProcessName"); err != nil {
+		return "", err
+	}
+	for _, d := range f.Devices {
+		for _, procs := range [][]FakeProcess{d.ComputeProcesses, d.GraphicsProcesses} {
+			for _, p := range procs {
+				if p.Pid != pid {
+					continue
+				}
+				// Like NVML, truncate the name to fit in a buffer of length
+				// bytes including the NUL terminator.
+				name := p.Name
+				if length > 0 && uint(len(name)) >= length {
+					name = name[:length-1]
+				}
+				return name, nil
+			}
+		}
+	}
+	return "", newError("nvmlSystemGetProcessName", ReturnErrorNotFound)
+}
+
+func (f *FakeBackend) DeviceGetCount() (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	if err := f.check("nvmlDeviceGetCount"); err != nil {
+		return 0, err
+	}
+	return uint(len(f.Devices)), nil
+}
+
+func (f *FakeBackend) DeviceGetHandleByIndex(idx uint) (DeviceHandle, error) {
+	f.Lock()
+	defer f.Unlock()
+	if err := f.check("nvmlDeviceGetHandleByIndex"); err != nil {
+		return nil, err
+	}
+	if idx >= uint(len(f.Devices)) {
+		return nil, newError("nvmlDeviceGetHandleByIndex", ReturnErrorInvalidArgument)
+	}
+	return f.Devices[idx], nil
+}
+
+func (f *FakeBackend) DeviceGetHandleByUUID(uuid string) (DeviceHandle, error) {
+	f.Lock()
+	defer f.Unlock()
+	if err := f.check("nvmlDeviceGetHandleByUUID"); err != nil {
+		return nil, err
+	}
+	if uuid == "" {
+		return nil, newError("nvmlDeviceGetHandleByUUID", ReturnErrorInvalidArgument)
+	}
+	for _, d := range f.Devices {
+		if d.UUID == uuid {
+			return d, nil
+		}
+		for _, mig := range d.migDevices() {
+			if mig.UUID == uuid {
+				return mig, nil
+			}
+		}
+	}
+	return nil, newError("nvmlDeviceGetHandleByUUID", ReturnErrorNotFound)
+}
+
+func (f *FakeBackend) DeviceGetHandleByPciBusId(busID string) (DeviceHandle, error) {
+	f.Lock()
+	defer f.Unlock()
+	if err := f.check("nvmlDeviceGetHandleByPciBusId_v2"); err != nil {
+		return nil, err
+	}
+	want, err := normalizePciBusID(busID)
+	if err != nil {
+		return nil, newError("nvmlDeviceGetHandleByPciBusId_v2", ReturnErrorInvalidArgument)
+	}
+	for _, d := range f.Devices {
+		if got, err := normalizePciBusID(d.PciInfo.BusID); err == nil && got == want {
+			return d, nil
+		}
+	}
+	return nil, newError("nvmlDeviceGetHandleByPciBusId_v2", ReturnErrorNotFound)
+}
+
+func (f *FakeBackend) DeviceGetHandleBySerial(serial string) (DeviceHandle, error) {
+	f.Lock()
+	defer f.Unlock()
+	if err := f.check("nvmlDeviceGetHandleBySerial"); err != nil {
+		return nil, err
+	}
+	var found *FakeDevice
+	for _, d := range f.Devices {
+		if serial == "" || d.Serial != serial {
+			continue
+		}
+		if found != nil {
+			// Like NVML, refuse to pick one of the GPUs of a multi-GPU board.
+			return nil, newError("nvmlDeviceGetHandleBySerial", ReturnErrorInvalidArgument)
+		}
+		found = d
+	}
+	if found == nil {
+		return nil, newError("nvmlDeviceGetHandleBySerial", ReturnErrorNotFound)
+	}
+	return found, nil
+}
+
+func (f *FakeBackend) DeviceGetIndex(h DeviceHandle) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetIndex")
+	if err != nil {
+		return 0, err
+	}
+	for i, dev := range f.Devices {
+		if dev == d {
+			return uint(i), nil
+		}
+	}
+	return 0, newError("nvmlDeviceGetIndex", ReturnErrorInvalidArgument)
+}
+
+func (f *FakeBackend) DeviceGetBrand(h DeviceHandle) (DeviceBrand, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetBrand")
+	if err != nil {
+		return DeviceBrandUnknown, err
+	}
+	return d.Brand, nil
+}
+
+func (f *FakeBackend) DeviceGetBoardId(h DeviceHandle) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetBoardId")
+	if err != nil {
+		return 0, err
+	}
+	return d.BoardID, nil
+}
+
+func (f *FakeBackend) DeviceGetComputeMode(h DeviceHandle) (ComputeMode, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetComputeMode")
+	if err != nil {
+		return ComputeModeDefault, err
+	}
+	return d.ComputeMode, nil
+}
+
+func (f *FakeBackend) DeviceSetComputeMode(h DeviceHandle, mode ComputeMode) error {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceSetComputeMode")
+	if err != nil {
+		return err
+	}
+	d.ComputeMode = mode
+	return nil
+}
+
+func (f *FakeBackend) DeviceGetDisplayMode(h DeviceHandle) (EnableState, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetDisplayMode")
+	if err != nil {
+		return -1, err
+	}
+	return d.DisplayMode, nil
+}
+
+func (f *FakeBackend) DeviceGetDisplayActive(h DeviceHandle) (EnableState, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetDisplayActive")
+	if err != nil {
+		return -1, err
+	}
+	return d.DisplayActive, nil
+}
+
+func (f *FakeBackend) DeviceGetVbiosVersion(h DeviceHandle) (string, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetVbiosVersion")
+	if err != nil {
+		return "", err
+	}
+	return d.VbiosVersion, nil
+}
+
+func (f *FakeBackend) DeviceGetCurrentClocksThrottleReasons(h DeviceHandle) (uint64, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetCurrentClocksThrottleReasons")
+	if err != nil {
+		return 0, err
+	}
+	return d.ThrottleReasons, nil
+}
+
+func (f *FakeBackend) DeviceGetTotalEnergyConsumption(h DeviceHandle) (uint64, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetTotalEnergyConsumption")
+	if err != nil {
+		return 0, err
+	}
+	return d.TotalEnergyConsumption, nil
+}
+
+func (f *FakeBackend) DeviceGetTotalEccErrors(h DeviceHandle, errorType MemoryErrorType, counterType EccCounterType) (uint64, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetTotalEccErrors")
+	if err != nil {
+		return 0, err
+	}
+	return d.EccErrors[FakeEccCounter{errorType, counterType}], nil
+}
+
+func (f *FakeBackend) DeviceGetSerial(h DeviceHandle) (string, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetSerial")
+	if err != nil {
+		return "", err
+	}
+	return d.Serial, nil
+}
+
+func (f *FakeBackend) DeviceGetMinorNumber(h DeviceHandle) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetMinorNumber")
+	if err != nil {
+		return 0, err
+	}
+	return d.MinorNumber, nil
+}
+
+func (f *FakeBackend) DeviceGetPciInfo(h DeviceHandle) (PciInfo, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetPciInfo")
+	if err != nil {
+		return PciInfo{}, err
+	}
+	return d.PciInfo, nil
+}
+
+func (f *FakeBackend) DeviceGetUUID(h DeviceHandle) (string, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetUUID")
+	if err != nil {
+		return "", err
+	}
+	return d.UUID, nil
+}
+
+func (f *FakeBackend) DeviceGetName(h DeviceHandle) (string, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetName")
+	if err != nil {
+		return "", err
+	}
+	return d.Name, nil
+}
+
+func (f *FakeBackend) DeviceGetPersistenceMode(h DeviceHandle) (EnableState, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetPersistenceMode")
+	if err != nil {
+		return 0, err
+	}
+	return d.PersistenceMode, nil
+}
+
+func (f *FakeBackend) DeviceSetPersistenceMode(h DeviceHandle, mode EnableState) error {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceSetPersistenceMode")
+	if err != nil {
+		return err
+	}
+	d.PersistenceMode = mode
+	return nil
+}
+
+func (f *FakeBackend) DeviceGetPerformanceState(h DeviceHandle) (PowerState, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetPerformanceState")
+	if err != nil {
+		return 0, err
+	}
+	return d.PerformanceState, nil
+}
+
+func (f *FakeBackend) DeviceGetClockInfo(h DeviceHandle, clockType ClockType) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetClockInfo")
+	if err != nil {
+		return 0, err
+	}
+	return d.Clocks[clockType], nil
+}
+
+func (f *FakeBackend) DeviceGetMaxClockInfo(h DeviceHandle, clockType ClockType) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetMaxClockInfo")
+	if err != nil {
+		return 0, err
+	}
+	return d.MaxClocks[clockType], nil
+}
+
+func (f *FakeBackend) DeviceGetApplicationsClock(h DeviceHandle, clockType ClockType) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetApplicationsClock")
+	if err != nil {
+		return 0, err
+	}
+	return d.ApplicationsClocks[clockType], nil
+}
+
+func (f *FakeBackend) DeviceGetMemoryInfo(h DeviceHandle) (uint64, uint64, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetMemoryInfo")
+	if err != nil {
+		return 0, 0, err
+	}
+	return d.MemoryTotal, d.MemoryUsed, nil
+}
+
+func (f *FakeBackend) DeviceGetBAR1MemoryInfo(h DeviceHandle) (uint64, uint64, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetBAR1MemoryInfo")
+	if err != nil {
+		return 0, 0, err
+	}
+	return d.BAR1Total, d.BAR1Used, nil
+}
+
+func (f *FakeBackend) DeviceGetUtilizationRates(h DeviceHandle) (uint, uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetUtilizationRates")
+	if err != nil {
+		return 0, 0, err
+	}
+	return d.GPUUtilization, d.MemoryUtilization, nil
+}
+
+func (f *FakeBackend) DeviceGetPowerUsage(h DeviceHandle) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetPowerUsage")
+	if err != nil {
+		return 0, err
+	}
+	return d.PowerUsage, nil
+}
+
+func (f *FakeBackend) DeviceGetPowerManagementLimitConstraints(h DeviceHandle) (uint, uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetPowerManagementLimitConstraints")
+	if err != nil {
+		return 0, 0, err
+	}
+	return d.MinPowerLimit, d.MaxPowerLimit, nil
+}
+
+func (f *FakeBackend) DeviceGetPowerManagementLimit(h DeviceHandle) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetPowerManagementLimit")
+	if err != nil {
+		return 0, err
+	}
+	return d.PowerLimit, nil
+}
+
+func (f *FakeBackend) DeviceGetPowerManagementDefaultLimit(h DeviceHandle) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetPowerManagementDefaultLimit")
+	if err != nil {
+		return 0, err
+	}
+	return d.DefaultPowerLimit, nil
+}
+
+func (f *FakeBackend) DeviceGetEnforcedPowerLimit(h DeviceHandle) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetEnforcedPowerLimit")
+	if err != nil {
+		return 0, err
+	}
+	return d.EnforcedPowerLimit, nil
+}
+
+func (f *FakeBackend) DeviceGetPcieThroughput(h DeviceHandle, counter PcieUtilCounter) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetPcieThroughput")
+	if err != nil {
+		return 0, err
+	}
+	return d.PcieThroughput[counter], nil
+}
+
+func (f *FakeBackend) DeviceGetCurrPcieLinkGeneration(h DeviceHandle) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetCurrPcieLinkGeneration")
+	if err != nil {
+		return 0, err
+	}
+	return d.PcieLinkGeneration, nil
+}
+
+func (f *FakeBackend) DeviceGetCurrPcieLinkWidth(h DeviceHandle) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetCurrPcieLinkWidth")
+	if err != nil {
+		return 0, err
+	}
+	return d.PcieLinkWidth, nil
+}
+
+func (f *FakeBackend) DeviceGetMaxPcieLinkGeneration(h DeviceHandle) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetMaxPcieLinkGeneration")
+	if err != nil {
+		return 0, err
+	}
+	return d.MaxPcieLinkGeneration, nil
+}
+
+func (f *FakeBackend) DeviceGetMaxPcieLinkWidth(h DeviceHandle) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetMaxPcieLinkWidth")
+	if err != nil {
+		return 0, err
+	}
+	return d.MaxPcieLinkWidth, nil
+}
+
+func (f *FakeBackend) DeviceGetTemperature(h DeviceHandle, sensor TemperatureSensor) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetTemperature")
+	if err != nil {
+		return 0, err
+	}
+	if sensor != TemperatureSensorGPU {
+		return 0, newError("nvmlDeviceGetTemperature", ReturnErrorInvalidArgument)
+	}
+	return d.Temperature, nil
+}
+
+func (f *FakeBackend) DeviceGetTemperatureThreshold(h DeviceHandle, threshold TemperatureThreshold) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetTemperatureThreshold")
+	if err != nil {
+		return 0, err
+	}
+	return d.TemperatureThresholds[threshold], nil
+}
+
+func (f *FakeBackend) DeviceGetFanSpeed(h DeviceHandle) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetFanSpeed")
+	if err != nil {
+		return 0, err
+	}
+	return d.FanSpeed, nil
+}
+
+func (f *FakeBackend) DeviceGetEncoderUtilization(h DeviceHandle) (uint, uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetEncoderUtilization")
+	if err != nil {
+		return 0, 0, err
+	}
+	return d.EncoderUtilization, d.SamplingPeriodUs, nil
+}
+
+func (f *FakeBackend) DeviceGetEncoderCapacity(h DeviceHandle, encoderType EncoderType) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetEncoderCapacity")
+	if err != nil {
+		return 0, err
+	}
+	return d.EncoderCapacity[encoderType], nil
+}
+
+func (f *FakeBackend) DeviceGetDecoderUtilization(h DeviceHandle) (uint, uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetDecoderUtilization")
+	if err != nil {
+		return 0, 0, err
+	}
+	return d.DecoderUtilization, d.SamplingPeriodUs, nil
+}
+
+func (f *FakeBackend) DeviceGetSamples(h DeviceHandle, samplingType SamplingType, lastSeenTimeStamp uint64) ([]Sample, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetSamples")
+	if err != nil {
+		return nil, err
+	}
+	var samples []Sample
+	for _, s := range d.Samples[samplingType] {
+		if lastSeenTimeStamp == 0 || uint64(s.Timestamp.UnixNano()/1000) > lastSeenTimeStamp {
+			samples = append(samples, s)
+		}
+	}
+	return samples, nil
+}
+
+func (f *FakeBackend) DeviceGetAccountingMode(h DeviceHandle) (EnableState, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetAccountingMode")
+	if err != nil {
+		return 0, err
+	}
+	return d.AccountingMode, nil
+}
+
+func (f *FakeBackend) DeviceGetAccountingStats(h DeviceHandle, pid uint) (AccountingStats, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetAccountingStats")
+	if err != nil {
+		return AccountingStats{}, err
+	}
+	stats, ok := d.AccountingStats[pid]
+	if !ok {
+		return AccountingStats{}, newError("nvmlDeviceGetAccountingStats", ReturnErrorNotFound)
+	}
+	return stats, nil
+}
+
+func (f *FakeBackend) DeviceGetAccountingPids(h DeviceHandle, count uint) ([]uint, uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetAccountingPids")
+	if err != nil {
+		return nil, 0, err
+	}
+	var all []uint
+	for pid := range d.AccountingStats {
+		all = append(all, pid)
+	}
+	if count == 0 {
+		return nil, uint(len(all)), nil
+	}
+	pids := make([]uint, count)
+	copy(pids, all)
+	if count < uint(len(all)) {
+		return pids, uint(len(all)), newError("nvmlDeviceGetAccountingPids", ReturnErrorInsufficientSize)
+	}
+	return pids, uint(len(all)), nil
+}
+
+func (f *FakeBackend) DeviceGetAccountingBufferSize(h DeviceHandle) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetAccountingBufferSize")
+	if err != nil {
+		return 0, err
+	}
+	return d.AccountingBufferSize, nil
+}
+
+func (f *FakeBackend) DeviceGetProcessUtilization(h DeviceHandle, processCount uint, lastSeenTimeStamp uint64) ([]*Utilization, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetProcessUtilization")
+	if err != nil {
+		return nil, err
+	}
+	if uint(len(d.ProcessUtilization)) > processCount {
+		return nil, newError("nvmlDeviceGetProcessUtilization", ReturnErrorInsufficientSize)
+	}
+	var utilizations []*Utilization
+	for i := range d.ProcessUtilization {
+		u := d.ProcessUtilization[i]
+		utilizations = append(utilizations, &u)
+	}
+	return utilizations, nil
+}
+
+func (f *FakeBackend) DeviceGetComputeRunningProcesses(h DeviceHandle) ([]Process, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetComputeRunningProcesses")
+	if err != nil {
+		return nil, err
+	}
+	return fakeProcesses(d.ComputeProcesses), nil
+}
+
+func (f *FakeBackend) DeviceGetGraphicsRunningProcesses(h DeviceHandle) ([]Process, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetGraphicsRunningProcesses")
+	if err != nil {
+		return nil, err
+	}
+	return fakeProcesses(d.GraphicsProcesses), nil
+}
+
+func fakeProcesses(fprocs []FakeProcess) []Process {
+	if len(fprocs) == 0 {
+		return nil
+	}
+	procs := make([]Process, len(fprocs))
+	for i, p := range fprocs {
+		procs[i] = p
+	}
+	return procs
+}
+
+// fakeEventBufferSize is the number of events a fake event set can hold
+// before it starts dropping them.
+const fakeEventBufferSize = 64
+
+// fakeEventSet is an event set created by a FakeBackend.
+type fakeEventSet struct {
+	events     chan EventData
+	registered map[*FakeDevice]EventType
+}
+
+// InjectEvent delivers an event of type t that happened on device d to the
+// event sets that registered it. data is the XID of
+// EventTypeXidCriticalError events.
+func (f *FakeBackend) InjectEvent(d *FakeDevice, t EventType, data uint64) {
+	f.Lock()
+	defer f.Unlock()
+	e := EventData{
+		Device:            d,
+		Type:              t,
+		Data:              data,
+		GpuInstanceID:     NoInstanceID,
+		ComputeInstanceID: NoInstanceID,
+	}
+	for _, set := range f.eventSets {
+		if set.registered[d]&t == 0 {
+			continue
+		}
+		select {
+		case set.events <- e:
+		default:
+		}
+	}
+}
+
+// eventSet returns the fakeEventSet identified by s, or the error that fn
+// has to fail with.
+func (f *FakeBackend) eventSet(s EventSetHandle, fn string) (*fakeEventSet, error) {
+	if err := f.check(fn); err != nil {
+		return nil, err
+	}
+	set, ok := s.(*fakeEventSet)
+	if !ok || set == nil {
+		return nil, newError(fn, ReturnErrorInvalidArgument)
+	}
+	return set, nil
+}
+
+func (f *FakeBackend) EventSetCreate() (EventSetHandle, error) {
+	f.Lock()
+	defer f.Unlock()
+	if err := f.check("nvmlEventSetCreate"); err != nil {
+		return nil, err
+	}
+	set := &fakeEventSet{
+		events:     make(chan EventData, fakeEventBufferSize),
+		registered: make(map[*FakeDevice]EventType),
+	}
+	f.eventSets = append(f.eventSets, set)
+	return set, nil
+}
+
+func (f *FakeBackend) EventSetFree(s EventSetHandle) error {
+	f.Lock()
+	defer f.Unlock()
+	set, err := f.eventSet(s, "nvmlEventSetFree")
+	if err != nil {
+		return err
+	}
+	for i, other := range f.eventSets {
+		if other == set {
+			f.eventSets = append(f.eventSets[:i], f.eventSets[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (f *FakeBackend) EventSetWait(s EventSetHandle, timeoutMs uint) (EventData, error) {
+	f.Lock()
+	set, err := f.eventSet(s, "nvmlEventSetWait_v2")
+	f.Unlock()
+	if err != nil {
+		return EventData{}, err
+	}
+	timer := time.NewTimer(time.Duration(timeoutMs) * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case e := <-set.events:
+		return e, nil
+	case <-timer.C:
+		return EventData{}, newError("nvmlEventSetWait_v2", ReturnErrorTimeout)
+	}
+}
+
+func (f *FakeBackend) DeviceRegisterEvents(h DeviceHandle, eventTypes EventType, s EventSetHandle) error {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceRegisterEvents")
+	if err != nil {
+		return err
+	}
+	set, err := f.eventSet(s, "nvmlDeviceRegisterEvents")
+	if err != nil {
+		return err
+	}
+	if eventTypes&^d.SupportedEventTypes != 0 {
+		return newError("nvmlDeviceRegisterEvents", ReturnErrorNotSupported)
+	}
+	set.registered[d] |= eventTypes
+	return nil
+}
+
+func (f *FakeBackend) DeviceGetSupportedEventTypes(h DeviceHandle) (EventType, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetSupportedEventTypes")
+	if err != nil {
+		return EventTypeNone, err
+	}
+	return d.SupportedEventTypes, nil
+}
+
+func (f *FakeBackend) DeviceGetFieldValues(h DeviceHandle, values []FieldValue) error {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetFieldValues")
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for i := range values {
+		values[i].Timestamp = now
+		values[i].Latency = 0
+		v, ok := d.ScopedFieldValues[FakeFieldScope{values[i].FieldID, values[i].ScopeID}]
+		if !ok {
+			v, ok = d.FieldValues[values[i].FieldID]
+		}
+		if !ok {
+			values[i].Value = Value{}
+			values[i].Err = newError("nvmlDeviceGetFieldValues", ReturnErrorNotSupported)
+			continue
+		}
+		values[i].Value = v
+		values[i].Err = nil
+	}
+	return nil
+}
+
+// nvLink returns the FakeNvLink of the device identified by h, or the error
+// that fn has to fail with.
+func (f *FakeBackend) nvLink(h DeviceHandle, link uint, fn string) (*FakeNvLink, error) {
+	d, err := f.device(h, fn)
+	if err != nil {
+		return nil, err
+	}
+	if len(d.NvLinks) == 0 {
+		return nil, newError(fn, ReturnErrorNotSupported)
+	}
+	if link >= uint(len(d.NvLinks)) {
+		return nil, newError(fn, ReturnErrorInvalidArgument)
+	}
+	if d.NvLinks[link] == nil {
+		return nil, newError(fn, ReturnErrorNotSupported)
+	}
+	return d.NvLinks[link], nil
+}
+
+// nvLinkCounter is like nvLink but also validates the utilization counter.
+func (f *FakeBackend) nvLinkCounter(h DeviceHandle, link, counter uint, fn string) (*FakeNvLink, error) {
+	l, err := f.nvLink(h, link, fn)
+	if err != nil {
+		return nil, err
+	}
+	if counter >= uint(len(l.UtilizationControls)) {
+		return nil, newError(fn, ReturnErrorInvalidArgument)
+	}
+	return l, nil
+}
+
+func (f *FakeBackend) DeviceGetNvLinkState(h DeviceHandle, link uint) (EnableState, error) {
+	f.Lock()
+	defer f.Unlock()
+	l, err := f.nvLink(h, link, "nvmlDeviceGetNvLinkState")
+	if err != nil {
+		return 0, err
+	}
+	return l.State, nil
+}
+
+func (f *FakeBackend) DeviceGetNvLinkVersion(h DeviceHandle, link uint) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	l, err := f.nvLink(h, link, "nvmlDeviceGetNvLinkVersion")
+	if err != nil {
+		return 0, err
+	}
+	return l.Version, nil
+}
+
+func (f *FakeBackend) DeviceGetNvLinkCapability(h DeviceHandle, link uint, capability NvLinkCapability) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	l, err := f.nvLink(h, link, "nvmlDeviceGetNvLinkCapability")
+	if err != nil {
+		return 0, err
+	}
+	if l.Capabilities[capability] {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (f *FakeBackend) DeviceGetNvLinkRemotePciInfo(h DeviceHandle, link uint) (PciInfo, error) {
+	f.Lock()
+	defer f.Unlock()
+	l, err := f.nvLink(h, link, "nvmlDeviceGetNvLinkRemotePciInfo_v2")
+	if err != nil {
+		return PciInfo{}, err
+	}
+	return l.RemotePciInfo, nil
+}
+
+func (f *FakeBackend) DeviceGetNvLinkErrorCounter(h DeviceHandle, link uint, counter NvLinkErrorCounter) (uint64, error) {
+	f.Lock()
+	defer f.Unlock()
+	l, err := f.nvLink(h, link, "nvmlDeviceGetNvLinkErrorCounter")
+	if err != nil {
+		return 0, err
+	}
+	return l.ErrorCounters[counter], nil
+}
+
+func (f *FakeBackend) DeviceResetNvLinkErrorCounters(h DeviceHandle, link uint) error {
+	f.Lock()
+	defer f.Unlock()
+	l, err := f.nvLink(h, link, "nvmlDeviceResetNvLinkErrorCounters")
+	if err != nil {
+		return err
+	}
+	l.ErrorCounters = nil
+	return nil
+}
+
+func (f *FakeBackend) DeviceSetNvLinkUtilizationControl(h DeviceHandle, link, counter uint, control NvLinkUtilizationControl, reset bool) error {
+	f.Lock()
+	defer f.Unlock()
+	l, err := f.nvLinkCounter(h, link, counter, "nvmlDeviceSetNvLinkUtilizationControl")
+	if err != nil {
+		return err
+	}
+	l.UtilizationControls[counter] = control
+	if reset {
+		l.RxCounters[counter], l.TxCounters[counter] = 0, 0
+	}
+	return nil
+}
+
+func (f *FakeBackend) DeviceGetNvLinkUtilizationControl(h DeviceHandle, link, counter uint) (NvLinkUtilizationControl, error) {
+	f.Lock()
+	defer f.Unlock()
+	l, err := f.nvLinkCounter(h, link, counter, "nvmlDeviceGetNvLinkUtilizationControl")
+	if err != nil {
+		return NvLinkUtilizationControl{}, err
+	}
+	return l.UtilizationControls[counter], nil
+}
+
+func (f *FakeBackend) DeviceGetNvLinkUtilizationCounter(h DeviceHandle, link, counter uint) (uint64, uint64, error) {
+	f.Lock()
+	defer f.Unlock()
+	l, err := f.nvLinkCounter(h, link, counter, "nvmlDeviceGetNvLinkUtilizationCounter")
+	if err != nil {
+		return 0, 0, err
+	}
+	return l.RxCounters[counter], l.TxCounters[counter], nil
+}
+
+func (f *FakeBackend) DeviceFreezeNvLinkUtilizationCounter(h DeviceHandle, link, counter uint, freeze EnableState) error {
+	f.Lock()
+	defer f.Unlock()
+	l, err := f.nvLinkCounter(h, link, counter, "nvmlDeviceFreezeNvLinkUtilizationCounter")
+	if err != nil {
+		return err
+	}
+	l.Frozen[counter] = freeze == EnableStateFeatureEnabled
+	return nil
+}
+
+func (f *FakeBackend) DeviceResetNvLinkUtilizationCounter(h DeviceHandle, link, counter uint) error {
+	f.Lock()
+	defer f.Unlock()
+	l, err := f.nvLinkCounter(h, link, counter, "nvmlDeviceResetNvLinkUtilizationCounter")
+	if err != nil {
+		return err
+	}
+	l.RxCounters[counter], l.TxCounters[counter] = 0, 0
+	return nil
+}
+
+// fakeMaxMigDevices is the number of MIG devices of a FakeDevice in MIG
+// mode, the number of slices of an A100.
+const fakeMaxMigDevices = 7
+
+// migDevice returns the device identified by h if it is in MIG mode, or the
+// error that fn has to fail with.
+func (f *FakeBackend) migDevice(h DeviceHandle, fn string) (*FakeDevice, error) {
+	d, err := f.device(h, fn)
+	if err != nil {
+		return nil, err
+	}
+	if d.MigMode != EnableStateFeatureEnabled {
+		return nil, newError(fn, ReturnErrorNotSupported)
+	}
+	return d, nil
+}
+
+// gpuInstance returns the FakeGpuInstance identified by gi and its device, or
+// the error that fn has to fail with.
+func (f *FakeBackend) gpuInstance(gi GpuInstanceHandle, fn string) (*FakeDevice, *FakeGpuInstance, error) {
+	if err := f.check(fn); err != nil {
+		return nil, nil, err
+	}
+	instance, _ := gi.(*FakeGpuInstance)
+	for _, d := range f.Devices {
+		for _, other := range d.GpuInstances {
+			if instance != nil && other == instance {
+				return d, instance, nil
+			}
+		}
+	}
+	return nil, nil, newError(fn, ReturnErrorInvalidArgument)
+}
+
+// computeInstance returns the FakeComputeInstance identified by ci and its
+// parents, or the error that fn has to fail with.
+func (f *FakeBackend) computeInstance(ci ComputeInstanceHandle, fn string) (*FakeDevice, *FakeGpuInstance, *FakeComputeInstance, error) {
+	if err := f.check(fn); err != nil {
+		return nil, nil, nil, err
+	}
+	instance, _ := ci.(*FakeComputeInstance)
+	for _, d := range f.Devices {
+		for _, gi := range d.GpuInstances {
+			for _, other := range gi.ComputeInstances {
+				if instance != nil && other == instance {
+					return d, gi, instance, nil
+				}
+			}
+		}
+	}
+	return nil, nil, nil, newError(fn, ReturnErrorInvalidArgument)
+}
+
+// migParents returns the parents of d if it is a MIG device.
+func (f *FakeBackend) migParents(d *FakeDevice) (*FakeDevice, *FakeGpuInstance, *FakeComputeInstance, bool) {
+	for _, parent := range f.Devices {
+		for _, gi := range parent.GpuInstances {
+			for _, ci := range gi.ComputeInstances {
+				if ci.Device == d {
+					return parent, gi, ci, true
+				}
+			}
+		}
+	}
+	return nil, nil, nil, false
+}
+
+// migDevices returns the MIG devices of d.
+func (d *FakeDevice) migDevices() []*FakeDevice {
+	var devices []*FakeDevice
+	for _, gi := range d.GpuInstances {
+		for _, ci := range gi.ComputeInstances {
+			if ci.Device != nil {
+				devices = append(devices, ci.Device)
+			}
+		}
+	}
+	return devices
+}
+
+// gpuInstanceProfile returns the GPU instance profile of d with the given ID.
+func (d *FakeDevice) gpuInstanceProfile(profileID uint) (GpuInstanceProfileInfo, bool) {
+	for _, info := range d.GpuInstanceProfiles {
+		if info.ID == profileID {
+			return info, true
+		}
+	}
+	return GpuInstanceProfileInfo{}, false
+}
+
+// gpuInstanceCount returns how many GPU instances of the given profile d has.
+func (d *FakeDevice) gpuInstanceCount(profileID uint) uint {
+	var n uint
+	for _, gi := range d.GpuInstances {
+		if gi.ProfileID == profileID {
+			n++
+		}
+	}
+	return n
+}
+
+// fakeGpuInstancePlacements returns the placements of the GPU instances of
+// the given profile: they are laid out one after the other.
+func fakeGpuInstancePlacements(profile GpuInstanceProfileInfo) []GpuInstancePlacement {
+	placements := make([]GpuInstancePlacement, profile.InstanceCount)
+	for i := range placements {
+		placements[i] = GpuInstancePlacement{Start: uint(i) * profile.SliceCount, Size: profile.SliceCount}
+	}
+	return placements
+}
+
+// computeInstanceProfile returns the compute instance profile of gi with the
+// given ID.
+func (gi *FakeGpuInstance) computeInstanceProfile(profileID uint) (ComputeInstanceProfileInfo, bool) {
+	for _, info := range gi.ComputeInstanceProfiles {
+		if info.ID == profileID {
+			return info, true
+		}
+	}
+	return ComputeInstanceProfileInfo{}, false
+}
+
+// computeInstanceCount returns how many compute instances of the given
+// profile gi has.
+func (gi *FakeGpuInstance) computeInstanceCount(profileID uint) uint {
+	var n uint
+	for _, ci := range gi.ComputeInstances {
+		if ci.ProfileID == profileID {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *FakeBackend) DeviceGetMigMode(h DeviceHandle) (EnableState, EnableState, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetMigMode")
+	if err != nil {
+		return 0, 0, err
+	}
+	return d.MigMode, d.PendingMigMode, nil
+}
+
+func (f *FakeBackend) DeviceSetMigMode(h DeviceHandle, mode EnableState) (Return, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceSetMigMode")
+	if err != nil {
+		return ReturnSuccess, err
+	}
+	if mode != EnableStateFeatureEnabled && mode != EnableStateFeatureDisabled {
+		return ReturnSuccess, newError("nvmlDeviceSetMigMode", ReturnErrorInvalidArgument)
+	}
+	d.PendingMigMode = mode
+	if len(d.GpuInstances) > 0 || len(d.ComputeProcesses) > 0 || len(d.GraphicsProcesses) > 0 {
+		return ReturnErrorInUse, nil
+	}
+	d.MigMode = mode
+	return ReturnSuccess, nil
+}
+
+func (f *FakeBackend) DeviceGetGpuInstanceProfileInfo(h DeviceHandle, profile GpuInstanceProfile) (GpuInstanceProfileInfo, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.migDevice(h, "nvmlDeviceGetGpuInstanceProfileInfo")
+	if err != nil {
+		return GpuInstanceProfileInfo{}, err
+	}
+	info, ok := d.GpuInstanceProfiles[profile]
+	if !ok {
+		return GpuInstanceProfileInfo{}, newError("nvmlDeviceGetGpuInstanceProfileInfo", ReturnErrorNotSupported)
+	}
+	return info, nil
+}
+
+func (f *FakeBackend) DeviceGetGpuInstancePossiblePlacements(h DeviceHandle, profileID, count uint) ([]GpuInstancePlacement, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.migDevice(h, "nvmlDeviceGetGpuInstancePossiblePlacements")
+	if err != nil {
+		return nil, err
+	}
+	profile, ok := d.gpuInstanceProfile(profileID)
+	if !ok {
+		return nil, newError("nvmlDeviceGetGpuInstancePossiblePlacements", ReturnErrorNotSupported)
+	}
+	placements := fakeGpuInstancePlacements(profile)
+	if count < uint(len(placements)) {
+		return nil, newError("nvmlDeviceGetGpuInstancePossiblePlacements", ReturnErrorInsufficientSize)
+	}
+	return placements, nil
+}
+
+func (f *FakeBackend) DeviceGetGpuInstanceRemainingCapacity(h DeviceHandle, profileID uint) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.migDevice(h, "nvmlDeviceGetGpuInstanceRemainingCapacity")
+	if err != nil {
+		return 0, err
+	}
+	profile, ok := d.gpuInstanceProfile(profileID)
+	if !ok {
+		return 0, newError("nvmlDeviceGetGpuInstanceRemainingCapacity", ReturnErrorNotSupported)
+	}
+	return profile.InstanceCount - d.gpuInstanceCount(profileID), nil
+}
+
+func (f *FakeBackend) DeviceCreateGpuInstance(h DeviceHandle, profileID uint) (GpuInstanceHandle, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.migDevice(h, "nvmlDeviceCreateGpuInstance")
+	if err != nil {
+		return nil, err
+	}
+	profile, ok := d.gpuInstanceProfile(profileID)
+	if !ok {
+		return nil, newError("nvmlDeviceCreateGpuInstance", ReturnErrorInvalidArgument)
+	}
+	var id uint
+	used := make(map[GpuInstancePlacement]bool)
+	for _, gi := range d.GpuInstances {
+		if gi.ID >= id {
+			id = gi.ID + 1
+		}
+		used[gi.Placement] = true
+	}
+	for _, placement := range fakeGpuInstancePlacements(profile) {
+		if used[placement] {
+			continue
+		}
+		gi := &FakeGpuInstance{ID: id, ProfileID: profileID, Placement: placement}
+		d.GpuInstances = append(d.GpuInstances, gi)
+		return gi, nil
+	}
+	return nil, newError("nvmlDeviceCreateGpuInstance", ReturnErrorInsufficientResources)
+}
+
+func (f *FakeBackend) DeviceGetGpuInstances(h DeviceHandle, profileID, count uint) ([]GpuInstanceHandle, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.migDevice(h, "nvmlDeviceGetGpuInstances")
+	if err != nil {
+		return nil, err
+	}
+	var instances []GpuInstanceHandle
+	for _, gi := range d.GpuInstances {
+		if gi.ProfileID == profileID {
+			instances = append(instances, gi)
+		}
+	}
+	if count < uint(len(instances)) {
+		return nil, newError("nvmlDeviceGetGpuInstances", ReturnErrorInsufficientSize)
+	}
+	return instances, nil
+}
+
+func (f *FakeBackend) DeviceGetGpuInstanceById(h DeviceHandle, id uint) (GpuInstanceHandle, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.migDevice(h, "nvmlDeviceGetGpuInstanceById")
+	if err != nil {
+		return nil, err
+	}
+	for _, gi := range d.GpuInstances {
+		if gi.ID == id {
+			return gi, nil
+		}
+	}
+	return nil, newError("nvmlDeviceGetGpuInstanceById", ReturnErrorNotFound)
+}
+
+func (f *FakeBackend) GpuInstanceDestroy(h GpuInstanceHandle) error {
+	f.Lock()
+	defer f.Unlock()
+	d, gi, err := f.gpuInstance(h, "nvmlGpuInstanceDestroy")
+	if err != nil {
+		return err
+	}
+	if len(gi.ComputeInstances) > 0 {
+		return newError("nvmlGpuInstanceDestroy", ReturnErrorInUse)
+	}
+	for i, other := range d.GpuInstances {
+		if other == gi {
+			d.GpuInstances = append(d.GpuInstances[:i], d.GpuInstances[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (f *FakeBackend) GpuInstanceGetInfo(h GpuInstanceHandle) (DeviceHandle, GpuInstanceInfo, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, gi, err := f.gpuInstance(h, "nvmlGpuInstanceGetInfo")
+	if err != nil {
+		return nil, GpuInstanceInfo{}, err
+	}
+	return d, GpuInstanceInfo{ID: gi.ID, ProfileID: gi.ProfileID, Placement: gi.Placement}, nil
+}
+
+func (f *FakeBackend) GpuInstanceGetComputeInstanceProfileInfo(h GpuInstanceHandle, profile ComputeInstanceProfile, engProfile ComputeInstanceEngineProfile) (ComputeInstanceProfileInfo, error) {
+	f.Lock()
+	defer f.Unlock()
+	_, gi, err := f.gpuInstance(h, "nvmlGpuInstanceGetComputeInstanceProfileInfo")
+	if err != nil {
+		return ComputeInstanceProfileInfo{}, err
+	}
+	if engProfile != ComputeInstanceEngineProfileShared {
+		return ComputeInstanceProfileInfo{}, newError("nvmlGpuInstanceGetComputeInstanceProfileInfo", ReturnErrorInvalidArgument)
+	}
+	info, ok := gi.ComputeInstanceProfiles[profile]
+	if !ok {
+		return ComputeInstanceProfileInfo{}, newError("nvmlGpuInstanceGetComputeInstanceProfileInfo", ReturnErrorNotSupported)
+	}
+	return info, nil
+}
+
+func (f *FakeBackend) GpuInstanceGetComputeInstanceRemainingCapacity(h GpuInstanceHandle, profileID uint) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	_, gi, err := f.gpuInstance(h, "nvmlGpuInstanceGetComputeInstanceRemainingCapacity")
+	if err != nil {
+		return 0, err
+	}
+	profile, ok := gi.computeInstanceProfile(profileID)
+	if !ok {
+		return 0, newError("nvmlGpuInstanceGetComputeInstanceRemainingCapacity", ReturnErrorNotSupported)
+	}
+	return profile.InstanceCount - gi.computeInstanceCount(profileID), nil
+}
+
+func (f *FakeBackend) GpuInstanceCreateComputeInstance(h GpuInstanceHandle, profileID uint) (ComputeInstanceHandle, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, gi, err := f.gpuInstance(h, "nvmlGpuInstanceCreateComputeInstance")
+	if err != nil {
+		return nil, err
+	}
+	profile, ok := gi.computeInstanceProfile(profileID)
+	if !ok {
+		return nil, newError("nvmlGpuInstanceCreateComputeInstance", ReturnErrorNotSupported)
+	}
+	if gi.computeInstanceCount(profileID) >= profile.InstanceCount {
+		return nil, newError("nvmlGpuInstanceCreateComputeInstance", ReturnErrorInsufficientResources)
+	}
+	var id uint
+	for _, ci := range gi.ComputeInstances {
+		if ci.ID >= id {
+			id = ci.ID + 1
+		}
+	}
+	giProfile, _ := d.gpuInstanceProfile(gi.ProfileID)
+	ci := &FakeComputeInstance{
+		ID:        id,
+		ProfileID: profileID,
+		Device: &FakeDevice{
+			Name:        d.Name,
+			UUID:        fmt.Sprintf("MIG-%s/%d/%d", d.UUID, gi.ID, id),
+			MemoryTotal: giProfile.MemorySizeMB << 20,
+		},
+	}
+	gi.ComputeInstances = append(gi.ComputeInstances, ci)
+	return ci, nil
+}
+
+func (f *FakeBackend) GpuInstanceGetComputeInstances(h GpuInstanceHandle, profileID, count uint) ([]ComputeInstanceHandle, error) {
+	f.Lock()
+	defer f.Unlock()
+	_, gi, err := f.gpuInstance(h, "nvmlGpuInstanceGetComputeInstances")
+	if err != nil {
+		return nil, err
+	}
+	var instances []ComputeInstanceHandle
+	for _, ci := range gi.ComputeInstances {
+		if ci.ProfileID == profileID {
+			instances = append(instances, ci)
+		}
+	}
+	if count < uint(len(instances)) {
+		return nil, newError("nvmlGpuInstanceGetComputeInstances", ReturnErrorInsufficientSize)
+	}
+	return instances, nil
+}
+
+func (f *FakeBackend) GpuInstanceGetComputeInstanceById(h GpuInstanceHandle, id uint) (ComputeInstanceHandle, error) {
+	f.Lock()
+	defer f.Unlock()
+	_, gi, err := f.gpuInstance(h, "nvmlGpuInstanceGetComputeInstanceById")
+	if err != nil {
+		return nil, err
+	}
+	for _, ci := range gi.ComputeInstances {
+		if ci.ID == id {
+			return ci, nil
+		}
+	}
+	return nil, newError("nvmlGpuInstanceGetComputeInstanceById", ReturnErrorNotFound)
+}
+
+func (f *FakeBackend) ComputeInstanceDestroy(h ComputeInstanceHandle) error {
+	f.Lock()
+	defer f.Unlock()
+	_, gi, ci, err := f.computeInstance(h, "nvmlComputeInstanceDestroy")
+	if err != nil {
+		return err
+	}
+	if ci.Device != nil && (len(ci.Device.ComputeProcesses) > 0 || len(ci.Device.GraphicsProcesses) > 0) {
+		return newError("nvmlComputeInstanceDestroy", ReturnErrorInUse)
+	}
+	for i, other := range gi.ComputeInstances {
+		if other == ci {
+			gi.ComputeInstances = append(gi.ComputeInstances[:i], gi.ComputeInstances[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (f *FakeBackend) ComputeInstanceGetInfo(h ComputeInstanceHandle) (DeviceHandle, GpuInstanceHandle, ComputeInstanceInfo, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, gi, ci, err := f.computeInstance(h, "nvmlComputeInstanceGetInfo")
+	if err != nil {
+		return nil, nil, ComputeInstanceInfo{}, err
+	}
+	return d, gi, ComputeInstanceInfo{ID: ci.ID, ProfileID: ci.ProfileID}, nil
+}
+
+func (f *FakeBackend) DeviceIsMigDeviceHandle(h DeviceHandle) (bool, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceIsMigDeviceHandle")
+	if err != nil {
+		return false, err
+	}
+	_, _, _, ok := f.migParents(d)
+	return ok, nil
+}
+
+func (f *FakeBackend) DeviceGetGpuInstanceId(h DeviceHandle) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetGpuInstanceId")
+	if err != nil {
+		return 0, err
+	}
+	_, gi, _, ok := f.migParents(d)
+	if !ok {
+		return 0, newError("nvmlDeviceGetGpuInstanceId", ReturnErrorNotSupported)
+	}
+	return gi.ID, nil
+}
+
+func (f *FakeBackend) DeviceGetComputeInstanceId(h DeviceHandle) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetComputeInstanceId")
+	if err != nil {
+		return 0, err
+	}
+	_, _, ci, ok := f.migParents(d)
+	if !ok {
+		return 0, newError("nvmlDeviceGetComputeInstanceId", ReturnErrorNotSupported)
+	}
+	return ci.ID, nil
+}
+
+func (f *FakeBackend) DeviceGetMaxMigDeviceCount(h DeviceHandle) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetMaxMigDeviceCount")
+	if err != nil {
+		return 0, err
+	}
+	if d.MigMode != EnableStateFeatureEnabled {
+		return 0, nil
+	}
+	return fakeMaxMigDevices, nil
+}
+
+func (f *FakeBackend) DeviceGetMigDeviceHandleByIndex(h DeviceHandle, index uint) (DeviceHandle, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.migDevice(h, "nvmlDeviceGetMigDeviceHandleByIndex")
+	if err != nil {
+		return nil, err
+	}
+	if index >= fakeMaxMigDevices {
+		return nil, newError("nvmlDeviceGetMigDeviceHandleByIndex", ReturnErrorInvalidArgument)
+	}
+	devices := d.migDevices()
+	if index >= uint(len(devices)) {
+		return nil, newError("nvmlDeviceGetMigDeviceHandleByIndex", ReturnErrorNotFound)
+	}
+	return devices[index], nil
+}
+
+func (f *FakeBackend) DeviceGetDeviceHandleFromMigDeviceHandle(h DeviceHandle) (DeviceHandle, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetDeviceHandleFromMigDeviceHandle")
+	if err != nil {
+		return nil, err
+	}
+	parent, _, _, ok := f.migParents(d)
+	if !ok {
+		return nil, newError("nvmlDeviceGetDeviceHandleFromMigDeviceHandle", ReturnErrorInvalidArgument)
+	}
+	return parent, nil
+}
+
+func (f *FakeBackend) DeviceGetDefaultApplicationsClock(h DeviceHandle, clockType ClockType) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetDefaultApplicationsClock")
+	if err != nil {
+		return 0, err
+	}
+	return d.DefaultApplicationsClocks[clockType], nil
+}
+
+func (f *FakeBackend) DeviceGetMaxCustomerBoostClock(h DeviceHandle, clockType ClockType) (uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetMaxCustomerBoostClock")
+	if err != nil {
+		return 0, err
+	}
+	return d.MaxCustomerBoostClocks[clockType], nil
+}
+
+func (f *FakeBackend) DeviceGetSupportedMemoryClocks(h DeviceHandle) ([]uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetSupportedMemoryClocks")
+	if err != nil {
+		return nil, err
+	}
+	clocks := make([]uint, 0, len(d.SupportedClocks))
+	for mem := range d.SupportedClocks {
+		clocks = append(clocks, mem)
+	}
+	// Like NVML, list the highest clocks first.
+	sort.Slice(clocks, func(i, j int) bool { return clocks[i] > clocks[j] })
+	return clocks, nil
+}
+
+func (f *FakeBackend) DeviceGetSupportedGraphicsClocks(h DeviceHandle, memoryClockMHz uint) ([]uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetSupportedGraphicsClocks")
+	if err != nil {
+		return nil, err
+	}
+	graphics, ok := d.SupportedClocks[memoryClockMHz]
+	if !ok {
+		return nil, newError("nvmlDeviceGetSupportedGraphicsClocks", ReturnErrorNotFound)
+	}
+	clocks := append([]uint(nil), graphics...)
+	sort.Slice(clocks, func(i, j int) bool { return clocks[i] > clocks[j] })
+	return clocks, nil
+}
+
+func (f *FakeBackend) DeviceSetApplicationsClocks(h DeviceHandle, memClockMHz, graphicsClockMHz uint) error {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceSetApplicationsClocks")
+	if err != nil {
+		return err
+	}
+	if d.SupportedClocks != nil && !containsUint(d.SupportedClocks[memClockMHz], graphicsClockMHz) {
+		return newError("nvmlDeviceSetApplicationsClocks", ReturnErrorInvalidArgument)
+	}
+	if d.ApplicationsClocks == nil {
+		d.ApplicationsClocks = make(map[ClockType]uint)
+	}
+	d.ApplicationsClocks[ClockTypeMem] = memClockMHz
+	d.ApplicationsClocks[ClockTypeGraphics] = graphicsClockMHz
+	return nil
+}
+
+func containsUint(values []uint, v uint) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *FakeBackend) DeviceResetApplicationsClocks(h DeviceHandle) error {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceResetApplicationsClocks")
+	if err != nil {
+		return err
+	}
+	if d.ApplicationsClocks == nil {
+		d.ApplicationsClocks = make(map[ClockType]uint)
+	}
+	d.ApplicationsClocks[ClockTypeMem] = d.DefaultApplicationsClocks[ClockTypeMem]
+	d.ApplicationsClocks[ClockTypeGraphics] = d.DefaultApplicationsClocks[ClockTypeGraphics]
+	return nil
+}
+
+func (f *FakeBackend) DeviceSetGpuLockedClocks(h DeviceHandle, minGpuClockMHz, maxGpuClockMHz uint) error {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceSetGpuLockedClocks")
+	if err != nil {
+		return err
+	}
+	if minGpuClockMHz > maxGpuClockMHz {
+		return newError("nvmlDeviceSetGpuLockedClocks", ReturnErrorInvalidArgument)
+	}
+	d.LockedClocks = [2]uint{minGpuClockMHz, maxGpuClockMHz}
+	return nil
+}
+
+func (f *FakeBackend) DeviceResetGpuLockedClocks(h DeviceHandle) error {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceResetGpuLockedClocks")
+	if err != nil {
+		return err
+	}
+	d.LockedClocks = [2]uint{}
+	return nil
+}
+
+func (f *FakeBackend) DeviceGetAutoBoostedClocksEnabled(h DeviceHandle) (EnableState, EnableState, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetAutoBoostedClocksEnabled")
+	if err != nil {
+		return 0, 0, err
+	}
+	return d.AutoBoost, d.DefaultAutoBoost, nil
+}
+
+func (f *FakeBackend) DeviceSetAutoBoostedClocksEnabled(h DeviceHandle, enabled EnableState) error {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceSetAutoBoostedClocksEnabled")
+	if err != nil {
+		return err
+	}
+	d.AutoBoost = enabled
+	return nil
+}
+
+func (f *FakeBackend) DeviceSetDefaultAutoBoostedClocksEnabled(h DeviceHandle, enabled EnableState, flags uint) error {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceSetDefaultAutoBoostedClocksEnabled")
+	if err != nil {
+		return err
+	}
+	if flags != 0 {
+		return newError("nvmlDeviceSetDefaultAutoBoostedClocksEnabled", ReturnErrorInvalidArgument)
+	}
+	d.DefaultAutoBoost = enabled
+	return nil
+}
+
+func (f *FakeBackend) DeviceSetPowerManagementLimit(h DeviceHandle, limit uint) error {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceSetPowerManagementLimit")
+	if err != nil {
+		return err
+	}
+	if limit < d.MinPowerLimit || (d.MaxPowerLimit != 0 && limit > d.MaxPowerLimit) {
+		return newError("nvmlDeviceSetPowerManagementLimit", ReturnErrorInvalidArgument)
+	}
+	d.PowerLimit = limit
+	d.EnforcedPowerLimit = limit
+	return nil
+}
+
+func (f *FakeBackend) DeviceGetPowerManagementMode(h DeviceHandle) (EnableState, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetPowerManagementMode")
+	if err != nil {
+		return 0, err
+	}
+	return d.PowerManagementMode, nil
+}
+
+func (f *FakeBackend) DeviceGetPowerState(h DeviceHandle) (PowerState, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetPowerState")
+	if err != nil {
+		return PowerStateUnknown, err
+	}
+	return d.PerformanceState, nil
+}
+
+func (f *FakeBackend) DeviceGetDetailedEccErrors(h DeviceHandle, errorType MemoryErrorType, counterType EccCounterType) (EccErrorCounts, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetDetailedEccErrors")
+	if err != nil {
+		return EccErrorCounts{}, err
+	}
+	return EccErrorCounts{
+		L1Cache:      d.MemoryErrors[MemoryLocationL1Cache].Count(errorType, counterType),
+		L2Cache:      d.MemoryErrors[MemoryLocationL2Cache].Count(errorType, counterType),
+		DeviceMemory: d.MemoryErrors[MemoryLocationDeviceMemory].Count(errorType, counterType),
+		RegisterFile: d.MemoryErrors[MemoryLocationRegisterFile].Count(errorType, counterType),
+	}, nil
+}
+
+func (f *FakeBackend) DeviceGetMemoryErrorCounter(h DeviceHandle, errorType MemoryErrorType, counterType EccCounterType, location MemoryLocation) (uint64, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetMemoryErrorCounter")
+	if err != nil {
+		return 0, err
+	}
+	return d.MemoryErrors[location].Count(errorType, counterType), nil
+}
+
+func (f *FakeBackend) DeviceGetEccMode(h DeviceHandle) (EnableState, EnableState, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetEccMode")
+	if err != nil {
+		return 0, 0, err
+	}
+	return d.EccMode, d.PendingEccMode, nil
+}
+
+func (f *FakeBackend) DeviceSetEccMode(h DeviceHandle, mode EnableState) error {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceSetEccMode")
+	if err != nil {
+		return err
+	}
+	d.PendingEccMode = mode
+	return nil
+}
+
+func (f *FakeBackend) DeviceClearEccErrorCounts(h DeviceHandle, counterType EccCounterType) error {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceClearEccErrorCounts")
+	if err != nil {
+		return err
+	}
+	for c := range d.EccErrors {
+		if c.CounterType == counterType {
+			delete(d.EccErrors, c)
+		}
+	}
+	for location, counts := range d.MemoryErrors {
+		if counterType == EccCounterTypeVolatile {
+			counts.CorrectedVolatile, counts.UncorrectedVolatile = 0, 0
+		} else {
+			counts.CorrectedAggregate, counts.UncorrectedAggregate = 0, 0
+		}
+		d.MemoryErrors[location] = counts
+	}
+	return nil
+}
+
+func (f *FakeBackend) DeviceGetRetiredPages(h DeviceHandle, cause PageRetirementCause) ([]RetiredPage, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetRetiredPages_v2")
+	if err != nil {
+		return nil, err
+	}
+	pages := []RetiredPage{}
+	for _, p := range d.RetiredPages {
+		if p.Cause == cause {
+			pages = append(pages, p)
+		}
+	}
+	return pages, nil
+}
+
+func (f *FakeBackend) DeviceGetRetiredPagesPendingStatus(h DeviceHandle) (EnableState, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetRetiredPagesPendingStatus")
+	if err != nil {
+		return 0, err
+	}
+	return d.RetiredPagesPending, nil
+}
+
+func (f *FakeBackend) DeviceGetRemappedRows(h DeviceHandle) (RemappedRows, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetRemappedRows")
+	if err != nil {
+		return RemappedRows{}, err
+	}
+	return d.RemappedRows, nil
+}
+
+func (f *FakeBackend) DeviceGetRowRemapperHistogram(h DeviceHandle) (RowRemapperHistogram, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetRowRemapperHistogram")
+	if err != nil {
+		return RowRemapperHistogram{}, err
+	}
+	return d.RowRemapperHistogram, nil
+}
+
+// topology returns the common ancestor of d1 and d2.
+func (f *FakeBackend) topology(d1, d2 *FakeDevice) TopologyLevel {
+	if level, ok := d1.Topology[d2]; ok {
+		return level
+	}
+	if level, ok := d2.Topology[d1]; ok {
+		return level
+	}
+	return TopologySystem
+}
+
+func (f *FakeBackend) DeviceGetTopologyCommonAncestor(h1, h2 DeviceHandle) (TopologyLevel, error) {
+	f.Lock()
+	defer f.Unlock()
+	d1, err := f.device(h1, "nvmlDeviceGetTopologyCommonAncestor")
+	if err != nil {
+		return 0, err
+	}
+	d2, err := f.device(h2, "nvmlDeviceGetTopologyCommonAncestor")
+	if err != nil {
+		return 0, err
+	}
+	return f.topology(d1, d2), nil
+}
+
+// DeviceGetTopologyNearestGpus returns the other devices whose common
+// ancestor with h is level or closer.
+func (f *FakeBackend) DeviceGetTopologyNearestGpus(h DeviceHandle, level TopologyLevel) ([]DeviceHandle, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetTopologyNearestGpus")
+	if err != nil {
+		return nil, err
+	}
+	var handles []DeviceHandle
+	for _, other := range f.Devices {
+		if other != d && f.topology(d, other) <= level {
+			handles = append(handles, other)
+		}
+	}
+	return handles, nil
+}
+
+func (f *FakeBackend) SystemGetTopologyGpuSet(cpu uint) ([]DeviceHandle, error) {
+	f.Lock()
+	defer f.Unlock()
+	if err := f.check("nvmlSystemGetTopologyGpuSet"); err != nil {
+		return nil, err
+	}
+	var handles []DeviceHandle
+	for _, d := range f.Devices {
+		if containsUint(d.CPUs, cpu) {
+			handles = append(handles, d)
+		}
+	}
+	return handles, nil
+}
+
+func (f *FakeBackend) DeviceGetP2PStatus(h1, h2 DeviceHandle, index P2PCapsIndex) (P2PStatus, error) {
+	f.Lock()
+	defer f.Unlock()
+	d1, err := f.device(h1, "nvmlDeviceGetP2PStatus")
+	if err != nil {
+		return P2PStatusUnknown, err
+	}
+	d2, err := f.device(h2, "nvmlDeviceGetP2PStatus")
+	if err != nil {
+		return P2PStatusUnknown, err
+	}
+	if status, ok := d1.P2P[FakeP2PCaps{d2, index}]; ok {
+		return status, nil
+	}
+	if status, ok := d2.P2P[FakeP2PCaps{d1, index}]; ok {
+		return status, nil
+	}
+	return P2PStatusNotSupported, nil
+}
+
+func (f *FakeBackend) DeviceGetCpuAffinityWithinScope(h DeviceHandle, scope AffinityScope) ([]uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetCpuAffinityWithinScope")
+	if err != nil {
+		return nil, err
+	}
+	return d.affinity(scope, d.CPUs, d.SocketCPUs, "nvmlDeviceGetCpuAffinityWithinScope")
+}
+
+func (f *FakeBackend) DeviceGetCpuAffinity(h DeviceHandle) ([]uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetCpuAffinity")
+	if err != nil {
+		return nil, err
+	}
+	return d.affinity(AffinityScopeNode, d.CPUs, d.SocketCPUs, "nvmlDeviceGetCpuAffinity")
+}
+
+func (f *FakeBackend) DeviceGetMemoryAffinity(h DeviceHandle, scope AffinityScope) ([]uint, error) {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceGetMemoryAffinity")
+	if err != nil {
+		return nil, err
+	}
+	return d.affinity(scope, d.NumaNodes, d.SocketNumaNodes, "nvmlDeviceGetMemoryAffinity")
+}
+
+// affinity returns the ids of the node or socket scope like the mask filled
+// by NVML would: sorted, without duplicates and only those that fit.
+func (d *FakeDevice) affinity(scope AffinityScope, node, socket []uint, fn string) ([]uint, error) {
+	var set []uint
+	switch scope {
+	case AffinityScopeNode:
+		set = node
+	case AffinityScopeSocket:
+		set = socket
+		if len(set) == 0 {
+			set = node
+		}
+	default:
+		return nil, newError(fn, ReturnErrorInvalidArgument)
+	}
+	var ids []uint
+	for _, id := range set {
+		if id < maxAffinityIDs && !containsUint(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (f *FakeBackend) DeviceSetCpuAffinity(h DeviceHandle) error {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceSetCpuAffinity")
+	if err != nil {
+		return err
+	}
+	f.CpuAffinity = append([]uint(nil), d.CPUs...)
+	return nil
+}
+
+func (f *FakeBackend) DeviceClearCpuAffinity(h DeviceHandle) error {
+	f.Lock()
+	defer f.Unlock()
+	if _, err := f.device(h, "nvmlDeviceClearCpuAffinity"); err != nil {
+		return err
+	}
+	f.CpuAffinity = nil
+	return nil
+}
+
+func (f *FakeBackend) DeviceSetAccountingMode(h DeviceHandle, mode EnableState) error {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceSetAccountingMode")
+	if err != nil {
+		return err
+	}
+	// Like NVML, disabling accounting clears the stats of all processes.
+	if mode == EnableStateFeatureDisabled {
+		d.AccountingStats = nil
+	}
+	d.AccountingMode = mode
+	return nil
+}
+
+func (f *FakeBackend) DeviceClearAccountingPids(h DeviceHandle) error {
+	f.Lock()
+	defer f.Unlock()
+	d, err := f.device(h, "nvmlDeviceClearAccountingPids")
+	if err != nil {
+		return err
+	}
+	// Like NVML, only clear the stats of the terminated processes.
+	for pid, stats := range d.AccountingStats {
+		if !stats.IsRunning {
+			delete(d.AccountingStats, pid)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFakeDevices(t *testing.T) {
+	f := useFakeBackend(
+		&FakeDevice{
+			Name:              "Tesla T4",
+			UUID:              "GPU-8932f937-d72c-4106-c12f-20bd9faed9f6",
+			Serial:            "1320219000001",
+			PciInfo:           PciInfo{BusID: "00000000:3B:00.0"},
+			MemoryTotal:       16 << 30,
+			MemoryUsed:        1 << 30,
+			GPUUtilization:    80,
+			MemoryUtilization: 20,
+			PowerUsage:        70000,
+			Temperature:       45,
+			ComputeProcesses:  []FakeProcess{{Pid: 42, Name: "python", UsedGpuMemory: 512 << 20}},
+		},
+		&FakeDevice{Name: "Tesla V100", UUID: "GPU-2"},
+	)
+	defer SetBackend(nil)
+	f.DriverVersion = "450.80.02"
+	if err := Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer Shutdown()
+
+	if n, err := DeviceCount(); err != nil || n != 2 {
+		t.Fatalf("DeviceCount() = %d, %v; want 2", n, err)
+	}
+	if v, err := SystemDriverVersion(); err != nil || v != "450.80.02" {
+		t.Errorf("SystemDriverVersion() = %q, %v", v, err)
+	}
+	d, err := DeviceHandleByIndex(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, err := d.Name(); err != nil || name != "Tesla T4" {
+		t.Errorf("Name() = %q, %v", name, err)
+	}
+	if total, used, err := d.MemoryInfo(); err != nil || total != 16<<30 || used != 1<<30 {
+		t.Errorf("MemoryInfo() = %d, %d, %v", total, used, err)
+	}
+	if gpu, mem, err := d.UtilizationRates(); err != nil || gpu != 80 || mem != 20 {
+		t.Errorf("UtilizationRates() = %d, %d, %v", gpu, mem, err)
+	}
+	if p, err := d.PowerUsage(); err != nil || p != 70000 {
+		t.Errorf("PowerUsage() = %d, %v", p, err)
+	}
+	if temp, err := d.Temperature(); err != nil || temp != 45 {
+		t.Errorf("Temperature() = %d, %v", temp, err)
+	}
+	procs, err := d.ComputeProcesses()
+	if err != nil || len(procs) != 1 || procs[0].PID() != 42 || procs[0].Memory() != 512<<20 {
+		t.Errorf("ComputeProcesses() = %v, %v", procs, err)
+	}
+	if name, err := SystemGetProcessName(42, 4); err != nil || name != "pyt" {
+		t.Errorf("SystemGetProcessName(42, 4) = %q, %v; want the name truncated to the buffer", name, err)
+	}
+
+	for _, c := range []struct {
+		lookup string
+		get    func() (Device, error)
+	}{
+		{"uuid", func() (Device, error) { return DeviceHandleByUUID("GPU-2") }},
+		{"pci", func() (Device, error) { return DeviceHandleByPciBusID("00000000:3B:00.0") }},
+		{"serial", func() (Device, error) { return DeviceHandleBySerial("1320219000001") }},
+	} {
+		d, err := c.get()
+		if err != nil {
+			t.Errorf("lookup by %s: %v", c.lookup, err)
+			continue
+		}
+		if _, err := d.Name(); err != nil {
+			t.Errorf("lookup by %s: Name(): %v", c.lookup, err)
+		}
+	}
+	if _, err := DeviceHandleByIndex(2); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("DeviceHandleByIndex(2) = %v, want ErrInvalidArgument", err)
+	}
+	if _, err := DeviceHandleByUUID("GPU-3"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("DeviceHandleByUUID(GPU-3) = %v, want ErrNotFound", err)
+	}
+}
+
+func TestFakeErrors(t *testing.T) {
+	dev := &FakeDevice{Name: "Tesla T4"}
+	f := useFakeBackend(dev)
+	defer SetBackend(nil)
+	if _, err := DeviceCount(); !errors.Is(err, ErrLibraryNotFound) {
+		t.Errorf("DeviceCount() before Initialize = %v, want ErrLibraryNotFound", err)
+	}
+	if err := Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer Shutdown()
+	d, err := DeviceHandleByIndex(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c := range []struct {
+		ret  Return
+		want error
+	}{
+		{ReturnErrorNotSupported, ErrNotSupported},
+		{ReturnErrorNoPermission, ErrNoPermission},
+		{ReturnErrorGpuIsLost, ErrGpuIsLost},
+		{ReturnErrorFunctionNotFound, ErrFunctionNotFound},
+		{ReturnErrorUnknown, ErrUnknown},
+	} {
+		dev.Errors = map[string]Return{"nvmlDeviceGetTemperature": c.ret}
+		_, err := d.Temperature()
+		if !errors.Is(err, c.want) {
+			t.Errorf("Temperature() with %v = %v, want %v", c.ret, err, c.want)
+			continue
+		}
+		var e *Error
+		if !errors.As(err, &e) || e.Func != "nvmlDeviceGetTemperature" || e.Code != c.ret {
+			t.Errorf("Temperature() with %v = %#v, want an *Error of nvmlDeviceGetTemperature", c.ret, err)
+		}
+	}
+	dev.Errors = nil
+	if _, err := d.Temperature(); err != nil {
+		t.Errorf("Temperature() with no error injected = %v", err)
+	}
+
+	f.Lock()
+	f.Errors = map[string]Return{"nvmlDeviceGetCount": ReturnErrorDriverNotLoaded}
+	f.Unlock()
+	if _, err := DeviceCount(); !errors.Is(err, ErrDriverNotLoaded) {
+		t.Errorf("DeviceCount() = %v, want ErrDriverNotLoaded", err)
+	}
+}
+
+func TestFakeInitError(t *testing.T) {
+	f := useFakeBackend()
+	defer SetBackend(nil)
+	f.Errors = map[string]Return{"nvmlInit": ReturnErrorDriverNotLoaded}
+	if err := Initialize(); !errors.Is(err, ErrDriverNotLoaded) {
+		t.Fatalf("Initialize() = %v, want ErrDriverNotLoaded", err)
+	}
+	if _, err := DeviceCount(); !errors.Is(err, ErrLibraryNotFound) {
+		t.Errorf("DeviceCount() after a failed Initialize = %v, want ErrLibraryNotFound", err)
+	}
+}
+
+func TestSetBackendResetsInitState(t *testing.T) {
+	first := useFakeBackend(&FakeDevice{Name: "first"})
+	defer SetBackend(nil)
+	if err := Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	if err := Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	second := useFakeBackend(&FakeDevice{Name: "second"})
+	if err := Shutdown(); err != nil {
+		t.Errorf("Shutdown() after SetBackend = %v, want nil", err)
+	}
+	if refs, initialized := initState(second); refs != 0 || initialized {
+		t.Errorf("after Shutdown: refs = %d, initialized = %v; want 0, false", refs, initialized)
+	}
+	if err := Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	d, err := DeviceHandleByIndex(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name, err := d.Name(); err != nil || name != "second" {
+		t.Errorf("Name() = %q, %v; want the device of the new backend", name, err)
+	}
+	if err := Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if refs, initialized := initState(second); refs != 0 || initialized {
+		t.Errorf("second backend: refs = %d, initialized = %v; want 0, false", refs, initialized)
+	}
+	// The references were dropped without shutting the first backend down.
+	if _, initialized := initState(first); !initialized {
+		t.Error("SetBackend shut the previous backend down")
+	}
+}
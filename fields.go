@@ -0,0 +1,377 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// FieldID is the identifier of a field queried with Device.FieldValues, the
+// equivalent of the NVML_FI_* defines.
+type FieldID uint
+
+// Enumeration mapping for FieldID to the NVML_FI_* defines
+const (
+	FieldIDEccCurrent FieldID = 1 // Current ECC mode. 1=Active. 0=Inactive
+	FieldIDEccPending FieldID = 2 // Pending ECC mode. 1=Active. 0=Inactive
+
+	// ECC Count Totals
+	FieldIDEccSbeVolTotal FieldID = 3 // Total single bit volatile ECC errors
+	FieldIDEccDbeVolTotal FieldID = 4 // Total double bit volatile ECC errors
+	FieldIDEccSbeAggTotal FieldID = 5 // Total single bit aggregate (persistent) ECC errors
+	FieldIDEccDbeAggTotal FieldID = 6 // Total double bit aggregate (persistent) ECC errors
+
+	// Individual ECC locations
+	FieldIDEccSbeVolL1  FieldID = 7  // L1 cache single bit volatile ECC errors
+	FieldIDEccDbeVolL1  FieldID = 8  // L1 cache double bit volatile ECC errors
+	FieldIDEccSbeVolL2  FieldID = 9  // L2 cache single bit volatile ECC errors
+	FieldIDEccDbeVolL2  FieldID = 10 // L2 cache double bit volatile ECC errors
+	FieldIDEccSbeVolDev FieldID = 11 // Device memory single bit volatile ECC errors
+	FieldIDEccDbeVolDev FieldID = 12 // Device memory double bit volatile ECC errors
+	FieldIDEccSbeVolReg FieldID = 13 // Register file single bit volatile ECC errors
+	FieldIDEccDbeVolReg FieldID = 14 // Register file double bit volatile ECC errors
+	FieldIDEccSbeVolTex FieldID = 15 // Texture memory single bit volatile ECC errors
+	FieldIDEccDbeVolTex FieldID = 16 // Texture memory double bit volatile ECC errors
+	FieldIDEccDbeVolCbu FieldID = 17 // CBU double bit volatile ECC errors
+	FieldIDEccSbeAggL1  FieldID = 18 // L1 cache single bit aggregate (persistent) ECC errors
+	FieldIDEccDbeAggL1  FieldID = 19 // L1 cache double bit aggregate (persistent) ECC errors
+	FieldIDEccSbeAggL2  FieldID = 20 // L2 cache single bit aggregate (persistent) ECC errors
+	FieldIDEccDbeAggL2  FieldID = 21 // L2 cache double bit aggregate (persistent) ECC errors
+	FieldIDEccSbeAggDev FieldID = 22 // Device memory single bit aggregate (persistent) ECC errors
+	FieldIDEccDbeAggDev FieldID = 23 // Device memory double bit aggregate (persistent) ECC errors
+	FieldIDEccSbeAggReg FieldID = 24 // Register File single bit aggregate (persistent) ECC errors
+	FieldIDEccDbeAggReg FieldID = 25 // Register File double bit aggregate (persistent) ECC errors
+	FieldIDEccSbeAggTex FieldID = 26 // Texture memory single bit aggregate (persistent) ECC errors
+	FieldIDEccDbeAggTex FieldID = 27 // Texture memory double bit aggregate (persistent) ECC errors
+	FieldIDEccDbeAggCbu FieldID = 28 // CBU double bit aggregate ECC errors
+
+	// Page Retirement
+	FieldIDRetiredSbe     FieldID = 29 // Number of retired pages because of single bit errors
+	FieldIDRetiredDbe     FieldID = 30 // Number of retired pages because of double bit errors
+	FieldIDRetiredPending FieldID = 31 // If any pages are pending retirement. 1=yes. 0=no.
+
+	// NvLink Flit Error Counters
+	FieldIDNvLinkCrcFlitErrorCountL0    FieldID = 32 // NVLink flow control CRC Error Counter for Lane 0
+	FieldIDNvLinkCrcFlitErrorCountL1    FieldID = 33 // NVLink flow control CRC Error Counter for Lane 1
+	FieldIDNvLinkCrcFlitErrorCountL2    FieldID = 34 // NVLink flow control CRC Error Counter for Lane 2
+	FieldIDNvLinkCrcFlitErrorCountL3    FieldID = 35 // NVLink flow control CRC Error Counter for Lane 3
+	FieldIDNvLinkCrcFlitErrorCountL4    FieldID = 36 // NVLink flow control CRC Error Counter for Lane 4
+	FieldIDNvLinkCrcFlitErrorCountL5    FieldID = 37 // NVLink flow control CRC Error Counter for Lane 5
+	FieldIDNvLinkCrcFlitErrorCountTotal FieldID = 38 // NVLink flow control CRC Error Counter total for all Lanes
+
+	// NvLink CRC Data Error Counters
+	FieldIDNvLinkCrcDataErrorCountL0    FieldID = 39 // NVLink data CRC Error Counter for Lane 0
+	FieldIDNvLinkCrcDataErrorCountL1    FieldID = 40 // NVLink data CRC Error Counter for Lane 1
+	FieldIDNvLinkCrcDataErrorCountL2    FieldID = 41 // NVLink data CRC Error Counter for Lane 2
+	FieldIDNvLinkCrcDataErrorCountL3    FieldID = 42 // NVLink data CRC Error Counter for Lane 3
+	FieldIDNvLinkCrcDataErrorCountL4    FieldID = 43 // NVLink data CRC Error Counter for Lane 4
+	FieldIDNvLinkCrcDataErrorCountL5    FieldID = 44 // NVLink data CRC Error Counter for Lane 5
+	FieldIDNvLinkCrcDataErrorCountTotal FieldID = 45 // NvLink data CRC Error Counter total for all Lanes
+
+	// NvLink Replay Error Counters
+	FieldIDNvLinkReplayErrorCountL0    FieldID = 46 // NVLink Replay Error Counter for Lane 0
+	FieldIDNvLinkReplayErrorCountL1    FieldID = 47 // NVLink Replay Error Counter for Lane 1
+	FieldIDNvLinkReplayErrorCountL2    FieldID = 48 // NVLink Replay Error Counter for Lane 2
+	FieldIDNvLinkReplayErrorCountL3    FieldID = 49 // NVLink Replay Error Counter for Lane 3
+	FieldIDNvLinkReplayErrorCountL4    FieldID = 50 // NVLink Replay Error Counter for Lane 4
+	FieldIDNvLinkReplayErrorCountL5    FieldID = 51 // NVLink Replay Error Counter for Lane 5
+	FieldIDNvLinkReplayErrorCountTotal FieldID = 52 // NVLink Replay Error Counter total for all Lanes
+
+	// NvLink Recovery Error Counters
+	FieldIDNvLinkRecoveryErrorCountL0    FieldID = 53 // NVLink Recovery Error Counter for Lane 0
+	FieldIDNvLinkRecoveryErrorCountL1    FieldID = 54 // NVLink Recovery Error Counter for Lane 1
+	FieldIDNvLinkRecoveryErrorCountL2    FieldID = 55 // NVLink Recovery Error Counter for Lane 2
+	FieldIDNvLinkRecoveryErrorCountL3    FieldID = 56 // NVLink Recovery Error Counter for Lane 3
+	FieldIDNvLinkRecoveryErrorCountL4    FieldID = 57 // NVLink Recovery Error Counter for Lane 4
+	FieldIDNvLinkRecoveryErrorCountL5    FieldID = 58 // NVLink Recovery Error Counter for Lane 5
+	FieldIDNvLinkRecoveryErrorCountTotal FieldID = 59 // NVLink Recovery Error Counter total for all Lanes
+
+	// NvLink Bandwidth Counters
+	FieldIDNvLinkBandwidthC0L0    FieldID = 60 // NVLink Bandwidth Counter for Counter Set 0, Lane 0
+	FieldIDNvLinkBandwidthC0L1    FieldID = 61 // NVLink Bandwidth Counter for Counter Set 0, Lane 1
+	FieldIDNvLinkBandwidthC0L2    FieldID = 62 // NVLink Bandwidth Counter for Counter Set 0, Lane 2
+	FieldIDNvLinkBandwidthC0L3    FieldID = 63 // NVLink Bandwidth Counter for Counter Set 0, Lane 3
+	FieldIDNvLinkBandwidthC0L4    FieldID = 64 // NVLink Bandwidth Counter for Counter Set 0, Lane 4
+	FieldIDNvLinkBandwidthC0L5    FieldID = 65 // NVLink Bandwidth Counter for Counter Set 0, Lane 5
+	FieldIDNvLinkBandwidthC0Total FieldID = 66 // NVLink Bandwidth Counter Total for Counter Set 0, All Lanes
+
+	// NvLink Bandwidth Counters
+	FieldIDNvLinkBandwidthC1L0    FieldID = 67 // NVLink Bandwidth Counter for Counter Set 1, Lane 0
+	FieldIDNvLinkBandwidthC1L1    FieldID = 68 // NVLink Bandwidth Counter for Counter Set 1, Lane 1
+	FieldIDNvLinkBandwidthC1L2    FieldID = 69 // NVLink Bandwidth Counter for Counter Set 1, Lane 2
+	FieldIDNvLinkBandwidthC1L3    FieldID = 70 // NVLink Bandwidth Counter for Counter Set 1, Lane 3
+	FieldIDNvLinkBandwidthC1L4    FieldID = 71 // NVLink Bandwidth Counter for Counter Set 1, Lane 4
+	FieldIDNvLinkBandwidthC1L5    FieldID = 72 // NVLink Bandwidth Counter for Counter Set 1, Lane 5
+	FieldIDNvLinkBandwidthC1Total FieldID = 73 // NVLink Bandwidth Counter Total for Counter Set 1, All Lanes
+
+	// NVML Perf Policy Counters
+	FieldIDPerfPolicyPower           FieldID = 74 // Perf Policy Counter for Power Policy
+	FieldIDPerfPolicyThermal         FieldID = 75 // Perf Policy Counter for Thermal Policy
+	FieldIDPerfPolicySyncBoost       FieldID = 76 // Perf Policy Counter for Sync boost Policy
+	FieldIDPerfPolicyBoardLimit      FieldID = 77 // Perf Policy Counter for Board Limit
+	FieldIDPerfPolicyLowUtilization  FieldID = 78 // Perf Policy Counter for Low GPU Utilization Policy
+	FieldIDPerfPolicyReliability     FieldID = 79 // Perf Policy Counter for Reliability Policy
+	FieldIDPerfPolicyTotalAppClocks  FieldID = 80 // Perf Policy Counter for Total App Clock Policy
+	FieldIDPerfPolicyTotalBaseClocks FieldID = 81 // Perf Policy Counter for Total Base Clocks Policy
+
+	// Memory temperatures
+	FieldIDMemoryTemp FieldID = 82 // Memory temperature for the device
+
+	// Energy Counter
+	FieldIDTotalEnergyConsumption FieldID = 83 // Total energy consumption for the GPU in mJ since the driver was last reloaded
+
+	// NVLink Speed
+	FieldIDNvLinkSpeedMbpsL0         FieldID = 84 // NVLink Speed in MBps for Link 0
+	FieldIDNvLinkSpeedMbpsL1         FieldID = 85 // NVLink Speed in MBps for Link 1
+	FieldIDNvLinkSpeedMbpsL2         FieldID = 86 // NVLink Speed in MBps for Link 2
+	FieldIDNvLinkSpeedMbpsL3         FieldID = 87 // NVLink Speed in MBps for Link 3
+	FieldIDNvLinkSpeedMbpsL4         FieldID = 88 // NVLink Speed in MBps for Link 4
+	FieldIDNvLinkSpeedMbpsL5         FieldID = 89 // NVLink Speed in MBps for Link 5
+	FieldIDNvLinkSpeedMbpsCommon     FieldID = 90 // Common NVLink Speed in MBps for active links
+	FieldIDNvLinkLinkCount           FieldID = 91 // Number of NVLinks present on the device
+	FieldIDRetiredPendingSbe         FieldID = 92 // If any pages are pending retirement due to SBE. 1=yes. 0=no.
+	FieldIDRetiredPendingDbe         FieldID = 93 // If any pages are pending retirement due to DBE. 1=yes. 0=no.
+	FieldIDPcieReplayCounter         FieldID = 94 // PCIe replay counter
+	FieldIDPcieReplayRolloverCounter FieldID = 95 // PCIe replay rollover counter
+
+	// NvLink Flit Error Counters
+	FieldIDNvLinkCrcFlitErrorCountL6  FieldID = 96  // NVLink flow control CRC Error Counter for Lane 6
+	FieldIDNvLinkCrcFlitErrorCountL7  FieldID = 97  // NVLink flow control CRC Error Counter for Lane 7
+	FieldIDNvLinkCrcFlitErrorCountL8  FieldID = 98  // NVLink flow control CRC Error Counter for Lane 8
+	FieldIDNvLinkCrcFlitErrorCountL9  FieldID = 99  // NVLink flow control CRC Error Counter for Lane 9
+	FieldIDNvLinkCrcFlitErrorCountL10 FieldID = 100 // NVLink flow control CRC Error Counter for Lane 10
+	FieldIDNvLinkCrcFlitErrorCountL11 FieldID = 101 // NVLink flow control CRC Error Counter for Lane 11
+
+	// NvLink CRC Data Error Counters
+	FieldIDNvLinkCrcDataErrorCountL6  FieldID = 102 // NVLink data CRC Error Counter for Lane 6
+	FieldIDNvLinkCrcDataErrorCountL7  FieldID = 103 // NVLink data CRC Error Counter for Lane 7
+	FieldIDNvLinkCrcDataErrorCountL8  FieldID = 104 // NVLink data CRC Error Counter for Lane 8
+	FieldIDNvLinkCrcDataErrorCountL9  FieldID = 105 // NVLink data CRC Error Counter for Lane 9
+	FieldIDNvLinkCrcDataErrorCountL10 FieldID = 106 // NVLink data CRC Error Counter for Lane 10
+	FieldIDNvLinkCrcDataErrorCountL11 FieldID = 107 // NVLink data CRC Error Counter for Lane 11
+
+	// NvLink Replay Error Counters
+	FieldIDNvLinkReplayErrorCountL6  FieldID = 108 // NVLink Replay Error Counter for Lane 6
+	FieldIDNvLinkReplayErrorCountL7  FieldID = 109 // NVLink Replay Error Counter for Lane 7
+	FieldIDNvLinkReplayErrorCountL8  FieldID = 110 // NVLink Replay Error Counter for Lane 8
+	FieldIDNvLinkReplayErrorCountL9  FieldID = 111 // NVLink Replay Error Counter for Lane 9
+	FieldIDNvLinkReplayErrorCountL10 FieldID = 112 // NVLink Replay Error Counter for Lane 10
+	FieldIDNvLinkReplayErrorCountL11 FieldID = 113 // NVLink Replay Error Counter for Lane 11
+
+	// NvLink Recovery Error Counters
+	FieldIDNvLinkRecoveryErrorCountL6  FieldID = 114 // NVLink Recovery Error Counter for Lane 6
+	FieldIDNvLinkRecoveryErrorCountL7  FieldID = 115 // NVLink Recovery Error Counter for Lane 7
+	FieldIDNvLinkRecoveryErrorCountL8  FieldID = 116 // NVLink Recovery Error Counter for Lane 8
+	FieldIDNvLinkRecoveryErrorCountL9  FieldID = 117 // NVLink Recovery Error Counter for Lane 9
+	FieldIDNvLinkRecoveryErrorCountL10 FieldID = 118 // NVLink Recovery Error Counter for Lane 10
+	FieldIDNvLinkRecoveryErrorCountL11 FieldID = 119 // NVLink Recovery Error Counter for Lane 11
+
+	// NvLink Bandwidth Counters
+	FieldIDNvLinkBandwidthC0L6  FieldID = 120 // NVLink Bandwidth Counter for Counter Set 0, Lane 6
+	FieldIDNvLinkBandwidthC0L7  FieldID = 121 // NVLink Bandwidth Counter for Counter Set 0, Lane 7
+	FieldIDNvLinkBandwidthC0L8  FieldID = 122 // NVLink Bandwidth Counter for Counter Set 0, Lane 8
+	FieldIDNvLinkBandwidthC0L9  FieldID = 123 // NVLink Bandwidth Counter for Counter Set 0, Lane 9
+	FieldIDNvLinkBandwidthC0L10 FieldID = 124 // NVLink Bandwidth Counter for Counter Set 0, Lane 10
+	FieldIDNvLinkBandwidthC0L11 FieldID = 125 // NVLink Bandwidth Counter for Counter Set 0, Lane 11
+
+	// NvLink Bandwidth Counters
+	FieldIDNvLinkBandwidthC1L6  FieldID = 126 // NVLink Bandwidth Counter for Counter Set 1, Lane 6
+	FieldIDNvLinkBandwidthC1L7  FieldID = 127 // NVLink Bandwidth Counter for Counter Set 1, Lane 7
+	FieldIDNvLinkBandwidthC1L8  FieldID = 128 // NVLink Bandwidth Counter for Counter Set 1, Lane 8
+	FieldIDNvLinkBandwidthC1L9  FieldID = 129 // NVLink Bandwidth Counter for Counter Set 1, Lane 9
+	FieldIDNvLinkBandwidthC1L10 FieldID = 130 // NVLink Bandwidth Counter for Counter Set 1, Lane 10
+	FieldIDNvLinkBandwidthC1L11 FieldID = 131 // NVLink Bandwidth Counter for Counter Set 1, Lane 11
+
+	// NVLink Speed
+	FieldIDNvLinkSpeedMbpsL6      FieldID = 132 // NVLink Speed in MBps for Link 6
+	FieldIDNvLinkSpeedMbpsL7      FieldID = 133 // NVLink Speed in MBps for Link 7
+	FieldIDNvLinkSpeedMbpsL8      FieldID = 134 // NVLink Speed in MBps for Link 8
+	FieldIDNvLinkSpeedMbpsL9      FieldID = 135 // NVLink Speed in MBps for Link 9
+	FieldIDNvLinkSpeedMbpsL10     FieldID = 136 // NVLink Speed in MBps for Link 10
+	FieldIDNvLinkSpeedMbpsL11     FieldID = 137 // NVLink Speed in MBps for Link 11
+	FieldIDNvLinkThroughputDataTx FieldID = 138 // NVLink TX Data throughput in KiB
+	FieldIDNvLinkThroughputDataRx FieldID = 139 // NVLink RX Data throughput in KiB
+	FieldIDNvLinkThroughputRawTx  FieldID = 140 // NVLink TX Data + protocol overhead in KiB
+	FieldIDNvLinkThroughputRawRx  FieldID = 141 // NVLink RX Data + protocol overhead in KiB
+
+	// Row Remapper
+	FieldIDRemappedCor     FieldID = 142 // Number of remapped rows due to correctable errors
+	FieldIDRemappedUnc     FieldID = 143 // Number of remapped rows due to uncorrectable errors
+	FieldIDRemappedPending FieldID = 144 // If any rows are pending remapping. 1=yes 0=no
+	FieldIDRemappedFailure FieldID = 145 // If any rows failed to be remapped 1=yes 0=no
+)
+
+// ValueType is the equivalent of nvmlValueType_t.
+type ValueType int
+
+// Enumeration mapping for ValueType to nvmlValueType_t
+const (
+	ValueTypeDouble           ValueType = 0
+	ValueTypeUnsignedInt      ValueType = 1
+	ValueTypeUnsignedLong     ValueType = 2
+	ValueTypeUnsignedLongLong ValueType = 3
+	ValueTypeSignedLongLong   ValueType = 4
+)
+
+func (t ValueType) String() string {
+	switch t {
+	case ValueTypeDouble:
+		return "double"
+	case ValueTypeUnsignedInt:
+		return "unsigned int"
+	case ValueTypeUnsignedLong:
+		return "unsigned long"
+	case ValueTypeUnsignedLongLong:
+		return "unsigned long long"
+	case ValueTypeSignedLongLong:
+		return "signed long long"
+	default:
+		return "unknown"
+	}
+}
+
+// Value is the equivalent of nvmlValue_t tagged with its ValueType. Its
+// accessors convert it to the requested Go type.
+type Value struct {
+	Type ValueType
+	// bits holds the IEEE 754 representation of doubles and the two's
+	// complement representation of the integers.
+	bits uint64
+}
+
+// DoubleValue returns a Value of type ValueTypeDouble.
+func DoubleValue(v float64) Value {
+	return Value{ValueTypeDouble, math.Float64bits(v)}
+}
+
+// UintValue returns a Value of type ValueTypeUnsignedInt.
+func UintValue(v uint32) Value {
+	return Value{ValueTypeUnsignedInt, uint64(v)}
+}
+
+// UlongValue returns a Value of type ValueTypeUnsignedLong.
+func UlongValue(v uint64) Value {
+	return Value{ValueTypeUnsignedLong, v}
+}
+
+// Uint64Value returns a Value of type ValueTypeUnsignedLongLong.
+func Uint64Value(v uint64) Value {
+	return Value{ValueTypeUnsignedLongLong, v}
+}
+
+// Int64Value returns a Value of type ValueTypeSignedLongLong.
+func Int64Value(v int64) Value {
+	return Value{ValueTypeSignedLongLong, uint64(v)}
+}
+
+// Float64 returns the value as a float64.
+func (v Value) Float64() float64 {
+	switch v.Type {
+	case ValueTypeDouble:
+		return math.Float64frombits(v.bits)
+	case ValueTypeSignedLongLong:
+		return float64(int64(v.bits))
+	default:
+		return float64(v.bits)
+	}
+}
+
+// Uint64 returns the value as an uint64. Doubles are truncated and negative
+// values are returned as 0.
+func (v Value) Uint64() uint64 {
+	switch v.Type {
+	case ValueTypeDouble:
+		if f := math.Float64frombits(v.bits); f > 0 {
+			return uint64(f)
+		}
+		return 0
+	case ValueTypeSignedLongLong:
+		if int64(v.bits) < 0 {
+			return 0
+		}
+		return v.bits
+	default:
+		return v.bits
+	}
+}
+
+// Int64 returns the value as an int64. Doubles are truncated.
+func (v Value) Int64() int64 {
+	if v.Type == ValueTypeDouble {
+		return int64(math.Float64frombits(v.bits))
+	}
+	return int64(v.bits)
+}
+
+func (v Value) String() string {
+	switch v.Type {
+	case ValueTypeDouble:
+		return fmt.Sprint(v.Float64())
+	case ValueTypeSignedLongLong:
+		return fmt.Sprint(v.Int64())
+	default:
+		return fmt.Sprint(v.Uint64())
+	}
+}
+
+// FieldValue is the equivalent of nvmlFieldValue_t.
+type FieldValue struct {
+	FieldID FieldID
+	// ScopeID selects what the field applies to for the fields that need
+	// it, e.g. the link of the FieldIDNvLinkThroughput* fields. Query such
+	// fields with Device.FieldValuesScoped.
+	ScopeID   uint
+	Timestamp time.Time     // when the value was retrieved
+	Latency   time.Duration // how long NVML took to update the value
+	Value     Value
+	// Err is the error NVML returned for this field, nil if Value is valid.
+	// It wraps the return code, see Error.
+	Err error
+}
+
+// FieldValues returns the values of the given fields, retrieved with a single
+// NVML call. Failures to query individual fields are reported in the Err of
+// the corresponding FieldValue rather than in the returned error.
+func (d Device) FieldValues(ids ...FieldID) ([]FieldValue, error) {
+	values := make([]FieldValue, len(ids))
+	for i, id := range ids {
+		values[i].FieldID = id
+	}
+	if len(values) == 0 {
+		return values, nil
+	}
+	err := backend.DeviceGetFieldValues(d.handle, values)
+	return values, err
+}
+
+// FieldValuesScoped is like FieldValues for fields that need a ScopeID. Only
+// the FieldID and ScopeID of the requests are used; the values are returned
+// in a new slice, in the same order.
+func (d Device) FieldValuesScoped(requests ...FieldValue) ([]FieldValue, error) {
+	values := make([]FieldValue, len(requests))
+	for i, r := range requests {
+		values[i].FieldID = r.FieldID
+		values[i].ScopeID = r.ScopeID
+	}
+	if len(values) == 0 {
+		return values, nil
+	}
+	err := backend.DeviceGetFieldValues(d.handle, values)
+	return values, err
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestFieldValuesScoped(t *testing.T) {
+	_, devices := initFakeDevices(t, &FakeDevice{
+		FieldValues: map[FieldID]Value{
+			FieldIDNvLinkLinkCount: UintValue(2),
+		},
+		ScopedFieldValues: map[FakeFieldScope]Value{
+			{FieldIDNvLinkThroughputDataTx, 0}: Uint64Value(100),
+			{FieldIDNvLinkThroughputDataTx, 1}: Uint64Value(200),
+		},
+	})
+	defer SetBackend(nil)
+	defer Shutdown()
+
+	requests := []FieldValue{
+		{FieldID: FieldIDNvLinkThroughputDataTx, ScopeID: 1},
+		{FieldID: FieldIDNvLinkThroughputDataTx, ScopeID: 0},
+		{FieldID: FieldIDNvLinkThroughputDataTx, ScopeID: 2},
+		{FieldID: FieldIDNvLinkLinkCount, ScopeID: 5},
+	}
+	values, err := devices[0].FieldValuesScoped(requests...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != len(requests) {
+		t.Fatalf("got %d values, want %d", len(values), len(requests))
+	}
+	for i, want := range []uint64{200, 100, 0, 2} {
+		v := values[i]
+		if v.FieldID != requests[i].FieldID || v.ScopeID != requests[i].ScopeID {
+			t.Errorf("values[%d] is field %d scope %d, want field %d scope %d", i, v.FieldID, v.ScopeID, requests[i].FieldID, requests[i].ScopeID)
+		}
+		if i == 2 {
+			if !errors.Is(v.Err, ErrNotSupported) {
+				t.Errorf("values[%d].Err = %v, want ErrNotSupported", i, v.Err)
+			}
+			continue
+		}
+		if v.Err != nil || v.Value.Uint64() != want {
+			t.Errorf("values[%d] = %v, %v; want %d", i, v.Value, v.Err, want)
+		}
+	}
+	// The requests are left alone.
+	if requests[0].Err != nil || requests[0].Value != (Value{}) || !requests[0].Timestamp.IsZero() {
+		t.Errorf("FieldValuesScoped modified its requests: %+v", requests[0])
+	}
+
+	// FieldValues queries scope 0.
+	values, err = devices[0].FieldValues(FieldIDNvLinkThroughputDataTx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values[0].Err != nil || values[0].Value.Uint64() != 100 {
+		t.Errorf("FieldValues() = %v, %v; want 100", values[0].Value, values[0].Err)
+	}
+
+	if values, err := devices[0].FieldValuesScoped(); err != nil || len(values) != 0 {
+		t.Errorf("FieldValuesScoped() = %v, %v; want no values", values, err)
+	}
+}
@@ -0,0 +1,5 @@
+module github.com/cfsmp3/gonvml
+
+go 1.18
+
+require github.com/ebitengine/purego v0.9.1
@@ -0,0 +1,246 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultLibrary is the NVML library looked up by the dynamic loader when no
+// library path is given.
+const defaultLibrary = "libnvidia-ml.so.1"
+
+// InitFlags is the bitmask of the NVML_INIT_FLAG_* defines accepted by
+// nvmlInitWithFlags.
+type InitFlags uint
+
+// Enumeration mapping for InitFlags to the NVML_INIT_FLAG_* defines
+const (
+	// InitFlagNoGpus doesn't fail the initialization when no GPUs are found.
+	InitFlagNoGpus InitFlags = 1
+	// InitFlagNoAttach doesn't attach the GPUs.
+	InitFlagNoAttach InitFlags = 2
+)
+
+// initOptions holds the settings of InitializeWithOptions.
+type initOptions struct {
+	libraryPath string
+	searchPaths []string
+	flags       InitFlags
+}
+
+// libraries returns the library files to try loading, in order.
+func (o *initOptions) libraries() []string {
+	if o.libraryPath != "" {
+		return []string{o.libraryPath}
+	}
+	var libraries []string
+	for _, dir := range o.searchPaths {
+		libraries = append(libraries, filepath.Join(dir, defaultLibrary))
+	}
+	return append(libraries, defaultLibrary)
+}
+
+// Option is a setting of InitializeWithOptions.
+type Option func(*initOptions)
+
+// WithLibraryPath loads NVML from the given file instead of looking up
+// libnvidia-ml.so.1.
+func WithLibraryPath(path string) Option {
+	return func(o *initOptions) {
+		o.libraryPath = path
+	}
+}
+
+// WithSearchPaths looks for libnvidia-ml.so.1 in the given directories, in
+// order, before falling back to the directories searched by the dynamic
+// loader. This is meant for containers where the driver libraries are
+// mounted in nonstandard places such as /usr/local/nvidia/lib64.
+func WithSearchPaths(dirs ...string) Option {
+	return func(o *initOptions) {
+		o.searchPaths = append(o.searchPaths, dirs...)
+	}
+}
+
+// WithInitFlags initializes NVML with nvmlInitWithFlags and the given flags.
+func WithInitFlags(flags InitFlags) Option {
+	return func(o *initOptions) {
+		o.flags |= flags
+	}
+}
+
+var (
+	// initMu serializes the loading and unloading of NVML.
+	initMu sync.Mutex
+	// initRefs is the number of references to the loaded NVML library.
+	initRefs int
+	// loadedLibrary is the path of the library file loaded by the last
+	// successful initialization.
+	loadedLibrary string
+)
+
+// InitializeWithOptions initializes NVML like Initialize, with the given
+// settings:
+//
+//	err := gonvml.InitializeWithOptions(
+//		gonvml.WithSearchPaths("/usr/local/nvidia/lib64", "/run/nvidia/driver/usr/lib64"),
+//		gonvml.WithInitFlags(gonvml.InitFlagNoGpus),
+//	)
+//
+// It fails with an error matching ErrLibraryNotFound if none of the
+// candidate library files can be loaded. The settings only apply when NVML is
+// not loaded yet; otherwise the call only takes another reference to the
+// library already loaded.
+func InitializeWithOptions(opts ...Option) error {
+	var o initOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	initMu.Lock()
+	defer initMu.Unlock()
+	if initRefs > 0 {
+		initRefs++
+		return nil
+	}
+	path, err := backend.Init(o.libraries(), o.flags)
+	if err != nil {
+		return err
+	}
+	initRefs, loadedLibrary = 1, path
+	return nil
+}
+
+// release drops a reference to the loaded NVML library and shuts NVML down
+// when it was the last one. It does nothing when no reference is held, so
+// that the count never goes negative.
+func release() error {
+	initMu.Lock()
+	defer initMu.Unlock()
+	switch initRefs {
+	case 0:
+		return nil
+	case 1:
+		if err := backend.Shutdown(); err != nil {
+			return err
+		}
+		loadedLibrary = ""
+	}
+	initRefs--
+	return nil
+}
+
+// Session is a reference to the loaded NVML library. Components sharing a
+// process can each open their own Session without unloading NVML under one
+// another:
+//
+//	s, err := gonvml.NewSession()
+//	if err != nil {
+//		return err
+//	}
+//	defer s.Close()
+type Session struct {
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewSession initializes NVML like InitializeWithOptions and returns the
+// Session holding the reference it took.
+func NewSession(opts ...Option) (*Session, error) {
+	if err := InitializeWithOptions(opts...); err != nil {
+		return nil, err
+	}
+	return &Session{}, nil
+}
+
+// Close releases the reference held by s, shutting NVML down if it was the
+// last one. Calling it again does nothing.
+func (s *Session) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	if err := release(); err != nil {
+		return err
+	}
+	s.closed = true
+	return nil
+}
+
+// LibraryInfo describes the NVML library in use.
+type LibraryInfo struct {
+	Path          string // file the library was loaded from
+	Version       string // NVML version, e.g. "11.450.80.02"
+	DriverVersion string // driver version, e.g. "450.80.02"
+}
+
+// LoadedLibrary returns the NVML library loaded by Initialize or
+// InitializeWithOptions.
+func LoadedLibrary() (LibraryInfo, error) {
+	version, err := SystemNVMLVersion()
+	if err != nil {
+		return LibraryInfo{}, err
+	}
+	driverVersion, err := SystemDriverVersion()
+	if err != nil {
+		return LibraryInfo{}, err
+	}
+	initMu.Lock()
+	path := loadedLibrary
+	initMu.Unlock()
+	return LibraryInfo{Path: path, Version: version, DriverVersion: driverVersion}, nil
+}
+
+// libraryFile returns the path of the shared library mapped at addr, the
+// address of one of its symbols, as listed by /proc/self/maps. It returns
+// name, the name the library was loaded with, if that cannot be determined.
+func libraryFile(name string, addr uintptr) string {
+	f, err := os.Open("/proc/self/maps")
+	if err != nil {
+		return name
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// Lines are "start-end perms offset dev inode [path]".
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 {
+			continue
+		}
+		bounds := strings.SplitN(fields[0], "-", 2)
+		if len(bounds) != 2 {
+			continue
+		}
+		start, err := strconv.ParseUint(bounds[0], 16, 64)
+		if err != nil {
+			continue
+		}
+		end, err := strconv.ParseUint(bounds[1], 16, 64)
+		if err != nil {
+			continue
+		}
+		if uint64(addr) >= start && uint64(addr) < end {
+			return strings.Join(fields[5:], " ")
+		}
+	}
+	return name
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+// useFakeBackend selects a FakeBackend with the given devices. The caller
+// restores the default backend with SetBackend(nil).
+func useFakeBackend(devices ...*FakeDevice) *FakeBackend {
+	f := NewFakeBackend(devices...)
+	SetBackend(f)
+	return f
+}
+
+// initFakeDevices selects a FakeBackend with the given devices, initializes
+// NVML and returns the handles of the devices. The caller shuts NVML down and
+// restores the default backend.
+func initFakeDevices(t *testing.T, devices ...*FakeDevice) (*FakeBackend, []Device) {
+	f := useFakeBackend(devices...)
+	if err := Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	handles := make([]Device, len(devices))
+	for i := range handles {
+		d, err := DeviceHandleByIndex(uint(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		handles[i] = d
+	}
+	return f, handles
+}
+
+// initState returns the reference count and whether the fake is initialized.
+func initState(f *FakeBackend) (int, bool) {
+	initMu.Lock()
+	refs := initRefs
+	initMu.Unlock()
+	f.Lock()
+	defer f.Unlock()
+	return refs, f.initialized
+}
+
+func TestInitializeShutdownConcurrent(t *testing.T) {
+	f := useFakeBackend()
+	defer SetBackend(nil)
+	const goroutines = 50
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if err := Initialize(); err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := DeviceCount(); err != nil {
+					t.Error(err)
+				}
+				if err := Shutdown(); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if refs, initialized := initState(f); refs != 0 || initialized {
+		t.Errorf("after balanced calls: refs = %d, initialized = %v; want 0, false", refs, initialized)
+	}
+}
+
+func TestSessionCloseConcurrent(t *testing.T) {
+	f := useFakeBackend()
+	defer SetBackend(nil)
+	if err := Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	sessions := make([]*Session, 20)
+	for i := range sessions {
+		s, err := NewSession()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sessions[i] = s
+	}
+	if refs, _ := initState(f); refs != len(sessions)+1 {
+		t.Fatalf("refs = %d, want %d", refs, len(sessions)+1)
+	}
+
+	// Close each session from several goroutines at once: only one of
+	// the calls may release its reference.
+	var wg sync.WaitGroup
+	for _, s := range sessions {
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func(s *Session) {
+				defer wg.Done()
+				if err := s.Close(); err != nil {
+					t.Error(err)
+				}
+			}(s)
+		}
+	}
+	wg.Wait()
+	if refs, initialized := initState(f); refs != 1 || !initialized {
+		t.Fatalf("after closing the sessions: refs = %d, initialized = %v; want 1, true", refs, initialized)
+	}
+	if err := Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if refs, initialized := initState(f); refs != 0 || initialized {
+		t.Errorf("after Shutdown: refs = %d, initialized = %v; want 0, false", refs, initialized)
+	}
+	if err := sessions[0].Close(); err != nil {
+		t.Errorf("closing a closed session: %v", err)
+	}
+}
+
+func TestShutdownWithoutReferences(t *testing.T) {
+	f := useFakeBackend()
+	defer SetBackend(nil)
+	if err := Shutdown(); err != nil {
+		t.Errorf("Shutdown() without references = %v, want nil", err)
+	}
+	if err := Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	if err := Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if err := Shutdown(); err != nil {
+		t.Errorf("second Shutdown() = %v, want nil", err)
+	}
+	if refs, initialized := initState(f); refs != 0 || initialized {
+		t.Errorf("refs = %d, initialized = %v; want 0, false", refs, initialized)
+	}
+}
+
+func TestSetBackendResetsReferences(t *testing.T) {
+	useFakeBackend()
+	defer SetBackend(nil)
+	if err := Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	f := useFakeBackend()
+	if _, err := DeviceCount(); !errors.Is(err, ErrLibraryNotFound) {
+		t.Errorf("DeviceCount() before Initialize = %v, want ErrLibraryNotFound", err)
+	}
+	if err := Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	if refs, initialized := initState(f); refs != 1 || !initialized {
+		t.Errorf("refs = %d, initialized = %v; want 1, true", refs, initialized)
+	}
+	if err := Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDefaultBackendSkipsLibraryWithoutNVML(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("needs the Linux C library")
+	}
+	// libc loads but lacks the NVML symbols: the backend has to unload it,
+	// try the next candidate and report why libc was rejected.
+	b := newDefaultBackend()
+	_, err := b.Init([]string{"libc.so.6", "/nonexistent/libnvidia-ml.so.1"}, 0)
+	if !errors.Is(err, ErrFunctionNotFound) {
+		t.Fatalf("Init() = %v, want ErrFunctionNotFound", err)
+	}
+	if _, err := b.SupportedFunctions(); !errors.Is(err, ErrLibraryNotFound) {
+		t.Errorf("SupportedFunctions() after a failed Init = %v, want ErrLibraryNotFound", err)
+	}
+}
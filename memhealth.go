@@ -0,0 +1,202 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RetiredPage is a page of device memory retired because of ECC errors.
+type RetiredPage struct {
+	// Address is the hardware address of the page, as reported by XID 63,
+	// which doesn't match the virtual addresses used in CUDA.
+	Address   uint64
+	Timestamp time.Time // when the page was retired
+	Cause     PageRetirementCause
+}
+
+// RetiredPages returns the pages retired for the given cause, including the
+// ones pending retirement until the next reboot.
+func (d Device) RetiredPages(cause PageRetirementCause) ([]RetiredPage, error) {
+	return backend.DeviceGetRetiredPages(d.handle, cause)
+}
+
+// RetiredPagesPending returns whether pages are pending retirement, which
+// needs a reboot.
+func (d Device) RetiredPagesPending() (bool, error) {
+	pending, err := backend.DeviceGetRetiredPagesPendingStatus(d.handle)
+	return pending == EnableStateFeatureEnabled, err
+}
+
+// RemappedRows are the rows of device memory remapped by the row remapper
+// of Ampere and newer devices, which replaces page retirement.
+type RemappedRows struct {
+	Correctable   uint // rows remapped because of correctable errors
+	Uncorrectable uint // rows remapped because of uncorrectable errors
+	// Pending is set while remappings wait for a GPU reset.
+	Pending bool
+	// Failure is set if a remapping ever failed.
+	Failure bool
+}
+
+// RemappedRows returns the rows remapped by the row remapper. It fails with
+// ErrNotSupported on devices in MIG mode.
+func (d Device) RemappedRows() (RemappedRows, error) {
+	return backend.DeviceGetRemappedRows(d.handle)
+}
+
+// RowRemapperHistogram is the equivalent of
+// nvmlRowRemapperHistogramValues_t: the number of memory banks by how many
+// spare rows they have left.
+type RowRemapperHistogram struct {
+	Max     uint
+	High    uint
+	Partial uint
+	Low     uint
+	None    uint
+}
+
+// RowRemapperHistogram returns the remapping availability of the memory
+// banks.
+func (d Device) RowRemapperHistogram() (RowRemapperHistogram, error) {
+	return backend.DeviceGetRowRemapperHistogram(d.handle)
+}
+
+// MemoryHealthVerdict summarizes the state of the device memory.
+type MemoryHealthVerdict int
+
+// The verdicts, from the best to the worst.
+const (
+	MemoryHealthy MemoryHealthVerdict = iota
+	// MemoryRebootRequired is given while page retirements or row
+	// remappings wait for a reboot or GPU reset.
+	MemoryRebootRequired
+	// MemoryRMARecommended is given when a row remapping failed or too many
+	// pages were retired.
+	MemoryRMARecommended
+)
+
+func (v MemoryHealthVerdict) String() string {
+	switch v {
+	case MemoryHealthy:
+		return "healthy"
+	case MemoryRebootRequired:
+		return "reboot-required"
+	case MemoryRMARecommended:
+		return "rma-recommended"
+	default:
+		return "unknown"
+	}
+}
+
+// RetiredPagesRMAThreshold is the number of retired pages from which an RMA
+// is recommended, following the NVIDIA page retirement RMA policy.
+const RetiredPagesRMAThreshold = 60
+
+// MemoryHealth is the page retirement and row remapping state of a device.
+// Devices before Ampere retire pages, Ampere and newer remap rows.
+type MemoryHealth struct {
+	// RetiredPages are nil if the device doesn't support page retirement.
+	RetiredPages        []RetiredPage
+	RetiredPagesPending bool
+	// RemappedRows and RowRemapperHistogram are nil if the device doesn't
+	// support row remapping.
+	RemappedRows         *RemappedRows
+	RowRemapperHistogram *RowRemapperHistogram
+
+	Verdict MemoryHealthVerdict
+	// Reasons explain the verdict, they are empty for healthy devices.
+	Reasons []string
+}
+
+// MemoryHealth returns the page retirement and row remapping state of the
+// device and its verdict. The parts the device doesn't support are left
+// out; if it supports neither, the error matches ErrNotSupported. The other
+// errors are combined in the returned error, and the verdict is based on the
+// parts that could be read.
+func (d Device) MemoryHealth() (MemoryHealth, error) {
+	var h MemoryHealth
+	var errs, unsupported errorList
+	check := func(err error) bool {
+		switch {
+		case err == nil:
+			return true
+		case errors.Is(err, ErrNotSupported) || errors.Is(err, ErrFunctionNotFound):
+			unsupported = append(unsupported, err)
+		default:
+			errs = append(errs, err)
+		}
+		return false
+	}
+
+	retirement := false
+	for _, cause := range []PageRetirementCause{PageRetirementCauseMultipleSingleBitEccErrors, PageRetirementCauseDoubleBitEccError} {
+		pages, err := d.RetiredPages(cause)
+		if check(err) {
+			retirement = true
+			h.RetiredPages = append(h.RetiredPages, pages...)
+		}
+	}
+	if retirement {
+		if h.RetiredPages == nil {
+			h.RetiredPages = []RetiredPage{}
+		}
+		if pending, err := d.RetiredPagesPending(); check(err) {
+			h.RetiredPagesPending = pending
+		}
+	}
+	if rows, err := d.RemappedRows(); check(err) {
+		h.RemappedRows = &rows
+	}
+	if hist, err := d.RowRemapperHistogram(); check(err) {
+		h.RowRemapperHistogram = &hist
+	}
+	if len(errs) == 0 && !retirement && h.RemappedRows == nil {
+		return h, unsupported.err()
+	}
+
+	h.verdict()
+	return h, errs.err()
+}
+
+// verdict sets the Verdict and Reasons of h.
+func (h *MemoryHealth) verdict() {
+	rma := func(reason string) {
+		h.Verdict = MemoryRMARecommended
+		h.Reasons = append(h.Reasons, reason)
+	}
+	reboot := func(reason string) {
+		if h.Verdict < MemoryRebootRequired {
+			h.Verdict = MemoryRebootRequired
+		}
+		h.Reasons = append(h.Reasons, reason)
+	}
+	if h.RemappedRows != nil && h.RemappedRows.Failure {
+		rma("row remapping failed")
+	}
+	if len(h.RetiredPages) >= RetiredPagesRMAThreshold {
+		rma(fmt.Sprintf("%d pages retired", len(h.RetiredPages)))
+	}
+	if h.RetiredPagesPending {
+		reboot("pages pending retirement")
+	}
+	if h.RemappedRows != nil && h.RemappedRows.Pending {
+		reboot("row remappings pending")
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// retiredPages returns n retired pages, alternating between the causes.
+func retiredPages(n int) []RetiredPage {
+	pages := make([]RetiredPage, n)
+	for i := range pages {
+		pages[i] = RetiredPage{Address: uint64(i) << 12, Cause: PageRetirementCauseMultipleSingleBitEccErrors}
+		if i%2 == 1 {
+			pages[i].Cause = PageRetirementCauseDoubleBitEccError
+		}
+	}
+	return pages
+}
+
+func TestMemoryHealth(t *testing.T) {
+	// Devices before Ampere retire pages, Ampere and newer remap rows.
+	noRemapping := map[string]Return{
+		"nvmlDeviceGetRemappedRows":         ReturnErrorNotSupported,
+		"nvmlDeviceGetRowRemapperHistogram": ReturnErrorNotSupported,
+	}
+	noRetirement := map[string]Return{
+		"nvmlDeviceGetRetiredPages_v2":           ReturnErrorNotSupported,
+		"nvmlDeviceGetRetiredPagesPendingStatus": ReturnErrorNotSupported,
+	}
+	for _, c := range []struct {
+		name    string
+		device  *FakeDevice
+		verdict MemoryHealthVerdict
+		reasons []string
+		pages   int  // -1 if RetiredPages is nil
+		rows    bool // whether RemappedRows is set
+		err     error
+	}{
+		{
+			name:    "healthy retirement",
+			device:  &FakeDevice{Errors: noRemapping},
+			verdict: MemoryHealthy,
+		},
+		{
+			name: "pending retirement",
+			device: &FakeDevice{
+				Errors:              noRemapping,
+				RetiredPages:        retiredPages(2),
+				RetiredPagesPending: EnableStateFeatureEnabled,
+			},
+			verdict: MemoryRebootRequired,
+			reasons: []string{"pages pending retirement"},
+			pages:   2,
+		},
+		{
+			name:    "below the threshold",
+			device:  &FakeDevice{Errors: noRemapping, RetiredPages: retiredPages(RetiredPagesRMAThreshold - 1)},
+			verdict: MemoryHealthy,
+			pages:   RetiredPagesRMAThreshold - 1,
+		},
+		{
+			name: "at the threshold",
+			device: &FakeDevice{
+				Errors:              noRemapping,
+				RetiredPages:        retiredPages(RetiredPagesRMAThreshold),
+				RetiredPagesPending: EnableStateFeatureEnabled,
+			},
+			verdict: MemoryRMARecommended,
+			reasons: []string{"60 pages retired", "pages pending retirement"},
+			pages:   RetiredPagesRMAThreshold,
+		},
+		{
+			name:    "healthy remapping",
+			device:  &FakeDevice{Errors: noRetirement},
+			verdict: MemoryHealthy,
+			pages:   -1,
+			rows:    true,
+		},
+		{
+			name:    "pending remapping",
+			device:  &FakeDevice{Errors: noRetirement, RemappedRows: RemappedRows{Uncorrectable: 1, Pending: true}},
+			verdict: MemoryRebootRequired,
+			reasons: []string{"row remappings pending"},
+			pages:   -1,
+			rows:    true,
+		},
+		{
+			name:    "remapping failure",
+			device:  &FakeDevice{Errors: noRetirement, RemappedRows: RemappedRows{Uncorrectable: 512, Failure: true}},
+			verdict: MemoryRMARecommended,
+			reasons: []string{"row remapping failed"},
+			pages:   -1,
+			rows:    true,
+		},
+		{
+			name: "neither",
+			device: &FakeDevice{Errors: map[string]Return{
+				"nvmlDeviceGetRetiredPages_v2":      ReturnErrorNotSupported,
+				"nvmlDeviceGetRemappedRows":         ReturnErrorNotSupported,
+				"nvmlDeviceGetRowRemapperHistogram": ReturnErrorNotSupported,
+			}},
+			verdict: MemoryHealthy,
+			pages:   -1,
+			err:     ErrNotSupported,
+		},
+		{
+			name: "failed pending status",
+			device: &FakeDevice{
+				Errors: map[string]Return{
+					"nvmlDeviceGetRetiredPagesPendingStatus": ReturnErrorUnknown,
+					"nvmlDeviceGetRemappedRows":              ReturnErrorNotSupported,
+					"nvmlDeviceGetRowRemapperHistogram":      ReturnErrorNotSupported,
+				},
+				RetiredPages: retiredPages(RetiredPagesRMAThreshold),
+			},
+			verdict: MemoryRMARecommended,
+			reasons: []string{"60 pages retired"},
+			pages:   RetiredPagesRMAThreshold,
+			err:     ErrUnknown,
+		},
+	} {
+		_, devices := initFakeDevices(t, c.device)
+		h, err := devices[0].MemoryHealth()
+		Shutdown()
+		SetBackend(nil)
+
+		if !errors.Is(err, c.err) {
+			t.Errorf("%s: MemoryHealth() error = %v, want %v", c.name, err, c.err)
+		}
+		if h.Verdict != c.verdict || !reflect.DeepEqual(h.Reasons, c.reasons) {
+			t.Errorf("%s: verdict = %v %q, want %v %q", c.name, h.Verdict, h.Reasons, c.verdict, c.reasons)
+		}
+		if c.pages < 0 && h.RetiredPages != nil || c.pages >= 0 && (h.RetiredPages == nil || len(h.RetiredPages) != c.pages) {
+			t.Errorf("%s: RetiredPages = %v, want %d pages", c.name, h.RetiredPages, c.pages)
+		}
+		if (h.RemappedRows != nil) != c.rows || (h.RowRemapperHistogram != nil) != c.rows {
+			t.Errorf("%s: RemappedRows = %v, RowRemapperHistogram = %v; want them set: %v", c.name, h.RemappedRows, h.RowRemapperHistogram, c.rows)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import "errors"
+
+// GpuInstanceProfile is one of the NVML_GPU_INSTANCE_PROFILE_* defines.
+type GpuInstanceProfile uint
+
+// Enumeration mapping for GpuInstanceProfile to NVML_GPU_INSTANCE_PROFILE_*
+const (
+	GpuInstanceProfile1Slice GpuInstanceProfile = 0x0
+	GpuInstanceProfile2Slice GpuInstanceProfile = 0x1
+	GpuInstanceProfile3Slice GpuInstanceProfile = 0x2
+	GpuInstanceProfile4Slice GpuInstanceProfile = 0x3
+	GpuInstanceProfile7Slice GpuInstanceProfile = 0x4
+)
+
+// ComputeInstanceProfile is one of the NVML_COMPUTE_INSTANCE_PROFILE_*
+// defines.
+type ComputeInstanceProfile uint
+
+// Enumeration mapping for ComputeInstanceProfile to NVML_COMPUTE_INSTANCE_PROFILE_*
+const (
+	ComputeInstanceProfile1Slice ComputeInstanceProfile = 0x0
+	ComputeInstanceProfile2Slice ComputeInstanceProfile = 0x1
+	ComputeInstanceProfile3Slice ComputeInstanceProfile = 0x2
+	ComputeInstanceProfile4Slice ComputeInstanceProfile = 0x3
+	ComputeInstanceProfile7Slice ComputeInstanceProfile = 0x4
+)
+
+// ComputeInstanceEngineProfile is one of the
+// NVML_COMPUTE_INSTANCE_ENGINE_PROFILE_* defines.
+type ComputeInstanceEngineProfile uint
+
+// Enumeration mapping for ComputeInstanceEngineProfile to NVML_COMPUTE_INSTANCE_ENGINE_PROFILE_*
+const (
+	// All the engines except multiprocessors are shared.
+	ComputeInstanceEngineProfileShared ComputeInstanceEngineProfile = 0x0
+)
+
+// GpuInstanceProfileInfo is the equivalent of nvmlGpuInstanceProfileInfo_t.
+type GpuInstanceProfileInfo struct {
+	ID                  uint // unique profile ID within the device
+	IsP2pSupported      bool
+	SliceCount          uint
+	InstanceCount       uint // maximum number of instances of the profile
+	MultiprocessorCount uint
+	CopyEngineCount     uint
+	DecoderCount        uint
+	EncoderCount        uint
+	JpegCount           uint
+	OfaCount            uint
+	MemorySizeMB        uint64
+}
+
+// GpuInstancePlacement is the equivalent of nvmlGpuInstancePlacement_t, the
+// location of a GPU instance within its device in slices.
+type GpuInstancePlacement struct {
+	Start uint
+	Size  uint
+}
+
+// GpuInstanceInfo is the equivalent of nvmlGpuInstanceInfo_t.
+type GpuInstanceInfo struct {
+	Device    Device // parent device
+	ID        uint   // unique instance ID within the device
+	ProfileID uint
+	Placement GpuInstancePlacement
+}
+
+// ComputeInstanceProfileInfo is the equivalent of
+// nvmlComputeInstanceProfileInfo_t.
+type ComputeInstanceProfileInfo struct {
+	ID                    uint // unique profile ID within the GPU instance
+	SliceCount            uint
+	InstanceCount         uint // maximum number of instances of the profile
+	MultiprocessorCount   uint
+	SharedCopyEngineCount uint
+	SharedDecoderCount    uint
+	SharedEncoderCount    uint
+	SharedJpegCount       uint
+	SharedOfaCount        uint
+}
+
+// ComputeInstanceInfo is the equivalent of nvmlComputeInstanceInfo_t.
+type ComputeInstanceInfo struct {
+	Device      Device      // parent device
+	GpuInstance GpuInstance // parent GPU instance
+	ID          uint        // unique instance ID within the GPU instance
+	ProfileID   uint
+}
+
+// GpuInstanceHandle is the backend specific value identifying a GPU instance.
+// It is opaque to everything but the Backend that returned it.
+type GpuInstanceHandle interface{}
+
+// ComputeInstanceHandle is the backend specific value identifying a compute
+// instance. It is opaque to everything but the Backend that returned it.
+type ComputeInstanceHandle interface{}
+
+// GpuInstance is the handle for a MIG GPU instance, a partition of the
+// memory and multiprocessors of a device.
+type GpuInstance struct {
+	handle GpuInstanceHandle
+}
+
+// ComputeInstance is the handle for a MIG compute instance, a partition of
+// the multiprocessors of a GPU instance.
+type ComputeInstance struct {
+	handle ComputeInstanceHandle
+}
+
+// MigMode returns the current MIG mode of the device and the one it will
+// switch to at the next reset.
+func (d Device) MigMode() (current, pending EnableState, err error) {
+	return backend.DeviceGetMigMode(d.handle)
+}
+
+// SetMigMode enables or disables MIG mode. This may unbind or reset the
+// device; if the mode cannot be activated right away, e.g. because the device
+// is in use (ErrInUse) or has to be reset by the administrator
+// (ErrResetRequired), the corresponding error is returned and the mode stays
+// pending.
+func (d Device) SetMigMode(mode EnableState) error {
+	activationStatus, err := backend.DeviceSetMigMode(d.handle, mode)
+	if err != nil {
+		return err
+	}
+	return newError("nvmlDeviceSetMigMode", activationStatus)
+}
+
+// GpuInstanceProfileInfo returns the description of a GPU instance profile.
+func (d Device) GpuInstanceProfileInfo(profile GpuInstanceProfile) (GpuInstanceProfileInfo, error) {
+	return backend.DeviceGetGpuInstanceProfileInfo(d.handle, profile)
+}
+
+// GpuInstancePossiblePlacements returns all the placements GPU instances of
+// the given profile can have, free or not.
+func (d Device) GpuInstancePossiblePlacements(profile GpuInstanceProfileInfo) ([]GpuInstancePlacement, error) {
+	return backend.DeviceGetGpuInstancePossiblePlacements(d.handle, profile.ID, profile.InstanceCount)
+}
+
+// GpuInstanceRemainingCapacity returns how many more GPU instances of the
+// profile with the given ID can be created.
+func (d Device) GpuInstanceRemainingCapacity(profileID uint) (uint, error) {
+	return backend.DeviceGetGpuInstanceRemainingCapacity(d.handle, profileID)
+}
+
+// CreateGpuInstance creates a GPU instance of the profile with the given ID.
+func (d Device) CreateGpuInstance(profileID uint) (GpuInstance, error) {
+	h, err := backend.DeviceCreateGpuInstance(d.handle, profileID)
+	return GpuInstance{h}, err
+}
+
+// GpuInstances returns the existing GPU instances of the given profile.
+func (d Device) GpuInstances(profile GpuInstanceProfileInfo) ([]GpuInstance, error) {
+	handles, err := backend.DeviceGetGpuInstances(d.handle, profile.ID, profile.InstanceCount)
+	if err != nil {
+		return nil, err
+	}
+	instances := make([]GpuInstance, len(handles))
+	for i, h := range handles {
+		instances[i] = GpuInstance{h}
+	}
+	return instances, nil
+}
+
+// GpuInstanceByID returns the GPU instance with the given ID.
+func (d Device) GpuInstanceByID(id uint) (GpuInstance, error) {
+	h, err := backend.DeviceGetGpuInstanceById(d.handle, id)
+	return GpuInstance{h}, err
+}
+
+// IsMigDevice returns whether the device is a MIG device, i.e. a compute
+// instance obtained with MigDeviceByIndex.
+func (d Device) IsMigDevice() (bool, error) {
+	return backend.DeviceIsMigDeviceHandle(d.handle)
+}
+
+// GpuInstanceID returns the ID of the GPU instance of a MIG device.
+func (d Device) GpuInstanceID() (uint, error) {
+	return backend.DeviceGetGpuInstanceId(d.handle)
+}
+
+// ComputeInstanceID returns the ID of the compute instance of a MIG device.
+func (d Device) ComputeInstanceID() (uint, error) {
+	return backend.DeviceGetComputeInstanceId(d.handle)
+}
+
+// MaxMigDeviceCount returns how many MIG devices the device can have, 0 if
+// MIG is not supported or not enabled.
+func (d Device) MaxMigDeviceCount() (uint, error) {
+	return backend.DeviceGetMaxMigDeviceCount(d.handle)
+}
+
+// MigDeviceByIndex returns the MIG device with the given index, from 0 to
+// MaxMigDeviceCount()-1. It fails with an error matching ErrNotFound if there
+// is no MIG device at that index.
+//
+// MIG devices are Devices scoped to a compute instance: methods such as
+// MemoryInfo, UUID or ComputeProcesses report on the instance only,
+// while the ones that don't make sense for an instance fail.
+func (d Device) MigDeviceByIndex(index uint) (Device, error) {
+	h, err := backend.DeviceGetMigDeviceHandleByIndex(d.handle, index)
+	return Device{h}, err
+}
+
+// MigDevices returns all the MIG devices of the device.
+func (d Device) MigDevices() ([]Device, error) {
+	count, err := d.MaxMigDeviceCount()
+	if err != nil {
+		return nil, err
+	}
+	var devices []Device
+	for i := uint(0); i < count; i++ {
+		mig, err := d.MigDeviceByIndex(i)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, mig)
+	}
+	return devices, nil
+}
+
+// ParentDevice returns the device a MIG device belongs to.
+func (d Device) ParentDevice() (Device, error) {
+	h, err := backend.DeviceGetDeviceHandleFromMigDeviceHandle(d.handle)
+	return Device{h}, err
+}
+
+// Info returns the description of the GPU instance.
+func (gi GpuInstance) Info() (GpuInstanceInfo, error) {
+	device, info, err := backend.GpuInstanceGetInfo(gi.handle)
+	info.Device = Device{device}
+	return info, err
+}
+
+// Destroy destroys the GPU instance. It fails with an error matching ErrInUse
+// if it still has compute instances or running processes.
+func (gi GpuInstance) Destroy() error {
+	return backend.GpuInstanceDestroy(gi.handle)
+}
+
+// ComputeInstanceProfileInfo returns the description of a compute instance
+// profile of the GPU instance.
+func (gi GpuInstance) ComputeInstanceProfileInfo(profile ComputeInstanceProfile, engProfile ComputeInstanceEngineProfile) (ComputeInstanceProfileInfo, error) {
+	return backend.GpuInstanceGetComputeInstanceProfileInfo(gi.handle, profile, engProfile)
+}
+
+// ComputeInstanceRemainingCapacity returns how many more compute instances of
+// the profile with the given ID can be created.
+func (gi GpuInstance) ComputeInstanceRemainingCapacity(profileID uint) (uint, error) {
+	return backend.GpuInstanceGetComputeInstanceRemainingCapacity(gi.handle, profileID)
+}
+
+// CreateComputeInstance creates a compute instance of the profile with the
+// given ID.
+func (gi GpuInstance) CreateComputeInstance(profileID uint) (ComputeInstance, error) {
+	h, err := backend.GpuInstanceCreateComputeInstance(gi.handle, profileID)
+	return ComputeInstance{h}, err
+}
+
+// ComputeInstances returns the existing compute instances of the given
+// profile.
+func (gi GpuInstance) ComputeInstances(profile ComputeInstanceProfileInfo) ([]ComputeInstance, error) {
+	handles, err := backend.GpuInstanceGetComputeInstances(gi.handle, profile.ID, profile.InstanceCount)
+	if err != nil {
+		return nil, err
+	}
+	instances := make([]ComputeInstance, len(handles))
+	for i, h := range handles {
+		instances[i] = ComputeInstance{h}
+	}
+	return instances, nil
+}
+
+// ComputeInstanceByID returns the compute instance with the given ID.
+func (gi GpuInstance) ComputeInstanceByID(id uint) (ComputeInstance, error) {
+	h, err := backend.GpuInstanceGetComputeInstanceById(gi.handle, id)
+	return ComputeInstance{h}, err
+}
+
+// Info returns the description of the compute instance.
+func (ci ComputeInstance) Info() (ComputeInstanceInfo, error) {
+	device, gpuInstance, info, err := backend.ComputeInstanceGetInfo(ci.handle)
+	info.Device = Device{device}
+	info.GpuInstance = GpuInstance{gpuInstance}
+	return info, err
+}
+
+// Destroy destroys the compute instance. It fails with an error matching
+// ErrInUse if processes are running on it.
+func (ci ComputeInstance) Destroy() error {
+	return backend.ComputeInstanceDestroy(ci.handle)
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMigMode(t *testing.T) {
+	_, devices := initFakeDevices(t, &FakeDevice{})
+	defer SetBackend(nil)
+	defer Shutdown()
+	d := devices[0]
+
+	if count, err := d.MaxMigDeviceCount(); err != nil || count != 0 {
+		t.Errorf("MaxMigDeviceCount() without MIG = %d, %v; want 0", count, err)
+	}
+	if _, err := d.CreateGpuInstance(0); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("CreateGpuInstance() without MIG = %v, want ErrNotSupported", err)
+	}
+	if err := d.SetMigMode(EnableStateFeatureEnabled); err != nil {
+		t.Fatal(err)
+	}
+	if current, pending, err := d.MigMode(); err != nil || current != EnableStateFeatureEnabled || pending != EnableStateFeatureEnabled {
+		t.Errorf("MigMode() = %v, %v, %v; want enabled", current, pending, err)
+	}
+	if count, err := d.MaxMigDeviceCount(); err != nil || count != fakeMaxMigDevices {
+		t.Errorf("MaxMigDeviceCount() = %d, %v; want %d", count, err, fakeMaxMigDevices)
+	}
+	if err := d.SetMigMode(EnableState(2)); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("SetMigMode(2) = %v, want ErrInvalidArgument", err)
+	}
+}
+
+func TestMigInstances(t *testing.T) {
+	a := &FakeDevice{
+		UUID:    "GPU-8932f937-d72c-4106-c12f-20bd9faed9f6",
+		MigMode: EnableStateFeatureEnabled,
+		GpuInstanceProfiles: map[GpuInstanceProfile]GpuInstanceProfileInfo{
+			GpuInstanceProfile3Slice: {ID: 9, SliceCount: 3, InstanceCount: 2, MemorySizeMB: 20096},
+		},
+	}
+	f, devices := initFakeDevices(t, a)
+	defer SetBackend(nil)
+	defer Shutdown()
+	d := devices[0]
+
+	profile, err := d.GpuInstanceProfileInfo(GpuInstanceProfile3Slice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.GpuInstanceProfileInfo(GpuInstanceProfile1Slice); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("GpuInstanceProfileInfo(1 slice) = %v, want ErrNotSupported", err)
+	}
+	placements, err := d.GpuInstancePossiblePlacements(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []GpuInstancePlacement{{0, 3}, {3, 3}}; !reflect.DeepEqual(placements, want) {
+		t.Errorf("GpuInstancePossiblePlacements() = %v, want %v", placements, want)
+	}
+
+	// Create GPU instances until the profile runs out of placements.
+	for i := uint(0); i < 2; i++ {
+		if capacity, err := d.GpuInstanceRemainingCapacity(9); err != nil || capacity != 2-i {
+			t.Errorf("GpuInstanceRemainingCapacity() = %d, %v; want %d", capacity, err, 2-i)
+		}
+		if _, err := d.CreateGpuInstance(9); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := d.CreateGpuInstance(9); !errors.Is(err, ErrInsufficientResources) {
+		t.Errorf("CreateGpuInstance() without capacity = %v, want ErrInsufficientResources", err)
+	}
+	instances, err := d.GpuInstances(profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(instances) != 2 {
+		t.Fatalf("GpuInstances() = %v, want 2 instances", instances)
+	}
+	gi, err := d.GpuInstanceByID(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := gi.Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (GpuInstanceInfo{Device: d, ID: 1, ProfileID: 9, Placement: GpuInstancePlacement{3, 3}}); info != want {
+		t.Errorf("Info() = %+v, want %+v", info, want)
+	}
+	if _, err := d.GpuInstanceByID(2); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GpuInstanceByID(2) = %v, want ErrNotFound", err)
+	}
+
+	// Create a compute instance, which comes with its MIG device.
+	f.Lock()
+	gi.handle.(*FakeGpuInstance).ComputeInstanceProfiles = map[ComputeInstanceProfile]ComputeInstanceProfileInfo{
+		ComputeInstanceProfile3Slice: {ID: 2, SliceCount: 3, InstanceCount: 1},
+	}
+	f.Unlock()
+	ciProfile, err := gi.ComputeInstanceProfileInfo(ComputeInstanceProfile3Slice, ComputeInstanceEngineProfileShared)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := gi.CreateComputeInstance(2); err != nil {
+		t.Fatal(err)
+	}
+	if capacity, err := gi.ComputeInstanceRemainingCapacity(2); err != nil || capacity != 0 {
+		t.Errorf("ComputeInstanceRemainingCapacity() = %d, %v; want 0", capacity, err)
+	}
+	if _, err := gi.CreateComputeInstance(2); !errors.Is(err, ErrInsufficientResources) {
+		t.Errorf("CreateComputeInstance() without capacity = %v, want ErrInsufficientResources", err)
+	}
+	computeInstances, err := gi.ComputeInstances(ciProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(computeInstances) != 1 {
+		t.Fatalf("ComputeInstances() = %v, want 1 instance", computeInstances)
+	}
+	ci, err := gi.ComputeInstanceByID(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ciInfo, err := ci.Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (ComputeInstanceInfo{Device: d, GpuInstance: gi, ID: 0, ProfileID: 2}); ciInfo != want {
+		t.Errorf("Info() = %+v, want %+v", ciInfo, want)
+	}
+
+	// The GPU instance can't go while it has compute instances.
+	if err := gi.Destroy(); !errors.Is(err, ErrInUse) {
+		t.Errorf("Destroy() of a used GPU instance = %v, want ErrInUse", err)
+	}
+	if err := ci.Destroy(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gi.Destroy(); err != nil {
+		t.Fatal(err)
+	}
+	if instances, err := d.GpuInstances(profile); err != nil || len(instances) != 1 {
+		t.Errorf("GpuInstances() after Destroy() = %v, %v; want 1 instance", instances, err)
+	}
+	if _, err := gi.Info(); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Info() of a destroyed GPU instance = %v, want ErrInvalidArgument", err)
+	}
+}
+
+func TestMigDevices(t *testing.T) {
+	_, devices := useVisibleDevicesFake(t)
+	defer SetBackend(nil)
+	defer Shutdown()
+	d := devices[0]
+
+	mig, err := d.MigDeviceByIndex(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.MigDeviceByIndex(1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("MigDeviceByIndex(1) = %v, want ErrNotFound", err)
+	}
+	if _, err := d.MigDeviceByIndex(fakeMaxMigDevices); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("MigDeviceByIndex(%d) = %v, want ErrInvalidArgument", fakeMaxMigDevices, err)
+	}
+	migs, err := d.MigDevices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []Device{mig}; !reflect.DeepEqual(migs, want) {
+		t.Errorf("MigDevices() = %v, want %v", migs, want)
+	}
+
+	if isMig, err := mig.IsMigDevice(); err != nil || !isMig {
+		t.Errorf("IsMigDevice() = %v, %v; want true", isMig, err)
+	}
+	if isMig, err := d.IsMigDevice(); err != nil || isMig {
+		t.Errorf("IsMigDevice() of the parent = %v, %v; want false", isMig, err)
+	}
+	if id, err := mig.GpuInstanceID(); err != nil || id != 0 {
+		t.Errorf("GpuInstanceID() = %d, %v; want 0", id, err)
+	}
+	if id, err := mig.ComputeInstanceID(); err != nil || id != 0 {
+		t.Errorf("ComputeInstanceID() = %d, %v; want 0", id, err)
+	}
+	if _, err := d.GpuInstanceID(); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("GpuInstanceID() of the parent = %v, want ErrNotSupported", err)
+	}
+	if parent, err := mig.ParentDevice(); err != nil || parent != d {
+		t.Errorf("ParentDevice() = %v, %v; want %v", parent, err, d)
+	}
+	if uuid, err := mig.UUID(); err != nil || uuid != "MIG-GPU-8932f937-d72c-4106-c12f-20bd9faed9f6/0/0" {
+		t.Errorf("UUID() = %q, %v", uuid, err)
+	}
+
+	// Devices without MIG have no MIG devices.
+	if migs, err := devices[1].MigDevices(); err != nil || len(migs) != 0 {
+		t.Errorf("MigDevices() without MIG = %v, %v; want none", migs, err)
+	}
+
+	// MIG mode can't be disabled while there are GPU instances.
+	if err := d.SetMigMode(EnableStateFeatureDisabled); !errors.Is(err, ErrInUse) {
+		t.Errorf("SetMigMode(disabled) = %v, want ErrInUse", err)
+	}
+	if current, pending, err := d.MigMode(); err != nil || current != EnableStateFeatureEnabled || pending != EnableStateFeatureDisabled {
+		t.Errorf("MigMode() = %v, %v, %v; want enabled, disabled pending", current, pending, err)
+	}
+}
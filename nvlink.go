@@ -0,0 +1,240 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import "errors"
+
+// MaxNvLinks is the maximum number of NvLink links of a device, the
+// equivalent of NVML_NVLINK_MAX_LINKS.
+const MaxNvLinks = 12
+
+// NvLinkCapability is the equivalent of nvmlNvLinkCapability_t.
+type NvLinkCapability int
+
+// Enumeration mapping for NvLinkCapability to nvmlNvLinkCapability_t
+const (
+	NvLinkCapP2PSupported  NvLinkCapability = 0 // P2P over NVLink is supported
+	NvLinkCapSysmemAccess  NvLinkCapability = 1 // Access to system memory is supported
+	NvLinkCapP2PAtomics    NvLinkCapability = 2 // P2P atomics are supported
+	NvLinkCapSysmemAtomics NvLinkCapability = 3 // System memory atomics are supported
+	NvLinkCapSliBridge     NvLinkCapability = 4 // SLI is supported over this link
+	NvLinkCapValid         NvLinkCapability = 5 // Link is supported on this device
+)
+
+func (c NvLinkCapability) String() string {
+	switch c {
+	case NvLinkCapP2PSupported:
+		return "p2p supported"
+	case NvLinkCapSysmemAccess:
+		return "sysmem access"
+	case NvLinkCapP2PAtomics:
+		return "p2p atomics"
+	case NvLinkCapSysmemAtomics:
+		return "sysmem atomics"
+	case NvLinkCapSliBridge:
+		return "sli bridge"
+	case NvLinkCapValid:
+		return "valid"
+	default:
+		return "unknown"
+	}
+}
+
+// NvLinkErrorCounter is the equivalent of nvmlNvLinkErrorCounter_t.
+type NvLinkErrorCounter int
+
+// Enumeration mapping for NvLinkErrorCounter to nvmlNvLinkErrorCounter_t
+const (
+	NvLinkErrorDlReplay   NvLinkErrorCounter = 0 // Data link transmit replay error counter
+	NvLinkErrorDlRecovery NvLinkErrorCounter = 1 // Data link transmit recovery error counter
+	NvLinkErrorDlCrcFlit  NvLinkErrorCounter = 2 // Data link receive flow control digit CRC error counter
+	NvLinkErrorDlCrcData  NvLinkErrorCounter = 3 // Data link receive data CRC error counter
+)
+
+func (c NvLinkErrorCounter) String() string {
+	switch c {
+	case NvLinkErrorDlReplay:
+		return "replay"
+	case NvLinkErrorDlRecovery:
+		return "recovery"
+	case NvLinkErrorDlCrcFlit:
+		return "crc flit"
+	case NvLinkErrorDlCrcData:
+		return "crc data"
+	default:
+		return "unknown"
+	}
+}
+
+// NvLinkCounterUnit is the equivalent of nvmlNvLinkUtilizationCountUnits_t.
+type NvLinkCounterUnit int
+
+// Enumeration mapping for NvLinkCounterUnit to nvmlNvLinkUtilizationCountUnits_t
+const (
+	NvLinkCounterUnitCycles   NvLinkCounterUnit = 0
+	NvLinkCounterUnitPackets  NvLinkCounterUnit = 1
+	NvLinkCounterUnitBytes    NvLinkCounterUnit = 2
+	NvLinkCounterUnitReserved NvLinkCounterUnit = 3
+)
+
+func (u NvLinkCounterUnit) String() string {
+	switch u {
+	case NvLinkCounterUnitCycles:
+		return "cycles"
+	case NvLinkCounterUnitPackets:
+		return "packets"
+	case NvLinkCounterUnitBytes:
+		return "bytes"
+	case NvLinkCounterUnitReserved:
+		return "reserved"
+	default:
+		return "unknown"
+	}
+}
+
+// NvLinkPacketFilter is the equivalent of
+// nvmlNvLinkUtilizationCountPktTypes_t. Filters can be combined with the
+// bitwise or operator; they only apply to packet and byte counters.
+type NvLinkPacketFilter int
+
+// Enumeration mapping for NvLinkPacketFilter to nvmlNvLinkUtilizationCountPktTypes_t
+const (
+	NvLinkPacketFilterNop        NvLinkPacketFilter = 0x1  // no operation packets
+	NvLinkPacketFilterRead       NvLinkPacketFilter = 0x2  // read packets
+	NvLinkPacketFilterWrite      NvLinkPacketFilter = 0x4  // write packets
+	NvLinkPacketFilterRatom      NvLinkPacketFilter = 0x8  // reduction atomic requests
+	NvLinkPacketFilterNratom     NvLinkPacketFilter = 0x10 // non-reduction atomic requests
+	NvLinkPacketFilterFlush      NvLinkPacketFilter = 0x20 // flush requests
+	NvLinkPacketFilterRespData   NvLinkPacketFilter = 0x40 // responses with data
+	NvLinkPacketFilterRespNoData NvLinkPacketFilter = 0x80 // responses without data
+	NvLinkPacketFilterAll        NvLinkPacketFilter = 0xFF // all packets
+)
+
+// NvLinkUtilizationControl is the equivalent of
+// nvmlNvLinkUtilizationControl_t.
+type NvLinkUtilizationControl struct {
+	Units        NvLinkCounterUnit
+	PacketFilter NvLinkPacketFilter
+}
+
+// NvLink is the handle for one of the NvLink links of a device, obtained with
+// Device.NvLinks.
+//
+// Each link has two utilization counters, 0 and 1, which count what their
+// NvLinkUtilizationControl selects. Newer drivers deprecate them in favor
+// of the FieldIDNvLinkThroughput* fields of Device.FieldValuesScoped.
+type NvLink struct {
+	Device Device
+	Link   uint
+}
+
+// NvLinks returns the NvLink links of the device, active or not. Devices
+// without NvLink have none.
+func (d Device) NvLinks() ([]NvLink, error) {
+	var links []NvLink
+	for link := uint(0); link < MaxNvLinks; link++ {
+		_, err := backend.DeviceGetNvLinkState(d.handle, link)
+		if errors.Is(err, ErrNotSupported) || errors.Is(err, ErrInvalidArgument) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, NvLink{d, link})
+	}
+	return links, nil
+}
+
+// State returns whether the link is active.
+func (l NvLink) State() (EnableState, error) {
+	return backend.DeviceGetNvLinkState(l.Device.handle, l.Link)
+}
+
+// Version returns the NvLink version of the link.
+func (l NvLink) Version() (uint, error) {
+	return backend.DeviceGetNvLinkVersion(l.Device.handle, l.Link)
+}
+
+// Capability returns whether the link has the capability c.
+func (l NvLink) Capability(c NvLinkCapability) (bool, error) {
+	result, err := backend.DeviceGetNvLinkCapability(l.Device.handle, l.Link, c)
+	return result != 0, err
+}
+
+// RemotePciInfo returns the PCI attributes of the node at the other end of the
+// link. PciSubSystemID is not filled in.
+func (l NvLink) RemotePciInfo() (PciInfo, error) {
+	return backend.DeviceGetNvLinkRemotePciInfo(l.Device.handle, l.Link)
+}
+
+// ErrorCounter returns the value of the error counter c.
+func (l NvLink) ErrorCounter(c NvLinkErrorCounter) (uint64, error) {
+	return backend.DeviceGetNvLinkErrorCounter(l.Device.handle, l.Link, c)
+}
+
+// ErrorCounters returns the values of all the error counters of the link.
+func (l NvLink) ErrorCounters() (map[NvLinkErrorCounter]uint64, error) {
+	var errs errorList
+	counters := make(map[NvLinkErrorCounter]uint64)
+	for _, c := range []NvLinkErrorCounter{NvLinkErrorDlReplay, NvLinkErrorDlRecovery, NvLinkErrorDlCrcFlit, NvLinkErrorDlCrcData} {
+		v, err := l.ErrorCounter(c)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		counters[c] = v
+	}
+	return counters, errs.err()
+}
+
+// ResetErrorCounters resets all the error counters of the link to zero.
+func (l NvLink) ResetErrorCounters() error {
+	return backend.DeviceResetNvLinkErrorCounters(l.Device.handle, l.Link)
+}
+
+// UtilizationControl returns what the utilization counter (0 or 1) counts.
+func (l NvLink) UtilizationControl(counter uint) (NvLinkUtilizationControl, error) {
+	return backend.DeviceGetNvLinkUtilizationControl(l.Device.handle, l.Link, counter)
+}
+
+// SetUtilizationControl sets what the utilization counter (0 or 1) counts,
+// resetting it if reset is true.
+func (l NvLink) SetUtilizationControl(counter uint, control NvLinkUtilizationControl, reset bool) error {
+	return backend.DeviceSetNvLinkUtilizationControl(l.Device.handle, l.Link, counter, control, reset)
+}
+
+// UtilizationCounter returns the receive and transmit values of the
+// utilization counter (0 or 1), in the units set by SetUtilizationControl.
+func (l NvLink) UtilizationCounter(counter uint) (rx, tx uint64, err error) {
+	return backend.DeviceGetNvLinkUtilizationCounter(l.Device.handle, l.Link, counter)
+}
+
+// FreezeUtilizationCounter freezes, or unfreezes if freeze is false, the
+// receive and transmit values of the utilization counter (0 or 1).
+func (l NvLink) FreezeUtilizationCounter(counter uint, freeze bool) error {
+	state := EnableStateFeatureDisabled
+	if freeze {
+		state = EnableStateFeatureEnabled
+	}
+	return backend.DeviceFreezeNvLinkUtilizationCounter(l.Device.handle, l.Link, counter, state)
+}
+
+// ResetUtilizationCounter resets the receive and transmit values of the
+// utilization counter (0 or 1).
+func (l NvLink) ResetUtilizationCounter(counter uint) error {
+	return backend.DeviceResetNvLinkUtilizationCounter(l.Device.handle, l.Link, counter)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNvLinks(t *testing.T) {
+	a := &FakeDevice{
+		// Link 1 isn't supported, and the links from 3 on don't exist.
+		NvLinks: []*FakeNvLink{
+			{State: EnableStateFeatureEnabled, Version: 3},
+			nil,
+			{State: EnableStateFeatureDisabled, Version: 3},
+		},
+	}
+	b := &FakeDevice{}
+	c := &FakeDevice{
+		NvLinks: []*FakeNvLink{{}},
+		Errors:  map[string]Return{"nvmlDeviceGetNvLinkState": ReturnErrorUnknown},
+	}
+	_, devices := initFakeDevices(t, a, b, c)
+	defer SetBackend(nil)
+	defer Shutdown()
+
+	links, err := devices[0].NvLinks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []NvLink{{devices[0], 0}, {devices[0], 2}}; !reflect.DeepEqual(links, want) {
+		t.Errorf("NvLinks() = %v, want %v", links, want)
+	}
+	if _, err := (NvLink{devices[0], 1}).Version(); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("Version() of link 1 = %v, want ErrNotSupported", err)
+	}
+	if state, err := links[1].State(); err != nil || state != EnableStateFeatureDisabled {
+		t.Errorf("State() of link 2 = %v, %v; want disabled", state, err)
+	}
+
+	// Devices without NvLink have no links.
+	if links, err := devices[1].NvLinks(); err != nil || len(links) != 0 {
+		t.Errorf("NvLinks() without NvLink = %v, %v; want none", links, err)
+	}
+	if _, err := devices[2].NvLinks(); !errors.Is(err, ErrUnknown) {
+		t.Errorf("NvLinks() = %v, want ErrUnknown", err)
+	}
+}
+
+func TestNvLinkCounters(t *testing.T) {
+	link := &FakeNvLink{
+		State: EnableStateFeatureEnabled,
+		ErrorCounters: map[NvLinkErrorCounter]uint64{
+			NvLinkErrorDlReplay:  3,
+			NvLinkErrorDlCrcData: 1,
+		},
+	}
+	_, devices := initFakeDevices(t, &FakeDevice{NvLinks: []*FakeNvLink{link}})
+	defer SetBackend(nil)
+	defer Shutdown()
+	l := NvLink{devices[0], 0}
+
+	counters, err := l.ErrorCounters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[NvLinkErrorCounter]uint64{
+		NvLinkErrorDlReplay:   3,
+		NvLinkErrorDlRecovery: 0,
+		NvLinkErrorDlCrcFlit:  0,
+		NvLinkErrorDlCrcData:  1,
+	}
+	if !reflect.DeepEqual(counters, want) {
+		t.Errorf("ErrorCounters() = %v, want %v", counters, want)
+	}
+	if err := l.ResetErrorCounters(); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := l.ErrorCounter(NvLinkErrorDlReplay); err != nil || v != 0 {
+		t.Errorf("ErrorCounter() after the reset = %d, %v; want 0", v, err)
+	}
+
+	control := NvLinkUtilizationControl{Units: NvLinkCounterUnitBytes, PacketFilter: NvLinkPacketFilterRead | NvLinkPacketFilterWrite}
+	if err := l.SetUtilizationControl(1, control, true); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := l.UtilizationControl(1); err != nil || got != control {
+		t.Errorf("UtilizationControl(1) = %+v, %v; want %+v", got, err, control)
+	}
+	if _, _, err := l.UtilizationCounter(2); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("UtilizationCounter(2) = %v, want ErrInvalidArgument", err)
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Initialize initializes NVML.
+// Call this before calling any other methods.
+//
+// Initialize is reference counted: NVML is loaded by the first call and stays
+// loaded until Shutdown has been called once for every successful Initialize.
+func Initialize() error {
+	return InitializeWithOptions()
+}
+
+// Shutdown releases a reference taken by Initialize, and shuts down NVML once
+// no references are left. Calling it with no reference held does nothing.
+// Call this once NVML is no longer being used.
+func Shutdown() error {
+	return release()
+}
+
+// SystemDriverVersion returns the the driver version on the system.
+func SystemDriverVersion() (string, error) {
+	return backend.SystemGetDriverVersion()
+}
+
+// SystemNVMLVersion returns the NVML Library Version being used.
+func SystemNVMLVersion() (string, error) {
+	return backend.SystemGetNVMLVersion()
+}
+
+// SystemGetProcessName GetProcessName by pid
+// @param pid                      Process's id
+// @param buffersize               The process name's buffersize
+// @return name                    Process name
+func SystemGetProcessName(pid, buffersize uint) (string, error) {
+	return backend.SystemGetProcessName(pid, buffersize)
+}
+
+// DeviceCount returns the number of nvidia devices on the system.
+func DeviceCount() (uint, error) {
+	return backend.DeviceGetCount()
+}
+
+// DeviceHandleByIndex returns the device handle for a particular index.
+// The indices range from 0 to DeviceCount()-1. The order in which NVML
+// enumerates devices has no guarantees of consistency between reboots.
+func DeviceHandleByIndex(idx uint) (Device, error) {
+	h, err := backend.DeviceGetHandleByIndex(idx)
+	return Device{h}, err
+}
+
+// DeviceHandleByUUID returns the device with the given UUID, e.g.
+// "GPU-8932f937-d72c-4106-c12f-20bd9faed9f6". Unlike the index, the UUID of a
+// device is stable across reboots. MIG devices can be looked up by their
+// "MIG-" UUID too.
+func DeviceHandleByUUID(uuid string) (Device, error) {
+	h, err := backend.DeviceGetHandleByUUID(uuid)
+	return Device{h}, err
+}
+
+// DeviceHandleByPciBusID returns the device at the given PCI bus ID. Both the
+// short domain form used by Linux ("0000:3b:00.0") and the full one reported
+// by NVML ("00000000:3B:00.0") are accepted, as is the form without domain
+// ("3b:00.0").
+func DeviceHandleByPciBusID(busID string) (Device, error) {
+	normalized, err := normalizePciBusID(busID)
+	if err != nil {
+		return Device{}, err
+	}
+	h, err := backend.DeviceGetHandleByPciBusId(normalized)
+	return Device{h}, err
+}
+
+// DeviceHandleBySerial returns the device with the given board serial number.
+// It fails with an error matching ErrInvalidArgument for the boards holding
+// more than one GPU, which share their serial number.
+func DeviceHandleBySerial(serial string) (Device, error) {
+	h, err := backend.DeviceGetHandleBySerial(serial)
+	return Device{h}, err
+}
+
+// normalizePciBusID parses a PCI bus ID with a domain of any width, or
+// without one, and formats it the way NVML does.
+func normalizePciBusID(busID string) (string, error) {
+	invalid := fmt.Errorf("invalid PCI bus ID %q: %w", busID, ErrInvalidArgument)
+	parts := strings.Split(strings.TrimSpace(busID), ":")
+	if len(parts) == 2 {
+		parts = append([]string{"0"}, parts...)
+	}
+	if len(parts) != 3 {
+		return "", invalid
+	}
+	slot := strings.Split(parts[2], ".")
+	if len(slot) != 2 {
+		return "", invalid
+	}
+	var values [4]uint64
+	for i, field := range []struct {
+		s    string
+		bits int
+	}{{parts[0], 32}, {parts[1], 8}, {slot[0], 5}, {slot[1], 3}} {
+		v, err := strconv.ParseUint(field.s, 16, field.bits)
+		if err != nil {
+			return "", invalid
+		}
+		values[i] = v
+	}
+	return fmt.Sprintf("%08X:%02X:%02X.%X", values[0], values[1], values[2], values[3]), nil
+}
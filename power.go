@@ -0,0 +1,126 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import "fmt"
+
+// SetPowerLimit sets the power management limit of the device in
+// milliwatts, after checking that it is within PowerLimitConstraints. It
+// requires root and lasts until the driver is unloaded.
+func (d Device) SetPowerLimit(milliwatts uint) error {
+	lo, hi, err := d.PowerLimitConstraints()
+	if err != nil {
+		return err
+	}
+	if milliwatts < lo || milliwatts > hi {
+		return fmt.Errorf("power limit %d mW outside of the [%d, %d] mW constraints: %w", milliwatts, lo, hi, ErrInvalidArgument)
+	}
+	return backend.DeviceSetPowerManagementLimit(d.handle, milliwatts)
+}
+
+// PowerManagementMode returns whether power management is enabled, which is
+// required to read the power usage and set power limits.
+func (d Device) PowerManagementMode() (EnableState, error) {
+	return backend.DeviceGetPowerManagementMode(d.handle)
+}
+
+// PowerState returns the current performance state of the device, from
+// PowerState0 (maximum performance) to PowerState15 (minimum performance).
+func (d Device) PowerState() (PowerState, error) {
+	return backend.DeviceGetPowerState(d.handle)
+}
+
+// PowerCap is a power limit applied to a set of devices by ApplyPowerCap.
+type PowerCap struct {
+	Limit   uint // milliwatts
+	Devices []Device
+
+	// previous are the power limits of the devices before the cap, and
+	// applied whether the cap is still applied to each of them.
+	previous []uint
+	applied  []bool
+}
+
+// ApplyPowerCap sets the power limit of all the devices to milliwatts, or of
+// none of them: the limit is first checked against the constraints of every
+// device, and if setting it fails on one device the devices already capped
+// get their previous limit back. The returned PowerCap restores the previous
+// limits.
+//
+// The errors of the devices are prefixed with their index.
+func ApplyPowerCap(devices []Device, milliwatts uint) (*PowerCap, error) {
+	c := &PowerCap{
+		Limit:    milliwatts,
+		Devices:  append([]Device(nil), devices...),
+		previous: make([]uint, len(devices)),
+		applied:  make([]bool, len(devices)),
+	}
+	var errs errorList
+	for i, d := range c.Devices {
+		lo, hi, err := d.PowerLimitConstraints()
+		if err == nil && (milliwatts < lo || milliwatts > hi) {
+			err = fmt.Errorf("power limit %d mW outside of the [%d, %d] mW constraints: %w", milliwatts, lo, hi, ErrInvalidArgument)
+		}
+		if err == nil {
+			c.previous[i], err = d.PowerLimit()
+		}
+		if err != nil {
+			errs = append(errs, c.deviceError(i, err))
+		}
+	}
+	if len(errs) != 0 {
+		return nil, errs.err()
+	}
+
+	for i, d := range c.Devices {
+		if err := backend.DeviceSetPowerManagementLimit(d.handle, milliwatts); err != nil {
+			errs = append(errs, c.deviceError(i, err))
+			if err := c.Restore(); err != nil {
+				errs = append(errs, err)
+			}
+			return nil, errs.err()
+		}
+		c.applied[i] = true
+	}
+	return c, nil
+}
+
+// Restore sets the devices back to the power limits they had before the cap.
+// The devices restored successfully are not restored again by later calls.
+func (c *PowerCap) Restore() error {
+	var errs errorList
+	for i, d := range c.Devices {
+		if !c.applied[i] {
+			continue
+		}
+		if err := backend.DeviceSetPowerManagementLimit(d.handle, c.previous[i]); err != nil {
+			errs = append(errs, c.deviceError(i, err))
+			continue
+		}
+		c.applied[i] = false
+	}
+	return errs.err()
+}
+
+// deviceError prefixes err with the index of the i-th device.
+func (c *PowerCap) deviceError(i int, err error) error {
+	idx, ierr := c.Devices[i].Index()
+	if ierr != nil {
+		return fmt.Errorf("device #%d of the cap: %w", i, err)
+	}
+	return fmt.Errorf("device %d: %w", idx, err)
+}
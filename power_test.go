@@ -0,0 +1,167 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// powerDevices returns devices with the power limit 200 W, settable between
+// 100 and 250 W.
+func powerDevices(n int) []*FakeDevice {
+	devices := make([]*FakeDevice, n)
+	for i := range devices {
+		devices[i] = &FakeDevice{
+			PowerLimit:         200000,
+			EnforcedPowerLimit: 200000,
+			MinPowerLimit:      100000,
+			MaxPowerLimit:      250000,
+		}
+	}
+	return devices
+}
+
+// powerLimits returns the power limits of the devices.
+func powerLimits(t *testing.T, devices []Device) []uint {
+	limits := make([]uint, len(devices))
+	for i, d := range devices {
+		limit, err := d.PowerLimit()
+		if err != nil {
+			t.Fatal(err)
+		}
+		limits[i] = limit
+	}
+	return limits
+}
+
+func samePowerLimits(got []uint, want ...uint) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestApplyPowerCap(t *testing.T) {
+	fakes := powerDevices(2)
+	fakes[1].PowerLimit = 180000
+	f, devices := initFakeDevices(t, fakes...)
+	defer SetBackend(nil)
+	defer Shutdown()
+
+	c, err := ApplyPowerCap(devices, 150000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := powerLimits(t, devices); !samePowerLimits(got, 150000, 150000) {
+		t.Errorf("capped limits = %v, want 150000 for both", got)
+	}
+	if err := c.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	if got := powerLimits(t, devices); !samePowerLimits(got, 200000, 180000) {
+		t.Errorf("restored limits = %v, want [200000 180000]", got)
+	}
+
+	// A second Restore doesn't touch the devices.
+	f.Lock()
+	for _, d := range fakes {
+		d.Errors = map[string]Return{"nvmlDeviceSetPowerManagementLimit": ReturnErrorNoPermission}
+	}
+	f.Unlock()
+	if err := c.Restore(); err != nil {
+		t.Errorf("second Restore() = %v, want nil", err)
+	}
+}
+
+func TestApplyPowerCapOutsideConstraints(t *testing.T) {
+	fakes := powerDevices(2)
+	fakes[1].MaxPowerLimit = 120000
+	_, devices := initFakeDevices(t, fakes...)
+	defer SetBackend(nil)
+	defer Shutdown()
+
+	c, err := ApplyPowerCap(devices, 150000)
+	if !errors.Is(err, ErrInvalidArgument) || c != nil {
+		t.Fatalf("ApplyPowerCap() = %v, %v; want ErrInvalidArgument", c, err)
+	}
+	if !strings.HasPrefix(err.Error(), "device 1: ") {
+		t.Errorf("error %q isn't prefixed with the device index", err)
+	}
+	if got := powerLimits(t, devices); !samePowerLimits(got, 200000, 200000) {
+		t.Errorf("limits after the rejected cap = %v, want them unchanged", got)
+	}
+}
+
+func TestApplyPowerCapRollback(t *testing.T) {
+	fakes := powerDevices(3)
+	fakes[0].PowerLimit = 190000
+	fakes[2].Errors = map[string]Return{"nvmlDeviceSetPowerManagementLimit": ReturnErrorNoPermission}
+	_, devices := initFakeDevices(t, fakes...)
+	defer SetBackend(nil)
+	defer Shutdown()
+
+	c, err := ApplyPowerCap(devices, 150000)
+	if !errors.Is(err, ErrNoPermission) || c != nil {
+		t.Fatalf("ApplyPowerCap() = %v, %v; want ErrNoPermission", c, err)
+	}
+	if !strings.HasPrefix(err.Error(), "device 2: ") {
+		t.Errorf("error %q isn't prefixed with the device index", err)
+	}
+	if got := powerLimits(t, devices); !samePowerLimits(got, 190000, 200000, 200000) {
+		t.Errorf("limits after the rollback = %v, want [190000 200000 200000]", got)
+	}
+}
+
+func TestPowerCapRestoreRetries(t *testing.T) {
+	fakes := powerDevices(2)
+	f, devices := initFakeDevices(t, fakes...)
+	defer SetBackend(nil)
+	defer Shutdown()
+
+	c, err := ApplyPowerCap(devices, 150000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Lock()
+	fakes[1].Errors = map[string]Return{"nvmlDeviceSetPowerManagementLimit": ReturnErrorUnknown}
+	f.Unlock()
+	if err := c.Restore(); !errors.Is(err, ErrUnknown) {
+		t.Errorf("Restore() = %v, want ErrUnknown", err)
+	}
+	if got := powerLimits(t, devices); !samePowerLimits(got, 200000, 150000) {
+		t.Errorf("limits after the failed Restore = %v, want [200000 150000]", got)
+	}
+
+	// Only the device that failed is restored again.
+	f.Lock()
+	fakes[0].PowerLimit = 170000
+	fakes[1].Errors = nil
+	f.Unlock()
+	if err := c.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	if got := powerLimits(t, devices); !samePowerLimits(got, 170000, 200000) {
+		t.Errorf("limits after the second Restore = %v, want [170000 200000]", got)
+	}
+}
@@ -0,0 +1,323 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProcessType is the bitmask of the kinds of contexts a process has on a
+// device.
+type ProcessType uint
+
+// Bits of ProcessType
+const (
+	ProcessTypeCompute  ProcessType = 1 << 0
+	ProcessTypeGraphics ProcessType = 1 << 1
+)
+
+func (t ProcessType) String() string {
+	switch t {
+	case ProcessTypeCompute:
+		return "compute"
+	case ProcessTypeGraphics:
+		return "graphics"
+	case ProcessTypeCompute | ProcessTypeGraphics:
+		return "compute+graphics"
+	default:
+		return "unknown"
+	}
+}
+
+// ProcessOptions selects what Device.Processes collects besides the PIDs,
+// types and memory usage of the processes.
+type ProcessOptions struct {
+	// Names looks up the names of the processes with
+	// nvmlSystemGetProcessName.
+	Names bool
+	// Utilization includes the latest utilization sample NVML took of each
+	// process within the last UtilizationWindow, 5 seconds if 0.
+	Utilization       bool
+	UtilizationWindow time.Duration
+	// Host reads the user, command line and cgroup of the processes from
+	// the proc filesystem mounted at ProcRoot, "/proc" if empty. When
+	// running in a container, ProcRoot has to be where the proc filesystem
+	// of the host is mounted, as NVML reports the PIDs of the host.
+	Host     bool
+	ProcRoot string
+}
+
+// ProcessInfo is a process running on a device, as returned by
+// Device.Processes. The fields not selected by the ProcessOptions, or that
+// couldn't be read, e.g. because the process exited meanwhile, are left
+// empty.
+type ProcessInfo struct {
+	PID  uint
+	Type ProcessType
+	Name string
+	// UsedMemory is the device memory used by the process in bytes.
+	UsedMemory  uint64
+	Utilization *Utilization
+	Host        *HostProcess
+}
+
+// HostProcess is what the proc filesystem tells about a process.
+type HostProcess struct {
+	UID uint
+	// User is the name of the user with UID, empty if it has none.
+	User    string
+	Cmdline []string
+	// Cgroup is the path of the cgroup of the process, in the unified
+	// hierarchy of cgroup v2, or in the memory controller hierarchy of
+	// cgroup v1 if the process is at the root of the unified one.
+	Cgroup string
+	// ContainerID is the ID of the container of the process, as found in
+	// its cgroup paths by Docker, containerd, CRI-O and Podman, empty if
+	// none.
+	ContainerID string
+	// PodUID is the UID of the Kubernetes pod of the process, empty if
+	// none.
+	PodUID string
+}
+
+const (
+	// defaultUtilizationWindow is the UtilizationWindow used if 0.
+	defaultUtilizationWindow = 5 * time.Second
+	// maxProcessUtilizationSamples bounds the buffer the process
+	// utilization samples are read into.
+	maxProcessUtilizationSamples = 1 << 14
+)
+
+// Processes returns the compute and graphics processes running on the device,
+// with the processes that have both kinds of contexts listed once. If only
+// one of the lists can be read, its processes are returned along with the
+// error of the other, unless it is ErrNotSupported.
+func (d Device) Processes(opts ProcessOptions) ([]ProcessInfo, error) {
+	var (
+		errs      errorList
+		supported int
+		infos     []ProcessInfo
+		indexes   = make(map[uint]int)
+	)
+	for _, l := range []struct {
+		typ ProcessType
+		get func() ([]Process, error)
+	}{
+		{ProcessTypeCompute, d.ComputeProcesses},
+		{ProcessTypeGraphics, d.GraphicsProcesses},
+	} {
+		procs, err := l.get()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		supported++
+		for _, p := range procs {
+			i, ok := indexes[p.PID()]
+			if !ok {
+				i = len(infos)
+				indexes[p.PID()] = i
+				infos = append(infos, ProcessInfo{PID: p.PID()})
+			}
+			infos[i].Type |= l.typ
+			if p.Memory() > infos[i].UsedMemory {
+				infos[i].UsedMemory = p.Memory()
+			}
+		}
+	}
+	if supported == 1 && errors.Is(errs[0], ErrNotSupported) {
+		errs = nil
+	}
+	if supported == 0 || len(infos) == 0 {
+		return nil, errs.err()
+	}
+
+	if opts.Names {
+		for i := range infos {
+			// The process may have exited since it was listed, so
+			// failing to look up its name is not an error.
+			if name, err := SystemGetProcessName(infos[i].PID, 256); err == nil {
+				infos[i].Name = name
+			}
+		}
+	}
+	if opts.Utilization {
+		window := opts.UtilizationWindow
+		if window == 0 {
+			window = defaultUtilizationWindow
+		}
+		// NVML keeps several samples per process, and fails with
+		// ErrInsufficientSize until the buffer holds all of those in
+		// the window. It fails with ErrNotFound when it has none, which
+		// leaves the utilizations empty like an unsupported device
+		// does.
+		count := uint(2 * len(infos))
+		samples, err := d.ProcessUtilization(count, window)
+		for errors.Is(err, ErrInsufficientSize) && count < maxProcessUtilizationSamples {
+			count *= 2
+			samples, err = d.ProcessUtilization(count, window)
+		}
+		if err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrNotSupported) {
+			errs = append(errs, err)
+		}
+		for _, s := range samples {
+			i, ok := indexes[s.Pid]
+			if !ok {
+				continue
+			}
+			if u := infos[i].Utilization; u == nil || s.timeStamp > u.timeStamp {
+				infos[i].Utilization = s
+			}
+		}
+	}
+	if opts.Host {
+		root := opts.ProcRoot
+		if root == "" {
+			root = "/proc"
+		}
+		for i := range infos {
+			if host, err := ReadHostProcess(root, infos[i].PID); err == nil {
+				infos[i].Host = &host
+			}
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].PID < infos[j].PID })
+	return infos, errs.err()
+}
+
+// ReadHostProcess reads what the proc filesystem mounted at procRoot tells
+// about the process pid.
+func ReadHostProcess(procRoot string, pid uint) (HostProcess, error) {
+	dir := filepath.Join(procRoot, strconv.FormatUint(uint64(pid), 10))
+	var p HostProcess
+
+	status, err := ioutil.ReadFile(filepath.Join(dir, "status"))
+	if err != nil {
+		return HostProcess{}, err
+	}
+	if p.UID, err = statusUID(status); err != nil {
+		return HostProcess{}, fmt.Errorf("%s: %w", filepath.Join(dir, "status"), err)
+	}
+	if u, err := user.LookupId(strconv.FormatUint(uint64(p.UID), 10)); err == nil {
+		p.User = u.Username
+	}
+
+	cmdline, err := ioutil.ReadFile(filepath.Join(dir, "cmdline"))
+	if err != nil {
+		return HostProcess{}, err
+	}
+	// The arguments are NUL terminated, and kernel threads have none.
+	if cmdline = bytes.TrimSuffix(cmdline, []byte{0}); len(cmdline) > 0 {
+		p.Cmdline = strings.Split(string(cmdline), "\x00")
+	}
+
+	cgroup, err := ioutil.ReadFile(filepath.Join(dir, "cgroup"))
+	if err != nil {
+		return HostProcess{}, err
+	}
+	p.Cgroup, p.ContainerID, p.PodUID = parseCgroup(string(cgroup))
+	return p, nil
+}
+
+// statusUID returns the real UID from the content of a /proc/<pid>/status
+// file.
+func statusUID(status []byte) (uint, error) {
+	for _, line := range strings.Split(string(status), "\n") {
+		if !strings.HasPrefix(line, "Uid:") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, "Uid:"))
+		if len(fields) == 0 {
+			break
+		}
+		uid, err := strconv.ParseUint(fields[0], 10, 32)
+		return uint(uid), err
+	}
+	return 0, errors.New("no Uid line")
+}
+
+var (
+	// containerIDPattern matches the 64 hex digits container IDs in the
+	// cgroup paths, e.g. "/docker/<id>", "docker-<id>.scope",
+	// "cri-containerd-<id>.scope", "crio-<id>.scope" or "libpod-<id>.scope".
+	containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+	// podUIDPattern matches the pod UIDs in the cgroup paths made by the
+	// kubelet, with the cgroupfs driver ("/kubepods/burstable/pod<uid>")
+	// or the systemd one, which replaces the dashes with underscores
+	// ("kubepods-burstable-pod<uid>.slice").
+	podUIDPattern = regexp.MustCompile(`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})`)
+)
+
+// parseCgroup returns the cgroup path, the container ID and the pod UID from
+// the content of a /proc/<pid>/cgroup file, whose lines are
+// "hierarchy-ID:controllers:path". On hybrid hosts, where the unified
+// hierarchy is mounted alongside the v1 ones but the controllers are in v1,
+// the processes are usually left at the root of the unified hierarchy, so
+// the path in the memory hierarchy is returned instead.
+func parseCgroup(content string) (path, containerID, podUID string) {
+	var unified, memory, first string
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[0] == "0" && fields[1] == "" {
+			unified = fields[2]
+		} else {
+			if containsString(strings.Split(fields[1], ","), "memory") {
+				memory = fields[2]
+			}
+			if first == "" {
+				first = fields[2]
+			}
+		}
+		if ids := containerIDPattern.FindAllString(fields[2], -1); len(ids) > 0 && containerID == "" {
+			containerID = ids[len(ids)-1]
+		}
+		if m := podUIDPattern.FindStringSubmatch(fields[2]); m != nil && podUID == "" {
+			podUID = strings.Replace(m[1], "_", "-", -1)
+		}
+	}
+	switch {
+	case unified != "" && (unified != "/" || first == ""):
+		path = unified
+	case memory != "":
+		path = memory
+	default:
+		path = first
+	}
+	return path, containerID, podUID
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
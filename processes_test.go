@@ -0,0 +1,330 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+const (
+	testContainerID = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	testPodUID      = "1234abcd-1111-2222-3333-444455556666"
+)
+
+func TestParseCgroup(t *testing.T) {
+	const (
+		id     = testContainerID
+		podUID = testPodUID
+		// The systemd cgroup driver of the kubelet replaces the dashes
+		// of the pod UIDs with underscores.
+		systemdPodUID = "1234abcd_1111_2222_3333_444455556666"
+	)
+	for _, c := range []struct {
+		name                      string
+		content                   string
+		path, containerID, podUID string
+	}{
+		{
+			name:    "v2 root",
+			content: "0::/\n",
+			path:    "/",
+		},
+		{
+			name:        "v2 docker",
+			content:     "0::/system.slice/docker-" + id + ".scope\n",
+			path:        "/system.slice/docker-" + id + ".scope",
+			containerID: id,
+		},
+		{
+			name:        "v2 containerd systemd kubepods",
+			content:     "0::/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod" + systemdPodUID + ".slice/cri-containerd-" + id + ".scope\n",
+			path:        "/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod" + systemdPodUID + ".slice/cri-containerd-" + id + ".scope",
+			containerID: id,
+			podUID:      podUID,
+		},
+		{
+			name:        "v2 cri-o systemd kubepods",
+			content:     "0::/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod" + systemdPodUID + ".slice/crio-" + id + ".scope\n",
+			path:        "/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod" + systemdPodUID + ".slice/crio-" + id + ".scope",
+			containerID: id,
+			podUID:      podUID,
+		},
+		{
+			name:        "v2 rootless libpod",
+			content:     "0::/user.slice/user-1000.slice/user@1000.service/user.slice/libpod-" + id + ".scope/container\n",
+			path:        "/user.slice/user-1000.slice/user@1000.service/user.slice/libpod-" + id + ".scope/container",
+			containerID: id,
+		},
+		{
+			name:        "v1 docker",
+			content:     "12:pids:/docker/" + id + "\n11:memory:/docker/" + id + "\n4:cpu,cpuacct:/docker/" + id + "\n1:name=systemd:/docker/" + id + "\n",
+			path:        "/docker/" + id,
+			containerID: id,
+		},
+		{
+			name:        "v1 cgroupfs kubepods",
+			content:     "11:memory:/kubepods/burstable/pod" + podUID + "/" + id + "\n1:name=systemd:/kubepods/burstable/pod" + podUID + "/" + id + "\n",
+			path:        "/kubepods/burstable/pod" + podUID + "/" + id,
+			containerID: id,
+			podUID:      podUID,
+		},
+		{
+			name:    "v1 without memory controller",
+			content: "4:cpu,cpuacct:/user.slice\n1:name=systemd:/user.slice/user-1000.slice/session-2.scope\n",
+			path:    "/user.slice",
+		},
+		{
+			name:        "hybrid at the unified root",
+			content:     "11:memory:/kubepods/besteffort/pod" + podUID + "/" + id + "\n1:name=systemd:/kubepods/besteffort/pod" + podUID + "/" + id + "\n0::/\n",
+			path:        "/kubepods/besteffort/pod" + podUID + "/" + id,
+			containerID: id,
+			podUID:      podUID,
+		},
+		{
+			name:    "hybrid in the unified hierarchy",
+			content: "11:memory:/user.slice\n1:name=systemd:/user.slice/user-1000.slice/session-2.scope\n0::/user.slice/user-1000.slice/session-2.scope\n",
+			path:    "/user.slice/user-1000.slice/session-2.scope",
+		},
+		{
+			name: "empty",
+		},
+	} {
+		path, containerID, podUID := parseCgroup(c.content)
+		if path != c.path || containerID != c.containerID || podUID != c.podUID {
+			t.Errorf("%s: parseCgroup() = %q, %q, %q; want %q, %q, %q", c.name, path, containerID, podUID, c.path, c.containerID, c.podUID)
+		}
+	}
+}
+
+// writeProc writes the files of the process pid in the proc filesystem at
+// root, mapped by name to their content.
+func writeProc(t *testing.T, root string, pid uint, files map[string]string) {
+	dir := filepath.Join(root, strconv.FormatUint(uint64(pid), 10))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// tempProc returns a proc filesystem with the processes:
+//
+//	100  root, in a Kubernetes pod on a cgroup v2 host
+//	200  a user without name, in a Docker container on a cgroup v1 host
+//	300  a kernel thread
+//	400  one that exited between reading its status and its cmdline
+func tempProc(t *testing.T) string {
+	root, err := ioutil.TempDir("", "gonvml-proc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeProc(t, root, 100, map[string]string{
+		"status":  "Name:\tpython3\nUmask:\t0022\nState:\tS (sleeping)\nUid:\t0\t0\t0\t0\nGid:\t0\t0\t0\t0\n",
+		"cmdline": "python3\x00train.py\x00--lr=0.1\x00",
+		"cgroup":  "0::/kubepods.slice/kubepods-pod1234abcd_1111_2222_3333_444455556666.slice/cri-containerd-" + testContainerID + ".scope\n",
+	})
+	writeProc(t, root, 200, map[string]string{
+		"status":  "Name:\tcuda-app\nUid:\t4242\t4242\t4242\t4242\n",
+		"cmdline": "/app/cuda-app\x00",
+		"cgroup":  "11:memory:/docker/" + testContainerID + "\n1:name=systemd:/docker/" + testContainerID + "\n",
+	})
+	writeProc(t, root, 300, map[string]string{
+		"status":  "Name:\tkworker/0:1\nUid:\t0\t0\t0\t0\n",
+		"cmdline": "",
+		"cgroup":  "0::/\n",
+	})
+	writeProc(t, root, 400, map[string]string{
+		"status": "Name:\tshort-lived\nUid:\t0\t0\t0\t0\n",
+	})
+	return root
+}
+
+// userName returns the name of the user uid on this system, or "" if it has
+// none, like ReadHostProcess does.
+func userName(uid string) string {
+	u, err := user.LookupId(uid)
+	if err != nil {
+		return ""
+	}
+	return u.Username
+}
+
+func TestReadHostProcess(t *testing.T) {
+	root := tempProc(t)
+	defer os.RemoveAll(root)
+
+	got, err := ReadHostProcess(root, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := HostProcess{
+		UID:         0,
+		User:        userName("0"),
+		Cmdline:     []string{"python3", "train.py", "--lr=0.1"},
+		Cgroup:      "/kubepods.slice/kubepods-pod1234abcd_1111_2222_3333_444455556666.slice/cri-containerd-" + testContainerID + ".scope",
+		ContainerID: testContainerID,
+		PodUID:      testPodUID,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadHostProcess(100) = %+v, want %+v", got, want)
+	}
+
+	got, err = ReadHostProcess(root, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = HostProcess{
+		UID:         4242,
+		User:        userName("4242"),
+		Cmdline:     []string{"/app/cuda-app"},
+		Cgroup:      "/docker/" + testContainerID,
+		ContainerID: testContainerID,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadHostProcess(200) = %+v, want %+v", got, want)
+	}
+
+	if got, err := ReadHostProcess(root, 300); err != nil || got.Cmdline != nil || got.Cgroup != "/" {
+		t.Errorf("ReadHostProcess(300) = %+v, %v; want no command line", got, err)
+	}
+	for _, pid := range []uint{400, 500} {
+		if _, err := ReadHostProcess(root, pid); !os.IsNotExist(err) {
+			t.Errorf("ReadHostProcess(%d) = %v, want a not exist error", pid, err)
+		}
+	}
+
+	writeProc(t, root, 600, map[string]string{"status": "Name:\tbroken\n"})
+	if _, err := ReadHostProcess(root, 600); err == nil {
+		t.Error("ReadHostProcess() of a status without Uid succeeded")
+	}
+}
+
+func TestProcesses(t *testing.T) {
+	root := tempProc(t)
+	defer os.RemoveAll(root)
+	dev := &FakeDevice{
+		ComputeProcesses: []FakeProcess{
+			{Pid: 200, Name: "cuda-app", UsedGpuMemory: 10 << 20},
+			{Pid: 100, Name: "python3", UsedGpuMemory: 5 << 20},
+			{Pid: 400, Name: "short-lived", UsedGpuMemory: 1 << 20},
+		},
+		GraphicsProcesses: []FakeProcess{
+			{Pid: 100, Name: "python3", UsedGpuMemory: 7 << 20},
+			// Listed by NVML, but gone from the proc filesystem.
+			{Pid: 999, Name: "Xorg"},
+		},
+		ProcessUtilization: []Utilization{
+			{Pid: 100, timeStamp: 1, SMUtil: 10},
+			{Pid: 100, timeStamp: 5, SMUtil: 50},
+			{Pid: 200, timeStamp: 2, SMUtil: 20},
+			{Pid: 7, timeStamp: 3, SMUtil: 70},
+		},
+	}
+	useFakeBackend(dev)
+	defer SetBackend(nil)
+	if err := Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer Shutdown()
+	d, err := DeviceHandleByIndex(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err := d.Processes(ProcessOptions{Names: true, Utilization: true, Host: true, ProcRoot: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	type process struct {
+		pid    uint
+		typ    ProcessType
+		name   string
+		memory uint64
+		sm     uint
+		cgroup string
+	}
+	var got []process
+	for _, p := range infos {
+		g := process{pid: p.PID, typ: p.Type, name: p.Name, memory: p.UsedMemory, sm: 999}
+		if p.Utilization != nil {
+			g.sm = p.Utilization.SMUtil
+		}
+		if p.Host != nil {
+			g.cgroup = p.Host.Cgroup
+		}
+		got = append(got, g)
+	}
+	want := []process{
+		{100, ProcessTypeCompute | ProcessTypeGraphics, "python3", 7 << 20, 50, "/kubepods.slice/kubepods-pod1234abcd_1111_2222_3333_444455556666.slice/cri-containerd-" + testContainerID + ".scope"},
+		{200, ProcessTypeCompute, "cuda-app", 10 << 20, 20, "/docker/" + testContainerID},
+		{400, ProcessTypeCompute, "short-lived", 1 << 20, 999, ""},
+		{999, ProcessTypeGraphics, "Xorg", 0, 999, ""},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Processes() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	infos, err = d.Processes(ProcessOptions{})
+	if err != nil || len(infos) != 4 || infos[0].Name != "" || infos[0].Utilization != nil || infos[0].Host != nil {
+		t.Errorf("Processes() without options = %+v, %v; want the processes only", infos, err)
+	}
+
+	dev.Errors = map[string]Return{"nvmlDeviceGetGraphicsRunningProcesses": ReturnErrorNotSupported}
+	if infos, err := d.Processes(ProcessOptions{}); err != nil || len(infos) != 3 {
+		t.Errorf("Processes() without graphics processes = %+v, %v; want the 3 compute processes", infos, err)
+	}
+	dev.Errors["nvmlDeviceGetComputeRunningProcesses"] = ReturnErrorNoPermission
+	if _, err := d.Processes(ProcessOptions{}); !errors.Is(err, ErrNoPermission) {
+		t.Errorf("Processes() = %v, want ErrNoPermission", err)
+	}
+}
+
+func TestProcessesUtilizationBuffer(t *testing.T) {
+	// NVML keeps many samples per process, more than fit in a buffer
+	// sized after the number of processes.
+	dev := &FakeDevice{ComputeProcesses: []FakeProcess{{Pid: 100}}}
+	for i := 0; i < 100; i++ {
+		dev.ProcessUtilization = append(dev.ProcessUtilization, Utilization{Pid: 100, timeStamp: uint64(i), SMUtil: uint(i)})
+	}
+	useFakeBackend(dev)
+	defer SetBackend(nil)
+	if err := Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer Shutdown()
+	d, err := DeviceHandleByIndex(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos, err := d.Processes(ProcessOptions{Utilization: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 1 || infos[0].Utilization == nil || infos[0].Utilization.SMUtil != 99 {
+		t.Errorf("Processes() = %+v, want the latest utilization sample", infos)
+	}
+}
@@ -0,0 +1,397 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Metric is a device metric polled by a Sampler.
+type Metric int
+
+// Metrics a Sampler can poll
+const (
+	MetricGPUUtilization    Metric = iota // percent, see UtilizationRates
+	MetricMemoryUtilization               // percent, see UtilizationRates
+	MetricMemoryUsed                      // bytes
+	MetricPowerUsage                      // milliwatts
+	MetricEnergyConsumption               // millijoules since the driver was loaded
+	MetricTemperature                     // degrees Celsius
+	MetricFanSpeed                        // percent
+	MetricSMClock                         // MHz
+	MetricMemClock                        // MHz
+	MetricPcieTxThroughput                // KB/s
+	MetricPcieRxThroughput                // KB/s
+)
+
+func (m Metric) String() string {
+	switch m {
+	case MetricGPUUtilization:
+		return "gpu_utilization"
+	case MetricMemoryUtilization:
+		return "memory_utilization"
+	case MetricMemoryUsed:
+		return "memory_used"
+	case MetricPowerUsage:
+		return "power_usage"
+	case MetricEnergyConsumption:
+		return "energy_consumption"
+	case MetricTemperature:
+		return "temperature"
+	case MetricFanSpeed:
+		return "fan_speed"
+	case MetricSMClock:
+		return "sm_clock"
+	case MetricMemClock:
+		return "mem_clock"
+	case MetricPcieTxThroughput:
+		return "pcie_tx_throughput"
+	case MetricPcieRxThroughput:
+		return "pcie_rx_throughput"
+	default:
+		return fmt.Sprintf("Metric(%d)", int(m))
+	}
+}
+
+// read returns the current value of m for d.
+func (m Metric) read(d Device) (float64, error) {
+	var v uint
+	var err error
+	switch m {
+	case MetricGPUUtilization:
+		v, _, err = backend.DeviceGetUtilizationRates(d.handle)
+	case MetricMemoryUtilization:
+		_, v, err = backend.DeviceGetUtilizationRates(d.handle)
+	case MetricMemoryUsed:
+		_, used, err := backend.DeviceGetMemoryInfo(d.handle)
+		return float64(used), err
+	case MetricPowerUsage:
+		v, err = backend.DeviceGetPowerUsage(d.handle)
+	case MetricEnergyConsumption:
+		energy, err := backend.DeviceGetTotalEnergyConsumption(d.handle)
+		return float64(energy), err
+	case MetricTemperature:
+		v, err = backend.DeviceGetTemperature(d.handle, TemperatureSensorGPU)
+	case MetricFanSpeed:
+		v, err = backend.DeviceGetFanSpeed(d.handle)
+	case MetricSMClock:
+		v, err = backend.DeviceGetClockInfo(d.handle, ClockTypeSM)
+	case MetricMemClock:
+		v, err = backend.DeviceGetClockInfo(d.handle, ClockTypeMem)
+	case MetricPcieTxThroughput:
+		v, err = backend.DeviceGetPcieThroughput(d.handle, PcieUtilCounterTxBytes)
+	case MetricPcieRxThroughput:
+		v, err = backend.DeviceGetPcieThroughput(d.handle, PcieUtilCounterRxBytes)
+	default:
+		return 0, fmt.Errorf("unknown metric %v: %w", m, ErrInvalidArgument)
+	}
+	return float64(v), err
+}
+
+// Reading is a value of a metric polled by a Sampler.
+type Reading struct {
+	Device Device
+	Metric Metric
+	Time   time.Time
+	Value  float64
+	// Err is the error reading the value, in which case Value is 0.
+	// Failed readings are sent to the subscribers but not kept in the
+	// history.
+	Err error
+}
+
+// SamplerConfig configures a Sampler.
+type SamplerConfig struct {
+	Devices  []Device
+	Metrics  []Metric
+	Interval time.Duration // time between two polls, 1s if 0
+	// History is the number of readings kept per device and metric, enough
+	// for 10 minutes if 0.
+	History int
+}
+
+// ring is a fixed size buffer of the last readings of a series. It grows up
+// to size as readings are added, so that a long history is only allocated if
+// the sampler runs long enough to fill it.
+type ring struct {
+	readings []Reading
+	size     int
+	start    int
+}
+
+func (r *ring) add(reading Reading) {
+	if len(r.readings) < r.size {
+		r.readings = append(r.readings, reading)
+		return
+	}
+	r.readings[r.start] = reading
+	r.start = (r.start + 1) % r.size
+}
+
+// since returns the readings newer than t, oldest first.
+func (r *ring) since(t time.Time) []Reading {
+	var readings []Reading
+	for i := range r.readings {
+		reading := r.readings[(r.start+i)%len(r.readings)]
+		if reading.Time.After(t) {
+			readings = append(readings, reading)
+		}
+	}
+	return readings
+}
+
+// seriesKey identifies the readings of a metric of a device.
+type seriesKey struct {
+	device DeviceHandle
+	metric Metric
+}
+
+// Sampler polls metrics of devices in the background and keeps their
+// recent history, unlike the Average* methods of Device, which depend on the
+// short and irregular buffer of samples kept by NVML:
+//
+//	s, err := gonvml.NewSampler(gonvml.SamplerConfig{
+//		Devices:  devices,
+//		Metrics:  []gonvml.Metric{gonvml.MetricGPUUtilization, gonvml.MetricPowerUsage},
+//		Interval: time.Second,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	defer s.Stop()
+//	...
+//	stats, err := s.Stats(devices[0], gonvml.MetricPowerUsage, 5*time.Minute)
+//
+// NVML has to stay initialized until the Sampler is stopped.
+type Sampler struct {
+	config SamplerConfig
+
+	mu          sync.Mutex
+	series      map[seriesKey]*ring
+	subscribers map[chan Reading]struct{}
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewSampler starts polling the metrics of the devices in cfg.
+func NewSampler(cfg SamplerConfig) (*Sampler, error) {
+	if len(cfg.Devices) == 0 || len(cfg.Metrics) == 0 {
+		return nil, fmt.Errorf("sampler without devices or metrics: %w", ErrInvalidArgument)
+	}
+	if cfg.Interval < 0 || cfg.History < 0 {
+		return nil, fmt.Errorf("negative sampler interval or history: %w", ErrInvalidArgument)
+	}
+	if cfg.Interval == 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.History == 0 {
+		cfg.History = int(10*time.Minute/cfg.Interval) + 1
+	}
+	s := &Sampler{
+		config:      cfg,
+		series:      make(map[seriesKey]*ring),
+		subscribers: make(map[chan Reading]struct{}),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	for _, d := range cfg.Devices {
+		for _, m := range cfg.Metrics {
+			s.series[seriesKey{d.handle, m}] = &ring{size: cfg.History}
+		}
+	}
+	go s.run()
+	return s, nil
+}
+
+func (s *Sampler) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+	for {
+		s.poll()
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll reads every metric once, records the readings and sends them to the
+// subscribers.
+func (s *Sampler) poll() {
+	var readings []Reading
+	for _, d := range s.config.Devices {
+		for _, m := range s.config.Metrics {
+			v, err := m.read(d)
+			readings = append(readings, Reading{Device: d, Metric: m, Time: time.Now(), Value: v, Err: err})
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range readings {
+		if r.Err == nil {
+			s.series[seriesKey{r.Device.handle, r.Metric}].add(r)
+		}
+		for ch := range s.subscribers {
+			// Slow subscribers miss readings rather than stall the
+			// sampler.
+			select {
+			case ch <- r:
+			default:
+			}
+		}
+	}
+}
+
+// Stop stops polling and closes the subscription channels. The history
+// stays available. Calling it again does nothing.
+func (s *Sampler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+		<-s.done
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		for ch := range s.subscribers {
+			close(ch)
+		}
+		s.subscribers = nil
+	})
+}
+
+// Subscribe returns a channel receiving every reading, including the failed
+// ones. Readings are dropped when the buffer of the channel is full. The
+// channel is closed by Unsubscribe or Stop.
+func (s *Sampler) Subscribe(buffer int) <-chan Reading {
+	ch := make(chan Reading, buffer)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subscribers == nil {
+		close(ch)
+		return ch
+	}
+	s.subscribers[ch] = struct{}{}
+	return ch
+}
+
+// Unsubscribe stops sending readings to ch, a channel returned by Subscribe,
+// and closes it.
+func (s *Sampler) Unsubscribe(ch <-chan Reading) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.subscribers {
+		if c == ch {
+			delete(s.subscribers, c)
+			close(c)
+		}
+	}
+}
+
+// History returns the readings of metric m of device d taken in the last
+// window, oldest first, or the whole history if window is 0.
+func (s *Sampler) History(d Device, m Metric, window time.Duration) ([]Reading, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.series[seriesKey{d.handle, m}]
+	if !ok {
+		return nil, fmt.Errorf("metric %v of the device is not sampled: %w", m, ErrInvalidArgument)
+	}
+	var since time.Time
+	if window > 0 {
+		since = time.Now().Add(-window)
+	}
+	return r.since(since), nil
+}
+
+// Stats returns statistics of the readings of metric m of device d taken in
+// the last window, or over the whole history if window is 0. It fails with
+// an error matching ErrNoData if there are no such readings.
+func (s *Sampler) Stats(d Device, m Metric, window time.Duration) (Stats, error) {
+	readings, err := s.History(d, m, window)
+	if err != nil {
+		return Stats{}, err
+	}
+	if len(readings) == 0 {
+		return Stats{}, fmt.Errorf("no readings of metric %v: %w", m, ErrNoData)
+	}
+	return newStats(readings), nil
+}
+
+// Stats are statistics of the readings of a metric over a window.
+type Stats struct {
+	Count int
+	Min   float64
+	Max   float64
+	Mean  float64
+	// First and Last are the oldest and newest readings.
+	First Reading
+	Last  Reading
+
+	sorted []float64
+}
+
+func newStats(readings []Reading) Stats {
+	st := Stats{
+		Count:  len(readings),
+		Min:    math.Inf(1),
+		Max:    math.Inf(-1),
+		First:  readings[0],
+		Last:   readings[len(readings)-1],
+		sorted: make([]float64, len(readings)),
+	}
+	var sum float64
+	for i, r := range readings {
+		st.Min = math.Min(st.Min, r.Value)
+		st.Max = math.Max(st.Max, r.Value)
+		sum += r.Value
+		st.sorted[i] = r.Value
+	}
+	st.Mean = sum / float64(len(readings))
+	sort.Float64s(st.sorted)
+	return st
+}
+
+// Percentile returns the p-th percentile of the values, p being between 0
+// and 100, interpolating linearly between the closest values. It is NaN if
+// there are no values or p is NaN.
+func (st Stats) Percentile(p float64) float64 {
+	if len(st.sorted) == 0 || math.IsNaN(p) {
+		return math.NaN()
+	}
+	p = math.Max(0, math.Min(100, p))
+	rank := p / 100 * float64(len(st.sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return st.sorted[lo] + (st.sorted[hi]-st.sorted[lo])*(rank-float64(lo))
+}
+
+// Rate returns the change of the value per second between the first and the
+// last reading, e.g. the power in milliwatts for MetricEnergyConsumption. It
+// is 0 if all the readings were taken at the same time.
+func (st Stats) Rate() float64 {
+	elapsed := st.Last.Time.Sub(st.First.Time).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+	return (st.Last.Value - st.First.Value) / elapsed
+}
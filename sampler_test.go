@@ -0,0 +1,95 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRing(t *testing.T) {
+	r := &ring{size: 3}
+	base := time.Now()
+	for i := 0; i < 5; i++ {
+		r.add(Reading{Time: base.Add(time.Duration(i) * time.Second), Value: float64(i)})
+		if want := i + 1; want <= r.size && len(r.readings) != want {
+			t.Errorf("after %d readings, ring holds %d, want %d", i+1, len(r.readings), want)
+		}
+	}
+	got := r.since(time.Time{})
+	if len(got) != 3 || got[0].Value != 2 || got[2].Value != 4 {
+		t.Errorf("since(zero) = %v, want the readings 2 to 4", got)
+	}
+	if got := r.since(base.Add(3 * time.Second)); len(got) != 1 || got[0].Value != 4 {
+		t.Errorf("since(3s) = %v, want reading 4", got)
+	}
+}
+
+func TestNewSamplerHistory(t *testing.T) {
+	useFakeBackend(&FakeDevice{PowerUsage: 100})
+	defer SetBackend(nil)
+	if err := Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer Shutdown()
+	d, err := DeviceHandleByIndex(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewSampler(SamplerConfig{Devices: []Device{d}, Metrics: []Metric{MetricPowerUsage}, Interval: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Stop()
+
+	// The 10 minutes of history are only allocated as they are filled.
+	r := s.series[seriesKey{d.handle, MetricPowerUsage}]
+	if r.size != 600001 {
+		t.Errorf("history size = %d, want 600001", r.size)
+	}
+	if cap(r.readings) > 1000 {
+		t.Errorf("history of %d readings allocated after %d readings", cap(r.readings), len(r.readings))
+	}
+}
+
+func TestStatsPercentile(t *testing.T) {
+	var readings []Reading
+	for _, v := range []float64{4, 1, 3, 2} {
+		readings = append(readings, Reading{Value: v})
+	}
+	st := newStats(readings)
+	for _, c := range []struct {
+		p, want float64
+	}{
+		{0, 1},
+		{50, 2.5},
+		{100, 4},
+		{-10, 1},
+		{110, 4},
+	} {
+		if got := st.Percentile(c.p); got != c.want {
+			t.Errorf("Percentile(%v) = %v, want %v", c.p, got, c.want)
+		}
+	}
+	if got := st.Percentile(math.NaN()); !math.IsNaN(got) {
+		t.Errorf("Percentile(NaN) = %v, want NaN", got)
+	}
+	if got := (Stats{}).Percentile(50); !math.IsNaN(got) {
+		t.Errorf("Percentile(50) without values = %v, want NaN", got)
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+func (t SamplingType) String() string {
+	switch t {
+	case SamplingTypeTotalPower:
+		return "total power"
+	case SamplingTypeGPUUtilization:
+		return "GPU utilization"
+	case SamplingTypeMemoryUtilization:
+		return "memory utilization"
+	case SamplingTypeEncUtilization:
+		return "encoder utilization"
+	case SamplingTypeDecUtilization:
+		return "decoder utilization"
+	case SamplingTypeProcessorClk:
+		return "processor clock"
+	case SamplingTypeMemoryClk:
+		return "memory clock"
+	default:
+		return fmt.Sprintf("SamplingType(%d)", int(t))
+	}
+}
+
+// Sample is the equivalent of nvmlSample_t.
+type Sample struct {
+	Timestamp time.Time // CPU time the sample was taken at
+	// Value is in the unit of the sampling type: milliwatts for
+	// SamplingTypeTotalPower, percent for the utilizations and MHz for the
+	// clocks.
+	Value Value
+}
+
+// Samples returns the samples of the given type taken after since, oldest
+// first, or all the samples NVML holds if since is the zero time. NVML only
+// keeps the last few seconds to a minute of samples, depending on the type
+// and the device.
+func (d Device) Samples(typ SamplingType, since time.Time) ([]Sample, error) {
+	var lastSeenTimeStamp uint64
+	if !since.IsZero() {
+		lastSeenTimeStamp = uint64(since.UnixNano() / 1000)
+	}
+	samples, err := backend.DeviceGetSamples(d.handle, typ, lastSeenTimeStamp)
+	if err != nil {
+		return nil, err
+	}
+	// NVML returns its ring buffer as is: starting at an arbitrary slot,
+	// and with the slots not written yet at timestamp 0.
+	epoch := time.Unix(0, 0)
+	taken := samples[:0]
+	for _, s := range samples {
+		if s.Timestamp.After(epoch) {
+			taken = append(taken, s)
+		}
+	}
+	sort.SliceStable(taken, func(i, j int) bool { return taken[i].Timestamp.Before(taken[j].Timestamp) })
+	return taken, nil
+}
+
+// averageUsage averages the samples of the given type taken in the last
+// since duration. It fails with an error matching ErrNoData if there are
+// none.
+func (d Device) averageUsage(typ SamplingType, since time.Duration) (uint, error) {
+	samples, err := d.Samples(typ, time.Now().Add(-since))
+	if err != nil {
+		return 0, err
+	}
+	if len(samples) == 0 {
+		return 0, newError("nvmlDeviceGetSamples", ReturnErrorNoData)
+	}
+	var sum float64
+	for _, s := range samples {
+		sum += s.Value.Float64()
+	}
+	return uint(sum / float64(len(samples))), nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSamples(t *testing.T) {
+	now := time.Now().Truncate(time.Microsecond)
+	at := func(ago time.Duration, mw uint32) Sample {
+		return Sample{Timestamp: now.Add(-ago), Value: UintValue(mw)}
+	}
+	unused := Sample{Timestamp: time.Unix(0, 0)}
+	// A ring buffer that wrapped around after the sample of 1s ago, and
+	// whose last slots were never written.
+	_, devices := initFakeDevices(t, &FakeDevice{
+		Samples: map[SamplingType][]Sample{
+			SamplingTypeTotalPower: {
+				at(2*time.Second, 100000),
+				at(time.Second, 120000),
+				at(5*time.Second, 60000),
+				at(4*time.Second, 70000),
+				at(3*time.Second, 80000),
+				unused,
+				unused,
+			},
+		},
+	})
+	defer SetBackend(nil)
+	defer Shutdown()
+
+	samples, err := devices[0].Samples(SamplingTypeTotalPower, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uint64{60000, 70000, 80000, 100000, 120000}
+	if len(samples) != len(want) {
+		t.Fatalf("got %d samples, want %d: %v", len(samples), len(want), samples)
+	}
+	for i, s := range samples {
+		if s.Value.Uint64() != want[i] {
+			t.Errorf("samples[%d] = %v, want %d", i, s.Value, want[i])
+		}
+		if i > 0 && s.Timestamp.Before(samples[i-1].Timestamp) {
+			t.Errorf("samples[%d] at %v is older than the previous one", i, s.Timestamp)
+		}
+	}
+
+	// The unused slots don't drag the average down.
+	avg, err := devices[0].AveragePowerUsage(10 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if avg != 86000 {
+		t.Errorf("AveragePowerUsage() = %d, want 86000", avg)
+	}
+	samples, err = devices[0].Samples(SamplingTypeTotalPower, now.Add(-2500*time.Millisecond))
+	if err != nil || len(samples) != 2 || samples[0].Value.Uint64() != 100000 {
+		t.Errorf("Samples() since 2.5s ago = %v, %v; want the last 2 samples", samples, err)
+	}
+	if _, err := devices[0].AverageGPUUtilization(time.Second); !errors.Is(err, ErrNoData) {
+		t.Errorf("AverageGPUUtilization() without samples = %v, want ErrNoData", err)
+	}
+}
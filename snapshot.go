@@ -0,0 +1,440 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// SnapshotSchemaVersion is the version of the DeviceStatus schema. It changes
+// when a field is renamed or removed or changes meaning, not when fields are
+// added.
+const SnapshotSchemaVersion = 1
+
+// SnapshotOptions selects what Device.Snapshot collects.
+type SnapshotOptions struct {
+	// Processes includes the compute and graphics processes running on the
+	// device.
+	Processes bool
+	// ProcessNames looks up the names of the processes. It requires
+	// Processes.
+	ProcessNames bool
+	// IgnoreUnsupported leaves the metrics failing with ErrNotSupported or
+	// ErrFunctionNotFound out of DeviceStatus.Errors.
+	IgnoreUnsupported bool
+}
+
+// DeviceStatus is the state of a device at a point in time, as collected by
+// Device.Snapshot. Metrics that couldn't be read are left out and their
+// errors are listed in Errors.
+type DeviceStatus struct {
+	SchemaVersion   int               `json:"schema_version" yaml:"schema_version"`
+	Timestamp       time.Time         `json:"timestamp" yaml:"timestamp"`
+	Identity        DeviceIdentity    `json:"identity" yaml:"identity"`
+	Clocks          ClockStatus       `json:"clocks" yaml:"clocks"`
+	Memory          *MemoryUsage      `json:"memory,omitempty" yaml:"memory,omitempty"`
+	BAR1            *MemoryUsage      `json:"bar1,omitempty" yaml:"bar1,omitempty"`
+	Utilization     UtilizationStatus `json:"utilization" yaml:"utilization"`
+	Power           PowerStatus       `json:"power" yaml:"power"`
+	Thermal         ThermalStatus     `json:"thermal" yaml:"thermal"`
+	PCIe            PCIeStatus        `json:"pcie" yaml:"pcie"`
+	ECC             ECCStatus         `json:"ecc" yaml:"ecc"`
+	ThrottleReasons *ThrottleReasons  `json:"throttle_reasons,omitempty" yaml:"throttle_reasons,omitempty"`
+	Processes       []ProcessStatus   `json:"processes,omitempty" yaml:"processes,omitempty"`
+	Errors          []FieldError      `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+// DeviceIdentity identifies a device.
+type DeviceIdentity struct {
+	Index            *uint   `json:"index,omitempty" yaml:"index,omitempty"`
+	Name             string  `json:"name,omitempty" yaml:"name,omitempty"`
+	UUID             string  `json:"uuid,omitempty" yaml:"uuid,omitempty"`
+	Serial           string  `json:"serial,omitempty" yaml:"serial,omitempty"`
+	PciBusID         string  `json:"pci_bus_id,omitempty" yaml:"pci_bus_id,omitempty"`
+	PciDeviceID      *uint32 `json:"pci_device_id,omitempty" yaml:"pci_device_id,omitempty"`
+	PciSubSystemID   *uint32 `json:"pci_subsystem_id,omitempty" yaml:"pci_subsystem_id,omitempty"`
+	MinorNumber      *uint   `json:"minor_number,omitempty" yaml:"minor_number,omitempty"`
+	BoardID          *uint   `json:"board_id,omitempty" yaml:"board_id,omitempty"`
+	Brand            string  `json:"brand,omitempty" yaml:"brand,omitempty"`
+	VBiosVersion     string  `json:"vbios_version,omitempty" yaml:"vbios_version,omitempty"`
+	PersistenceMode  string  `json:"persistence_mode,omitempty" yaml:"persistence_mode,omitempty"`
+	ComputeMode      string  `json:"compute_mode,omitempty" yaml:"compute_mode,omitempty"`
+	PerformanceState *int    `json:"performance_state,omitempty" yaml:"performance_state,omitempty"`
+}
+
+// ClockStatus holds the clocks of a device in MHz.
+type ClockStatus struct {
+	Current      Clocks `json:"current" yaml:"current"`
+	Max          Clocks `json:"max" yaml:"max"`
+	Applications Clocks `json:"applications" yaml:"applications"`
+}
+
+// Clocks holds one value in MHz per clock domain.
+type Clocks struct {
+	Graphics *uint `json:"graphics_mhz,omitempty" yaml:"graphics_mhz,omitempty"`
+	SM       *uint `json:"sm_mhz,omitempty" yaml:"sm_mhz,omitempty"`
+	Memory   *uint `json:"memory_mhz,omitempty" yaml:"memory_mhz,omitempty"`
+	Video    *uint `json:"video_mhz,omitempty" yaml:"video_mhz,omitempty"`
+}
+
+// MemoryUsage is the usage of the frame buffer or BAR1 memory of a device.
+type MemoryUsage struct {
+	TotalBytes uint64 `json:"total_bytes" yaml:"total_bytes"`
+	UsedBytes  uint64 `json:"used_bytes" yaml:"used_bytes"`
+	FreeBytes  uint64 `json:"free_bytes" yaml:"free_bytes"`
+}
+
+// UtilizationStatus holds the utilization rates of a device in percent.
+type UtilizationStatus struct {
+	GPU     *uint `json:"gpu_percent,omitempty" yaml:"gpu_percent,omitempty"`
+	Memory  *uint `json:"memory_percent,omitempty" yaml:"memory_percent,omitempty"`
+	Encoder *uint `json:"encoder_percent,omitempty" yaml:"encoder_percent,omitempty"`
+	Decoder *uint `json:"decoder_percent,omitempty" yaml:"decoder_percent,omitempty"`
+}
+
+// PowerStatus holds the power draw and limits of a device.
+type PowerStatus struct {
+	UsageMilliwatts         *uint   `json:"usage_mw,omitempty" yaml:"usage_mw,omitempty"`
+	LimitMilliwatts         *uint   `json:"limit_mw,omitempty" yaml:"limit_mw,omitempty"`
+	EnforcedLimitMilliwatts *uint   `json:"enforced_limit_mw,omitempty" yaml:"enforced_limit_mw,omitempty"`
+	DefaultLimitMilliwatts  *uint   `json:"default_limit_mw,omitempty" yaml:"default_limit_mw,omitempty"`
+	MinLimitMilliwatts      *uint   `json:"min_limit_mw,omitempty" yaml:"min_limit_mw,omitempty"`
+	MaxLimitMilliwatts      *uint   `json:"max_limit_mw,omitempty" yaml:"max_limit_mw,omitempty"`
+	EnergyMillijoules       *uint64 `json:"energy_mj,omitempty" yaml:"energy_mj,omitempty"`
+}
+
+// ThermalStatus holds the temperatures of a device in degrees Celsius and its
+// fan speed.
+type ThermalStatus struct {
+	Temperature       *uint `json:"temperature_c,omitempty" yaml:"temperature_c,omitempty"`
+	ShutdownThreshold *uint `json:"shutdown_threshold_c,omitempty" yaml:"shutdown_threshold_c,omitempty"`
+	SlowdownThreshold *uint `json:"slowdown_threshold_c,omitempty" yaml:"slowdown_threshold_c,omitempty"`
+	FanSpeed          *uint `json:"fan_speed_percent,omitempty" yaml:"fan_speed_percent,omitempty"`
+}
+
+// PCIeStatus holds the PCIe link state and throughput of a device.
+type PCIeStatus struct {
+	LinkGeneration    *uint `json:"link_generation,omitempty" yaml:"link_generation,omitempty"`
+	MaxLinkGeneration *uint `json:"max_link_generation,omitempty" yaml:"max_link_generation,omitempty"`
+	LinkWidth         *uint `json:"link_width,omitempty" yaml:"link_width,omitempty"`
+	MaxLinkWidth      *uint `json:"max_link_width,omitempty" yaml:"max_link_width,omitempty"`
+	TxKBps            *uint `json:"tx_kbps,omitempty" yaml:"tx_kbps,omitempty"`
+	RxKBps            *uint `json:"rx_kbps,omitempty" yaml:"rx_kbps,omitempty"`
+}
+
+// ECCStatus holds the ECC error counts of a device.
+type ECCStatus struct {
+	VolatileCorrected    *uint64 `json:"volatile_corrected,omitempty" yaml:"volatile_corrected,omitempty"`
+	VolatileUncorrected  *uint64 `json:"volatile_uncorrected,omitempty" yaml:"volatile_uncorrected,omitempty"`
+	AggregateCorrected   *uint64 `json:"aggregate_corrected,omitempty" yaml:"aggregate_corrected,omitempty"`
+	AggregateUncorrected *uint64 `json:"aggregate_uncorrected,omitempty" yaml:"aggregate_uncorrected,omitempty"`
+}
+
+// ThrottleReasons holds the reasons for the clocks of a device being
+// throttled.
+type ThrottleReasons struct {
+	Bitmap  uint64   `json:"bitmap" yaml:"bitmap"`
+	Reasons []string `json:"reasons,omitempty" yaml:"reasons,omitempty"`
+}
+
+// ProcessStatus is a process running on a device.
+type ProcessStatus struct {
+	PID             uint   `json:"pid" yaml:"pid"`
+	Type            string `json:"type" yaml:"type"` // "compute" or "graphics"
+	Name            string `json:"name,omitempty" yaml:"name,omitempty"`
+	UsedMemoryBytes uint64 `json:"used_memory_bytes" yaml:"used_memory_bytes"`
+}
+
+// FieldError records why a metric of a DeviceStatus couldn't be read.
+type FieldError struct {
+	// Field is the JSON path of the metric, e.g. "power.usage_mw".
+	Field   string `json:"field" yaml:"field"`
+	Message string `json:"error" yaml:"error"`
+	// Err is the error itself, which can be matched with errors.Is.
+	Err error `json:"-" yaml:"-"`
+}
+
+// throttleReasonNames names the bits of the throttle reasons bitmap.
+var throttleReasonNames = []struct {
+	bit  uint64
+	name string
+}{
+	{clocksThrottleReasonGpuIdle, "gpu_idle"},
+	{clocksThrottleReasonApplicationsClocksSetting, "applications_clocks_setting"},
+	{clocksThrottleReasonSwPowerCap, "sw_power_cap"},
+	{clocksThrottleReasonHwSlowdown, "hw_slowdown"},
+	{clocksThrottleReasonSyncBoost, "sync_boost"},
+	{clocksThrottleReasonSwThermalSlowdown, "sw_thermal_slowdown"},
+	{clocksThrottleReasonHwThermalSlowdown, "hw_thermal_slowdown"},
+	{clocksThrottleReasonHwPowerBrakeSlowdown, "hw_power_brake_slowdown"},
+	{clocksThrottleReasonDisplayClockSetting, "display_clock_setting"},
+}
+
+// snapshot collects a DeviceStatus.
+type snapshot struct {
+	h      DeviceHandle
+	opts   SnapshotOptions
+	status DeviceStatus
+}
+
+// ok reports whether err is nil, and otherwise records it as the error of
+// field.
+func (s *snapshot) ok(field string, err error) bool {
+	if err == nil {
+		return true
+	}
+	if s.opts.IgnoreUnsupported && (errors.Is(err, ErrNotSupported) || errors.Is(err, ErrFunctionNotFound)) {
+		return false
+	}
+	s.status.Errors = append(s.status.Errors, FieldError{Field: field, Message: err.Error(), Err: err})
+	return false
+}
+
+// Snapshot reads the state of d in one go. Metrics that can't be read, e.g.
+// because the device doesn't support them, are recorded in the Errors of the
+// returned DeviceStatus instead of failing the whole snapshot. The error
+// returned is only that of ctx, if it is done before the snapshot is
+// complete, in which case the partial DeviceStatus is returned with it.
+func (d Device) Snapshot(ctx context.Context, opts SnapshotOptions) (DeviceStatus, error) {
+	s := &snapshot{h: d.handle, opts: opts}
+	s.status.SchemaVersion = SnapshotSchemaVersion
+	s.status.Timestamp = time.Now()
+	for _, collect := range []func(){
+		s.identity, s.clocks, s.memory, s.utilization, s.power,
+		s.thermal, s.pcie, s.ecc, s.throttleReasons, s.processes,
+	} {
+		if err := ctx.Err(); err != nil {
+			return s.status, err
+		}
+		collect()
+	}
+	return s.status, nil
+}
+
+func (s *snapshot) identity() {
+	id := &s.status.Identity
+	if v, err := backend.DeviceGetIndex(s.h); s.ok("identity.index", err) {
+		id.Index = &v
+	}
+	if v, err := backend.DeviceGetName(s.h); s.ok("identity.name", err) {
+		id.Name = v
+	}
+	if v, err := backend.DeviceGetUUID(s.h); s.ok("identity.uuid", err) {
+		id.UUID = v
+	}
+	if v, err := backend.DeviceGetSerial(s.h); s.ok("identity.serial", err) {
+		id.Serial = v
+	}
+	if v, err := backend.DeviceGetPciInfo(s.h); s.ok("identity.pci_bus_id", err) {
+		id.PciBusID = v.BusID
+		id.PciDeviceID = &v.PciDeviceID
+		id.PciSubSystemID = &v.PciSubSystemID
+	}
+	if v, err := backend.DeviceGetMinorNumber(s.h); s.ok("identity.minor_number", err) {
+		id.MinorNumber = &v
+	}
+	if v, err := backend.DeviceGetBoardId(s.h); s.ok("identity.board_id", err) {
+		id.BoardID = &v
+	}
+	if v, err := backend.DeviceGetBrand(s.h); s.ok("identity.brand", err) {
+		id.Brand = v.String()
+	}
+	if v, err := backend.DeviceGetVbiosVersion(s.h); s.ok("identity.vbios_version", err) {
+		id.VBiosVersion = v
+	}
+	if v, err := backend.DeviceGetPersistenceMode(s.h); s.ok("identity.persistence_mode", err) {
+		id.PersistenceMode = v.String()
+	}
+	if v, err := backend.DeviceGetComputeMode(s.h); s.ok("identity.compute_mode", err) {
+		id.ComputeMode = v.String()
+	}
+	if v, err := backend.DeviceGetPerformanceState(s.h); s.ok("identity.performance_state", err) {
+		p := int(v)
+		id.PerformanceState = &p
+	}
+}
+
+func (s *snapshot) clocks() {
+	for _, c := range []struct {
+		name   string
+		get    func(DeviceHandle, ClockType) (uint, error)
+		clocks *Clocks
+	}{
+		{"current", backend.DeviceGetClockInfo, &s.status.Clocks.Current},
+		{"max", backend.DeviceGetMaxClockInfo, &s.status.Clocks.Max},
+		{"applications", backend.DeviceGetApplicationsClock, &s.status.Clocks.Applications},
+	} {
+		if v, err := c.get(s.h, ClockTypeGraphics); s.ok("clocks."+c.name+".graphics_mhz", err) {
+			c.clocks.Graphics = &v
+		}
+		if v, err := c.get(s.h, ClockTypeSM); s.ok("clocks."+c.name+".sm_mhz", err) {
+			c.clocks.SM = &v
+		}
+		if v, err := c.get(s.h, ClockTypeMem); s.ok("clocks."+c.name+".memory_mhz", err) {
+			c.clocks.Memory = &v
+		}
+		if v, err := c.get(s.h, ClockTypeVideo); s.ok("clocks."+c.name+".video_mhz", err) {
+			c.clocks.Video = &v
+		}
+	}
+}
+
+func (s *snapshot) memory() {
+	if total, used, err := backend.DeviceGetMemoryInfo(s.h); s.ok("memory", err) {
+		s.status.Memory = &MemoryUsage{TotalBytes: total, UsedBytes: used, FreeBytes: total - used}
+	}
+	if total, used, err := backend.DeviceGetBAR1MemoryInfo(s.h); s.ok("bar1", err) {
+		s.status.BAR1 = &MemoryUsage{TotalBytes: total, UsedBytes: used, FreeBytes: total - used}
+	}
+}
+
+func (s *snapshot) utilization() {
+	u := &s.status.Utilization
+	if gpu, memory, err := backend.DeviceGetUtilizationRates(s.h); s.ok("utilization.gpu_percent", err) {
+		u.GPU, u.Memory = &gpu, &memory
+	}
+	if v, _, err := backend.DeviceGetEncoderUtilization(s.h); s.ok("utilization.encoder_percent", err) {
+		u.Encoder = &v
+	}
+	if v, _, err := backend.DeviceGetDecoderUtilization(s.h); s.ok("utilization.decoder_percent", err) {
+		u.Decoder = &v
+	}
+}
+
+func (s *snapshot) power() {
+	p := &s.status.Power
+	if v, err := backend.DeviceGetPowerUsage(s.h); s.ok("power.usage_mw", err) {
+		p.UsageMilliwatts = &v
+	}
+	if v, err := backend.DeviceGetPowerManagementLimit(s.h); s.ok("power.limit_mw", err) {
+		p.LimitMilliwatts = &v
+	}
+	if v, err := backend.DeviceGetEnforcedPowerLimit(s.h); s.ok("power.enforced_limit_mw", err) {
+		p.EnforcedLimitMilliwatts = &v
+	}
+	if v, err := backend.DeviceGetPowerManagementDefaultLimit(s.h); s.ok("power.default_limit_mw", err) {
+		p.DefaultLimitMilliwatts = &v
+	}
+	if lo, hi, err := backend.DeviceGetPowerManagementLimitConstraints(s.h); s.ok("power.min_limit_mw", err) {
+		p.MinLimitMilliwatts, p.MaxLimitMilliwatts = &lo, &hi
+	}
+	if v, err := backend.DeviceGetTotalEnergyConsumption(s.h); s.ok("power.energy_mj", err) {
+		p.EnergyMillijoules = &v
+	}
+}
+
+func (s *snapshot) thermal() {
+	t := &s.status.Thermal
+	if v, err := backend.DeviceGetTemperature(s.h, TemperatureSensorGPU); s.ok("thermal.temperature_c", err) {
+		t.Temperature = &v
+	}
+	if v, err := backend.DeviceGetTemperatureThreshold(s.h, TemperatureThresholdShutdown); s.ok("thermal.shutdown_threshold_c", err) {
+		t.ShutdownThreshold = &v
+	}
+	if v, err := backend.DeviceGetTemperatureThreshold(s.h, TemperatureThresholdSlowdown); s.ok("thermal.slowdown_threshold_c", err) {
+		t.SlowdownThreshold = &v
+	}
+	if v, err := backend.DeviceGetFanSpeed(s.h); s.ok("thermal.fan_speed_percent", err) {
+		t.FanSpeed = &v
+	}
+}
+
+func (s *snapshot) pcie() {
+	p := &s.status.PCIe
+	if v, err := backend.DeviceGetCurrPcieLinkGeneration(s.h); s.ok("pcie.link_generation", err) {
+		p.LinkGeneration = &v
+	}
+	if v, err := backend.DeviceGetMaxPcieLinkGeneration(s.h); s.ok("pcie.max_link_generation", err) {
+		p.MaxLinkGeneration = &v
+	}
+	if v, err := backend.DeviceGetCurrPcieLinkWidth(s.h); s.ok("pcie.link_width", err) {
+		p.LinkWidth = &v
+	}
+	if v, err := backend.DeviceGetMaxPcieLinkWidth(s.h); s.ok("pcie.max_link_width", err) {
+		p.MaxLinkWidth = &v
+	}
+	if v, err := backend.DeviceGetPcieThroughput(s.h, PcieUtilCounterTxBytes); s.ok("pcie.tx_kbps", err) {
+		p.TxKBps = &v
+	}
+	if v, err := backend.DeviceGetPcieThroughput(s.h, PcieUtilCounterRxBytes); s.ok("pcie.rx_kbps", err) {
+		p.RxKBps = &v
+	}
+}
+
+func (s *snapshot) ecc() {
+	e := &s.status.ECC
+	for _, c := range []struct {
+		field       string
+		errorType   MemoryErrorType
+		counterType EccCounterType
+		count       **uint64
+	}{
+		{"ecc.volatile_corrected", MemoryErrorTypeCorrected, EccCounterTypeVolatile, &e.VolatileCorrected},
+		{"ecc.volatile_uncorrected", MemoryErrorTypeUncorrected, EccCounterTypeVolatile, &e.VolatileUncorrected},
+		{"ecc.aggregate_corrected", MemoryErrorTypeCorrected, EccCounterTypeAggregate, &e.AggregateCorrected},
+		{"ecc.aggregate_uncorrected", MemoryErrorTypeUncorrected, EccCounterTypeAggregate, &e.AggregateUncorrected},
+	} {
+		if v, err := backend.DeviceGetTotalEccErrors(s.h, c.errorType, c.counterType); s.ok(c.field, err) {
+			*c.count = &v
+		}
+	}
+}
+
+func (s *snapshot) throttleReasons() {
+	bitmap, err := backend.DeviceGetCurrentClocksThrottleReasons(s.h)
+	if !s.ok("throttle_reasons", err) {
+		return
+	}
+	t := &ThrottleReasons{Bitmap: bitmap}
+	for _, r := range throttleReasonNames {
+		if bitmap&r.bit != 0 {
+			t.Reasons = append(t.Reasons, r.name)
+		}
+	}
+	s.status.ThrottleReasons = t
+}
+
+func (s *snapshot) processes() {
+	if !s.opts.Processes {
+		return
+	}
+	for _, p := range []struct {
+		typ string
+		get func(DeviceHandle) ([]Process, error)
+	}{
+		{"compute", backend.DeviceGetComputeRunningProcesses},
+		{"graphics", backend.DeviceGetGraphicsRunningProcesses},
+	} {
+		procs, err := p.get(s.h)
+		if !s.ok("processes", err) {
+			continue
+		}
+		for _, proc := range procs {
+			ps := ProcessStatus{PID: proc.PID(), Type: p.typ, UsedMemoryBytes: proc.Memory()}
+			// The process may have exited since it was listed, so
+			// failing to look up its name is not an error.
+			if s.opts.ProcessNames {
+				if name, err := backend.SystemGetProcessName(proc.PID(), 256); err == nil {
+					ps.Name = name
+				}
+			}
+			s.status.Processes = append(s.status.Processes, ps)
+		}
+	}
+}
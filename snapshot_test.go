@@ -0,0 +1,137 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// snapshotDevice returns a device without power usage sampling or ECC, and
+// whose temperature sensor fails.
+func snapshotDevice() *FakeDevice {
+	return &FakeDevice{
+		Name:        "Tesla T4",
+		UUID:        "GPU-8932f937-d72c-4106-c12f-20bd9faed9f6",
+		PciInfo:     PciInfo{BusID: "00000000:3B:00.0"},
+		MemoryTotal: 16 << 30,
+		MemoryUsed:  1 << 30,
+		PowerLimit:  70000,
+		ComputeProcesses: []FakeProcess{
+			{Pid: 1234, Name: "python3", UsedGpuMemory: 1 << 30},
+		},
+		Errors: map[string]Return{
+			"nvmlDeviceGetPowerUsage":     ReturnErrorNotSupported,
+			"nvmlDeviceGetTotalEccErrors": ReturnErrorNotSupported,
+			"nvmlDeviceGetTemperature":    ReturnErrorUnknown,
+		},
+	}
+}
+
+// fieldErrors returns the fields of errs.
+func fieldErrors(errs []FieldError) []string {
+	var fields []string
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+	return fields
+}
+
+func TestSnapshot(t *testing.T) {
+	_, devices := initFakeDevices(t, snapshotDevice())
+	defer SetBackend(nil)
+	defer Shutdown()
+
+	status, err := devices[0].Snapshot(context.Background(), SnapshotOptions{Processes: true, ProcessNames: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.SchemaVersion != SnapshotSchemaVersion || status.Timestamp.IsZero() {
+		t.Errorf("schema version %d at %v, want %d at the time of the snapshot", status.SchemaVersion, status.Timestamp, SnapshotSchemaVersion)
+	}
+	if id := status.Identity; id.Name != "Tesla T4" || id.PciBusID != "00000000:3B:00.0" || id.Index == nil || *id.Index != 0 {
+		t.Errorf("Identity = %+v", id)
+	}
+	if m := status.Memory; m == nil || m.FreeBytes != 15<<30 {
+		t.Errorf("Memory = %+v, want 15 GiB free", m)
+	}
+	// The metrics that failed are left out, the others are kept.
+	if p := status.Power; p.UsageMilliwatts != nil || p.LimitMilliwatts == nil || *p.LimitMilliwatts != 70000 {
+		t.Errorf("Power = %+v, want no usage and a 70000 mW limit", p)
+	}
+	if status.Thermal.Temperature != nil || status.ECC.VolatileCorrected != nil {
+		t.Errorf("Thermal = %+v, ECC = %+v; want no temperature nor ECC", status.Thermal, status.ECC)
+	}
+	wantFields := []string{
+		"power.usage_mw",
+		"thermal.temperature_c",
+		"ecc.volatile_corrected",
+		"ecc.volatile_uncorrected",
+		"ecc.aggregate_corrected",
+		"ecc.aggregate_uncorrected",
+	}
+	if fields := fieldErrors(status.Errors); !reflect.DeepEqual(fields, wantFields) {
+		t.Errorf("Errors are for %q, want %q", fields, wantFields)
+	}
+	for _, e := range status.Errors {
+		if e.Message != e.Err.Error() {
+			t.Errorf("%s: Message %q isn't the error %q", e.Field, e.Message, e.Err)
+		}
+	}
+	if !errors.Is(status.Errors[0].Err, ErrNotSupported) || !errors.Is(status.Errors[1].Err, ErrUnknown) {
+		t.Errorf("Errors = %+v, want ErrNotSupported then ErrUnknown", status.Errors[:2])
+	}
+	wantProcesses := []ProcessStatus{{PID: 1234, Type: "compute", Name: "python3", UsedMemoryBytes: 1 << 30}}
+	if !reflect.DeepEqual(status.Processes, wantProcesses) {
+		t.Errorf("Processes = %+v, want %+v", status.Processes, wantProcesses)
+	}
+}
+
+func TestSnapshotIgnoreUnsupported(t *testing.T) {
+	_, devices := initFakeDevices(t, snapshotDevice())
+	defer SetBackend(nil)
+	defer Shutdown()
+
+	status, err := devices[0].Snapshot(context.Background(), SnapshotOptions{IgnoreUnsupported: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fields := fieldErrors(status.Errors); !reflect.DeepEqual(fields, []string{"thermal.temperature_c"}) {
+		t.Errorf("Errors are for %q, want only thermal.temperature_c", fields)
+	}
+	if status.Processes != nil {
+		t.Errorf("Processes = %+v without SnapshotOptions.Processes", status.Processes)
+	}
+}
+
+func TestSnapshotCanceled(t *testing.T) {
+	_, devices := initFakeDevices(t, snapshotDevice())
+	defer SetBackend(nil)
+	defer Shutdown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	status, err := devices[0].Snapshot(ctx, SnapshotOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Snapshot() = %v, want context.Canceled", err)
+	}
+	if status.SchemaVersion != SnapshotSchemaVersion || status.Identity.Name != "" {
+		t.Errorf("Snapshot() = %+v, want an empty status", status)
+	}
+}
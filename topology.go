@@ -0,0 +1,311 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// CommonAncestor returns the closest level of the PCIe topology the device
+// shares with other, e.g. TopologySingle if both are behind the same PCIe
+// switch.
+func (d Device) CommonAncestor(other Device) (TopologyLevel, error) {
+	return backend.DeviceGetTopologyCommonAncestor(d.handle, other.handle)
+}
+
+// NearestGpus returns the devices that share the given level of the PCIe
+// topology with the device.
+func (d Device) NearestGpus(level TopologyLevel) ([]Device, error) {
+	handles, err := backend.DeviceGetTopologyNearestGpus(d.handle, level)
+	return devices(handles), err
+}
+
+// TopologyGpuSet returns the devices that have an affinity with the CPU.
+func TopologyGpuSet(cpu uint) ([]Device, error) {
+	handles, err := backend.SystemGetTopologyGpuSet(cpu)
+	return devices(handles), err
+}
+
+func devices(handles []DeviceHandle) []Device {
+	if len(handles) == 0 {
+		return nil
+	}
+	devs := make([]Device, len(handles))
+	for i, h := range handles {
+		devs[i] = Device{h}
+	}
+	return devs
+}
+
+// P2PStatus returns whether the device can use the P2P capability with
+// other.
+func (d Device) P2PStatus(other Device, index P2PCapsIndex) (P2PStatus, error) {
+	return backend.DeviceGetP2PStatus(d.handle, other.handle, index)
+}
+
+// MarshalText encodes the level as its String.
+func (l TopologyLevel) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// MarshalText encodes the status as its String.
+func (s P2PStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Topology is the pairwise matrix of how devices are connected, as built by
+// TopologyMatrix. It encodes to JSON with the levels and P2P statuses as
+// strings, and to text like "nvidia-smi topo -m" with WriteText.
+type Topology struct {
+	GPUs []TopologyGPU `json:"gpus"`
+	// Links[i][j] is the link from GPUs[i] to GPUs[j]. It is nil on the
+	// diagonal and for the pairs whose common ancestor couldn't be read.
+	Links [][]*TopologyLink `json:"links"`
+}
+
+// TopologyGPU is a device of a Topology.
+type TopologyGPU struct {
+	Device   Device `json:"-"`
+	Index    uint   `json:"index"`
+	UUID     string `json:"uuid,omitempty"`
+	PciBusID string `json:"pci_bus_id,omitempty"`
+}
+
+// TopologyLink is how a device of a Topology is connected to another one.
+type TopologyLink struct {
+	Level TopologyLevel `json:"level"`
+	// NvLinks is the number of active NvLink links between the devices.
+	NvLinks uint    `json:"nvlinks"`
+	P2P     P2PCaps `json:"p2p"`
+}
+
+// P2PCaps are the statuses of the P2P capabilities from a device to another.
+type P2PCaps struct {
+	Read    P2PStatus `json:"read"`
+	Write   P2PStatus `json:"write"`
+	NvLink  P2PStatus `json:"nvlink"`
+	Atomics P2PStatus `json:"atomics"`
+}
+
+// TopologyMatrix builds the Topology of the given devices, or of all the
+// devices if none are given. The pairs that fail to be queried are left
+// incomplete and their errors are combined in the returned error.
+func TopologyMatrix(devs ...Device) (Topology, error) {
+	var errs errorList
+	if len(devs) == 0 {
+		n, err := DeviceCount()
+		if err != nil {
+			return Topology{}, err
+		}
+		for i := uint(0); i < n; i++ {
+			d, err := DeviceHandleByIndex(i)
+			if err != nil {
+				return Topology{}, err
+			}
+			devs = append(devs, d)
+		}
+	}
+
+	t := Topology{
+		GPUs:  make([]TopologyGPU, len(devs)),
+		Links: make([][]*TopologyLink, len(devs)),
+	}
+	busIDs := make(map[string]int)
+	for i, d := range devs {
+		g := TopologyGPU{Device: d, Index: uint(i)}
+		if idx, err := d.Index(); err == nil {
+			g.Index = idx
+		}
+		g.UUID, _ = d.UUID()
+		if pci, err := d.PciInfo(); err == nil {
+			g.PciBusID = pci.BusID
+			busIDs[pci.BusID] = i
+		}
+		t.GPUs[i] = g
+		t.Links[i] = make([]*TopologyLink, len(devs))
+	}
+
+	for i, d := range devs {
+		for j, other := range devs {
+			if i == j {
+				continue
+			}
+			level, err := d.CommonAncestor(other)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("GPU%d to GPU%d: %w", t.GPUs[i].Index, t.GPUs[j].Index, err))
+				continue
+			}
+			link := &TopologyLink{Level: level}
+			for _, c := range []struct {
+				index  P2PCapsIndex
+				status *P2PStatus
+			}{
+				{P2PCapsIndexRead, &link.P2P.Read},
+				{P2PCapsIndexWrite, &link.P2P.Write},
+				{P2PCapsIndexNvLink, &link.P2P.NvLink},
+				{P2PCapsIndexAtomics, &link.P2P.Atomics},
+			} {
+				status, err := d.P2PStatus(other, c.index)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("GPU%d to GPU%d: %w", t.GPUs[i].Index, t.GPUs[j].Index, err))
+					status = P2PStatusUnknown
+				}
+				*c.status = status
+			}
+			t.Links[i][j] = link
+		}
+
+		links, err := d.NvLinks()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("GPU%d: %w", t.GPUs[i].Index, err))
+		}
+		for _, l := range links {
+			state, err := l.State()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("GPU%d: %w", t.GPUs[i].Index, err))
+				continue
+			}
+			if state != EnableStateFeatureEnabled {
+				continue
+			}
+			remote, err := l.RemotePciInfo()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("GPU%d: %w", t.GPUs[i].Index, err))
+				continue
+			}
+			if j, ok := busIDs[remote.BusID]; ok && t.Links[i][j] != nil {
+				t.Links[i][j].NvLinks++
+			}
+		}
+	}
+	return t, errs.err()
+}
+
+// topologyLevelAbbreviations are the names of the levels in the text
+// matrix, as in nvidia-smi.
+var topologyLevelAbbreviations = map[TopologyLevel]string{
+	TopologyInternal:   "INT",
+	TopologySingle:     "PIX",
+	TopologyMultiple:   "PXB",
+	TopologyHostBridge: "PHB",
+	TopologyNode:       "NODE",
+	TopologySystem:     "SYS",
+}
+
+// p2pStatusAbbreviations are the names of the P2P statuses in the text
+// matrices, as in nvidia-smi.
+var p2pStatusAbbreviations = map[P2PStatus]string{
+	P2PStatusOK:                      "OK",
+	P2PStatusChipsetNotSupported:     "CNS",
+	P2PStatusGpuNotSupported:         "GNS",
+	P2PStatusIohTopologyNotSupported: "TNS",
+	P2PStatusDisabledByRegkey:        "DIS",
+	P2PStatusNotSupported:            "NS",
+	P2PStatusUnknown:                 "U",
+}
+
+const topologyLegend = `Legend:
+
+  X    = Self
+  SYS  = Connection traversing PCIe as well as the SMP interconnect between NUMA nodes
+  NODE = Connection traversing PCIe as well as the interconnect between PCIe host bridges within a NUMA node
+  PHB  = Connection traversing PCIe as well as a PCIe host bridge
+  PXB  = Connection traversing multiple PCIe switches
+  PIX  = Connection traversing a single PCIe switch
+  INT  = Connection between GPUs on the same board
+  NV#  = Connection traversing a bonded set of # NVLinks
+  ?    = Unknown
+
+  OK   = P2P supported
+  CNS  = Chipset not supported
+  GNS  = GPU not supported
+  TNS  = Topology not supported
+  DIS  = Disabled by regkey
+  NS   = Not supported
+  U    = Unknown
+`
+
+// WriteText writes the topology matrix followed by the matrices of the P2P
+// capabilities and a legend, in the format of "nvidia-smi topo -m".
+func (t Topology) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	t.writeMatrix(tw, func(l *TopologyLink) string {
+		if l.NvLinks > 0 {
+			return fmt.Sprintf("NV%d", l.NvLinks)
+		}
+		if abbrev, ok := topologyLevelAbbreviations[l.Level]; ok {
+			return abbrev
+		}
+		return "?"
+	})
+	for _, c := range []struct {
+		index  P2PCapsIndex
+		status func(p P2PCaps) P2PStatus
+	}{
+		{P2PCapsIndexRead, func(p P2PCaps) P2PStatus { return p.Read }},
+		{P2PCapsIndexWrite, func(p P2PCaps) P2PStatus { return p.Write }},
+		{P2PCapsIndexNvLink, func(p P2PCaps) P2PStatus { return p.NvLink }},
+		{P2PCapsIndexAtomics, func(p P2PCaps) P2PStatus { return p.Atomics }},
+	} {
+		status := c.status
+		fmt.Fprintf(tw, "\nP2P %s:\n", c.index)
+		t.writeMatrix(tw, func(l *TopologyLink) string {
+			if abbrev, ok := p2pStatusAbbreviations[status(l.P2P)]; ok {
+				return abbrev
+			}
+			return "U"
+		})
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%s", topologyLegend)
+	return err
+}
+
+// writeMatrix writes a row per GPU with the cells returned by cell for the
+// links to the other GPUs.
+func (t Topology) writeMatrix(w io.Writer, cell func(l *TopologyLink) string) {
+	for _, g := range t.GPUs {
+		fmt.Fprintf(w, "\tGPU%d", g.Index)
+	}
+	fmt.Fprintln(w)
+	for i, g := range t.GPUs {
+		fmt.Fprintf(w, "GPU%d", g.Index)
+		for j, l := range t.Links[i] {
+			switch {
+			case i == j:
+				fmt.Fprint(w, "\tX")
+			case l == nil:
+				fmt.Fprint(w, "\t?")
+			default:
+				fmt.Fprintf(w, "\t%s", cell(l))
+			}
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// WriteJSON writes the topology as indented JSON.
+func (t Topology) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(t)
+}
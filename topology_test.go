@@ -0,0 +1,206 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// useTopologyFake selects a FakeBackend with three devices: GPU0 and GPU1
+// share a PCIe switch and two active NvLink links, GPU2 shares the NUMA node
+// of GPU1 but not the one of GPU0.
+func useTopologyFake(t *testing.T) (*FakeBackend, []Device) {
+	a := &FakeDevice{
+		UUID:    "GPU-a",
+		PciInfo: PciInfo{BusID: "00000000:3B:00.0"},
+	}
+	b := &FakeDevice{
+		UUID:    "GPU-b",
+		PciInfo: PciInfo{BusID: "00000000:3C:00.0"},
+	}
+	c := &FakeDevice{
+		UUID:    "GPU-c",
+		PciInfo: PciInfo{BusID: "00000000:86:00.0"},
+	}
+	a.NvLinks = []*FakeNvLink{
+		{State: EnableStateFeatureEnabled, RemotePciInfo: b.PciInfo},
+		{State: EnableStateFeatureEnabled, RemotePciInfo: b.PciInfo},
+		{State: EnableStateFeatureDisabled, RemotePciInfo: b.PciInfo},
+	}
+	b.NvLinks = []*FakeNvLink{
+		{State: EnableStateFeatureEnabled, RemotePciInfo: a.PciInfo},
+		{State: EnableStateFeatureEnabled, RemotePciInfo: a.PciInfo},
+		// Links to NvSwitches or CPUs don't count.
+		{State: EnableStateFeatureEnabled, RemotePciInfo: PciInfo{BusID: "00000000:00:01.0"}},
+	}
+	a.Topology = map[*FakeDevice]TopologyLevel{b: TopologySingle}
+	b.Topology = map[*FakeDevice]TopologyLevel{c: TopologyNode}
+	a.P2P = map[FakeP2PCaps]P2PStatus{
+		{b, P2PCapsIndexRead}:    P2PStatusOK,
+		{b, P2PCapsIndexWrite}:   P2PStatusOK,
+		{b, P2PCapsIndexNvLink}:  P2PStatusOK,
+		{b, P2PCapsIndexAtomics}: P2PStatusOK,
+		{c, P2PCapsIndexRead}:    P2PStatusChipsetNotSupported,
+	}
+	return initFakeDevices(t, a, b, c)
+}
+
+const wantTopologyText = `      GPU0  GPU1  GPU2
+GPU0  X     NV2   SYS
+GPU1  NV2   X     NODE
+GPU2  SYS   NODE  X
+
+P2P read:
+      GPU0  GPU1  GPU2
+GPU0  X     OK    CNS
+GPU1  OK    X     NS
+GPU2  CNS   NS    X
+
+P2P write:
+      GPU0  GPU1  GPU2
+GPU0  X     OK    NS
+GPU1  OK    X     NS
+GPU2  NS    NS    X
+
+P2P nvlink:
+      GPU0  GPU1  GPU2
+GPU0  X     OK    NS
+GPU1  OK    X     NS
+GPU2  NS    NS    X
+
+P2P atomics:
+      GPU0  GPU1  GPU2
+GPU0  X     OK    NS
+GPU1  OK    X     NS
+GPU2  NS    NS    X
+
+` + topologyLegend
+
+const wantTopologyJSON = `{
+  "gpus": [
+    {
+      "index": 0,
+      "uuid": "GPU-a",
+      "pci_bus_id": "00000000:3B:00.0"
+    },
+    {
+      "index": 1,
+      "uuid": "GPU-b",
+      "pci_bus_id": "00000000:3C:00.0"
+    }
+  ],
+  "links": [
+    [
+      null,
+      {
+        "level": "single",
+        "nvlinks": 2,
+        "p2p": {
+          "read": "ok",
+          "write": "ok",
+          "nvlink": "ok",
+          "atomics": "ok"
+        }
+      }
+    ],
+    [
+      {
+        "level": "single",
+        "nvlinks": 2,
+        "p2p": {
+          "read": "ok",
+          "write": "ok",
+          "nvlink": "ok",
+          "atomics": "ok"
+        }
+      },
+      null
+    ]
+  ]
+}
+`
+
+func TestTopologyMatrix(t *testing.T) {
+	_, devices := useTopologyFake(t)
+	defer SetBackend(nil)
+	defer Shutdown()
+
+	topo, err := TopologyMatrix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(topo.GPUs) != 3 {
+		t.Fatalf("got %d GPUs, want all 3 devices", len(topo.GPUs))
+	}
+	link := topo.Links[0][1]
+	if link == nil || link.Level != TopologySingle || link.NvLinks != 2 || link.P2P.Read != P2PStatusOK {
+		t.Errorf("GPU0 to GPU1 = %+v, want single with 2 NvLinks and P2P", link)
+	}
+	if link := topo.Links[1][2]; link == nil || link.Level != TopologyNode || link.NvLinks != 0 {
+		t.Errorf("GPU1 to GPU2 = %+v, want node without NvLinks", link)
+	}
+	var buf bytes.Buffer
+	if err := topo.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != wantTopologyText {
+		t.Errorf("WriteText() wrote\n%s\nwant\n%s", got, wantTopologyText)
+	}
+
+	topo, err = TopologyMatrix(devices[0], devices[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := topo.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != wantTopologyJSON {
+		t.Errorf("WriteJSON() wrote\n%s\nwant\n%s", got, wantTopologyJSON)
+	}
+}
+
+func TestTopologyMatrixErrors(t *testing.T) {
+	f, devices := useTopologyFake(t)
+	defer SetBackend(nil)
+	defer Shutdown()
+	f.Lock()
+	f.Devices[2].Errors = map[string]Return{"nvmlDeviceGetTopologyCommonAncestor": ReturnErrorUnknown}
+	f.Unlock()
+
+	topo, err := TopologyMatrix(devices...)
+	if !errors.Is(err, ErrUnknown) {
+		t.Errorf("TopologyMatrix() = %v, want ErrUnknown", err)
+	}
+	for _, pair := range [][2]int{{0, 2}, {2, 0}, {1, 2}, {2, 1}} {
+		if link := topo.Links[pair[0]][pair[1]]; link != nil {
+			t.Errorf("GPU%d to GPU%d = %+v, want nil", pair[0], pair[1], link)
+		}
+	}
+	if link := topo.Links[0][1]; link == nil || link.NvLinks != 2 {
+		t.Errorf("GPU0 to GPU1 = %+v, want 2 NvLinks", link)
+	}
+	var buf bytes.Buffer
+	if err := topo.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := "GPU2  ?     ?     X\n"; !bytes.Contains(buf.Bytes(), []byte(want)) {
+		t.Errorf("WriteText() wrote\n%s\nwant a row %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,529 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+// The values of the enumerations below mirror the ones declared in nvml.h so
+// that they can be shared by every backend, including the ones built without
+// cgo.
+
+// Utilization is Structure to store utilization value and process Id
+type Utilization struct {
+	Pid       uint   //!< PID of process
+	timeStamp uint64 //!< CPU Timestamp in microseconds
+	SMUtil    uint   //!< SM (3D/Compute) Util Value
+	MemUtil   uint   //!< Frame Buffer Memory Util Value
+	EncUtil   uint   //!< Encoder Util Value
+	DecUtil   uint   //!< Decoder Util Value
+}
+
+// AccountingStats is a Structire to Store accounting Stats for every process
+type AccountingStats struct {
+	GPUUtilization uint
+	//!< Percent of time over the process's lifetime during which one or more kernels was executing on the GPU.
+	//! Utilization stats just like returned by \ref nvmlDeviceGetUtilizationRates but for the life time of a
+	//! process (not just the last sample period).
+	//! Set to NVML_VALUE_NOT_AVAILABLE if nvmlDeviceGetUtilizationRates is not supported
+
+	MemoryUtilization uint
+	//!< Percent of time over the process's lifetime during which global (device) memory was being read or written.
+	//! Set to NVML_VALUE_NOT_AVAILABLE if nvmlDeviceGetUtilizationRates is not supported
+
+	MaxMemoryUsage uint64
+	//!< Maximum total memory in bytes that was ever allocated by the process.
+	//! Set to NVML_VALUE_NOT_AVAILABLE if nvmlProcessInfo_t->usedGpuMemory is not supported
+
+	Time uint64
+	//!< Amount of time in ms during which the compute context was active. The time is reported as 0 if
+	//!< the process is not terminated
+
+	StartTime uint64
+	//!< CPU Timestamp in usec representing start time for the process
+
+	IsRunning bool
+	//!< Flag to represent if the process is running (1 for running, 0 for terminated)
+
+	Reserved [5]uint
+	// Reserved for
+}
+
+// PciInfo is the equivalent of nvmlPciInfo_t.
+type PciInfo struct {
+	BusID          string // domain:bus:device.function PCI identifier
+	Domain         uint   // PCI domain on which the device's bus resides
+	Bus            uint   // bus on which the device resides
+	Device         uint   // device's id on the bus
+	PciDeviceID    uint32 // combined 16-bit device id and 16-bit vendor id
+	PciSubSystemID uint32 // 32-bit Sub System Device ID
+}
+
+// Process is the exported handle for a process instance
+type Process interface {
+	PID() uint
+	Memory() uint64
+}
+
+type process struct {
+	pid           uint
+	usedGpuMemory uint64
+}
+
+func (proc process) PID() uint {
+	return proc.pid
+}
+
+func (proc process) Memory() uint64 {
+	return proc.usedGpuMemory
+}
+
+// DeviceBrand is the equivalent for nvmlBrandType_t.
+type DeviceBrand int
+
+// Enumeration mapping for DeviceBrand to nvmlBrandType_t
+const (
+	DeviceBrandUnknown DeviceBrand = 0
+	DeviceBrandTesla   DeviceBrand = 2
+	DeviceBrandNVS     DeviceBrand = 3
+	DeviceBrandGRID    DeviceBrand = 4
+	DeviceBrandGeForce DeviceBrand = 5
+)
+
+func (b DeviceBrand) String() string {
+	switch b {
+	case DeviceBrandTesla:
+		return "Tesla"
+	case DeviceBrandNVS:
+		return "NVS"
+	case DeviceBrandGRID:
+		return "GRID"
+	case DeviceBrandGeForce:
+		return "Geforce"
+	default:
+		return "unknown"
+	}
+}
+
+// ComputeMode is the quivalent for nvmlComputeMode_t.
+type ComputeMode int
+
+// Enumeration mapping for ComputeMode to nvmlComputeMode_t
+const (
+	ComputeModeDefault          ComputeMode = 0
+	ComputeModeExclusiveThread  ComputeMode = 1
+	ComputeModeProhibited       ComputeMode = 2
+	ComputeModeExclusiveProcess ComputeMode = 3
+)
+
+func (cm ComputeMode) String() string {
+	switch cm {
+	case ComputeModeProhibited:
+		return "prohibited"
+	case ComputeModeExclusiveThread:
+		return "exclusive thread"
+	case ComputeModeExclusiveProcess:
+		return "exclusive process"
+	case ComputeModeDefault:
+		return "default"
+	default:
+		return "unkown"
+	}
+}
+
+// EnableState is the quivalent for nvmlEnableState_t.
+type EnableState int
+
+// Enumeration mapping for ComputeMode to nvmlComputeMode_t
+const (
+	EnableStateFeatureEnabled  EnableState = 1
+	EnableStateFeatureDisabled EnableState = 0
+)
+
+func (es EnableState) String() string {
+	switch es {
+	case EnableStateFeatureEnabled:
+		return "enabled"
+	case EnableStateFeatureDisabled:
+		return "disabled"
+	default:
+		return "unknown"
+	}
+}
+
+// PowerState is the equivalent to nvmlPstates_t
+type PowerState int
+
+// Enumeration mapping for PowerState to nvmlPstates_t
+const (
+	PowerState0       PowerState = 0
+	PowerState1       PowerState = 1
+	PowerState2       PowerState = 2
+	PowerState3       PowerState = 3
+	PowerState4       PowerState = 4
+	PowerState5       PowerState = 5
+	PowerState6       PowerState = 6
+	PowerState7       PowerState = 7
+	PowerState8       PowerState = 8
+	PowerState9       PowerState = 9
+	PowerState10      PowerState = 10
+	PowerState11      PowerState = 11
+	PowerState12      PowerState = 12
+	PowerState13      PowerState = 13
+	PowerState14      PowerState = 14
+	PowerState15      PowerState = 15
+	PowerStateUnknown PowerState = 32
+)
+
+// ThrottlingReason is a summary of the throttle reasons bitmap, see
+// MostSeriousClocksThrottleReason.
+type ThrottlingReason int
+
+// Enumeration of reasons for throttling
+const (
+	ThrottlingReasonNone                 = 0
+	ThrottlingReasonIdle                 = 1
+	ThrottlingReasonApplicationClock     = 2
+	ThrottlingReasonUserDefinedClocks    = 3
+	ThrottlingReasonSwPowerCap           = 4
+	ThrottlingReasonHwSlowdown           = 5
+	ThrottlingReasonSyncBoost            = 6
+	ThrottlingReasonSwThermalSlowdown    = 7
+	ThrottlingReasonHwThermalSlowdown    = 8
+	ThrottlingReasonHwPowerBrakeSlowdown = 9
+	ThrottlingReasonDisplayClockSetting  = 10
+)
+
+// Bits of the bitmap returned by CurrentClocksThrottleReasons, see the
+// nvmlClocksThrottleReason* defines.
+const (
+	clocksThrottleReasonGpuIdle                   uint64 = 0x0000000000000001
+	clocksThrottleReasonApplicationsClocksSetting uint64 = 0x0000000000000002
+	clocksThrottleReasonUserDefinedClocks         uint64 = clocksThrottleReasonApplicationsClocksSetting
+	clocksThrottleReasonSwPowerCap                uint64 = 0x0000000000000004
+	clocksThrottleReasonHwSlowdown                uint64 = 0x0000000000000008
+	clocksThrottleReasonSyncBoost                 uint64 = 0x0000000000000010
+	clocksThrottleReasonSwThermalSlowdown         uint64 = 0x0000000000000020
+	clocksThrottleReasonHwThermalSlowdown         uint64 = 0x0000000000000040
+	clocksThrottleReasonHwPowerBrakeSlowdown      uint64 = 0x0000000000000080
+	clocksThrottleReasonDisplayClockSetting       uint64 = 0x0000000000000100
+)
+
+func (ps PowerState) String() string {
+	switch ps {
+	case PowerState0:
+		return "P0 - Maximum Performance"
+	case PowerState1:
+		return "P1"
+	case PowerState2:
+		return "P2"
+	case PowerState3:
+		return "P3"
+	case PowerState4:
+		return "P4"
+	case PowerState5:
+		return "P5"
+	case PowerState6:
+		return "P6"
+	case PowerState7:
+		return "P7"
+	case PowerState8:
+		return "P8"
+	case PowerState9:
+		return "P9"
+	case PowerState10:
+		return "P10"
+	case PowerState11:
+		return "P11"
+	case PowerState12:
+		return "P12"
+	case PowerState13:
+		return "P13"
+	case PowerState14:
+		return "P14"
+	case PowerState15:
+		return "P15 - Minimum Performance"
+	default:
+		return "unknown"
+	}
+}
+
+// ClockType is the equivalent nvmlClockType_t
+type ClockType int
+
+// Enumeration mapping for ClockType to nvmlClockType_t
+const (
+	ClockTypeGraphics ClockType = 0
+	ClockTypeSM       ClockType = 1
+	ClockTypeMem      ClockType = 2
+	ClockTypeVideo    ClockType = 3
+)
+
+func (ct ClockType) String() string {
+	switch ct {
+	case ClockTypeGraphics:
+		return "graphics"
+	case ClockTypeSM:
+		return "sm"
+	case ClockTypeMem:
+		return "memory"
+	case ClockTypeVideo:
+		return "video"
+	default:
+		return "unknown"
+	}
+}
+
+// PcieUtilCounter is the equivalent of nvmlPcieUtilCounter_t.
+type PcieUtilCounter int
+
+// Enumeration mapping for PcieUtilCounter to nvmlPcieUtilCounter_t
+const (
+	PcieUtilCounterTxBytes PcieUtilCounter = 0
+	PcieUtilCounterRxBytes PcieUtilCounter = 1
+)
+
+// TemperatureSensor is the equivalent of nvmlTemperatureSensors_t.
+type TemperatureSensor int
+
+// Enumeration mapping for TemperatureSensor to nvmlTemperatureSensors_t
+const (
+	TemperatureSensorGPU TemperatureSensor = 0
+)
+
+// TemperatureThreshold is the equivalent of nvmlTemperatureThresholds_t.
+type TemperatureThreshold int
+
+// Enumeration mapping for TemperatureThreshold to nvmlTemperatureThresholds_t
+const (
+	TemperatureThresholdShutdown TemperatureThreshold = 0
+	TemperatureThresholdSlowdown TemperatureThreshold = 1
+	TemperatureThresholdMemMax   TemperatureThreshold = 2
+	TemperatureThresholdGpuMax   TemperatureThreshold = 3
+)
+
+// EncoderType is the equivalent of nvmlEncoderType_t.
+type EncoderType int
+
+// Enumeration mapping for EncoderType to nvmlEncoderType_t
+const (
+	EncoderTypeH264 EncoderType = 0
+	EncoderTypeHEVC EncoderType = 1
+)
+
+// MemoryErrorType is the equivalent of nvmlMemoryErrorType_t.
+type MemoryErrorType int
+
+// Enumeration mapping for MemoryErrorType to nvmlMemoryErrorType_t
+const (
+	MemoryErrorTypeCorrected   MemoryErrorType = 0
+	MemoryErrorTypeUncorrected MemoryErrorType = 1
+)
+
+// EccCounterType is the equivalent of nvmlEccCounterType_t.
+type EccCounterType int
+
+// Enumeration mapping for EccCounterType to nvmlEccCounterType_t
+const (
+	EccCounterTypeVolatile  EccCounterType = 0
+	EccCounterTypeAggregate EccCounterType = 1
+)
+
+// MemoryLocation is the equivalent of nvmlMemoryLocation_t.
+type MemoryLocation int
+
+// Enumeration mapping for MemoryLocation to nvmlMemoryLocation_t
+const (
+	MemoryLocationL1Cache       MemoryLocation = 0
+	MemoryLocationL2Cache       MemoryLocation = 1
+	MemoryLocationDeviceMemory  MemoryLocation = 2 // DRAM on Turing and newer
+	MemoryLocationRegisterFile  MemoryLocation = 3
+	MemoryLocationTextureMemory MemoryLocation = 4
+	MemoryLocationTextureShm    MemoryLocation = 5
+	MemoryLocationCBU           MemoryLocation = 6
+	MemoryLocationSRAM          MemoryLocation = 7 // Turing and newer
+)
+
+func (l MemoryLocation) String() string {
+	switch l {
+	case MemoryLocationL1Cache:
+		return "l1_cache"
+	case MemoryLocationL2Cache:
+		return "l2_cache"
+	case MemoryLocationDeviceMemory:
+		return "device_memory"
+	case MemoryLocationRegisterFile:
+		return "register_file"
+	case MemoryLocationTextureMemory:
+		return "texture_memory"
+	case MemoryLocationTextureShm:
+		return "texture_shm"
+	case MemoryLocationCBU:
+		return "cbu"
+	case MemoryLocationSRAM:
+		return "sram"
+	default:
+		return "unknown"
+	}
+}
+
+// PageRetirementCause is the equivalent of nvmlPageRetirementCause_t.
+type PageRetirementCause int
+
+// Enumeration mapping for PageRetirementCause to nvmlPageRetirementCause_t
+const (
+	PageRetirementCauseMultipleSingleBitEccErrors PageRetirementCause = 0
+	PageRetirementCauseDoubleBitEccError          PageRetirementCause = 1
+)
+
+func (c PageRetirementCause) String() string {
+	switch c {
+	case PageRetirementCauseMultipleSingleBitEccErrors:
+		return "multiple single bit ECC errors"
+	case PageRetirementCauseDoubleBitEccError:
+		return "double bit ECC error"
+	default:
+		return "unknown"
+	}
+}
+
+// TopologyLevel is the equivalent of nvmlGpuTopologyLevel_t: how far apart
+// two devices are in the PCIe topology, from the closest to the farthest.
+type TopologyLevel int
+
+// Enumeration mapping for TopologyLevel to nvmlGpuTopologyLevel_t
+const (
+	TopologyInternal   TopologyLevel = 0  // on the same board, e.g. Tesla K80
+	TopologySingle     TopologyLevel = 10 // behind a single PCIe switch
+	TopologyMultiple   TopologyLevel = 20 // behind multiple PCIe switches, without a host bridge
+	TopologyHostBridge TopologyLevel = 30 // behind the same host bridge
+	TopologyNode       TopologyLevel = 40 // on the same NUMA node
+	TopologySystem     TopologyLevel = 50 // anywhere in the system
+)
+
+func (l TopologyLevel) String() string {
+	switch l {
+	case TopologyInternal:
+		return "internal"
+	case TopologySingle:
+		return "single"
+	case TopologyMultiple:
+		return "multiple"
+	case TopologyHostBridge:
+		return "hostbridge"
+	case TopologyNode:
+		return "node"
+	case TopologySystem:
+		return "system"
+	default:
+		return "unknown"
+	}
+}
+
+// P2PCapsIndex is the equivalent of nvmlGpuP2PCapsIndex_t.
+type P2PCapsIndex int
+
+// Enumeration mapping for P2PCapsIndex to nvmlGpuP2PCapsIndex_t
+const (
+	P2PCapsIndexRead    P2PCapsIndex = 0
+	P2PCapsIndexWrite   P2PCapsIndex = 1
+	P2PCapsIndexNvLink  P2PCapsIndex = 2
+	P2PCapsIndexAtomics P2PCapsIndex = 3
+	P2PCapsIndexProp    P2PCapsIndex = 4
+)
+
+func (i P2PCapsIndex) String() string {
+	switch i {
+	case P2PCapsIndexRead:
+		return "read"
+	case P2PCapsIndexWrite:
+		return "write"
+	case P2PCapsIndexNvLink:
+		return "nvlink"
+	case P2PCapsIndexAtomics:
+		return "atomics"
+	case P2PCapsIndexProp:
+		return "prop"
+	default:
+		return "unknown"
+	}
+}
+
+// P2PStatus is the equivalent of nvmlGpuP2PStatus_t.
+type P2PStatus int
+
+// Enumeration mapping for P2PStatus to nvmlGpuP2PStatus_t
+const (
+	P2PStatusOK                      P2PStatus = 0
+	P2PStatusChipsetNotSupported     P2PStatus = 1
+	P2PStatusGpuNotSupported         P2PStatus = 2
+	P2PStatusIohTopologyNotSupported P2PStatus = 3
+	P2PStatusDisabledByRegkey        P2PStatus = 4
+	P2PStatusNotSupported            P2PStatus = 5
+	P2PStatusUnknown                 P2PStatus = 6
+)
+
+func (s P2PStatus) String() string {
+	switch s {
+	case P2PStatusOK:
+		return "ok"
+	case P2PStatusChipsetNotSupported:
+		return "chipset not supported"
+	case P2PStatusGpuNotSupported:
+		return "GPU not supported"
+	case P2PStatusIohTopologyNotSupported:
+		return "IOH topology not supported"
+	case P2PStatusDisabledByRegkey:
+		return "disabled by regkey"
+	case P2PStatusNotSupported:
+		return "not supported"
+	default:
+		return "unknown"
+	}
+}
+
+// SamplingType is the equivalent of nvmlSamplingType_t.
+type SamplingType int
+
+// Enumeration mapping for SamplingType to nvmlSamplingType_t
+const (
+	SamplingTypeTotalPower        SamplingType = 0
+	SamplingTypeGPUUtilization    SamplingType = 1
+	SamplingTypeMemoryUtilization SamplingType = 2
+	SamplingTypeEncUtilization    SamplingType = 3
+	SamplingTypeDecUtilization    SamplingType = 4
+	SamplingTypeProcessorClk      SamplingType = 5
+	SamplingTypeMemoryClk         SamplingType = 6
+)
+
+// AffinityScope is the equivalent of nvmlAffinityScope_t: the CPUs or NUMA
+// nodes an affinity is reported for.
+type AffinityScope uint
+
+// Enumeration mapping for AffinityScope to nvmlAffinityScope_t
+const (
+	AffinityScopeNode   AffinityScope = 0 // the NUMA node closest to the device
+	AffinityScopeSocket AffinityScope = 1 // the CPU socket closest to the device
+)
+
+func (s AffinityScope) String() string {
+	switch s {
+	case AffinityScopeNode:
+		return "node"
+	case AffinityScopeSocket:
+		return "socket"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// VisibleDevices returns the devices selected by the CUDA_VISIBLE_DEVICES
+// environment variable, or by NVIDIA_VISIBLE_DEVICES if the former is not
+// set, see ParseVisibleDevices. All the devices are returned if neither is
+// set.
+func VisibleDevices() ([]Device, error) {
+	for _, name := range []string{"CUDA_VISIBLE_DEVICES", "NVIDIA_VISIBLE_DEVICES"} {
+		if value, ok := os.LookupEnv(name); ok {
+			devices, err := ParseVisibleDevices(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			return devices, nil
+		}
+	}
+	return ParseVisibleDevices("all")
+}
+
+// ParseVisibleDevices resolves a CUDA_VISIBLE_DEVICES or
+// NVIDIA_VISIBLE_DEVICES value to the devices it selects, in order. The value
+// is either "all", "none", "void", empty, or a comma separated list of:
+//
+//	0                                         a device index
+//	0:1                                       a MIG device index within a device
+//	GPU-8932f937-d72c-4106-c12f-20bd9faed9f6  a device UUID
+//	GPU-8932f937                              a unique prefix of a device UUID
+//	MIG-c1e8a5b4-9c6e-5b1d-8e5e-3f1a4f0e6a2d  a MIG device UUID
+//	MIG-GPU-8932f937-.../1/0                  a MIG device UUID of R450 drivers
+//	00000000:3B:00.0                          a device PCI bus ID
+//
+// Devices listed more than once are only returned the first time. Unlike CUDA,
+// which silently drops everything from the first invalid entry on,
+// ParseVisibleDevices fails if an entry cannot be resolved. PCI bus IDs are
+// not understood by CUDA, but make the same lists usable to select devices on
+// the command line, like the -i option of nvidia-smi.
+func ParseVisibleDevices(value string) ([]Device, error) {
+	switch strings.TrimSpace(value) {
+	case "", "none", "void":
+		return nil, nil
+	case "all":
+		count, err := DeviceCount()
+		if err != nil {
+			return nil, err
+		}
+		devices := make([]Device, 0, count)
+		for i := uint(0); i < count; i++ {
+			d, err := DeviceHandleByIndex(i)
+			if err != nil {
+				return nil, err
+			}
+			devices = append(devices, d)
+		}
+		return devices, nil
+	}
+
+	var devices []Device
+	seen := make(map[Device]bool)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		d, err := resolveVisibleDevice(entry)
+		if err != nil {
+			return nil, fmt.Errorf("visible device %q: %w", entry, err)
+		}
+		if !seen[d] {
+			seen[d] = true
+			devices = append(devices, d)
+		}
+	}
+	return devices, nil
+}
+
+// resolveVisibleDevice returns the device selected by one entry of a
+// CUDA_VISIBLE_DEVICES value.
+func resolveVisibleDevice(entry string) (Device, error) {
+	switch {
+	case strings.HasPrefix(entry, "GPU-"):
+		// Some drivers reject a truncated UUID as an invalid argument
+		// rather than not finding it.
+		d, err := DeviceHandleByUUID(entry)
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrInvalidArgument) {
+			return deviceByUUIDPrefix(entry)
+		}
+		return d, err
+	case strings.HasPrefix(entry, "MIG-"):
+		d, err := DeviceHandleByUUID(entry)
+		if errors.Is(err, ErrNotFound) && strings.HasPrefix(entry, "MIG-GPU-") {
+			return migDeviceByLegacyUUID(entry)
+		}
+		return d, err
+	case strings.Contains(entry, "."):
+		// Only PCI bus IDs have a function number.
+		return DeviceHandleByPciBusID(entry)
+	}
+
+	parts := strings.Split(entry, ":")
+	if len(parts) > 2 {
+		return Device{}, ErrInvalidArgument
+	}
+	index, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return Device{}, ErrInvalidArgument
+	}
+	d, err := DeviceHandleByIndex(uint(index))
+	if err != nil || len(parts) == 1 {
+		return d, err
+	}
+	migIndex, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return Device{}, ErrInvalidArgument
+	}
+	return d.MigDeviceByIndex(uint(migIndex))
+}
+
+// deviceByUUIDPrefix returns the device whose UUID starts with prefix, and
+// fails if there isn't exactly one.
+func deviceByUUIDPrefix(prefix string) (Device, error) {
+	count, err := DeviceCount()
+	if err != nil {
+		return Device{}, err
+	}
+	var found []Device
+	for i := uint(0); i < count; i++ {
+		d, err := DeviceHandleByIndex(i)
+		if err != nil {
+			return Device{}, err
+		}
+		uuid, err := d.UUID()
+		if err != nil {
+			return Device{}, err
+		}
+		if strings.HasPrefix(uuid, prefix) {
+			found = append(found, d)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Device{}, ErrNotFound
+	case 1:
+		return found[0], nil
+	default:
+		return Device{}, fmt.Errorf("ambiguous UUID prefix matching %d devices: %w", len(found), ErrInvalidArgument)
+	}
+}
+
+// migDeviceByLegacyUUID returns the MIG device identified by a
+// "MIG-<GPU UUID>/<GPU instance ID>/<compute instance ID>" UUID, which newer
+// drivers don't accept anymore.
+func migDeviceByLegacyUUID(uuid string) (Device, error) {
+	parts := strings.Split(strings.TrimPrefix(uuid, "MIG-"), "/")
+	if len(parts) != 3 {
+		return Device{}, ErrInvalidArgument
+	}
+	gi, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return Device{}, ErrInvalidArgument
+	}
+	ci, err := strconv.ParseUint(parts[2], 10, 32)
+	if err != nil {
+		return Device{}, ErrInvalidArgument
+	}
+	parent, err := DeviceHandleByUUID(parts[0])
+	if err != nil {
+		return Device{}, err
+	}
+	migs, err := parent.MigDevices()
+	if err != nil {
+		return Device{}, err
+	}
+	for _, mig := range migs {
+		giID, err := mig.GpuInstanceID()
+		if err != nil {
+			return Device{}, err
+		}
+		ciID, err := mig.ComputeInstanceID()
+		if err != nil {
+			return Device{}, err
+		}
+		if uint64(giID) == gi && uint64(ciID) == ci {
+			return mig, nil
+		}
+	}
+	return Device{}, ErrNotFound
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2017 Google Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gonvml
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+// useVisibleDevicesFake selects a FakeBackend with two devices, the first one
+// of which has a MIG device with GPU instance 0 and compute instance 0.
+func useVisibleDevicesFake(t *testing.T) (*FakeBackend, []Device) {
+	a := &FakeDevice{
+		UUID:    "GPU-8932f937-d72c-4106-c12f-20bd9faed9f6",
+		MigMode: EnableStateFeatureEnabled,
+		GpuInstanceProfiles: map[GpuInstanceProfile]GpuInstanceProfileInfo{
+			GpuInstanceProfile7Slice: {ID: 0, SliceCount: 7, InstanceCount: 1},
+		},
+	}
+	b := &FakeDevice{
+		UUID:    "GPU-8933a1b2-0000-0000-0000-000000000000",
+		PciInfo: PciInfo{BusID: "00000000:3B:00.0"},
+	}
+	f := useFakeBackend(a, b)
+	if err := Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	devices := make([]Device, 2)
+	for i := range devices {
+		d, err := DeviceHandleByIndex(uint(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		devices[i] = d
+	}
+	gi, err := devices[0].CreateGpuInstance(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Lock()
+	gi.handle.(*FakeGpuInstance).ComputeInstanceProfiles = map[ComputeInstanceProfile]ComputeInstanceProfileInfo{
+		ComputeInstanceProfile7Slice: {ID: 0, InstanceCount: 1},
+	}
+	f.Unlock()
+	if _, err := gi.CreateComputeInstance(0); err != nil {
+		t.Fatal(err)
+	}
+	return f, devices
+}
+
+func TestParseVisibleDevices(t *testing.T) {
+	f, devices := useVisibleDevicesFake(t)
+	defer SetBackend(nil)
+	defer Shutdown()
+	mig, err := devices[0].MigDeviceByIndex(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := devices[0], devices[1]
+
+	for _, c := range []struct {
+		value string
+		want  []Device
+	}{
+		{"", nil},
+		{"none", nil},
+		{"all", []Device{a, b}},
+		{"1,0", []Device{b, a}},
+		{"0,0", []Device{a}},
+		{"1, GPU-8932f", []Device{b, a}},
+		{"GPU-8932f937-d72c-4106-c12f-20bd9faed9f6", []Device{a}},
+		{"GPU-8933", []Device{b}},
+		{"0:0", []Device{mig}},
+		{"MIG-GPU-8932f937-d72c-4106-c12f-20bd9faed9f6/0/0", []Device{mig}},
+		{"00000000:3B:00.0,0", []Device{b, a}},
+		{"3b:00.0", []Device{b}},
+	} {
+		got, err := ParseVisibleDevices(c.value)
+		if err != nil {
+			t.Errorf("ParseVisibleDevices(%q): %v", c.value, err)
+			continue
+		}
+		if !sameDevices(got, c.want) {
+			t.Errorf("ParseVisibleDevices(%q) = %v, want %v", c.value, got, c.want)
+		}
+	}
+
+	for _, c := range []struct {
+		value string
+		want  error
+	}{
+		{"GPU-893", ErrInvalidArgument}, // ambiguous
+		{"GPU-0000", ErrNotFound},
+		{"7", ErrInvalidArgument},
+		{"x", ErrInvalidArgument},
+		{"0:5", ErrNotFound},
+		{"MIG-GPU-8932f937-d72c-4106-c12f-20bd9faed9f6/0/3", ErrNotFound},
+		{"00000000:3C:00.0", ErrNotFound},
+	} {
+		if _, err := ParseVisibleDevices(c.value); !errors.Is(err, c.want) {
+			t.Errorf("ParseVisibleDevices(%q) = %v, want %v", c.value, err, c.want)
+		}
+	}
+
+	// Some drivers fail to look up a truncated UUID with an invalid
+	// argument error rather than not finding it.
+	f.Lock()
+	f.Errors = map[string]Return{"nvmlDeviceGetHandleByUUID": ReturnErrorInvalidArgument}
+	f.Unlock()
+	if got, err := ParseVisibleDevices("GPU-8932f"); err != nil || !sameDevices(got, []Device{a}) {
+		t.Errorf("ParseVisibleDevices(GPU-8932f) with ErrInvalidArgument = %v, %v; want %v", got, err, []Device{a})
+	}
+}
+
+func TestVisibleDevices(t *testing.T) {
+	_, devices := useVisibleDevicesFake(t)
+	defer SetBackend(nil)
+	defer Shutdown()
+	for _, name := range []string{"CUDA_VISIBLE_DEVICES", "NVIDIA_VISIBLE_DEVICES"} {
+		if value, ok := os.LookupEnv(name); ok {
+			defer os.Setenv(name, value)
+		} else {
+			defer os.Unsetenv(name)
+		}
+		os.Unsetenv(name)
+	}
+
+	if got, err := VisibleDevices(); err != nil || !sameDevices(got, devices) {
+		t.Errorf("VisibleDevices() with no variable set = %v, %v; want all the devices", got, err)
+	}
+	os.Setenv("NVIDIA_VISIBLE_DEVICES", "0")
+	os.Setenv("CUDA_VISIBLE_DEVICES", "1")
+	if got, err := VisibleDevices(); err != nil || !sameDevices(got, devices[1:]) {
+		t.Errorf("VisibleDevices() = %v, %v; want CUDA_VISIBLE_DEVICES to win", got, err)
+	}
+	os.Setenv("CUDA_VISIBLE_DEVICES", "7")
+	if _, err := VisibleDevices(); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("VisibleDevices() with an invalid index = %v, want ErrInvalidArgument", err)
+	}
+}
+
+func sameDevices(got, want []Device) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}